@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/hanzoai/backendPB/core"
@@ -47,16 +48,21 @@ func backupsList(e *core.RequestEvent) error {
 		return e.BadRequestError("Failed to retrieve backup items. Raw error: \n"+err.Error(), nil)
 	}
 
-	result := make([]backupFileInfo, len(backups))
+	result := make([]backupFileInfo, 0, len(backups))
+
+	for _, obj := range backups {
+		// skip the continuous backup files
+		if strings.HasPrefix(obj.Key, core.StreamBackupsPrefix) {
+			continue
+		}
 
-	for i, obj := range backups {
 		modified, _ := types.ParseDateTime(obj.ModTime)
 
-		result[i] = backupFileInfo{
+		result = append(result, backupFileInfo{
 			Key:      obj.Key,
 			Size:     obj.Size,
 			Modified: modified,
-		}
+		})
 	}
 
 	return e.JSON(http.StatusOK, result)
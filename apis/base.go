@@ -32,6 +32,7 @@ func NewRouter(app core.App) (*router.Router[*core.RequestEvent], error) {
 	pbRouter.Bind(rateLimit())
 	pbRouter.Bind(loadAuthToken())
 	pbRouter.Bind(securityHeaders())
+	pbRouter.Bind(replicaWrites())
 	pbRouter.Bind(BodyLimit(DefaultMaxBodySize))
 
 	apiGroup := pbRouter.Group("/api")
@@ -42,10 +43,14 @@ func NewRouter(app core.App) (*router.Router[*core.RequestEvent], error) {
 	bindLogsApi(app, apiGroup)
 	bindBackupApi(app, apiGroup)
 	bindCronApi(app, apiGroup)
+	bindJobsApi(app, apiGroup)
 	bindFileApi(app, apiGroup)
 	bindBatchApi(app, apiGroup)
 	bindRealtimeApi(app, apiGroup)
 	bindHealthApi(app, apiGroup)
+	bindOIDCApi(app, apiGroup)
+
+	bindWellKnownApi(app, pbRouter.RouterGroup)
 
 	return pbRouter, nil
 }
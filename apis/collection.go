@@ -20,6 +20,7 @@ func bindCollectionApi(app core.App, rg *router.RouterGroup[*core.RequestEvent])
 	subGroup.PATCH("/{collection}", collectionUpdate)
 	subGroup.DELETE("/{collection}", collectionDelete)
 	subGroup.DELETE("/{collection}/truncate", collectionTruncate)
+	subGroup.POST("/{collection}/rotate-auth-token-key", collectionRotateAuthTokenKey)
 	subGroup.PUT("/import", collectionsImport)
 	subGroup.GET("/meta/scaffolds", collectionScaffolds)
 }
@@ -181,6 +182,27 @@ func collectionTruncate(e *core.RequestEvent) error {
 	return e.NoContent(http.StatusNoContent)
 }
 
+func collectionRotateAuthTokenKey(e *core.RequestEvent) error {
+	collection, err := e.App.FindCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("", err)
+	}
+
+	if !collection.IsAuth() || !collection.AuthTokenSigning.IsAsymmetric() {
+		return e.BadRequestError("The auth token key rotation is supported only for auth collections with RS256 or EdDSA auth token signing algorithm.", nil)
+	}
+
+	if err = collection.AuthTokenSigning.RotateKey(); err != nil {
+		return e.InternalServerError("Failed to generate new auth token signing key.", err)
+	}
+
+	if err = e.App.Save(collection); err != nil {
+		return e.BadRequestError("Failed to rotate the auth token signing key.", err)
+	}
+
+	return e.JSON(http.StatusOK, collection)
+}
+
 func collectionScaffolds(e *core.RequestEvent) error {
 	collections := map[string]*core.Collection{
 		core.CollectionTypeBase: core.NewBaseCollection(""),
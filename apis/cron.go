@@ -9,6 +9,8 @@ import (
 	"github.com/hanzoai/backendPB/tools/cron"
 	"github.com/hanzoai/backendPB/tools/router"
 	"github.com/hanzoai/backendPB/tools/routine"
+	"github.com/hanzoai/backendPB/tools/search"
+	"github.com/hanzoai/dbx"
 )
 
 // bindCronApi registers the crons api endpoint.
@@ -16,6 +18,14 @@ func bindCronApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	subGroup := rg.Group("/crons").Bind(RequireSuperuserAuth())
 	subGroup.GET("", cronsList)
 	subGroup.POST("/{id}", cronRun)
+	subGroup.GET("/{id}/runs", cronRunsList)
+}
+
+type cronJobInfo struct {
+	Id         string        `json:"id"`
+	Expression string        `json:"expression"`
+	Running    bool          `json:"running"`
+	LastRun    *core.CronRun `json:"lastRun"`
 }
 
 func cronsList(e *core.RequestEvent) error {
@@ -31,29 +41,65 @@ func cronsList(e *core.RequestEvent) error {
 		return strings.Compare(a.Id(), b.Id())
 	})
 
-	return e.JSON(http.StatusOK, jobs)
-}
+	lastRuns, err := e.App.FindLastCronRuns()
+	if err != nil {
+		return e.InternalServerError("Failed to load the cron jobs run history.", err)
+	}
 
-func cronRun(e *core.RequestEvent) error {
-	cronId := e.Request.PathValue("id")
+	result := make([]cronJobInfo, len(jobs))
+	for i, j := range jobs {
+		result[i] = cronJobInfo{
+			Id:         j.Id(),
+			Expression: j.Expression(),
+			Running:    j.IsRunning(),
+			LastRun:    lastRuns[j.Id()],
+		}
+	}
 
-	var foundJob *cron.Job
+	return e.JSON(http.StatusOK, result)
+}
 
-	jobs := e.App.Cron().Jobs()
-	for _, j := range jobs {
-		if j.Id() == cronId {
-			foundJob = j
-			break
+func findCronJob(app core.App, id string) *cron.Job {
+	for _, j := range app.Cron().Jobs() {
+		if j.Id() == id {
+			return j
 		}
 	}
 
+	return nil
+}
+
+func cronRun(e *core.RequestEvent) error {
+	foundJob := findCronJob(e.App, e.Request.PathValue("id"))
 	if foundJob == nil {
 		return e.NotFoundError("Missing or invalid cron job", nil)
 	}
 
+	if foundJob.IsRunning() {
+		return e.BadRequestError("The cron job is already running.", nil)
+	}
+
 	routine.FireAndForget(func() {
 		foundJob.Run()
 	})
 
 	return e.NoContent(http.StatusNoContent)
 }
+
+var cronRunFilterFields = []string{"id", "trigger", "error", "duration", "started"}
+
+func cronRunsList(e *core.RequestEvent) error {
+	cronId := e.Request.PathValue("id")
+
+	fieldResolver := search.NewSimpleFieldResolver(cronRunFilterFields...)
+
+	result, err := search.NewProvider(fieldResolver).
+		Query(e.App.CronRunQuery().AndWhere(dbx.HashExp{"jobId": cronId})).
+		ParseAndExec(e.Request.URL.Query().Encode(), &[]*core.CronRun{})
+
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
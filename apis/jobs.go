@@ -0,0 +1,73 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/router"
+	"github.com/hanzoai/backendPB/tools/search"
+)
+
+// bindJobsApi registers the background jobs api endpoints.
+func bindJobsApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/jobs").Bind(RequireSuperuserAuth())
+	sub.GET("", jobsList)
+	sub.GET("/{id}", jobsView)
+	sub.POST("/{id}/retry", jobRetry)
+	sub.POST("/{id}/cancel", jobCancel)
+}
+
+var jobFilterFields = []string{
+	"id", "name", "status", "priority", "attempts", "maxAttempts",
+	"uniqueKey", "lastError", "runAt", "created", "updated", "payload",
+	`^payload\.[\w\.\:]*\w+$`,
+}
+
+func jobsList(e *core.RequestEvent) error {
+	fieldResolver := search.NewSimpleFieldResolver(jobFilterFields...)
+
+	result, err := search.NewProvider(fieldResolver).
+		Query(e.App.ModelQuery(&core.Job{})).
+		ParseAndExec(e.Request.URL.Query().Encode(), &[]*core.Job{})
+
+	if err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func jobsView(e *core.RequestEvent) error {
+	job, err := e.App.FindJobById(e.Request.PathValue("id"))
+	if err != nil || job == nil {
+		return e.NotFoundError("", err)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+func jobRetry(e *core.RequestEvent) error {
+	job, err := e.App.FindJobById(e.Request.PathValue("id"))
+	if err != nil || job == nil {
+		return e.NotFoundError("", err)
+	}
+
+	if err := e.App.RetryJob(job); err != nil {
+		return e.BadRequestError("Failed to retry job.", err)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
+
+func jobCancel(e *core.RequestEvent) error {
+	job, err := e.App.FindJobById(e.Request.PathValue("id"))
+	if err != nil || job == nil {
+		return e.NotFoundError("", err)
+	}
+
+	if err := e.App.CancelJob(job); err != nil {
+		return e.BadRequestError("Failed to cancel job.", err)
+	}
+
+	return e.JSON(http.StatusOK, job)
+}
@@ -45,6 +45,9 @@ const (
 	DefaultSecurityHeadersMiddlewarePriority = DefaultRateLimitMiddlewarePriority - 10
 	DefaultSecurityHeadersMiddlewareId       = "pbSecurityHeaders"
 
+	DefaultReplicaWritesMiddlewarePriority = DefaultRateLimitMiddlewarePriority - 5
+	DefaultReplicaWritesMiddlewareId       = "pbReplicaWrites"
+
 	DefaultRequireGuestOnlyMiddlewareId                 = "pbRequireGuestOnly"
 	DefaultRequireAuthMiddlewareId                      = "pbRequireAuth"
 	DefaultRequireSuperuserAuthMiddlewareId             = "pbRequireSuperuserAuth"
@@ -297,6 +300,32 @@ func securityHeaders() *hook.Handler[*core.RequestEvent] {
 	}
 }
 
+// replicaWrites marks the session of the non-safe (POST, PUT, PATCH, DELETE) requests
+// as recently written so that its next reads are routed to the primary data db
+// (regardless whether the write was made by the api handler, a hook, the auth flows, etc.).
+//
+// This middleware is registered by default for all routes.
+func replicaWrites() *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id:       DefaultReplicaWritesMiddlewareId,
+		Priority: DefaultReplicaWritesMiddlewarePriority,
+		Func: func(e *core.RequestEvent) error {
+			switch e.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return e.Next()
+			}
+
+			// resolve the session key before the request execution
+			// in case the auth state changes (e.g. on auth record delete)
+			sessionKey := core.ReplicaSessionKey(e)
+
+			defer e.App.TrackReplicaWrite(sessionKey)
+
+			return e.Next()
+		},
+	}
+}
+
 // SkipSuccessActivityLog is a helper middleware that instructs the global
 // activity logger to log only requests that have failed/returned an error.
 func SkipSuccessActivityLog() *hook.Handler[*core.RequestEvent] {
@@ -0,0 +1,492 @@
+package apis
+
+import (
+	"crypto"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/router"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/spf13/cast"
+)
+
+// bindOIDCApi registers the OAuth2/OIDC provider api endpoints.
+func bindOIDCApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/oidc")
+	sub.GET("/authorize", oidcAuthorize)
+	sub.POST("/authorize", oidcAuthorizeConfirm).Bind(RequireAuth())
+	sub.POST("/token", oidcToken)
+	sub.GET("/userinfo", oidcUserInfo)
+	sub.POST("/userinfo", oidcUserInfo)
+}
+
+const (
+	oidcResponseTypeCode           = "code"
+	oidcGrantTypeAuthorizationCode = "authorization_code"
+	oidcCodeChallengeMethodS256    = "S256"
+)
+
+// oidcProvider holds the resolved OAuth2/OIDC provider configuration.
+type oidcProvider struct {
+	issuer        string
+	loginURL      string
+	tokenDuration time.Duration
+	collection    *core.Collection
+	signingKey    crypto.Signer
+	jwk           security.JWK
+}
+
+// loadOIDCProvider loads and validates the OAuth2/OIDC provider configuration
+// from the app settings.
+//
+// Returns 404 error if the provider is not enabled.
+func loadOIDCProvider(e *core.RequestEvent) (*oidcProvider, error) {
+	config := e.App.Settings().OIDC
+
+	if !config.Enabled {
+		return nil, e.NotFoundError("The OAuth2/OIDC provider is not enabled.", nil)
+	}
+
+	collection, err := e.App.FindCachedCollectionByNameOrId(config.AuthCollection)
+	if err != nil || !collection.IsAuth() {
+		return nil, e.InternalServerError("Missing or invalid OAuth2/OIDC provider auth collection.", err)
+	}
+
+	// the ID tokens are signed with the auth collection key
+	// (aka. the same key store as the auth tokens exposed in /.well-known/jwks.json)
+	if !collection.AuthTokenSigning.IsAsymmetric() {
+		return nil, e.InternalServerError("The OAuth2/OIDC provider auth collection must use RS256 or EdDSA auth token signing algorithm.", nil)
+	}
+
+	signingKey, jwk, err := collection.AuthTokenSigning.SigningKey()
+	if err != nil {
+		return nil, e.InternalServerError("Missing or invalid OAuth2/OIDC provider signing key.", err)
+	}
+
+	return &oidcProvider{
+		issuer:        strings.TrimRight(e.App.Settings().Meta.AppURL, "/"),
+		loginURL:      config.LoginURL,
+		tokenDuration: config.TokenDurationTime(),
+		collection:    collection,
+		signingKey:    signingKey,
+		jwk:           jwk,
+	}, nil
+}
+
+// oidcDiscovery returns the OpenID Provider metadata as described in
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata.
+func oidcDiscovery(e *core.RequestEvent) error {
+	provider, err := loadOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"issuer":                                provider.issuer,
+		"authorization_endpoint":                provider.issuer + "/api/oidc/authorize",
+		"token_endpoint":                        provider.issuer + "/api/oidc/token",
+		"userinfo_endpoint":                     provider.issuer + "/api/oidc/userinfo",
+		"jwks_uri":                              provider.issuer + "/.well-known/jwks.json",
+		"scopes_supported":                      core.OIDCScopes,
+		"response_types_supported":              []string{oidcResponseTypeCode},
+		"grant_types_supported":                 []string{oidcGrantTypeAuthorizationCode},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{provider.jwk.Alg},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{oidcCodeChallengeMethodS256},
+		"claims_supported": []string{
+			"iss", "sub", "aud", "exp", "iat", "nonce",
+			"email", "email_verified", "name", "preferred_username", "updated_at",
+		},
+	})
+}
+
+// oidcAuthorize validates the authorization request and redirects
+// the user to the provider login page (together with the original query params).
+//
+// Validation errors are redirected back to the client (as described in RFC 6749 4.1.2.1)
+// except when the client or the redirect uri are invalid.
+func oidcAuthorize(e *core.RequestEvent) error {
+	provider, err := loadOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	query := e.Request.URL.Query()
+
+	form := &oidcAuthorizeForm{
+		ClientId:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		ResponseType:        query.Get("response_type"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	if _, err := form.loadClient(e.App); err != nil {
+		// never redirect to an unregistered uri
+		return e.BadRequestError("Missing or invalid client_id or redirect_uri.", err)
+	}
+
+	if code, err := form.validate(); err != nil {
+		return e.Redirect(http.StatusFound, form.redirectURL(url.Values{
+			"error":             {code},
+			"error_description": {err.Error()},
+		}))
+	}
+
+	loginURL, err := url.Parse(provider.loginURL)
+	if err != nil {
+		return e.InternalServerError("Invalid OAuth2/OIDC provider login url.", err)
+	}
+
+	loginQuery := loginURL.Query()
+	for k, v := range query {
+		loginQuery[k] = v
+	}
+	loginURL.RawQuery = loginQuery.Encode()
+
+	return e.Redirect(http.StatusFound, loginURL.String())
+}
+
+// oidcAuthorizeConfirm issues a new authorization code for the current
+// auth record and returns the client redirect url with the code.
+//
+// It is expected to be called by the provider login page after the user has signed in.
+func oidcAuthorizeConfirm(e *core.RequestEvent) error {
+	provider, err := loadOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	if e.Auth.Collection().Id != provider.collection.Id {
+		return e.ForbiddenError("Only "+provider.collection.Name+" records could authorize OAuth2/OIDC clients.", nil)
+	}
+
+	form := &oidcAuthorizeForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+
+	client, err := form.loadClient(e.App)
+	if err != nil {
+		return e.BadRequestError("Missing or invalid client_id or redirect_uri.", err)
+	}
+
+	if code, err := form.validate(); err != nil {
+		return e.BadRequestError("Invalid authorization request ("+code+").", err)
+	}
+
+	code := security.RandomString(50)
+
+	authCode := core.NewOIDCAuthCode(e.App)
+	authCode.SetClientRef(client.Id)
+	authCode.SetCollectionRef(e.Auth.Collection().Id)
+	authCode.SetRecordRef(e.Auth.Id)
+	authCode.SetCode(code)
+	authCode.SetRedirectURI(form.RedirectURI)
+	authCode.SetScope(form.normalizedScope())
+	authCode.SetNonce(form.Nonce)
+	authCode.SetCodeChallenge(form.CodeChallenge)
+
+	if err = e.App.Save(authCode); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to create the authorization code.", err))
+	}
+
+	return e.JSON(http.StatusOK, map[string]string{
+		"redirectURL": form.redirectURL(url.Values{"code": {code}}),
+	})
+}
+
+// oidcToken exchanges an authorization code for access and ID tokens.
+//
+// The error responses are in the format described in RFC 6749 5.2.
+func oidcToken(e *core.RequestEvent) error {
+	provider, err := loadOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	e.Response.Header().Set("Cache-Control", "no-store")
+	e.Response.Header().Set("Pragma", "no-cache")
+
+	form := &oidcTokenForm{}
+	if err = e.BindBody(form); err != nil {
+		return oidcTokenError(e, http.StatusBadRequest, "invalid_request", "Failed to load the submitted data.")
+	}
+
+	// client_secret_basic
+	if id, secret, ok := e.Request.BasicAuth(); ok {
+		form.ClientId, _ = url.QueryUnescape(id)
+		form.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	client, err := e.App.FindOIDCClientById(form.ClientId)
+	if err != nil || (!client.Public() && !client.ValidateSecret(form.ClientSecret)) {
+		return oidcTokenError(e, http.StatusUnauthorized, "invalid_client", "Invalid client credentials.")
+	}
+
+	if form.GrantType != oidcGrantTypeAuthorizationCode {
+		return oidcTokenError(e, http.StatusBadRequest, "unsupported_grant_type", "Only the authorization_code grant type is supported.")
+	}
+
+	if form.Code == "" {
+		return oidcTokenError(e, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code.")
+	}
+
+	var result map[string]any
+	var grantErr error
+
+	// consume the single use authorization code and mint the tokens in a single transaction
+	// (the code is deleted even if the grant is invalid, but it is restored on token creation failure)
+	err = e.App.RunInTransaction(func(txApp core.App) error {
+		authCode, err := txApp.ConsumeOIDCAuthCode(form.Code)
+		if err != nil {
+			grantErr = err
+			return nil
+		}
+
+		if authCode.ClientRef() != client.Id ||
+			authCode.HasExpired(core.OIDCAuthCodeDuration) ||
+			authCode.RedirectURI() != form.RedirectURI ||
+			!authCode.ValidateCodeVerifier(form.CodeVerifier) {
+			grantErr = errors.New("invalid authorization code params")
+			return nil
+		}
+
+		record, err := txApp.FindRecordById(authCode.CollectionRef(), authCode.RecordRef())
+		if err != nil || record.Collection().Id != provider.collection.Id {
+			grantErr = errors.New("invalid authorization code record")
+			return nil
+		}
+
+		result, err = oidcIssueTokens(provider, client, authCode, record)
+
+		return err
+	})
+	if err != nil {
+		return e.InternalServerError("Failed to create the access token.", err)
+	}
+
+	if grantErr != nil {
+		return oidcTokenError(e, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code.")
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// oidcIssueTokens creates the token endpoint response with the access token
+// (and ID token if the openid scope was granted) for the provided auth record.
+func oidcIssueTokens(provider *oidcProvider, client *core.OIDCClient, authCode *core.OIDCAuthCode, record *core.Record) (map[string]any, error) {
+	accessToken, err := record.NewOIDCAccessToken(client.Id, authCode.Scope(), provider.tokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int64(provider.tokenDuration.Seconds()),
+		"scope":        authCode.Scope(),
+	}
+
+	scopes := strings.Fields(authCode.Scope())
+
+	if slices.Contains(scopes, core.OIDCScopeOpenId) {
+		claims := oidcUserClaims(record, scopes)
+		claims["iss"] = provider.issuer
+		claims["aud"] = client.Id
+		claims["iat"] = time.Now().Unix()
+		if nonce := authCode.Nonce(); nonce != "" {
+			claims["nonce"] = nonce
+		}
+
+		idToken, err := security.NewSignedJWT(claims, provider.signingKey, provider.jwk.Kid, provider.tokenDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		result["id_token"] = idToken
+	}
+
+	return result, nil
+}
+
+// oidcUserInfo returns the claims of the access token auth record
+// based on the granted scopes.
+func oidcUserInfo(e *core.RequestEvent) error {
+	provider, err := loadOIDCProvider(e)
+	if err != nil {
+		return err
+	}
+
+	// the OIDC access tokens are not accepted by the default loadAuthToken
+	// middleware so they are verified only here
+	token := getAuthTokenFromRequest(e)
+
+	record, err := e.App.FindAuthRecordByToken(token, core.TokenTypeOIDCAccess)
+	if err != nil || record.Collection().Id != provider.collection.Id {
+		e.Response.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		return e.UnauthorizedError("Missing or invalid OAuth2/OIDC access token.", err)
+	}
+
+	// the token is already verified
+	claims, _ := security.ParseUnverifiedJWT(token)
+	clientId := cast.ToString(claims[core.TokenClaimClientId])
+
+	if _, err := e.App.FindOIDCClientById(clientId); clientId == "" || err != nil {
+		e.Response.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		return e.UnauthorizedError("Missing or invalid OAuth2/OIDC access token.", err)
+	}
+
+	scopes := strings.Fields(cast.ToString(claims[core.TokenClaimScope]))
+
+	return e.JSON(http.StatusOK, oidcUserClaims(record, scopes))
+}
+
+// oidcUserClaims returns the standard OIDC claims of the
+// provided auth record based on the granted scopes.
+func oidcUserClaims(record *core.Record, scopes []string) map[string]any {
+	claims := map[string]any{
+		"sub": record.Id,
+	}
+
+	if slices.Contains(scopes, core.OIDCScopeEmail) && record.Email() != "" {
+		claims["email"] = record.Email()
+		claims["email_verified"] = record.Verified()
+	}
+
+	if slices.Contains(scopes, core.OIDCScopeProfile) {
+		if name := record.GetString("name"); name != "" {
+			claims["name"] = name
+		}
+
+		if username := record.GetString("username"); username != "" {
+			claims["preferred_username"] = username
+		}
+
+		if updated := record.GetDateTime("updated"); !updated.IsZero() {
+			claims["updated_at"] = updated.Time().Unix()
+		}
+	}
+
+	return claims
+}
+
+func oidcTokenError(e *core.RequestEvent, status int, code string, description string) error {
+	return e.JSON(status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// -------------------------------------------------------------------
+
+type oidcAuthorizeForm struct {
+	ClientId            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	ResponseType        string `form:"response_type" json:"response_type"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// loadClient loads the form client and checks whether
+// the form redirect uri is registered for it.
+func (form *oidcAuthorizeForm) loadClient(app core.App) (*core.OIDCClient, error) {
+	if form.ClientId == "" {
+		return nil, errors.New("missing client_id")
+	}
+
+	client, err := app.FindOIDCClientById(form.ClientId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.HasRedirectURI(form.RedirectURI) {
+		return nil, errors.New("unregistered redirect_uri")
+	}
+
+	return client, nil
+}
+
+// validate checks the authorization request params and
+// returns the RFC 6749 error code together with the validation error.
+func (form *oidcAuthorizeForm) validate() (string, error) {
+	if form.ResponseType != oidcResponseTypeCode {
+		return "unsupported_response_type", errors.New("only the code response type is supported")
+	}
+
+	scopes := strings.Fields(form.Scope)
+	if len(scopes) == 0 {
+		return "invalid_scope", errors.New("missing scope")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(core.OIDCScopes, scope) {
+			return "invalid_scope", errors.New("unsupported scope " + scope)
+		}
+	}
+
+	// PKCE is required for all clients
+	if form.CodeChallenge == "" || form.CodeChallengeMethod != oidcCodeChallengeMethodS256 {
+		return "invalid_request", errors.New("missing code_challenge or unsupported code_challenge_method (only S256 is supported)")
+	}
+
+	if len(form.Nonce) > 255 {
+		return "invalid_request", errors.New("nonce must be no more than 255 characters")
+	}
+
+	return "", nil
+}
+
+// normalizedScope returns the unique form scopes in their supported order.
+func (form *oidcAuthorizeForm) normalizedScope() string {
+	scopes := strings.Fields(form.Scope)
+
+	result := make([]string, 0, len(scopes))
+	for _, scope := range core.OIDCScopes {
+		if slices.Contains(scopes, scope) {
+			result = append(result, scope)
+		}
+	}
+
+	return strings.Join(result, " ")
+}
+
+// redirectURL returns the form redirect uri with the specified
+// params and the form state (if any) appended to its query.
+func (form *oidcAuthorizeForm) redirectURL(params url.Values) string {
+	u, err := url.Parse(form.RedirectURI)
+	if err != nil {
+		return form.RedirectURI // should be already validated
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	if form.State != "" {
+		query.Set("state", form.State)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+type oidcTokenForm struct {
+	GrantType    string `form:"grant_type" json:"grant_type"`
+	Code         string `form:"code" json:"code"`
+	RedirectURI  string `form:"redirect_uri" json:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier" json:"code_verifier"`
+	ClientId     string `form:"client_id" json:"client_id"`
+	ClientSecret string `form:"client_secret" json:"client_secret"`
+}
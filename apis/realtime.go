@@ -16,6 +16,7 @@ import (
 	"github.com/hanzoai/backendPB/tools/router"
 	"github.com/hanzoai/backendPB/tools/routine"
 	"github.com/hanzoai/backendPB/tools/search"
+	"github.com/hanzoai/backendPB/tools/security"
 	"github.com/hanzoai/backendPB/tools/subscriptions"
 	"github.com/hanzoai/dbx"
 	"golang.org/x/sync/errgroup"
@@ -27,13 +28,21 @@ const clientsChunkSize = 150
 // RealtimeClientAuthKey is the name of the realtime client store key that holds its auth state.
 const RealtimeClientAuthKey = "auth"
 
+// RealtimeClientAuthSessionKey is the name of the realtime client store key that holds
+// the auth session id of its auth state (if authenticated with a session bound token).
+const RealtimeClientAuthSessionKey = "authSessionId"
+
 // bindRealtimeApi registers the realtime api endpoints.
 func bindRealtimeApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	sub := rg.Group("/realtime")
 	sub.GET("", realtimeConnect).Bind(SkipSuccessActivityLog())
 	sub.POST("", realtimeSetSubscriptions)
+	sub.GET("/ws", realtimeWSConnect).Bind(SkipSuccessActivityLog())
+
+	bindRealtimeChannelsApi(sub)
 
 	bindRealtimeEvents(app)
+	bindRealtimeNodes(app)
 }
 
 func realtimeConnect(e *core.RequestEvent) error {
@@ -65,10 +74,17 @@ func realtimeConnect(e *core.RequestEvent) error {
 	connectEvent.Client = subscriptions.NewDefaultClient()
 	connectEvent.IdleTimeout = 5 * time.Minute
 
+	// store the last received event id of the reconnected client
+	// so that the missed messages can be replayed on subscribe
+	if lastEventId := realtimeLastEventId(e.Request); lastEventId != "" {
+		connectEvent.Client.Set(RealtimeClientLastEventIdKey, lastEventId)
+	}
+
 	return e.App.OnRealtimeConnectRequest().Trigger(connectEvent, func(ce *core.RealtimeConnectRequestEvent) error {
 		// register new subscription client
 		ce.App.SubscriptionsBroker().Register(ce.Client)
 		defer func() {
+			realtimeLeaveAllChannels(e.App, ce.Client)
 			e.App.SubscriptionsBroker().Unregister(ce.Client.Id())
 		}()
 
@@ -83,12 +99,7 @@ func realtimeConnect(e *core.RequestEvent) error {
 			Data: []byte(`{"clientId":"` + ce.Client.Id() + `"}`),
 		}
 		connectMsgErr := ce.App.OnRealtimeMessageSend().Trigger(connectMsgEvent, func(me *core.RealtimeMessageEvent) error {
-			me.Response.Write([]byte("id:" + me.Client.Id() + "\n"))
-			me.Response.Write([]byte("event:" + me.Message.Name + "\n"))
-			me.Response.Write([]byte("data:"))
-			me.Response.Write(me.Message.Data)
-			me.Response.Write([]byte("\n\n"))
-			return me.Flush()
+			return realtimeSSEWrite(me)
 		})
 		if connectMsgErr != nil {
 			ce.App.Logger().Debug(
@@ -122,12 +133,7 @@ func realtimeConnect(e *core.RequestEvent) error {
 				msgEvent.Client = ce.Client
 				msgEvent.Message = &msg
 				msgErr := ce.App.OnRealtimeMessageSend().Trigger(msgEvent, func(me *core.RealtimeMessageEvent) error {
-					me.Response.Write([]byte("id:" + me.Client.Id() + "\n"))
-					me.Response.Write([]byte("event:" + me.Message.Name + "\n"))
-					me.Response.Write([]byte("data:"))
-					me.Response.Write(me.Message.Data)
-					me.Response.Write([]byte("\n\n"))
-					return me.Flush()
+					return realtimeSSEWrite(me)
 				})
 				if msgErr != nil {
 					ce.App.Logger().Debug(
@@ -152,6 +158,25 @@ func realtimeConnect(e *core.RequestEvent) error {
 	})
 }
 
+// realtimeSSEWrite writes the provided realtime message event as a single SSE message.
+//
+// The client id is used as SSE message id for the messages without an explicit event id
+// (e.g. PB_CONNECT) to preserve backward compatibility with the existing clients.
+func realtimeSSEWrite(me *core.RealtimeMessageEvent) error {
+	id := me.Message.Id
+	if id == "" {
+		id = me.Client.Id()
+	}
+
+	me.Response.Write([]byte("id:" + id + "\n"))
+	me.Response.Write([]byte("event:" + me.Message.Name + "\n"))
+	me.Response.Write([]byte("data:"))
+	me.Response.Write(me.Message.Data)
+	me.Response.Write([]byte("\n\n"))
+
+	return me.Flush()
+}
+
 type realtimeSubscribeForm struct {
 	ClientId      string   `form:"clientId" json:"clientId"`
 	Subscriptions []string `form:"subscriptions" json:"subscriptions"`
@@ -200,7 +225,7 @@ func realtimeSetSubscriptions(e *core.RequestEvent) error {
 
 	return e.App.OnRealtimeSubscribeRequest().Trigger(event, func(e *core.RealtimeSubscribeRequestEvent) error {
 		// update auth state
-		e.Client.Set(RealtimeClientAuthKey, e.Auth)
+		realtimeSetClientAuth(e.Client, e.Auth, getAuthTokenFromRequest(e.RequestEvent))
 
 		// unsubscribe from any previous existing subscriptions
 		e.Client.Unsubscribe()
@@ -214,10 +239,33 @@ func realtimeSetSubscriptions(e *core.RequestEvent) error {
 			slog.Any("subscriptions", e.Subscriptions),
 		)
 
+		realtimeReplayMissedMessages(e.App, e.Client)
+
 		return e.NoContent(http.StatusNoContent)
 	})
 }
 
+// realtimeSetClientAuth updates the client auth state with the provided auth record
+// and the auth session id of its token (if any).
+func realtimeSetClientAuth(client subscriptions.Client, auth *core.Record, token string) {
+	client.Set(RealtimeClientAuthKey, auth)
+
+	var sessionId string
+	if auth != nil {
+		// note: the token is expected to be already verified
+		claims, _ := security.ParseUnverifiedJWT(token)
+		if id, _ := claims[core.TokenClaimId].(string); id == auth.Id {
+			sessionId, _ = claims[core.TokenClaimSessionId].(string)
+		}
+	}
+
+	if sessionId == "" {
+		client.Unset(RealtimeClientAuthSessionKey)
+	} else {
+		client.Set(RealtimeClientAuthSessionKey, sessionId)
+	}
+}
+
 // updateClientsAuth updates the existing clients auth record with the new one (matched by ID).
 func realtimeUpdateClientsAuth(app core.App, newAuthRecord *core.Record) error {
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
@@ -256,6 +304,31 @@ func realtimeUnsetClientsAuthState(app core.App, authModel core.Model) error {
 					clientAuth.Id == authModel.PK() &&
 					clientAuth.Collection().Name == authModel.TableName() {
 					client.Unset(RealtimeClientAuthKey)
+					client.Unset(RealtimeClientAuthSessionKey)
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+// realtimeUnsetSessionClientsAuthState unsets the auth state of all clients
+// that are authenticated with the provided auth session.
+func realtimeUnsetSessionClientsAuthState(app core.App, sessionId string) error {
+	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
+
+	group := new(errgroup.Group)
+
+	for _, chunk := range chunks {
+		group.Go(func() error {
+			for _, client := range chunk {
+				clientSessionId, _ := client.Get(RealtimeClientAuthSessionKey).(string)
+				if clientSessionId != "" && clientSessionId == sessionId {
+					client.Unset(RealtimeClientAuthKey)
+					client.Unset(RealtimeClientAuthSessionKey)
 				}
 			}
 
@@ -280,6 +353,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecordEvent(e.App, realtimeRecordOpUpdateClientsAuth, "", authRecord, false)
 			}
 
 			return e.Next()
@@ -301,6 +376,44 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				// note: custom auth models are not fanned out because
+				// they can't be resolved to a record after the delete
+				if record, ok := e.Model.(*core.Record); ok {
+					realtimePublishRecordEvent(e.App, realtimeRecordOpUnsetClientsAuth, "", record, false)
+				}
+			}
+
+			return e.Next()
+		},
+		Priority: -99,
+	})
+
+	// unset the auth state of the client(s) associated to the deleted (aka. revoked) auth session
+	app.OnModelAfterDeleteSuccess().Bind(&hook.Handler[*core.ModelEvent]{
+		Func: func(e *core.ModelEvent) error {
+			if e.Model.TableName() != core.CollectionNameAuthSessions {
+				return e.Next()
+			}
+
+			var record *core.Record
+			switch m := e.Model.(type) {
+			case *core.Record:
+				record = m
+			case core.RecordProxy:
+				record = m.ProxyRecord()
+			}
+
+			if record != nil {
+				if err := realtimeUnsetSessionClientsAuthState(e.App, record.Id); err != nil {
+					app.Logger().Warn(
+						"Failed to remove client(s) associated to the deleted auth session",
+						slog.String("id", record.Id),
+						slog.String("error", err.Error()),
+					)
+				}
+
+				realtimePublishRecordEvent(e.App, realtimeRecordOpUnsetSessionClientsAuth, "", record, false)
 			}
 
 			return e.Next()
@@ -321,6 +434,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecordEvent(e.App, realtimeRecordOpBroadcast, "create", record, false)
 			}
 
 			return e.Next()
@@ -332,15 +447,22 @@ func bindRealtimeEvents(app core.App) {
 		Func: func(e *core.ModelEvent) error {
 			record := realtimeResolveRecord(e.App, e.Model, "")
 			if record != nil {
-				err := realtimeBroadcastRecord(e.App, "update", record, false)
+				action := "update"
+				if record.IsRestored() {
+					action = "restore"
+				}
+
+				err := realtimeBroadcastRecord(e.App, action, record, false)
 				if err != nil {
 					app.Logger().Debug(
-						"Failed to broadcast record update",
+						"Failed to broadcast record "+action,
 						slog.String("id", record.Id),
 						slog.String("collectionName", record.Collection().Name),
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecordEvent(e.App, realtimeRecordOpBroadcast, action, record, false)
 			}
 
 			return e.Next()
@@ -362,6 +484,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecordEvent(e.App, realtimeRecordOpBroadcast, "delete", record, true)
 			}
 
 			return e.Next()
@@ -383,6 +507,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecordEvent(e.App, realtimeRecordOpBroadcastDryCached, "delete", record, false)
 			}
 
 			return e.Next()
@@ -404,6 +530,8 @@ func bindRealtimeEvents(app core.App) {
 						slog.String("error", err.Error()),
 					)
 				}
+
+				realtimePublishRecordEvent(e.App, realtimeRecordOpUnsetDryCached, "delete", record, false)
 			}
 
 			return e.Next()
@@ -466,21 +594,16 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 		return errors.New("[broadcastRecord] Record collection not set")
 	}
 
+	// register the event for the reconnecting clients
+	// (the dry cached events are replayable only after the delete confirmation)
+	eventId := realtimeReplayBufferFor(app).add(action, record, dryCache)
+
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
 	if len(chunks) == 0 {
 		return nil // no subscribers
 	}
 
-	subscriptionRuleMap := map[string]*string{
-		(collection.Name + "/" + record.Id + "?"): collection.ViewRule,
-		(collection.Id + "/" + record.Id + "?"):   collection.ViewRule,
-		(collection.Name + "/*?"):                 collection.ListRule,
-		(collection.Id + "/*?"):                   collection.ListRule,
-
-		// @deprecated: the same as the wildcard topic but kept for backward compatibility
-		(collection.Name + "?"): collection.ListRule,
-		(collection.Id + "?"):   collection.ListRule,
-	}
+	subscriptionRuleMap := realtimeSubscriptionRuleMap(collection, record.Id)
 
 	dryCacheKey := action + "/" + record.Id
 
@@ -488,142 +611,193 @@ func realtimeBroadcastRecord(app core.App, action string, record *core.Record, d
 
 	for _, chunk := range chunks {
 		group.Go(func() error {
-			var clientAuth *core.Record
-
 			for _, client := range chunk {
 				// note: not executed concurrently to avoid races and to ensure
 				// that the access checks are applied for the current record db state
-				for prefix, rule := range subscriptionRuleMap {
-					subs := client.Subscriptions(prefix)
-					if len(subs) == 0 {
-						continue
+				messages := realtimeClientRecordMessages(
+					app,
+					client,
+					collection,
+					subscriptionRuleMap,
+					action,
+					record,
+					eventId,
+					realtimeCanAccessRecord,
+				)
+
+				for _, msg := range messages {
+					if dryCache {
+						cached, ok := client.Get(dryCacheKey).([]subscriptions.Message)
+						if !ok {
+							cached = []subscriptions.Message{msg}
+						} else {
+							cached = append(cached, msg)
+						}
+						client.Set(dryCacheKey, cached)
+					} else {
+						routine.FireAndForget(func() {
+							client.Send(msg)
+						})
 					}
+				}
+			}
 
-					clientAuth, _ = client.Get(RealtimeClientAuthKey).(*core.Record)
+			return nil
+		})
+	}
 
-					for sub, options := range subs {
-						// create a clean record copy without expand and unknown fields
-						// because we don't know yet which exact fields the client subscription has permissions to access
-						cleanRecord := record.Fresh()
+	return group.Wait()
+}
 
-						// mock request data
-						requestInfo := &core.RequestInfo{
-							Context: core.RequestInfoContextRealtime,
-							Method:  "GET",
-							Query:   options.Query,
-							Headers: options.Headers,
-							Auth:    clientAuth,
-						}
+// realtimeSubscriptionRuleMap returns the record subscription topic prefixes
+// together with their collection access rule.
+func realtimeSubscriptionRuleMap(collection *core.Collection, recordId string) map[string]*string {
+	return map[string]*string{
+		(collection.Name + "/" + recordId + "?"): collection.ViewRule,
+		(collection.Id + "/" + recordId + "?"):   collection.ViewRule,
+		(collection.Name + "/*?"):                collection.ListRule,
+		(collection.Id + "/*?"):                  collection.ListRule,
 
-						if !realtimeCanAccessRecord(app, cleanRecord, requestInfo, rule) {
-							continue
-						}
+		// @deprecated: the same as the wildcard topic but kept for backward compatibility
+		(collection.Name + "?"): collection.ListRule,
+		(collection.Id + "?"):   collection.ListRule,
+	}
+}
 
-						// trigger the enrich hooks
-						enrichErr := triggerRecordEnrichHooks(app, requestInfo, []*core.Record{cleanRecord}, func() error {
-							// apply expand
-							rawExpand := options.Query[expandQueryParam]
-							if rawExpand != "" {
-								expandErrs := app.ExpandRecord(cleanRecord, strings.Split(rawExpand, ","), expandFetch(app, requestInfo))
-								if len(expandErrs) > 0 {
-									app.Logger().Debug(
-										"[broadcastRecord] expand errors",
-										slog.String("id", cleanRecord.Id),
-										slog.String("collectionName", cleanRecord.Collection().Name),
-										slog.String("sub", sub),
-										slog.String("expand", rawExpand),
-										slog.Any("errors", expandErrs),
-									)
-								}
-							}
-
-							// ignore the auth record email visibility checks
-							// for auth owner, superuser or manager
-							if collection.IsAuth() {
-								if isSameAuth(clientAuth, cleanRecord) ||
-									realtimeCanAccessRecord(app, cleanRecord, requestInfo, collection.ManageRule) {
-									cleanRecord.IgnoreEmailVisibility(true)
-								}
-							}
-
-							return nil
-						})
-						if enrichErr != nil {
-							app.Logger().Debug(
-								"[broadcastRecord] record enrich error",
-								slog.String("id", cleanRecord.Id),
-								slog.String("collectionName", cleanRecord.Collection().Name),
-								slog.String("sub", sub),
-								slog.Any("error", enrichErr),
-							)
-							continue
-						}
+// realtimeClientRecordMessages returns the record action messages
+// for all matching subscriptions of the specified client that pass the canAccess check.
+func realtimeClientRecordMessages(
+	app core.App,
+	client subscriptions.Client,
+	collection *core.Collection,
+	subscriptionRuleMap map[string]*string,
+	action string,
+	record *core.Record,
+	eventId string,
+	canAccess func(app core.App, record *core.Record, requestInfo *core.RequestInfo, accessRule *string) bool,
+) []subscriptions.Message {
+	var result []subscriptions.Message
+
+	for prefix, rule := range subscriptionRuleMap {
+		subs := client.Subscriptions(prefix)
+		if len(subs) == 0 {
+			continue
+		}
 
-						data := &recordData{
-							Action: action,
-							Record: cleanRecord,
-						}
+		clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
 
-						// check fields
-						rawFields := options.Query[fieldsQueryParam]
-						if rawFields != "" {
-							decoded, err := picker.Pick(cleanRecord, rawFields)
-							if err == nil {
-								data.Record = decoded
-							} else {
-								app.Logger().Debug(
-									"[broadcastRecord] pick fields error",
-									slog.String("id", cleanRecord.Id),
-									slog.String("collectionName", cleanRecord.Collection().Name),
-									slog.String("sub", sub),
-									slog.String("fields", rawFields),
-									slog.String("error", err.Error()),
-								)
-							}
-						}
+		for sub, options := range subs {
+			// create a clean record copy without expand and unknown fields
+			// because we don't know yet which exact fields the client subscription has permissions to access
+			cleanRecord := record.Fresh()
 
-						dataBytes, err := json.Marshal(data)
-						if err != nil {
-							app.Logger().Debug(
-								"[broadcastRecord] data marshal error",
-								slog.String("id", cleanRecord.Id),
-								slog.String("collectionName", cleanRecord.Collection().Name),
-								slog.String("error", err.Error()),
-							)
-							continue
-						}
+			// mock request data
+			requestInfo := &core.RequestInfo{
+				Context: core.RequestInfoContextRealtime,
+				Method:  "GET",
+				Query:   options.Query,
+				Headers: options.Headers,
+				Auth:    clientAuth,
+			}
 
-						msg := subscriptions.Message{
-							Name: sub,
-							Data: dataBytes,
-						}
+			if !canAccess(app, cleanRecord, requestInfo, rule) {
+				continue
+			}
 
-						if dryCache {
-							messages, ok := client.Get(dryCacheKey).([]subscriptions.Message)
-							if !ok {
-								messages = []subscriptions.Message{msg}
-							} else {
-								messages = append(messages, msg)
-							}
-							client.Set(dryCacheKey, messages)
-						} else {
-							routine.FireAndForget(func() {
-								client.Send(msg)
-							})
-						}
+			// trigger the enrich hooks
+			enrichErr := triggerRecordEnrichHooks(app, requestInfo, []*core.Record{cleanRecord}, func() error {
+				// hide the fields with unsatisfied view field rules
+				if err := hideFieldsByViewRules(app, []*core.Record{cleanRecord}, requestInfo); err != nil {
+					return err
+				}
+
+				// apply expand
+				rawExpand := options.Query[expandQueryParam]
+				if rawExpand != "" {
+					expandErrs := app.ExpandRecord(cleanRecord, strings.Split(rawExpand, ","), expandFetch(app, requestInfo))
+					if len(expandErrs) > 0 {
+						app.Logger().Debug(
+							"[broadcastRecord] expand errors",
+							slog.String("id", cleanRecord.Id),
+							slog.String("collectionName", cleanRecord.Collection().Name),
+							slog.String("sub", sub),
+							slog.String("expand", rawExpand),
+							slog.Any("errors", expandErrs),
+						)
+					}
+				}
+
+				// ignore the auth record email visibility checks
+				// for auth owner, superuser or manager
+				if collection.IsAuth() {
+					if isSameAuth(clientAuth, cleanRecord) ||
+						realtimeCanAccessRecord(app, cleanRecord, requestInfo, collection.ManageRule) {
+						cleanRecord.IgnoreEmailVisibility(true)
 					}
 				}
+
+				return nil
+			})
+			if enrichErr != nil {
+				app.Logger().Debug(
+					"[broadcastRecord] record enrich error",
+					slog.String("id", cleanRecord.Id),
+					slog.String("collectionName", cleanRecord.Collection().Name),
+					slog.String("sub", sub),
+					slog.Any("error", enrichErr),
+				)
+				continue
 			}
 
-			return nil
-		})
+			data := &recordData{
+				Action: action,
+				Record: cleanRecord,
+			}
+
+			// check fields
+			rawFields := options.Query[fieldsQueryParam]
+			if rawFields != "" {
+				decoded, err := picker.Pick(cleanRecord, rawFields)
+				if err == nil {
+					data.Record = decoded
+				} else {
+					app.Logger().Debug(
+						"[broadcastRecord] pick fields error",
+						slog.String("id", cleanRecord.Id),
+						slog.String("collectionName", cleanRecord.Collection().Name),
+						slog.String("sub", sub),
+						slog.String("fields", rawFields),
+						slog.String("error", err.Error()),
+					)
+				}
+			}
+
+			dataBytes, err := json.Marshal(data)
+			if err != nil {
+				app.Logger().Debug(
+					"[broadcastRecord] data marshal error",
+					slog.String("id", cleanRecord.Id),
+					slog.String("collectionName", cleanRecord.Collection().Name),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+
+			result = append(result, subscriptions.Message{
+				Name: sub,
+				Data: dataBytes,
+				Id:   eventId,
+			})
+		}
 	}
 
-	return group.Wait()
+	return result
 }
 
 // realtimeBroadcastDryCachedRecord broadcasts all cached record related messages.
 func realtimeBroadcastDryCachedRecord(app core.App, action string, record *core.Record) error {
+	realtimeReplayBufferFor(app).confirm(action, record)
+
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
 	if len(chunks) == 0 {
 		return nil // no subscribers
@@ -661,6 +835,8 @@ func realtimeBroadcastDryCachedRecord(app core.App, action string, record *core.
 
 // realtimeUnsetDryCachedRecord removes the dry cached record related messages.
 func realtimeUnsetDryCachedRecord(app core.App, action string, record *core.Record) error {
+	realtimeReplayBufferFor(app).discard(action, record)
+
 	chunks := app.SubscriptionsBroker().ChunkedClients(clientsChunkSize)
 	if len(chunks) == 0 {
 		return nil // no subscribers
@@ -0,0 +1,529 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"sync"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/router"
+	"github.com/hanzoai/backendPB/tools/routine"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/subscriptions"
+)
+
+// RealtimeClientChannelsKey is the name of the realtime client store key
+// that holds the set of the joined custom channels.
+const RealtimeClientChannelsKey = "channels"
+
+// RealtimeChannelMessagePrefix is the name prefix of the custom channel
+// realtime messages (e.g. "@channels/rooms:abc").
+const RealtimeChannelMessagePrefix = "@channels/"
+
+// List with the custom channel realtime message events.
+const (
+	RealtimeChannelEventJoin    = "join"
+	RealtimeChannelEventLeave   = "leave"
+	RealtimeChannelEventMessage = "message"
+)
+
+const (
+	// maxRealtimeChannelMessageSize specifies the max allowed size (in bytes)
+	// of a single published channel message data.
+	maxRealtimeChannelMessageSize = 64 << 10
+
+	// maxRealtimeChannelsPerClient specifies the max number of channels a single client could join.
+	maxRealtimeChannelsPerClient = 100
+)
+
+// realtimeChannelNodeEventName is the name of the custom channel
+// realtime events fanned out between the app nodes via the broker backend.
+const realtimeChannelNodeEventName = "channel"
+
+var (
+	errRealtimeChannelNotFound  = errors.New("missing or unknown realtime channel")
+	errRealtimeChannelForbidden = errors.New("the realtime channel access is not allowed")
+	errRealtimeChannelNotJoined = errors.New("the client hasn't joined the realtime channel")
+	errRealtimeChannelLimit     = errors.New("max allowed joined channels limit reached")
+	errRealtimeChannelData      = errors.New("invalid or too large channel message data")
+)
+
+// realtimeChannelsStoreKey is the name of the app store key that holds the realtime channels state.
+const realtimeChannelsStoreKey = "@realtimeChannels"
+
+// realtimeChannelsState defines the realtime channels state of a single app.
+type realtimeChannelsState struct {
+	// remote holds the presence of the channel members connected to the other
+	// app nodes replicated from their join/leave node events
+	// (channel name -> presence id -> presence).
+	//
+	// Note that the replication is best-effort, meaning that a node is aware only
+	// of the members that have joined after its start and the members of an
+	// abruptly terminated node remain until their leave event is received.
+	remote map[string]map[string]*realtimePresence
+
+	// mux guards the clients joined channels read-modify-write operations
+	// and the remote presence.
+	mux sync.Mutex
+}
+
+// realtimeChannelsStateFor returns the realtime channels state associated with the provided app
+// (it is created on first access).
+func realtimeChannelsStateFor(app core.App) *realtimeChannelsState {
+	return app.Store().GetOrSet(realtimeChannelsStoreKey, func() any {
+		return &realtimeChannelsState{
+			remote: map[string]map[string]*realtimePresence{},
+		}
+	}).(*realtimeChannelsState)
+}
+
+// setRemote adds (join) or removes (leave) the remote channel member presence.
+func (s *realtimeChannelsState) setRemote(name string, presence *realtimePresence, joined bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	members := s.remote[name]
+
+	if !joined {
+		delete(members, presence.Id)
+		if len(members) == 0 {
+			delete(s.remote, name)
+		}
+		return
+	}
+
+	if members == nil {
+		members = map[string]*realtimePresence{}
+		s.remote[name] = members
+	}
+
+	members[presence.Id] = presence
+}
+
+// remotePresence returns the presence of the channel members connected to the other app nodes.
+func (s *realtimeChannelsState) remotePresence(name string) []*realtimePresence {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	result := make([]*realtimePresence, 0, len(s.remote[name]))
+
+	for _, presence := range s.remote[name] {
+		result = append(result, presence)
+	}
+
+	return result
+}
+
+// realtimePresence defines a single channel member presence info.
+//
+// The realtime client id is never exposed because it is used
+// to authorize the SSE subscription changes.
+type realtimePresence struct {
+	Auth *realtimePresenceAuth `json:"auth"`
+	Id   string                `json:"id"`
+}
+
+type realtimePresenceAuth struct {
+	Id             string `json:"id"`
+	CollectionName string `json:"collectionName"`
+}
+
+// realtimeChannelMessage defines the data of a single custom channel realtime message.
+type realtimeChannelMessage struct {
+	Client  *realtimePresence `json:"client"`
+	Channel string            `json:"channel"`
+	Event   string            `json:"event"`
+	Data    json.RawMessage   `json:"data,omitempty"`
+}
+
+// bindRealtimeChannelsApi registers the custom realtime channels api endpoints.
+func bindRealtimeChannelsApi(rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/channels/{channel}")
+	sub.GET("/presence", realtimeChannelPresenceList)
+	sub.POST("/join", realtimeChannelJoin)
+	sub.POST("/leave", realtimeChannelLeave)
+	sub.POST("/publish", realtimeChannelPublish)
+}
+
+type realtimeChannelForm struct {
+	ClientId string          `form:"clientId" json:"clientId"`
+	Data     json.RawMessage `form:"data" json:"data"`
+}
+
+func (form *realtimeChannelForm) validate(requireClient bool) error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.ClientId, validation.When(requireClient, validation.Required), validation.Length(0, 255)),
+	)
+}
+
+func realtimeChannelPresenceList(e *core.RequestEvent) error {
+	name := e.Request.PathValue("channel")
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	if err := realtimeCheckChannelAccess(e.App, name, requestInfo, false); err != nil {
+		return realtimeChannelApiError(e, err)
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"presence": realtimeChannelPresence(e.App, name),
+	})
+}
+
+func realtimeChannelJoin(e *core.RequestEvent) error {
+	form, client, err := realtimeBindChannelForm(e, true)
+	if err != nil {
+		return err
+	}
+
+	name := e.Request.PathValue("channel")
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	if err := realtimeCheckChannelAccess(e.App, name, requestInfo, false); err != nil {
+		return realtimeChannelApiError(e, err)
+	}
+
+	// update auth state (the same as for the subscriptions)
+	realtimeSetClientAuth(client, e.Auth, getAuthTokenFromRequest(e))
+
+	if err := realtimeJoinChannel(e.App, client, name); err != nil {
+		return realtimeChannelApiError(e, err)
+	}
+
+	e.App.Logger().Debug(
+		"Realtime channel joined.",
+		slog.String("clientId", form.ClientId),
+		slog.String("channel", name),
+	)
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"id":       realtimePresenceId(client),
+		"presence": realtimeChannelPresence(e.App, name),
+	})
+}
+
+func realtimeChannelLeave(e *core.RequestEvent) error {
+	_, client, err := realtimeBindChannelForm(e, true)
+	if err != nil {
+		return err
+	}
+
+	if !realtimeLeaveChannel(e.App, client, e.Request.PathValue("channel")) {
+		return realtimeChannelApiError(e, errRealtimeChannelNotJoined)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func realtimeChannelPublish(e *core.RequestEvent) error {
+	form, client, err := realtimeBindChannelForm(e, false)
+	if err != nil {
+		return err
+	}
+
+	name := e.Request.PathValue("channel")
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	if err := realtimeCheckChannelAccess(e.App, name, requestInfo, true); err != nil {
+		return realtimeChannelApiError(e, err)
+	}
+
+	// the publisher is optional but if set it must be a channel member
+	var sender *realtimePresence
+	if client != nil {
+		if !realtimeHasJoinedChannel(client, name) {
+			return realtimeChannelApiError(e, errRealtimeChannelNotJoined)
+		}
+		sender = realtimeClientPresence(client)
+	}
+
+	if err := realtimeSendChannelMessage(e.App, name, RealtimeChannelEventMessage, sender, form.Data); err != nil {
+		return realtimeChannelApiError(e, err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// realtimeBindChannelForm binds and validates the channel request body
+// and returns the related subscription client (if clientId is set).
+func realtimeBindChannelForm(e *core.RequestEvent, requireClient bool) (*realtimeChannelForm, subscriptions.Client, error) {
+	form := new(realtimeChannelForm)
+
+	if err := e.BindBody(form); err != nil {
+		return nil, nil, e.BadRequestError("", err)
+	}
+
+	if err := form.validate(requireClient); err != nil {
+		return nil, nil, e.BadRequestError("", err)
+	}
+
+	if form.ClientId == "" {
+		return form, nil, nil
+	}
+
+	client, err := e.App.SubscriptionsBroker().ClientById(form.ClientId)
+	if err != nil {
+		return nil, nil, e.NotFoundError("Missing or invalid client id.", err)
+	}
+
+	// for now allow only guest->auth upgrades and any other auth change is forbidden
+	clientAuth, _ := client.Get(RealtimeClientAuthKey).(*core.Record)
+	if clientAuth != nil && !isSameAuth(clientAuth, e.Auth) {
+		return nil, nil, e.ForbiddenError("The current and the previous request authorization don't match.", nil)
+	}
+
+	return form, client, nil
+}
+
+// realtimeChannelApiError converts the provided channel error into an api error.
+func realtimeChannelApiError(e *core.RequestEvent, err error) error {
+	switch {
+	case errors.Is(err, errRealtimeChannelNotFound):
+		return e.NotFoundError("Missing or unknown realtime channel.", err)
+	case errors.Is(err, errRealtimeChannelForbidden):
+		return e.ForbiddenError("You are not allowed to access the realtime channel.", err)
+	default:
+		return e.BadRequestError(err.Error(), nil)
+	}
+}
+
+// realtimeCheckChannelAccess checks whether the requestInfo satisfies
+// the join (or publish) rule of the channel with the specified name.
+func realtimeCheckChannelAccess(app core.App, name string, requestInfo *core.RequestInfo, publish bool) error {
+	if len(name) > 255 || !core.RealtimeChannelNameRegex.MatchString(name) {
+		return errRealtimeChannelNotFound
+	}
+
+	channel, ok := app.Settings().Realtime.FindChannel(name)
+	if !ok {
+		return errRealtimeChannelNotFound
+	}
+
+	rule := channel.JoinRule
+	if publish {
+		rule = channel.PublishRule
+	}
+
+	canAccess, err := app.CanAccessRealtimeChannel(name, requestInfo, rule)
+	if err != nil {
+		app.Logger().Debug(
+			"Realtime channel rule check failure",
+			slog.String("channel", name),
+			slog.String("error", err.Error()),
+		)
+	}
+	if !canAccess {
+		return errRealtimeChannelForbidden
+	}
+
+	return nil
+}
+
+// realtimeJoinChannel adds the client to the specified channel members
+// and notifies the other channel members.
+//
+// Joining an already joined channel is a no-op.
+func realtimeJoinChannel(app core.App, client subscriptions.Client, name string) error {
+	state := realtimeChannelsStateFor(app)
+
+	state.mux.Lock()
+	channels, _ := client.Get(RealtimeClientChannelsKey).(map[string]struct{})
+	if _, ok := channels[name]; ok {
+		state.mux.Unlock()
+		return nil // already joined
+	}
+	if len(channels) >= maxRealtimeChannelsPerClient {
+		state.mux.Unlock()
+		return errRealtimeChannelLimit
+	}
+	channels = maps.Clone(channels)
+	if channels == nil {
+		channels = map[string]struct{}{}
+	}
+	channels[name] = struct{}{}
+	client.Set(RealtimeClientChannelsKey, channels)
+	state.mux.Unlock()
+
+	return realtimeSendChannelMessage(app, name, RealtimeChannelEventJoin, realtimeClientPresence(client), nil)
+}
+
+// realtimeLeaveChannel removes the client from the specified channel members
+// and notifies the remaining channel members.
+//
+// Returns false if the client wasn't a channel member.
+func realtimeLeaveChannel(app core.App, client subscriptions.Client, name string) bool {
+	state := realtimeChannelsStateFor(app)
+
+	state.mux.Lock()
+	channels, _ := client.Get(RealtimeClientChannelsKey).(map[string]struct{})
+	if _, ok := channels[name]; !ok {
+		state.mux.Unlock()
+		return false
+	}
+	channels = maps.Clone(channels)
+	delete(channels, name)
+	client.Set(RealtimeClientChannelsKey, channels)
+	state.mux.Unlock()
+
+	err := realtimeSendChannelMessage(app, name, RealtimeChannelEventLeave, realtimeClientPresence(client), nil)
+	if err != nil {
+		app.Logger().Debug(
+			"Failed to send realtime channel leave message",
+			slog.String("channel", name),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return true
+}
+
+// realtimeLeaveAllChannels removes the client from all of its joined channels
+// (usually called on disconnect).
+func realtimeLeaveAllChannels(app core.App, client subscriptions.Client) {
+	channels, _ := client.Get(RealtimeClientChannelsKey).(map[string]struct{})
+
+	for name := range channels {
+		realtimeLeaveChannel(app, client, name)
+	}
+}
+
+func realtimeHasJoinedChannel(client subscriptions.Client, name string) bool {
+	channels, _ := client.Get(RealtimeClientChannelsKey).(map[string]struct{})
+
+	_, ok := channels[name]
+
+	return ok
+}
+
+// realtimeChannelPresence returns the presence info of all channel members
+// (including the ones connected to other app nodes).
+func realtimeChannelPresence(app core.App, name string) []*realtimePresence {
+	result := []*realtimePresence{}
+
+	existing := map[string]struct{}{}
+
+	for _, client := range app.SubscriptionsBroker().Clients() {
+		if realtimeHasJoinedChannel(client, name) {
+			presence := realtimeClientPresence(client)
+			existing[presence.Id] = struct{}{}
+			result = append(result, presence)
+		}
+	}
+
+	for _, presence := range realtimeChannelsStateFor(app).remotePresence(name) {
+		if _, ok := existing[presence.Id]; !ok {
+			result = append(result, presence)
+		}
+	}
+
+	return result
+}
+
+// realtimePresenceId returns the public presence identifier of the client.
+func realtimePresenceId(client subscriptions.Client) string {
+	return security.SHA256(client.Id())[:20]
+}
+
+func realtimeClientPresence(client subscriptions.Client) *realtimePresence {
+	presence := &realtimePresence{Id: realtimePresenceId(client)}
+
+	if auth, _ := client.Get(RealtimeClientAuthKey).(*core.Record); auth != nil {
+		presence.Auth = &realtimePresenceAuth{
+			Id:             auth.Id,
+			CollectionName: auth.Collection().Name,
+		}
+	}
+
+	return presence
+}
+
+// realtimeSendChannelMessage sends a channel message to all channel members
+// (including the ones connected to other app nodes).
+func realtimeSendChannelMessage(app core.App, name string, event string, sender *realtimePresence, data json.RawMessage) error {
+	if len(data) > maxRealtimeChannelMessageSize || (len(data) > 0 && !json.Valid(data)) {
+		return errRealtimeChannelData
+	}
+
+	rawMsg, err := json.Marshal(realtimeChannelMessage{
+		Channel: name,
+		Event:   event,
+		Client:  sender,
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+
+	realtimeDeliverChannelMessage(app, name, rawMsg)
+
+	if backend := app.SubscriptionsBroker().Backend(); backend != nil {
+		err := backend.Publish(subscriptions.Event{
+			Name: realtimeChannelNodeEventName,
+			Data: rawMsg,
+		})
+		if err != nil {
+			app.Logger().Debug(
+				"Failed to publish realtime channel node event",
+				slog.String("channel", name),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return nil
+}
+
+// realtimeHandleChannelEvent delivers a single channel node event to the local channel members
+// and replicates the presence of the other node members.
+func realtimeHandleChannelEvent(app core.App, rawMsg []byte) error {
+	msg := new(realtimeChannelMessage)
+	if err := json.Unmarshal(rawMsg, msg); err != nil {
+		return err
+	}
+
+	if msg.Client != nil && msg.Client.Id != "" {
+		switch msg.Event {
+		case RealtimeChannelEventJoin:
+			realtimeChannelsStateFor(app).setRemote(msg.Channel, msg.Client, true)
+		case RealtimeChannelEventLeave:
+			realtimeChannelsStateFor(app).setRemote(msg.Channel, msg.Client, false)
+		}
+	}
+
+	realtimeDeliverChannelMessage(app, msg.Channel, rawMsg)
+
+	return nil
+}
+
+// realtimeDeliverChannelMessage sends the raw channel message to the channel members connected to the current app node.
+func realtimeDeliverChannelMessage(app core.App, name string, rawMsg []byte) {
+	msg := subscriptions.Message{
+		Name: RealtimeChannelMessagePrefix + name,
+		Data: rawMsg,
+	}
+
+	for _, client := range app.SubscriptionsBroker().Clients() {
+		if !realtimeHasJoinedChannel(client, name) {
+			continue
+		}
+
+		routine.FireAndForget(func() {
+			client.Send(msg)
+		})
+	}
+}
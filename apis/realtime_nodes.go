@@ -0,0 +1,168 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/subscriptions"
+)
+
+// realtimeRecordEventName is the name of the record realtime events
+// fanned out between the app nodes via the broker backend.
+const realtimeRecordEventName = "record"
+
+// List with the supported record realtime event operations.
+//
+// Each operation is replayed on the receiving node for its own clients
+// exactly as the corresponding local realtime hook handler.
+const (
+	realtimeRecordOpBroadcast               = "broadcast"
+	realtimeRecordOpBroadcastDryCached      = "broadcastDryCached"
+	realtimeRecordOpUnsetDryCached          = "unsetDryCached"
+	realtimeRecordOpUpdateClientsAuth       = "updateClientsAuth"
+	realtimeRecordOpUnsetClientsAuth        = "unsetClientsAuth"
+	realtimeRecordOpUnsetSessionClientsAuth = "unsetSessionClientsAuth"
+)
+
+// realtimeRecordEvent defines the data of a single record realtime node event.
+type realtimeRecordEvent struct {
+	Record     map[string]any `json:"record"`
+	Op         string         `json:"op"`
+	Action     string         `json:"action"`
+	Collection string         `json:"collection"`
+	DryCache   bool           `json:"dryCache,omitempty"`
+}
+
+// bindRealtimeNodes subscribes to the record and channel realtime events of the other
+// app nodes (if the app subscriptions broker has a backend set).
+//
+// The broker backend must be set before serve (e.g. in OnBootstrap).
+func bindRealtimeNodes(app core.App) {
+	backend := app.SubscriptionsBroker().Backend()
+	if backend == nil {
+		return // in-process only
+	}
+
+	unsubscribe := backend.Subscribe(func(event subscriptions.Event) {
+		var err error
+
+		switch event.Name {
+		case realtimeRecordEventName:
+			err = realtimeHandleRecordEvent(app, event.Data)
+		case realtimeChannelNodeEventName:
+			err = realtimeHandleChannelEvent(app, event.Data)
+		default:
+			return // unknown event
+		}
+
+		if err != nil {
+			app.Logger().Debug(
+				"Failed to process realtime node event",
+				slog.String("error", err.Error()),
+			)
+		}
+	})
+
+	app.OnTerminate().Bind(&hook.Handler[*core.TerminateEvent]{
+		Func: func(e *core.TerminateEvent) error {
+			unsubscribe()
+			return e.Next()
+		},
+		Priority: -99,
+	})
+}
+
+// realtimePublishRecordEvent publishes the record realtime operation
+// to the other app nodes (if the app subscriptions broker has a backend set).
+//
+// Publish errors are only logged because the local realtime
+// operation is not affected by them.
+func realtimePublishRecordEvent(app core.App, op string, action string, record *core.Record, dryCache bool) {
+	backend := app.SubscriptionsBroker().Backend()
+	if backend == nil {
+		return // in-process only
+	}
+
+	collection := record.Collection()
+
+	// forward only the public safe record fields
+	// (the same as the ones serialized in the client responses)
+	recordData := record.Fresh().
+		IgnoreEmailVisibility(false).
+		Hide(core.FieldNameCollectionId, core.FieldNameCollectionName, core.FieldNameExpand).
+		PublicExport()
+
+	data, err := json.Marshal(realtimeRecordEvent{
+		Op:         op,
+		Action:     action,
+		Collection: collection.Id,
+		Record:     recordData,
+		DryCache:   dryCache,
+	})
+	if err == nil {
+		err = backend.Publish(subscriptions.Event{
+			Name: realtimeRecordEventName,
+			Data: data,
+		})
+	}
+
+	if err != nil {
+		app.Logger().Debug(
+			"Failed to publish realtime node event",
+			slog.String("op", op),
+			slog.String("id", record.Id),
+			slog.String("collectionName", collection.Name),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// realtimeHandleRecordEvent replays a single record realtime node event for the local clients.
+func realtimeHandleRecordEvent(app core.App, rawData []byte) error {
+	event := new(realtimeRecordEvent)
+	if err := json.Unmarshal(rawData, event); err != nil {
+		return err
+	}
+
+	collection, err := app.FindCachedCollectionByNameOrId(event.Collection)
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	record.Load(event.Record)
+	if err := record.PostScan(); err != nil {
+		return err
+	}
+
+	// the forwarded record contains only its public fields so for the auth records
+	// load the full one from the db (e.g. the email and tokenKey for the clients auth state)
+	if collection.IsAuth() && event.Op != realtimeRecordOpUnsetClientsAuth {
+		full, err := app.FindRecordById(collection, record.Id)
+		if err == nil {
+			record = full
+		} else if event.Op == realtimeRecordOpUpdateClientsAuth {
+			return err
+		}
+	}
+
+	switch event.Op {
+	case realtimeRecordOpBroadcast:
+		return realtimeBroadcastRecord(app, event.Action, record, event.DryCache)
+	case realtimeRecordOpBroadcastDryCached:
+		return realtimeBroadcastDryCachedRecord(app, event.Action, record)
+	case realtimeRecordOpUnsetDryCached:
+		return realtimeUnsetDryCachedRecord(app, event.Action, record)
+	case realtimeRecordOpUpdateClientsAuth:
+		return realtimeUpdateClientsAuth(app, record)
+	case realtimeRecordOpUnsetClientsAuth:
+		return realtimeUnsetClientsAuthState(app, record)
+	case realtimeRecordOpUnsetSessionClientsAuth:
+		return realtimeUnsetSessionClientsAuthState(app, record.Id)
+	default:
+		return errors.New("unsupported realtime record event operation " + event.Op)
+	}
+}
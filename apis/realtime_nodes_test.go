@@ -0,0 +1,226 @@
+package apis_test
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/apis"
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/subscriptions"
+)
+
+// ensures that testBackend satisfies the subscriptions.Backend interface
+var _ subscriptions.Backend = (*testBackend)(nil)
+
+// testBackend is an in-memory subscriptions.Backend that
+// records the published events and allows manual delivery.
+type testBackend struct {
+	handler   func(event subscriptions.Event)
+	published []subscriptions.Event
+	mux       sync.Mutex
+}
+
+func (b *testBackend) Publish(event subscriptions.Event) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.published = append(b.published, event)
+
+	return nil
+}
+
+func (b *testBackend) Subscribe(handler func(event subscriptions.Event)) func() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.handler = handler
+
+	return func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+
+		b.handler = nil
+	}
+}
+
+func (b *testBackend) Close() error {
+	return nil
+}
+
+func (b *testBackend) flush() []subscriptions.Event {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	events := b.published
+	b.published = nil
+
+	return events
+}
+
+func (b *testBackend) deliver(event subscriptions.Event) {
+	b.mux.Lock()
+	handler := b.handler
+	b.mux.Unlock()
+
+	if handler != nil {
+		handler(event)
+	}
+}
+
+func TestRealtimeNodesRecordEvents(t *testing.T) {
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	backend := &testBackend{}
+	testApp.SubscriptionsBroker().SetBackend(backend)
+
+	// init realtime handlers
+	apis.NewRouter(testApp)
+
+	collection, err := testApp.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("text", "node_test")
+	if err := testApp.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	published := backend.flush()
+	if len(published) != 1 {
+		t.Fatalf("Expected 1 published event, got %d", len(published))
+	}
+
+	rawData := string(published[0].Data)
+	for _, expected := range []string{`"op":"broadcast"`, `"action":"create"`, `"collection":"` + collection.Id + `"`, `"id":"` + record.Id + `"`} {
+		if !strings.Contains(rawData, expected) {
+			t.Fatalf("Expected %s in the published event data, got %s", expected, rawData)
+		}
+	}
+
+	// simulate a client connected to another node
+	superuser, err := testApp.FindAuthRecordByEmail(core.CollectionNameSuperusers, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := subscriptions.NewDefaultClient()
+	client.Set(apis.RealtimeClientAuthKey, superuser)
+	client.Subscribe("demo1/*")
+	testApp.SubscriptionsBroker().Register(client)
+
+	backend.deliver(published[0])
+
+	select {
+	case msg := <-client.Channel():
+		if msg.Name != "demo1/*" {
+			t.Fatalf("Expected demo1/* message, got %q", msg.Name)
+		}
+
+		data := struct {
+			Action string         `json:"action"`
+			Record map[string]any `json:"record"`
+		}{}
+		json.Unmarshal(msg.Data, &data)
+
+		if data.Action != "create" || data.Record["id"] != record.Id || data.Record["text"] != "node_test" {
+			t.Fatalf("Invalid message data %s", msg.Data)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for the replayed node message")
+	}
+
+	// delete (dry cache + broadcast)
+	if err := testApp.Delete(record); err != nil {
+		t.Fatal(err)
+	}
+
+	published = backend.flush()
+	expectedOps := []string{`"op":"broadcast"`, `"op":"broadcastDryCached"`}
+	if len(published) != len(expectedOps) {
+		t.Fatalf("Expected %d published delete events, got %d", len(expectedOps), len(published))
+	}
+	for i, op := range expectedOps {
+		if !strings.Contains(string(published[i].Data), op) {
+			t.Fatalf("Expected %s in the delete event %d, got %s", op, i, published[i].Data)
+		}
+	}
+}
+
+func TestRealtimeNodesRecordEventsPublicData(t *testing.T) {
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	backend := &testBackend{}
+	testApp.SubscriptionsBroker().SetBackend(backend)
+
+	// init realtime handlers
+	apis.NewRouter(testApp)
+
+	user, err := testApp.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.SetEmailVisibility(false)
+	user.Set("name", "node_test")
+	if err := testApp.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	published := backend.flush()
+	if len(published) == 0 {
+		t.Fatal("Expected at least 1 published event")
+	}
+
+	for _, event := range published {
+		rawData := string(event.Data)
+
+		if !strings.Contains(rawData, `"id":"`+user.Id+`"`) {
+			t.Fatalf("Expected the user id in the published event data, got %s", rawData)
+		}
+
+		for _, notExpected := range []string{`"email"`, `"password"`, `"tokenKey"`, user.TokenKey()} {
+			if strings.Contains(rawData, notExpected) {
+				t.Fatalf("Didn't expect %s in the published event data, got %s", notExpected, rawData)
+			}
+		}
+	}
+}
+
+func TestRealtimeNodesInvalidEvents(t *testing.T) {
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	backend := &testBackend{}
+	testApp.SubscriptionsBroker().SetBackend(backend)
+
+	apis.NewRouter(testApp)
+
+	client := subscriptions.NewDefaultClient()
+	client.Subscribe("demo1/*")
+	testApp.SubscriptionsBroker().Register(client)
+
+	events := []subscriptions.Event{
+		{Name: "unknown", Data: []byte(`{}`)},
+		{Name: "record", Data: []byte(`invalid`)},
+		{Name: "record", Data: []byte(`{"op":"broadcast","action":"create","collection":"missing","record":{"id":"test"}}`)},
+		{Name: "record", Data: []byte(`{"op":"unknown","action":"create","collection":"demo1","record":{"id":"test"}}`)},
+	}
+
+	for _, event := range events {
+		backend.deliver(event)
+	}
+
+	select {
+	case msg := <-client.Channel():
+		t.Fatalf("Expected no messages, got %q", msg.Name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,327 @@
+package apis
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/routine"
+	"github.com/hanzoai/backendPB/tools/search"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/subscriptions"
+)
+
+// RealtimeClientLastEventIdKey is the name of the realtime client store key that holds
+// the last received event id of a reconnected client (until its first subscribe).
+const RealtimeClientLastEventIdKey = "lastEventId"
+
+// RealtimeResyncMessageName is the name of the realtime message sent to a reconnected client
+// when its missed messages can't be replayed and it should refetch its state.
+const RealtimeResyncMessageName = "PB_RESYNC"
+
+// maxRealtimeReplayEvents specifies the max number of recent broadcast events kept per collection.
+const maxRealtimeReplayEvents = 100
+
+// realtimeReplayStoreKey is the name of the app store key that holds the realtime replay buffer.
+const realtimeReplayStoreKey = "@realtimeReplayBuffer"
+
+// realtimeReplayEvent defines a single buffered record broadcast event.
+type realtimeReplayEvent struct {
+	record  *core.Record
+	action  string
+	seq     uint64
+	pending bool
+}
+
+// realtimeReplayRing defines a bounded list with the recent broadcast events of a single collection.
+type realtimeReplayRing struct {
+	events []*realtimeReplayEvent
+
+	// the sequence number of the last evicted event
+	evictedSeq uint64
+}
+
+// realtimeReplayBuffer keeps the recent record broadcast events per collection
+// so that they could be replayed for the reconnected clients.
+//
+// The event ids are in the format "epoch.seq", where the epoch is random for each
+// buffer instance so that ids from a previous process (or another node) are detected.
+type realtimeReplayBuffer struct {
+	rings map[string]*realtimeReplayRing
+	epoch string
+	seq   uint64
+	mux   sync.RWMutex
+}
+
+// realtimeReplayBufferFor returns the realtime replay buffer associated with the provided app
+// (it is created on first access).
+func realtimeReplayBufferFor(app core.App) *realtimeReplayBuffer {
+	return app.Store().GetOrSet(realtimeReplayStoreKey, func() any {
+		return &realtimeReplayBuffer{
+			rings: map[string]*realtimeReplayRing{},
+			epoch: security.PseudorandomString(8),
+		}
+	}).(*realtimeReplayBuffer)
+}
+
+// add registers a new record broadcast event and returns its id.
+//
+// Pending events are not replayed until confirmed (see confirm()).
+func (b *realtimeReplayBuffer) add(action string, record *core.Record, pending bool) string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.seq++
+
+	collectionId := record.Collection().Id
+
+	ring, ok := b.rings[collectionId]
+	if !ok {
+		ring = &realtimeReplayRing{}
+		b.rings[collectionId] = ring
+	}
+
+	ring.events = append(ring.events, &realtimeReplayEvent{
+		record:  record.Fresh(),
+		action:  action,
+		seq:     b.seq,
+		pending: pending,
+	})
+
+	if len(ring.events) > maxRealtimeReplayEvents {
+		ring.evictedSeq = ring.events[0].seq
+		ring.events = slices.Delete(ring.events, 0, 1)
+	}
+
+	return b.eventId(b.seq)
+}
+
+// confirm marks the last pending event of the specified record action as replayable.
+func (b *realtimeReplayBuffer) confirm(action string, record *core.Record) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if i, ring := b.findPending(action, record); ring != nil {
+		ring.events[i].pending = false
+	}
+}
+
+// discard removes the last pending event of the specified record action.
+func (b *realtimeReplayBuffer) discard(action string, record *core.Record) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if i, ring := b.findPending(action, record); ring != nil {
+		ring.events = slices.Delete(ring.events, i, i+1)
+	}
+}
+
+// note: expects the buffer to be already locked
+func (b *realtimeReplayBuffer) findPending(action string, record *core.Record) (int, *realtimeReplayRing) {
+	ring, ok := b.rings[record.Collection().Id]
+	if !ok {
+		return -1, nil
+	}
+
+	for i := len(ring.events) - 1; i >= 0; i-- {
+		event := ring.events[i]
+		if event.pending && event.action == action && event.record.Id == record.Id {
+			return i, ring
+		}
+	}
+
+	return -1, nil
+}
+
+// since returns the replayable events of the specified collections
+// that were registered after lastEventId (sorted by their sequence).
+//
+// Returns false if the missed events can't be fully restored
+// (invalid or foreign event id, evicted events, etc.).
+func (b *realtimeReplayBuffer) since(lastEventId string, collectionIds []string) ([]realtimeReplayEvent, bool) {
+	epoch, rawSeq, _ := strings.Cut(lastEventId, ".")
+
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	if epoch != b.epoch || seq > b.seq {
+		return nil, false
+	}
+
+	var result []realtimeReplayEvent
+
+	for _, id := range collectionIds {
+		ring, ok := b.rings[id]
+		if !ok {
+			continue
+		}
+
+		if seq < ring.evictedSeq {
+			return nil, false // the gap is too large
+		}
+
+		for _, event := range ring.events {
+			if event.seq > seq && !event.pending {
+				result = append(result, *event)
+			}
+		}
+	}
+
+	slices.SortFunc(result, func(a, b realtimeReplayEvent) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	return result, true
+}
+
+// eventId returns the full event id of the specified sequence number.
+func (b *realtimeReplayBuffer) eventId(seq uint64) string {
+	return b.epoch + "." + strconv.FormatUint(seq, 10)
+}
+
+// realtimeLastEventId extracts the last received event id of a reconnecting client
+// from the "Last-Event-ID" header or the "lastEventId" query parameter
+// (for clients that can't set custom headers).
+func realtimeLastEventId(r *http.Request) string {
+	id := r.Header.Get("Last-Event-ID")
+	if id == "" {
+		id = r.URL.Query().Get("lastEventId")
+	}
+
+	// ignore the ids that are not broadcast event ids
+	// (e.g. the client id of the previous PB_CONNECT message)
+	if !strings.Contains(id, ".") || len(id) > 100 {
+		return ""
+	}
+
+	return id
+}
+
+// realtimeReplayMissedMessages sends to the reconnected client (if any) the buffered
+// record messages after its last received event id that it is still allowed to see.
+//
+// A PB_RESYNC message is sent instead if the missed messages can't be fully restored
+// (e.g. the gap is too large, the server was restarted, etc.).
+//
+// Note that a message broadcasted during the subscribe could be delivered twice.
+func realtimeReplayMissedMessages(app core.App, client subscriptions.Client) {
+	lastEventId, _ := client.Get(RealtimeClientLastEventIdKey).(string)
+	if lastEventId == "" {
+		return // not a reconnected client
+	}
+
+	// replay only once
+	client.Unset(RealtimeClientLastEventIdKey)
+
+	collections := realtimeSubscribedCollections(app, client)
+	if len(collections) == 0 {
+		return
+	}
+
+	collectionIds := make([]string, 0, len(collections))
+	for id := range collections {
+		collectionIds = append(collectionIds, id)
+	}
+
+	buffer := realtimeReplayBufferFor(app)
+
+	events, ok := buffer.since(lastEventId, collectionIds)
+
+	var messages []subscriptions.Message
+
+	for i := 0; ok && i < len(events); i++ {
+		event := events[i]
+
+		// use the current collection rules
+		collection := collections[event.record.Collection().Id]
+
+		canAccess := realtimeCanAccessRecord
+		if event.action == "delete" {
+			canAccess = func(app core.App, record *core.Record, requestInfo *core.RequestInfo, accessRule *string) bool {
+				allowed, verified := realtimeCanAccessDeletedRecord(requestInfo, accessRule)
+				if !verified {
+					ok = false
+				}
+				return allowed
+			}
+		}
+
+		messages = append(messages, realtimeClientRecordMessages(
+			app,
+			client,
+			collection,
+			realtimeSubscriptionRuleMap(collection, event.record.Id),
+			event.action,
+			event.record,
+			buffer.eventId(event.seq),
+			canAccess,
+		)...)
+	}
+
+	if !ok {
+		messages = []subscriptions.Message{{
+			Name: RealtimeResyncMessageName,
+			Data: []byte(`{}`),
+		}}
+	}
+
+	if len(messages) == 0 {
+		return
+	}
+
+	routine.FireAndForget(func() {
+		for _, msg := range messages {
+			client.Send(msg)
+		}
+	})
+}
+
+// realtimeSubscribedCollections returns the collections (indexed by their id)
+// of all record subscriptions of the provided client.
+func realtimeSubscribedCollections(app core.App, client subscriptions.Client) map[string]*core.Collection {
+	result := map[string]*core.Collection{}
+
+	for sub := range client.Subscriptions() {
+		nameOrId, _, _ := strings.Cut(sub, "?")
+		nameOrId, _, _ = strings.Cut(nameOrId, "/")
+
+		collection, err := app.FindCachedCollectionByNameOrId(nameOrId)
+		if err != nil {
+			continue // not a record subscription
+		}
+
+		result[collection.Id] = collection
+	}
+
+	return result
+}
+
+// realtimeCanAccessDeletedRecord checks whether the subscription client has access
+// to an already deleted record.
+//
+// Because the record is no longer in the db, only the access rules that don't depend
+// on the record data could be verified (the second returned value reports that).
+func realtimeCanAccessDeletedRecord(requestInfo *core.RequestInfo, accessRule *string) (bool, bool) {
+	if requestInfo.HasSuperuserAuth() {
+		return true, true
+	}
+
+	if accessRule == nil {
+		return false, true // superusers only
+	}
+
+	if *accessRule == "" && requestInfo.Query[search.FilterQueryParam] == "" {
+		return true, true
+	}
+
+	return false, false
+}
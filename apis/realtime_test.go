@@ -99,6 +99,7 @@ func TestRealtimeSubscribe(t *testing.T) {
 	resetClient := func() {
 		client.Unsubscribe()
 		client.Set(apis.RealtimeClientAuthKey, nil)
+		client.Unset(apis.RealtimeClientAuthSessionKey)
 	}
 
 	validSubscriptionsLimit := make([]string, 1000)
@@ -328,6 +329,41 @@ func TestRealtimeSubscribe(t *testing.T) {
 				resetClient()
 			},
 		},
+		{
+			Name:           "existing client - guest -> authorized session bound auth record",
+			Method:         http.MethodPost,
+			URL:            "/api/realtime",
+			Body:           strings.NewReader(`{"clientId":"` + client.Id() + `","subscriptions":["test1", "test2"]}`),
+			ExpectedStatus: 204,
+			ExpectedEvents: map[string]int{
+				"*":                          0,
+				"OnRealtimeSubscribeRequest": 1,
+			},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersSessions(t, app)
+
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				saveTestAuthSession(t, app, user, "session1aaaaaaa", time.Now())
+				bindTestSessionAuthToken(t, e, user, "session1aaaaaaa")
+
+				app.SubscriptionsBroker().Register(client)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				authRecord, _ := client.Get(apis.RealtimeClientAuthKey).(*core.Record)
+				if authRecord == nil || authRecord.Id != "4q1xlclmfloku33" {
+					t.Errorf("Expected regular user auth record, got %v", authRecord)
+				}
+
+				if v := client.Get(apis.RealtimeClientAuthSessionKey); v != "session1aaaaaaa" {
+					t.Errorf("Expected auth session id %q, got %#v", "session1aaaaaaa", v)
+				}
+				resetClient()
+			},
+		},
 		{
 			Name:   "existing client - same auth",
 			Method: http.MethodPost,
@@ -475,6 +511,61 @@ func TestRealtimeAuthRecordDeleteEvent(t *testing.T) {
 	}
 }
 
+func TestRealtimeAuthSessionDeleteEvent(t *testing.T) {
+	testApp, _ := tests.NewTestApp()
+	defer testApp.Cleanup()
+
+	// init realtime handlers
+	apis.NewRouter(testApp)
+
+	enableUsersSessions(t, testApp)
+
+	authRecord, err := testApp.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session1 := saveTestAuthSession(t, testApp, authRecord, "session1aaaaaaa", time.Now())
+	saveTestAuthSession(t, testApp, authRecord, "session2aaaaaaa", time.Now())
+
+	client1 := subscriptions.NewDefaultClient()
+	client1.Set(apis.RealtimeClientAuthKey, authRecord)
+	client1.Set(apis.RealtimeClientAuthSessionKey, "session1aaaaaaa")
+	testApp.SubscriptionsBroker().Register(client1)
+
+	client2 := subscriptions.NewDefaultClient()
+	client2.Set(apis.RealtimeClientAuthKey, authRecord)
+	client2.Set(apis.RealtimeClientAuthSessionKey, "session2aaaaaaa")
+	testApp.SubscriptionsBroker().Register(client2)
+
+	// non-session bound auth
+	client3 := subscriptions.NewDefaultClient()
+	client3.Set(apis.RealtimeClientAuthKey, authRecord)
+	testApp.SubscriptionsBroker().Register(client3)
+
+	if err := testApp.Delete(session1); err != nil {
+		t.Fatal(err)
+	}
+
+	if total := len(testApp.SubscriptionsBroker().Clients()); total != 3 {
+		t.Fatalf("Expected %d subscription clients, found %d", 3, total)
+	}
+
+	if auth := client1.Get(apis.RealtimeClientAuthKey); auth != nil {
+		t.Fatalf("[client1] Expected the auth state to be unset, found %#v", auth)
+	}
+
+	if sessionId := client1.Get(apis.RealtimeClientAuthSessionKey); sessionId != nil {
+		t.Fatalf("[client1] Expected the auth session id to be unset, found %#v", sessionId)
+	}
+
+	for i, client := range []subscriptions.Client{client2, client3} {
+		if auth := client.Get(apis.RealtimeClientAuthKey); auth == nil || auth.(*core.Record).Id != authRecord.Id {
+			t.Fatalf("[client%d] Expected the auth state to be left unchanged, found %#v", i+2, auth)
+		}
+	}
+}
+
 func TestRealtimeAuthRecordUpdateEvent(t *testing.T) {
 	testApp, _ := tests.NewTestApp()
 	defer testApp.Cleanup()
@@ -0,0 +1,424 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/subscriptions"
+	"golang.org/x/net/websocket"
+)
+
+// Realtime WebSocket client command types.
+const (
+	RealtimeWSCommandSubscribe   = "subscribe"
+	RealtimeWSCommandUnsubscribe = "unsubscribe"
+	RealtimeWSCommandAuth        = "auth"
+	RealtimeWSCommandPing        = "ping"
+	RealtimeWSCommandJoin        = "join"
+	RealtimeWSCommandLeave       = "leave"
+	RealtimeWSCommandPublish     = "publish"
+)
+
+// Realtime WebSocket system message names.
+const (
+	RealtimeWSMessageAck   = "PB_ACK"
+	RealtimeWSMessageError = "PB_ERROR"
+)
+
+// maxRealtimeWSCommandSize specifies the max allowed size (in bytes) of a single client command.
+const maxRealtimeWSCommandSize = 1 << 20
+
+// maxRealtimeWSSubscriptions specifies the max number of subscriptions per WebSocket client.
+const maxRealtimeWSSubscriptions = 1000
+
+// realtimeWSCommand defines a single client->server realtime WebSocket command.
+//
+// Example:
+//
+//	{"id":"1","type":"subscribe","subscriptions":["posts/*"]}
+//	{"id":"2","type":"unsubscribe","subscriptions":["posts/*"]}
+//	{"id":"3","type":"auth","token":"..."}
+//	{"id":"4","type":"ping"}
+//	{"id":"5","type":"join","channel":"rooms:abc"}
+//	{"id":"6","type":"publish","channel":"rooms:abc","data":{"text":"hi"}}
+//	{"id":"7","type":"leave","channel":"rooms:abc"}
+type realtimeWSCommand struct {
+	Id            string          `json:"id"`
+	Type          string          `json:"type"`
+	Token         string          `json:"token"`
+	Channel       string          `json:"channel"`
+	Subscriptions []string        `json:"subscriptions"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// realtimeWSFrame defines a single server->client realtime WebSocket message.
+type realtimeWSFrame struct {
+	Id    string          `json:"id,omitempty"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// realtimeWSReply defines the data of the PB_ACK and PB_ERROR messages.
+type realtimeWSReply struct {
+	Data    any    `json:"data,omitempty"`
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+}
+
+// realtimeWSConnect upgrades the request to a bidirectional WebSocket realtime connection.
+//
+// In contrast to the SSE connection, the subscriptions and the client
+// auth state are managed with commands sent over the same connection.
+func realtimeWSConnect(e *core.RequestEvent) error {
+	if !isWebSocketUpgrade(e.Request) {
+		return e.BadRequestError("Invalid WebSocket upgrade request.", nil)
+	}
+
+	// create cancellable request
+	cancelCtx, cancelRequest := context.WithCancel(e.Request.Context())
+	defer cancelRequest()
+	e.Request = e.Request.Clone(cancelCtx)
+
+	connectEvent := new(core.RealtimeConnectRequestEvent)
+	connectEvent.RequestEvent = e
+	connectEvent.Client = subscriptions.NewDefaultClient()
+	connectEvent.IdleTimeout = 5 * time.Minute
+
+	if lastEventId := realtimeLastEventId(e.Request); lastEventId != "" {
+		connectEvent.Client.Set(RealtimeClientLastEventIdKey, lastEventId)
+	}
+
+	return e.App.OnRealtimeConnectRequest().Trigger(connectEvent, func(ce *core.RealtimeConnectRequestEvent) error {
+		server := websocket.Server{
+			// note: the Origin header is not checked because the WebSocket
+			// connection is authorized only with explicit auth token
+			// (Authorization header or "auth" command) and never with cookies
+			Handler: func(conn *websocket.Conn) {
+				realtimeWSServe(ce, conn, cancelRequest)
+			},
+		}
+
+		server.ServeHTTP(ce.Response, ce.Request)
+
+		return nil
+	})
+}
+
+func realtimeWSServe(ce *core.RealtimeConnectRequestEvent, conn *websocket.Conn, cancelRequest context.CancelFunc) {
+	defer conn.Close()
+
+	// the hijacked connection may have the server read/write deadlines already set
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		ce.App.Logger().Warn("Failed to reset the WebSocket connection deadlines", slog.String("error", err.Error()))
+	}
+
+	conn.MaxPayloadBytes = maxRealtimeWSCommandSize
+
+	// register new subscription client
+	ce.App.SubscriptionsBroker().Register(ce.Client)
+	defer func() {
+		realtimeLeaveAllChannels(ce.App, ce.Client)
+		ce.App.SubscriptionsBroker().Unregister(ce.Client.Id())
+	}()
+
+	if ce.Auth != nil {
+		realtimeSetClientAuth(ce.Client, ce.Auth, getAuthTokenFromRequest(ce.RequestEvent))
+	}
+
+	ce.App.Logger().Debug("Realtime WebSocket connection established.", slog.String("clientId", ce.Client.Id()))
+
+	send := func(msg subscriptions.Message) error {
+		msgEvent := new(core.RealtimeMessageEvent)
+		msgEvent.RequestEvent = ce.RequestEvent
+		msgEvent.Client = ce.Client
+		msgEvent.Message = &msg
+
+		return ce.App.OnRealtimeMessageSend().Trigger(msgEvent, func(me *core.RealtimeMessageEvent) error {
+			return realtimeWSWrite(conn, me.Message)
+		})
+	}
+
+	// signalize established connection (aka. fire "connect" message)
+	connectErr := send(subscriptions.Message{
+		Name: "PB_CONNECT",
+		Data: []byte(`{"clientId":"` + ce.Client.Id() + `"}`),
+	})
+	if connectErr != nil {
+		ce.App.Logger().Debug(
+			"Realtime WebSocket connection closed (failed to deliver PB_CONNECT)",
+			slog.String("clientId", ce.Client.Id()),
+			slog.String("error", connectErr.Error()),
+		)
+		return
+	}
+
+	// read the client commands in a separate goroutine
+	// so that they could be processed sequentially together with the broker messages
+	commands := make(chan []byte)
+	go func() {
+		defer cancelRequest()
+
+		for {
+			var raw []byte
+			if err := websocket.Message.Receive(conn, &raw); err != nil {
+				return // closed connection or invalid frame
+			}
+
+			select {
+			case commands <- raw:
+			case <-ce.Request.Context().Done():
+				return
+			}
+		}
+	}()
+
+	// start an idle timer to keep track of inactive/forgotten connections
+	idleTimer := time.NewTimer(ce.IdleTimeout)
+	defer idleTimer.Stop()
+
+	for {
+		select {
+		case <-idleTimer.C:
+			cancelRequest()
+		case raw := <-commands:
+			reply := realtimeWSHandleCommand(ce, raw)
+
+			if err := send(reply); err != nil {
+				ce.App.Logger().Debug(
+					"Realtime WebSocket connection closed (failed to deliver command reply)",
+					slog.String("clientId", ce.Client.Id()),
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+
+			idleTimer.Stop()
+			idleTimer.Reset(ce.IdleTimeout)
+		case msg, ok := <-ce.Client.Channel():
+			if !ok {
+				// channel is closed
+				ce.App.Logger().Debug(
+					"Realtime WebSocket connection closed (closed channel)",
+					slog.String("clientId", ce.Client.Id()),
+				)
+				return
+			}
+
+			if err := send(msg); err != nil {
+				ce.App.Logger().Debug(
+					"Realtime WebSocket connection closed (failed to deliver message)",
+					slog.String("clientId", ce.Client.Id()),
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+
+			idleTimer.Stop()
+			idleTimer.Reset(ce.IdleTimeout)
+		case <-ce.Request.Context().Done():
+			// connection is closed
+			ce.App.Logger().Debug(
+				"Realtime WebSocket connection closed (cancelled request)",
+				slog.String("clientId", ce.Client.Id()),
+			)
+			return
+		}
+	}
+}
+
+// realtimeWSHandleCommand processes a single raw client command
+// and returns the PB_ACK or PB_ERROR reply message.
+func realtimeWSHandleCommand(ce *core.RealtimeConnectRequestEvent, raw []byte) subscriptions.Message {
+	cmd := new(realtimeWSCommand)
+
+	var data any
+	var err error
+
+	if jsonErr := json.Unmarshal(raw, cmd); jsonErr != nil {
+		err = errors.New("invalid command format")
+	} else {
+		switch cmd.Type {
+		case RealtimeWSCommandSubscribe:
+			err = realtimeWSSubscribe(ce, cmd.Subscriptions)
+		case RealtimeWSCommandUnsubscribe:
+			// note: no subscriptions means unsubscribe from all
+			ce.Client.Unsubscribe(cmd.Subscriptions...)
+		case RealtimeWSCommandAuth:
+			err = realtimeWSAuth(ce, cmd.Token)
+		case RealtimeWSCommandPing:
+			// nothing to do, the reply resets the idle timer
+		case RealtimeWSCommandJoin:
+			data, err = realtimeWSJoin(ce, cmd.Channel)
+		case RealtimeWSCommandLeave:
+			if !realtimeLeaveChannel(ce.App, ce.Client, cmd.Channel) {
+				err = errRealtimeChannelNotJoined
+			}
+		case RealtimeWSCommandPublish:
+			err = realtimeWSPublish(ce, cmd.Channel, cmd.Data)
+		default:
+			err = errors.New("unsupported command type")
+		}
+	}
+
+	reply := realtimeWSReply{Id: cmd.Id, Type: cmd.Type}
+
+	name := RealtimeWSMessageAck
+	if err != nil {
+		name = RealtimeWSMessageError
+		reply.Message = err.Error()
+	} else {
+		reply.Data = data
+	}
+
+	data, _ := json.Marshal(reply)
+
+	return subscriptions.Message{Name: name, Data: data}
+}
+
+func realtimeWSSubscribe(ce *core.RealtimeConnectRequestEvent, subs []string) error {
+	err := validation.Validate(subs,
+		validation.Required,
+		validation.Length(1, maxRealtimeWSSubscriptions),
+		validation.Each(validation.Required, validation.Length(1, 2500)),
+	)
+	if err != nil {
+		return errors.New("invalid subscriptions list")
+	}
+
+	if len(ce.Client.Subscriptions())+len(subs) > maxRealtimeWSSubscriptions {
+		return errors.New("max allowed subscriptions limit reached")
+	}
+
+	// sync the request auth with the current client auth state
+	ce.Auth, _ = ce.Client.Get(RealtimeClientAuthKey).(*core.Record)
+
+	event := new(core.RealtimeSubscribeRequestEvent)
+	event.RequestEvent = ce.RequestEvent
+	event.Client = ce.Client
+	event.Subscriptions = subs
+
+	return ce.App.OnRealtimeSubscribeRequest().Trigger(event, func(e *core.RealtimeSubscribeRequestEvent) error {
+		// update auth state
+		e.Client.Set(RealtimeClientAuthKey, e.Auth)
+
+		// note: in contrast to the SSE endpoint the new subscriptions
+		// are added to the existing ones
+		e.Client.Subscribe(e.Subscriptions...)
+
+		e.App.Logger().Debug(
+			"Realtime WebSocket subscriptions updated.",
+			slog.String("clientId", e.Client.Id()),
+			slog.Any("subscriptions", e.Subscriptions),
+		)
+
+		realtimeReplayMissedMessages(e.App, e.Client)
+
+		return nil
+	})
+}
+
+func realtimeWSJoin(ce *core.RealtimeConnectRequestEvent, channel string) (any, error) {
+	if err := realtimeCheckChannelAccess(ce.App, channel, realtimeWSRequestInfo(ce), false); err != nil {
+		return nil, err
+	}
+
+	if err := realtimeJoinChannel(ce.App, ce.Client, channel); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"id":       realtimePresenceId(ce.Client),
+		"presence": realtimeChannelPresence(ce.App, channel),
+	}, nil
+}
+
+func realtimeWSPublish(ce *core.RealtimeConnectRequestEvent, channel string, data json.RawMessage) error {
+	if err := realtimeCheckChannelAccess(ce.App, channel, realtimeWSRequestInfo(ce), true); err != nil {
+		return err
+	}
+
+	if !realtimeHasJoinedChannel(ce.Client, channel) {
+		return errRealtimeChannelNotJoined
+	}
+
+	return realtimeSendChannelMessage(ce.App, channel, RealtimeChannelEventMessage, realtimeClientPresence(ce.Client), data)
+}
+
+// realtimeWSRequestInfo returns the realtime request info of the WebSocket client
+// that could be used for the channel rules checks.
+func realtimeWSRequestInfo(ce *core.RealtimeConnectRequestEvent) *core.RequestInfo {
+	auth, _ := ce.Client.Get(RealtimeClientAuthKey).(*core.Record)
+
+	return &core.RequestInfo{
+		Context: core.RequestInfoContextRealtime,
+		Method:  http.MethodGet,
+		Query:   map[string]string{},
+		Headers: map[string]string{},
+		Body:    map[string]any{},
+		Auth:    auth,
+	}
+}
+
+// realtimeWSAuth updates the client auth state with the auth record of the provided token.
+//
+// An empty token clears the client auth state.
+func realtimeWSAuth(ce *core.RealtimeConnectRequestEvent, token string) error {
+	token = strings.TrimPrefix(token, "Bearer ")
+
+	if token == "" {
+		ce.Client.Unset(RealtimeClientAuthKey)
+		ce.Client.Unset(RealtimeClientAuthSessionKey)
+		ce.Auth = nil
+		return nil
+	}
+
+	record, err := ce.App.FindAuthRecordByToken(token, core.TokenTypeAuth)
+	if err != nil || record == nil {
+		return errors.New("invalid or expired auth token")
+	}
+
+	realtimeSetClientAuth(ce.Client, record, token)
+	ce.Auth = record
+
+	return nil
+}
+
+// realtimeWSWrite writes the provided subscription message as a single JSON text frame.
+func realtimeWSWrite(conn *websocket.Conn, msg *subscriptions.Message) error {
+	frame := realtimeWSFrame{Id: msg.Id, Event: msg.Name, Data: msg.Data}
+
+	// wrap custom non-json payloads as json string
+	if !json.Valid(frame.Data) {
+		frame.Data, _ = json.Marshal(string(msg.Data))
+	}
+
+	raw, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	return websocket.Message.Send(conn, string(raw))
+}
+
+// isWebSocketUpgrade checks whether the request is a valid WebSocket upgrade request.
+func isWebSocketUpgrade(r *http.Request) bool {
+	if r.Method != http.MethodGet || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+
+	for _, v := range strings.Split(r.Header.Get("Connection"), ",") {
+		if strings.EqualFold(strings.TrimSpace(v), "upgrade") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -28,6 +28,22 @@ func bindRecordAuthApi(app core.App, rg *router.RouterGroup[*core.RequestEvent])
 		RequireSameCollectionContextAuth(""),
 	)
 
+	sub.POST("/auth-with-refresh-token", recordAuthWithRefreshToken).Bind(
+		collectionPathRateLimit("", "authWithRefreshToken", "auth"),
+	)
+	sub.GET("/auth-sessions", recordAuthSessionsList).Bind(
+		collectionPathRateLimit("", "listAuthSessions"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.DELETE("/auth-sessions", recordAuthSessionsDeleteAll).Bind(
+		collectionPathRateLimit("", "deleteAuthSessions"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.DELETE("/auth-sessions/{id}", recordAuthSessionDelete).Bind(
+		collectionPathRateLimit("", "deleteAuthSession"),
+		RequireSameCollectionContextAuth(""),
+	)
+
 	sub.POST("/auth-with-password", recordAuthWithPassword).Bind(
 		collectionPathRateLimit("", "authWithPassword", "auth"),
 	)
@@ -43,6 +59,37 @@ func bindRecordAuthApi(app core.App, rg *router.RouterGroup[*core.RequestEvent])
 		collectionPathRateLimit("", "authWithOTP", "auth"),
 	)
 
+	sub.POST("/request-webauthn-registration", recordRequestWebAuthnRegistration).Bind(
+		collectionPathRateLimit("", "requestWebAuthnRegistration"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/confirm-webauthn-registration", recordConfirmWebAuthnRegistration).Bind(
+		collectionPathRateLimit("", "confirmWebAuthnRegistration"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/request-webauthn", recordRequestWebAuthn).Bind(
+		collectionPathRateLimit("", "requestWebAuthn"),
+	)
+	sub.POST("/auth-with-webauthn", recordAuthWithWebAuthn).Bind(
+		collectionPathRateLimit("", "authWithWebAuthn", "auth"),
+	)
+
+	sub.POST("/request-totp-enrollment", recordRequestTOTPEnrollment).Bind(
+		collectionPathRateLimit("", "requestTOTPEnrollment"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/confirm-totp-enrollment", recordConfirmTOTPEnrollment).Bind(
+		collectionPathRateLimit("", "confirmTOTPEnrollment"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/disable-totp", recordDisableTOTP).Bind(
+		collectionPathRateLimit("", "disableTOTP"),
+		RequireSameCollectionContextAuth(""),
+	)
+	sub.POST("/auth-with-totp", recordAuthWithTOTP).Bind(
+		collectionPathRateLimit("", "authWithTOTP", "auth"),
+	)
+
 	sub.POST("/request-password-reset", recordRequestPasswordReset).Bind(
 		collectionPathRateLimit("", "requestPasswordReset"),
 	)
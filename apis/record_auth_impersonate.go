@@ -37,7 +37,7 @@ func recordAuthImpersonate(e *core.RequestEvent) error {
 		e.InternalServerError("Failed to generate static auth token", err)
 	}
 
-	return recordAuthResponse(e, record, token, "", nil)
+	return recordAuthResponse(e, record, token, "", nil, nil)
 }
 
 // -------------------------------------------------------------------
@@ -16,6 +16,20 @@ type otpResponse struct {
 	Duration int64 `json:"duration"` // in seconds
 }
 
+type webauthnResponse struct {
+	Enabled bool   `json:"enabled"`
+	RPId    string `json:"rpId"`
+}
+
+type totpResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+type sessionsResponse struct {
+	Enabled             bool  `json:"enabled"`
+	AccessTokenDuration int64 `json:"accessTokenDuration"` // in seconds
+}
+
 type mfaResponse struct {
 	Enabled  bool  `json:"enabled"`
 	Duration int64 `json:"duration"` // in seconds
@@ -54,6 +68,9 @@ type authMethodsResponse struct {
 	OAuth2   oauth2Response   `json:"oauth2"`
 	MFA      mfaResponse      `json:"mfa"`
 	OTP      otpResponse      `json:"otp"`
+	WebAuthn webauthnResponse `json:"webauthn"`
+	TOTP     totpResponse     `json:"totp"`
+	Sessions sessionsResponse `json:"sessions"`
 
 	// legacy fields
 	// @todo remove after dropping v0.22 support
@@ -88,9 +105,18 @@ func recordAuthMethods(e *core.RequestEvent) error {
 		OTP: otpResponse{
 			Enabled: collection.OTP.Enabled,
 		},
+		WebAuthn: webauthnResponse{
+			Enabled: collection.WebAuthn.Enabled,
+		},
+		TOTP: totpResponse{
+			Enabled: collection.TOTP.Enabled,
+		},
 		MFA: mfaResponse{
 			Enabled: collection.MFA.Enabled,
 		},
+		Sessions: sessionsResponse{
+			Enabled: collection.Sessions.Enabled,
+		},
 	}
 
 	if collection.PasswordAuth.Enabled {
@@ -102,10 +128,18 @@ func recordAuthMethods(e *core.RequestEvent) error {
 		result.OTP.Duration = collection.OTP.Duration
 	}
 
+	if collection.WebAuthn.Enabled {
+		result.WebAuthn.RPId = collection.WebAuthn.RPId
+	}
+
 	if collection.MFA.Enabled {
 		result.MFA.Duration = collection.MFA.Duration
 	}
 
+	if collection.Sessions.Enabled {
+		result.Sessions.AccessTokenDuration = collection.Sessions.AccessTokenDuration
+	}
+
 	if !collection.OAuth2.Enabled {
 		result.fillLegacyFields()
 
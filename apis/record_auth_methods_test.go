@@ -38,6 +38,9 @@ func TestRecordAuthMethodsList(t *testing.T) {
 				`"oauth2":{"providers":[],"enabled":false}`,
 				`"mfa":{"enabled":false,"duration":0}`,
 				`"otp":{"enabled":false,"duration":0}`,
+				`"webauthn":{"enabled":false,"rpId":""}`,
+				`"totp":{"enabled":false}`,
+				`"sessions":{"enabled":false,"accessTokenDuration":0}`,
 			},
 			ExpectedEvents: map[string]int{"*": 0},
 		},
@@ -64,6 +67,32 @@ func TestRecordAuthMethodsList(t *testing.T) {
 			},
 			ExpectedEvents: map[string]int{"*": 0},
 		},
+		{
+			Name:   "auth collection with webauthn enabled",
+			Method: http.MethodGet,
+			URL:    "/api/collections/users/auth-methods",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersWebAuthn(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"webauthn":{"enabled":true,"rpId":"localhost"}`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:   "auth collection with totp enabled",
+			Method: http.MethodGet,
+			URL:    "/api/collections/users/auth-methods",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersTOTP(t, app)
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"totp":{"enabled":true}`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
 
 		// rate limit checks
 		// -----------------------------------------------------------
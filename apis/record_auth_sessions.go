@@ -0,0 +1,172 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+func recordAuthWithRefreshToken(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.Sessions.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow auth sessions.", nil)
+	}
+
+	form := &authWithRefreshTokenForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	// extra validations
+	// (note: returns a generic 400 as a very basic sessions enumeration protection)
+	// ---
+	session, err := e.App.FindAuthSessionByRefreshToken(form.RefreshToken)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired refresh token.", err)
+	}
+
+	if session.CollectionRef() != collection.Id {
+		return e.BadRequestError("Invalid or expired refresh token.", errors.New("the session is for a different collection"))
+	}
+
+	if session.IsReusedRefreshToken(form.RefreshToken) {
+		return revokeReusedAuthSession(e, session)
+	}
+
+	if session.HasExpired(collection.Sessions.DurationTime()) {
+		if deleteErr := e.App.Delete(session); deleteErr != nil {
+			e.App.Logger().Warn("Failed to delete expired auth session", "error", deleteErr, "sessionId", session.Id)
+		}
+		return e.BadRequestError("Invalid or expired refresh token.", errors.New("the session is expired"))
+	}
+
+	record, err := e.App.FindRecordById(collection, session.RecordRef())
+	if err != nil {
+		return e.BadRequestError("Invalid or expired refresh token.", err)
+	}
+	// ---
+
+	token, err := record.NewSessionAuthToken(session.Id)
+	if err != nil {
+		return e.InternalServerError("Failed to create auth token.", err)
+	}
+
+	event := new(core.RecordAuthRefreshRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+	event.Record = record
+
+	return e.App.OnRecordAuthRefreshRequest().Trigger(event, func(e *core.RecordAuthRefreshRequestEvent) error {
+		// note: the refresh token is rotated with a conditional update
+		// only after all auth checks have passed
+		return recordAuthResponse(e.RequestEvent, e.Record, token, "", nil, &pendingAuthSession{
+			session:             session,
+			currentRefreshToken: form.RefreshToken,
+		})
+	})
+}
+
+// revokeReusedAuthSession revokes the session of an already rotated refresh token
+// (which means that it most likely has leaked) and returns a generic 400 error.
+func revokeReusedAuthSession(e *core.RequestEvent, session *core.AuthSession) error {
+	if err := e.App.Delete(session); err != nil {
+		e.App.Logger().Warn("Failed to revoke auth session", "error", err, "sessionId", session.Id)
+	}
+
+	return e.BadRequestError("Invalid or expired refresh token.", core.ErrReusedRefreshToken)
+}
+
+type authSessionResponse struct {
+	Id        string         `json:"id"`
+	UserAgent string         `json:"userAgent"`
+	IP        string         `json:"ip"`
+	Current   bool           `json:"current"`
+	Created   types.DateTime `json:"created"`
+	LastSeen  types.DateTime `json:"lastSeen"`
+}
+
+func recordAuthSessionsList(e *core.RequestEvent) error {
+	if !e.Auth.Collection().Sessions.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow auth sessions.", nil)
+	}
+
+	sessions, err := e.App.FindAllAuthSessionsByRecord(e.Auth)
+	if err != nil {
+		return e.BadRequestError("Failed to load the auth sessions.", err)
+	}
+
+	claims, _ := security.ParseUnverifiedJWT(getAuthTokenFromRequest(e))
+	currentSessionId, _ := claims[core.TokenClaimSessionId].(string)
+
+	maxElapsed := e.Auth.Collection().Sessions.DurationTime()
+
+	result := make([]authSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		if session.HasExpired(maxElapsed) {
+			continue
+		}
+
+		result = append(result, authSessionResponse{
+			Id:        session.Id,
+			UserAgent: session.UserAgent(),
+			IP:        session.IP(),
+			Current:   currentSessionId != "" && session.Id == currentSessionId,
+			Created:   session.Created(),
+			LastSeen:  session.Updated(),
+		})
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func recordAuthSessionDelete(e *core.RequestEvent) error {
+	if !e.Auth.Collection().Sessions.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow auth sessions.", nil)
+	}
+
+	session, err := e.App.FindAuthSessionById(e.Request.PathValue("id"))
+	if err != nil || session.RecordRef() != e.Auth.Id || session.CollectionRef() != e.Auth.Collection().Id {
+		return e.NotFoundError("", err)
+	}
+
+	if err = e.App.Delete(session); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to revoke the auth session.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func recordAuthSessionsDeleteAll(e *core.RequestEvent) error {
+	if !e.Auth.Collection().Sessions.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow auth sessions.", nil)
+	}
+
+	if err := e.App.DeleteAllAuthSessionsByRecord(e.Auth); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to revoke the auth sessions.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// -------------------------------------------------------------------
+
+type authWithRefreshTokenForm struct {
+	RefreshToken string `form:"refreshToken" json:"refreshToken"`
+}
+
+func (form *authWithRefreshTokenForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.RefreshToken, validation.Required, validation.Length(1, 255)),
+	)
+}
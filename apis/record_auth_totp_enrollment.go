@@ -0,0 +1,158 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func recordRequestTOTPEnrollment(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow authenticator app (TOTP) authentication.", nil)
+	}
+
+	record := e.Auth
+
+	totp, _ := e.App.FindTOTPByRecord(record)
+	if totp == nil {
+		totp = core.NewTOTP(e.App)
+		totp.SetCollectionRef(collection.Id)
+		totp.SetRecordRef(record.Id)
+	} else if totp.Verified() {
+		return e.BadRequestError("The authenticator app is already enabled.", nil)
+	}
+
+	// (re)generate the secret of the pending enrollment
+	secret := security.RandomTOTPSecret()
+
+	if err = totp.SetSecret(e.App, secret); err != nil {
+		return e.InternalServerError("Failed to generate the authenticator app secret.", err)
+	}
+	totp.SetLastUsedStep(0)
+
+	if err = e.App.Save(totp); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to create the authenticator app enrollment.", err))
+	}
+
+	issuer := collection.TOTP.Issuer
+	if issuer == "" {
+		issuer = e.App.Settings().Meta.AppName
+	}
+
+	account := record.Email()
+	if account == "" {
+		account = record.Id
+	}
+
+	generator := security.TOTP{
+		Secret: secret,
+		Digits: collection.TOTP.Digits,
+		Period: collection.TOTP.Period,
+	}
+
+	return e.JSON(http.StatusOK, map[string]string{
+		"secret": secret,
+		"uri":    generator.URI(issuer, account),
+	})
+}
+
+func recordConfirmTOTPEnrollment(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow authenticator app (TOTP) authentication.", nil)
+	}
+
+	form := &totpCodeForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	totp, err := e.App.FindTOTPByRecord(e.Auth)
+	if err != nil || totp.Verified() {
+		return e.BadRequestError("Missing or already confirmed authenticator app enrollment.", err)
+	}
+
+	if !totp.ValidateCode(e.App, form.Code) {
+		return e.BadRequestError("Invalid authenticator app code.", errors.New("incorrect code"))
+	}
+
+	totp.SetVerified(true)
+	recoveryCodes := totp.GenerateRecoveryCodes()
+
+	if err = e.App.Save(totp); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to confirm the authenticator app enrollment.", err))
+	}
+
+	return e.JSON(http.StatusOK, map[string][]string{
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+func recordDisableTOTP(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow authenticator app (TOTP) authentication.", nil)
+	}
+
+	form := &totpCodeForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	totp, err := e.App.FindTOTPByRecord(e.Auth)
+	if err != nil || !totp.Verified() {
+		return e.BadRequestError("The authenticator app is not enabled.", err)
+	}
+
+	// enforce the same extra rate limit rule as for the auth-with-totp
+	err = checkRateLimit(e, "@hb_totp_"+e.Auth.Id, core.RateLimitRule{MaxRequests: 5, Duration: 180})
+	if err != nil {
+		return e.TooManyRequestsError("Too many attempts, please try again later.", nil)
+	}
+
+	if !totp.ValidateCode(e.App, form.Code) && !totp.UseRecoveryCode(form.Code) {
+		return e.BadRequestError("Invalid authenticator app code.", errors.New("incorrect code"))
+	}
+
+	if err = e.App.Delete(totp); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to disable the authenticator app.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// -------------------------------------------------------------------
+
+type totpCodeForm struct {
+	// Code is the authenticator app code
+	// (or one of the single use recovery codes when disabling).
+	Code string `form:"code" json:"code"`
+}
+
+func (form *totpCodeForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Code, validation.Required, validation.Length(1, 50)),
+	)
+}
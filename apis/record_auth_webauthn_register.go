@@ -0,0 +1,99 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/webauthn"
+)
+
+func recordConfirmWebAuthnRegistration(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	record := e.Auth
+
+	form := &webauthnRegistrationForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	clientDataJSON, err := webauthn.DecodeBase64URL(form.ClientDataJSON)
+	if err != nil {
+		return e.BadRequestError("Invalid or malformed passkey registration data.", err)
+	}
+
+	attestationObject, err := webauthn.DecodeBase64URL(form.AttestationObject)
+	if err != nil {
+		return e.BadRequestError("Invalid or malformed passkey registration data.", err)
+	}
+
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey registration data.", err)
+	}
+
+	err = verifyWebAuthnChallenge(e.App, clientData.Challenge, collection, record)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey registration data.", err)
+	}
+
+	verified, err := newWebAuthnRelyingParty(collection).VerifyRegistration(clientDataJSON, attestationObject, clientData.Challenge)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey registration data.", err)
+	}
+
+	credentialId := webauthn.EncodeBase64URL(verified.Id)
+
+	existing, _ := e.App.FindWebAuthnCredentialByCredentialId(collection.Id, credentialId)
+	if existing != nil {
+		return e.BadRequestError("The passkey is already registered.", errors.New("duplicated credential id"))
+	}
+
+	credential := core.NewWebAuthnCredential(e.App)
+	credential.SetCollectionRef(collection.Id)
+	credential.SetRecordRef(record.Id)
+	credential.SetName(form.Name)
+	credential.SetCredentialId(credentialId)
+	credential.SetPublicKey(webauthn.EncodeBase64URL(verified.PublicKey))
+	credential.SetAlgorithm(int(verified.Algorithm))
+	credential.SetSignCount(verified.SignCount)
+
+	if err = e.App.Save(credential); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to register the passkey.", err))
+	}
+
+	return e.JSON(http.StatusOK, credential)
+}
+
+// -------------------------------------------------------------------
+
+type webauthnRegistrationForm struct {
+	// Name is an optional user friendly passkey label.
+	Name string `form:"name" json:"name"`
+
+	// ClientDataJSON is the base64url encoded response.clientDataJSON.
+	ClientDataJSON string `form:"clientDataJSON" json:"clientDataJSON"`
+
+	// AttestationObject is the base64url encoded response.attestationObject.
+	AttestationObject string `form:"attestationObject" json:"attestationObject"`
+}
+
+func (form *webauthnRegistrationForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Name, validation.Length(0, 255)),
+		validation.Field(&form.ClientDataJSON, validation.Required, validation.Length(1, 10000)),
+		validation.Field(&form.AttestationObject, validation.Required, validation.Length(1, 20000)),
+	)
+}
@@ -0,0 +1,156 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/webauthn"
+)
+
+type webauthnCredentialDescriptor struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+type webauthnRequestOptionsResponse struct {
+	Challenge        string                         `json:"challenge"`
+	RPId             string                         `json:"rpId"`
+	Timeout          int64                          `json:"timeout"` // in milliseconds
+	UserVerification string                         `json:"userVerification"`
+	AllowCredentials []webauthnCredentialDescriptor `json:"allowCredentials"`
+}
+
+// recordRequestWebAuthn returns the public key credential request options
+// (https://www.w3.org/TR/webauthn-3/#dictionary-assertion-options)
+// that should be passed to navigator.credentials.get().
+//
+// Binary values are base64url encoded.
+//
+// The allowCredentials list is always empty since the authenticating
+// record is not known in advance (aka. discoverable credentials).
+func recordRequestWebAuthn(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	challenge, err := newWebAuthnChallenge(e.App, collection, nil)
+	if err != nil {
+		return e.InternalServerError("Failed to generate WebAuthn challenge.", err)
+	}
+
+	return e.JSON(http.StatusOK, webauthnRequestOptionsResponse{
+		Challenge:        challenge,
+		RPId:             collection.WebAuthn.RPId,
+		Timeout:          collection.WebAuthn.DurationTime().Milliseconds(),
+		UserVerification: collection.WebAuthn.UserVerification,
+		AllowCredentials: []webauthnCredentialDescriptor{},
+	})
+}
+
+// -------------------------------------------------------------------
+
+type webauthnRelyingPartyInfo struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type webauthnUserInfo struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type webauthnCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type webauthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type webauthnCreationOptionsResponse struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     webauthnRelyingPartyInfo       `json:"rp"`
+	User                   webauthnUserInfo               `json:"user"`
+	PubKeyCredParams       []webauthnCredentialParam      `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"` // in milliseconds
+	Attestation            string                         `json:"attestation"`
+	AuthenticatorSelection webauthnAuthenticatorSelection `json:"authenticatorSelection"`
+	ExcludeCredentials     []webauthnCredentialDescriptor `json:"excludeCredentials"`
+}
+
+// recordRequestWebAuthnRegistration returns the public key credential creation options
+// (https://www.w3.org/TR/webauthn-3/#dictionary-makecredentialoptions)
+// for the authenticated record that should be passed to navigator.credentials.create().
+//
+// Binary values are base64url encoded.
+func recordRequestWebAuthnRegistration(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	record := e.Auth
+
+	challenge, err := newWebAuthnChallenge(e.App, collection, record)
+	if err != nil {
+		return e.InternalServerError("Failed to generate WebAuthn challenge.", err)
+	}
+
+	result := webauthnCreationOptionsResponse{
+		Challenge: challenge,
+		RP: webauthnRelyingPartyInfo{
+			Id:   collection.WebAuthn.RPId,
+			Name: collection.WebAuthn.RPName,
+		},
+		User: webauthnUserInfo{
+			Id:          webauthn.EncodeBase64URL([]byte(record.Id)),
+			Name:        record.Email(),
+			DisplayName: record.Email(),
+		},
+		PubKeyCredParams: make([]webauthnCredentialParam, 0, len(webauthn.SupportedAlgorithms)),
+		Timeout:          collection.WebAuthn.DurationTime().Milliseconds(),
+		Attestation:      "none",
+		AuthenticatorSelection: webauthnAuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: collection.WebAuthn.UserVerification,
+		},
+		ExcludeCredentials: []webauthnCredentialDescriptor{},
+	}
+
+	if result.RP.Name == "" {
+		result.RP.Name = e.App.Settings().Meta.AppName
+	}
+
+	if result.User.Name == "" {
+		result.User.Name = record.Id
+		result.User.DisplayName = record.Id
+	}
+
+	for _, alg := range webauthn.SupportedAlgorithms {
+		result.PubKeyCredParams = append(result.PubKeyCredParams, webauthnCredentialParam{Type: "public-key", Alg: alg})
+	}
+
+	// exclude the already registered record credentials
+	// (note: the error is ignored because the credentials collection is created on demand)
+	credentials, _ := e.App.FindAllWebAuthnCredentialsByRecord(record)
+	for _, c := range credentials {
+		result.ExcludeCredentials = append(result.ExcludeCredentials, webauthnCredentialDescriptor{
+			Type: "public-key",
+			Id:   c.CredentialId(),
+		})
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,98 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/core"
+)
+
+func recordAuthWithTOTP(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.TOTP.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow authenticator app (TOTP) authentication.", nil)
+	}
+
+	form := &authWithTOTPForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	event := new(core.RecordAuthWithTOTPRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+
+	// extra validations
+	// (note: returns a generic 400 as a very basic MFA sessions enumeration protection)
+	//
+	// the authenticator app code could be used only as a second factor
+	// so the auth record is resolved from the already started MFA session
+	// ---
+	event.MFA, err = e.App.FindMFAById(form.MFAId)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired MFA session.", err)
+	}
+
+	if event.MFA.CollectionRef() != collection.Id {
+		return e.BadRequestError("Invalid or expired MFA session.", errors.New("the MFA is for a different collection"))
+	}
+
+	if event.MFA.HasExpired(collection.MFA.DurationTime()) {
+		return e.BadRequestError("Invalid or expired MFA session.", errors.New("the MFA is expired"))
+	}
+
+	event.Record, err = e.App.FindRecordById(collection, event.MFA.RecordRef())
+	if err != nil {
+		return e.BadRequestError("Invalid or expired MFA session.", fmt.Errorf("missing auth record: %w", err))
+	}
+
+	// since the authenticator codes are short digit numbers, enforce an extra rate limit rule as basic enumeration protection
+	err = checkRateLimit(e, "@hb_totp_"+event.Record.Id, core.RateLimitRule{MaxRequests: 5, Duration: 180})
+	if err != nil {
+		return e.TooManyRequestsError("Too many attempts, please try again later.", nil)
+	}
+
+	event.TOTP, err = e.App.FindTOTPByRecord(event.Record)
+	if err != nil || !event.TOTP.Verified() {
+		return e.BadRequestError("Invalid authenticator app code.", errors.New("missing or unverified totp enrollment"))
+	}
+
+	// consume the code with a conditional update so that it couldn't be
+	// reused by concurrent requests (or if the auth fails later)
+	if err = e.App.ConsumeTOTPCode(event.TOTP, form.Code); err != nil {
+		if err = e.App.ConsumeTOTPRecoveryCode(event.TOTP, form.Code); err != nil {
+			return e.BadRequestError("Invalid authenticator app code.", err)
+		}
+		event.IsRecoveryCode = true
+	}
+	// ---
+
+	return e.App.OnRecordAuthWithTOTPRequest().Trigger(event, func(e *core.RecordAuthWithTOTPRequestEvent) error {
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodTOTP, nil)
+	})
+}
+
+// -------------------------------------------------------------------
+
+type authWithTOTPForm struct {
+	// MFAId is the id of the MFA session started with the first auth method.
+	MFAId string `form:"mfaId" json:"mfaId"`
+
+	// Code is the authenticator app code or one of the single use recovery codes.
+	Code string `form:"code" json:"code"`
+}
+
+func (form *authWithTOTPForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.MFAId, validation.Required, validation.Length(1, 255)),
+		validation.Field(&form.Code, validation.Required, validation.Length(1, 50)),
+	)
+}
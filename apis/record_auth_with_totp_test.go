@@ -0,0 +1,429 @@
+package apis_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func enableUsersTOTP(t testing.TB, app *tests.TestApp) *core.Collection {
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users.TOTP.Enabled = true
+
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	return users
+}
+
+// saveTestTOTP stores an authenticator app enrollment
+// with the provided secret for the auth record.
+func saveTestTOTP(t testing.TB, app core.App, record *core.Record, secret string, verified bool, lastUsedStep int64) {
+	totp := core.NewTOTP(app)
+	totp.SetCollectionRef(record.Collection().Id)
+	totp.SetRecordRef(record.Id)
+	totp.SetVerified(verified)
+	totp.SetLastUsedStep(lastUsedStep)
+
+	if err := totp.SetSecret(app, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	totp.GenerateRecoveryCodes()
+
+	if err := app.Save(totp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testTOTPCode(t testing.TB, secret string) string {
+	code, err := security.TOTP{Secret: secret}.Generate(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code
+}
+
+// saveTestMFA stores a first factor MFA session for the auth record.
+func saveTestMFA(t testing.TB, app core.App, record *core.Record, id string) {
+	mfa := core.NewMFA(app)
+	mfa.Id = id
+	mfa.SetCollectionRef(record.Collection().Id)
+	mfa.SetRecordRef(record.Id)
+	mfa.SetMethod(core.MFAMethodPassword)
+
+	if err := app.Save(mfa); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordAuthWithTOTP(t *testing.T) {
+	t.Parallel()
+
+	mfaId := strings.Repeat("a", 15)
+	secret := security.RandomTOTPSecret()
+	code := testTOTPCode(t, secret)
+
+	setup := func(verified bool, lastUsedStep int64) func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			enableUsersTOTP(t, app)
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			saveTestMFA(t, app, user, mfaId)
+			saveTestTOTP(t, app, user, secret, verified, lastUsedStep)
+		}
+	}
+
+	findTOTP := func(t testing.TB, app *tests.TestApp) *core.TOTP {
+		user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		totp, err := app.FindTOTPByRecord(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return totp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/auth-with-totp",
+			Body:            strings.NewReader(`{"mfaId":"` + mfaId + `","code":"` + code + `"}`),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled totp",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-totp",
+			Body:            strings.NewReader(`{"mfaId":"` + mfaId + `","code":"` + code + `"}`),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:           "empty body",
+			Method:         http.MethodPost,
+			URL:            "/api/collections/users/auth-with-totp",
+			Body:           strings.NewReader(``),
+			BeforeTestFunc: setup(true, 0),
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"data":{`,
+				`"mfaId":{"code":"validation_required"`,
+				`"code":{"code":"validation_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:            "missing mfa session",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-totp",
+			Body:            strings.NewReader(`{"mfaId":"missing","code":"` + code + `"}`),
+			BeforeTestFunc:  setup(true, 0),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "auth record without totp enrollment",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + mfaId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersTOTP(t, app)
+
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				saveTestMFA(t, app, user, mfaId)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "unconfirmed totp enrollment",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-totp",
+			Body:            strings.NewReader(`{"mfaId":"` + mfaId + `","code":"` + code + `"}`),
+			BeforeTestFunc:  setup(false, 0),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "invalid code",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-totp",
+			Body:            strings.NewReader(`{"mfaId":"` + mfaId + `","code":"invalid"}`),
+			BeforeTestFunc:  setup(true, 0),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "already used code",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-totp",
+			Body:            strings.NewReader(`{"mfaId":"` + mfaId + `","code":"` + code + `"}`),
+			BeforeTestFunc:  setup(true, time.Now().Unix()/security.DefaultTOTPPeriod+1),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:           "valid code",
+			Method:         http.MethodPost,
+			URL:            "/api/collections/users/auth-with-totp",
+			Body:           strings.NewReader(`{"mfaId":"` + mfaId + `","code":"` + code + `"}`),
+			BeforeTestFunc: setup(true, 0),
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"record":{`,
+				`"email":"test@example.com"`,
+			},
+			NotExpectedContent: []string{
+				`"mfaId"`,
+				// hidden fields
+				`"tokenKey"`,
+				`"password"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithTOTPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordEnrich":              1,
+				// mfa delete
+				"OnRecordDelete": 1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				totp := findTOTP(t, app)
+
+				if totp.LastUsedStep() == 0 {
+					t.Fatal("Expected the last used step to be updated")
+				}
+
+				if v := totp.RecoveryCodesLeft(); v != core.TOTPRecoveryCodesCount {
+					t.Fatalf("Expected %d recovery codes, got %d", core.TOTPRecoveryCodesCount, v)
+				}
+
+				if _, err := app.FindMFAById(mfaId); err == nil {
+					t.Fatal("Expected the mfa session to be deleted")
+				}
+			},
+		},
+		{
+			Name:   "valid recovery code",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + mfaId + `","code":"abcde-23456"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				setup(true, 0)(t, app, e)
+
+				// replace the random recovery codes with known ones
+				totp := findTOTP(t, app)
+				totp.Set("recoveryCodes", []string{security.SHA256("abcde23456"), security.SHA256("fghij23456")})
+				if err := app.Save(totp); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"email":"test@example.com"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithTOTPRequest": 1,
+				"OnRecordAuthRequest":         1,
+				"OnRecordEnrich":              1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				totp := findTOTP(t, app)
+
+				if v := totp.RecoveryCodesLeft(); v != 1 {
+					t.Fatalf("Expected 1 recovery code left, got %d", v)
+				}
+
+				if totp.UseRecoveryCode("abcde-23456") {
+					t.Fatal("Expected the used recovery code to be removed")
+				}
+			},
+		},
+
+		// rate limit checks
+		// -----------------------------------------------------------
+		{
+			Name:   "RateLimit rule - users:authWithTOTP",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-totp",
+			Body:   strings.NewReader(`{"mfaId":"` + mfaId + `","code":"` + code + `"}`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				setup(true, 0)(t, app, e)
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 100, Label: "*:authWithTOTP"},
+					{MaxRequests: 0, Label: "users:authWithTOTP"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+func TestRecordAuthWithTOTPRequiredAsSecondFactor(t *testing.T) {
+	t.Parallel()
+
+	mfaId := strings.Repeat("b", 15)
+	secret := security.RandomTOTPSecret()
+
+	body := `{
+		"mfaId": "` + mfaId + `",
+		"identity":"test@example.com",
+		"password":"1234567890"
+	}`
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:   "second factor without enrolled authenticator app",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-password",
+			Body:   strings.NewReader(body),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersTOTP(t, app)
+
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mfa := core.NewMFA(app)
+				mfa.Id = mfaId
+				mfa.SetCollectionRef(user.Collection().Id)
+				mfa.SetRecordRef(user.Id)
+				mfa.SetMethod(core.MFAMethodOTP)
+				if err := app.Save(mfa); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"email":"test@example.com"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+		},
+		{
+			Name:   "second factor with enrolled authenticator app",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-password",
+			Body:   strings.NewReader(body),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersTOTP(t, app)
+
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mfa := core.NewMFA(app)
+				mfa.Id = mfaId
+				mfa.SetCollectionRef(user.Collection().Id)
+				mfa.SetRecordRef(user.Id)
+				mfa.SetMethod(core.MFAMethodOTP)
+				if err := app.Save(mfa); err != nil {
+					t.Fatal(err)
+				}
+
+				saveTestTOTP(t, app, user, secret, true, 0)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if _, err := app.FindMFAById(mfaId); err != nil {
+					t.Fatalf("Expected the mfa session to be preserved, got %v", err)
+				}
+			},
+		},
+		{
+			Name:   "second factor without enrolled authenticator app and required totp",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-password",
+			Body:   strings.NewReader(body),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				users := enableUsersTOTP(t, app)
+				users.MFA.RequireTOTP = true
+				if err := app.Save(users); err != nil {
+					t.Fatal(err)
+				}
+
+				user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				mfa := core.NewMFA(app)
+				mfa.Id = mfaId
+				mfa.SetCollectionRef(user.Collection().Id)
+				mfa.SetRecordRef(user.Id)
+				mfa.SetMethod(core.MFAMethodOTP)
+				if err := app.Save(mfa); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithPasswordRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if _, err := app.FindMFAById(mfaId); err != nil {
+					t.Fatalf("Expected the mfa session to be preserved, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,205 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/webauthn"
+)
+
+func recordAuthWithWebAuthn(e *core.RequestEvent) error {
+	collection, err := findAuthCollection(e)
+	if err != nil {
+		return err
+	}
+
+	if !collection.WebAuthn.Enabled {
+		return e.ForbiddenError("The collection is not configured to allow WebAuthn authentication.", nil)
+	}
+
+	form := &authWithWebAuthnForm{}
+	if err = e.BindBody(form); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while loading the submitted data.", err))
+	}
+	if err = form.validate(); err != nil {
+		return firstApiError(err, e.BadRequestError("An error occurred while validating the submitted data.", err))
+	}
+
+	credentialId, clientDataJSON, authenticatorData, signature, err := form.decode()
+	if err != nil {
+		return e.BadRequestError("Invalid or malformed passkey assertion.", err)
+	}
+
+	// extra validations
+	// (note: returns a generic 400 as a very basic credentials enumeration protection)
+	// ---
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey assertion.", err)
+	}
+
+	err = verifyWebAuthnChallenge(e.App, clientData.Challenge, collection, nil)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey assertion.", err)
+	}
+
+	event := new(core.RecordAuthWithWebAuthnRequestEvent)
+	event.RequestEvent = e
+	event.Collection = collection
+
+	event.Credential, err = e.App.FindWebAuthnCredentialByCredentialId(collection.Id, credentialId)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey assertion.", fmt.Errorf("missing credential: %w", err))
+	}
+
+	event.Record, err = e.App.FindRecordById(collection, event.Credential.RecordRef())
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey assertion.", fmt.Errorf("missing auth record: %w", err))
+	}
+
+	publicKey, err := webauthn.DecodeBase64URL(event.Credential.PublicKey())
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey assertion.", fmt.Errorf("invalid stored public key: %w", err))
+	}
+
+	signCount, err := newWebAuthnRelyingParty(collection).VerifyAssertion(
+		publicKey,
+		event.Credential.SignCount(),
+		clientDataJSON,
+		authenticatorData,
+		signature,
+		clientData.Challenge,
+	)
+	if err != nil {
+		return e.BadRequestError("Invalid or expired passkey assertion.", err)
+	}
+	// ---
+
+	return e.App.OnRecordAuthWithWebAuthnRequest().Trigger(event, func(e *core.RecordAuthWithWebAuthnRequestEvent) error {
+		// persist the new sign count so that cloned authenticators could be detected
+		e.Credential.SetSignCount(signCount)
+		if err := e.App.Save(e.Credential); err != nil {
+			return e.InternalServerError("Failed to update the passkey sign counter.", err)
+		}
+
+		return RecordAuthResponse(e.RequestEvent, e.Record, core.MFAMethodWebAuthn, nil)
+	})
+}
+
+// -------------------------------------------------------------------
+
+type authWithWebAuthnForm struct {
+	// Id is the base64url encoded credential id.
+	Id string `form:"id" json:"id"`
+
+	// ClientDataJSON is the base64url encoded response.clientDataJSON.
+	ClientDataJSON string `form:"clientDataJSON" json:"clientDataJSON"`
+
+	// AuthenticatorData is the base64url encoded response.authenticatorData.
+	AuthenticatorData string `form:"authenticatorData" json:"authenticatorData"`
+
+	// Signature is the base64url encoded response.signature.
+	Signature string `form:"signature" json:"signature"`
+}
+
+func (form *authWithWebAuthnForm) validate() error {
+	return validation.ValidateStruct(form,
+		validation.Field(&form.Id, validation.Required, validation.Length(1, 1500)),
+		validation.Field(&form.ClientDataJSON, validation.Required, validation.Length(1, 10000)),
+		validation.Field(&form.AuthenticatorData, validation.Required, validation.Length(1, 10000)),
+		validation.Field(&form.Signature, validation.Required, validation.Length(1, 2000)),
+	)
+}
+
+func (form *authWithWebAuthnForm) decode() (credentialId string, clientDataJSON []byte, authenticatorData []byte, signature []byte, err error) {
+	rawId, err := webauthn.DecodeBase64URL(form.Id)
+	if err != nil {
+		return "", nil, nil, nil, fmt.Errorf("invalid id: %w", err)
+	}
+
+	clientDataJSON, err = webauthn.DecodeBase64URL(form.ClientDataJSON)
+	if err != nil {
+		return "", nil, nil, nil, fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+
+	authenticatorData, err = webauthn.DecodeBase64URL(form.AuthenticatorData)
+	if err != nil {
+		return "", nil, nil, nil, fmt.Errorf("invalid authenticatorData: %w", err)
+	}
+
+	signature, err = webauthn.DecodeBase64URL(form.Signature)
+	if err != nil {
+		return "", nil, nil, nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	// normalize the credential id encoding
+	return webauthn.EncodeBase64URL(rawId), clientDataJSON, authenticatorData, signature, nil
+}
+
+// -------------------------------------------------------------------
+
+// newWebAuthnRelyingParty returns a new WebAuthn relying party
+// initialized with the collection WebAuthn options.
+func newWebAuthnRelyingParty(collection *core.Collection) *webauthn.RelyingParty {
+	return &webauthn.RelyingParty{
+		Id:                      collection.WebAuthn.RPId,
+		Origins:                 collection.WebAuthn.Origins,
+		RequireUserVerification: collection.WebAuthn.UserVerification == core.WebAuthnUserVerificationRequired,
+		RequireSignCount:        collection.WebAuthn.RequireSignCount,
+	}
+}
+
+// newWebAuthnChallenge generates and stores a new single use WebAuthn challenge.
+//
+// If record is set, the challenge is created for the record passkey registration.
+// Otherwise the challenge is created for a passkey authentication.
+func newWebAuthnChallenge(app core.App, collection *core.Collection, record *core.Record) (string, error) {
+	challenge := core.NewWebAuthnChallenge(app)
+	challenge.SetCollectionRef(collection.Id)
+	challenge.SetType(core.WebAuthnChallengeTypeAuthentication)
+	challenge.SetChallenge(webauthn.EncodeBase64URL([]byte(security.RandomString(32))))
+
+	if record != nil {
+		challenge.SetType(core.WebAuthnChallengeTypeRegistration)
+		challenge.SetRecordRef(record.Id)
+	}
+
+	if err := app.Save(challenge); err != nil {
+		return "", err
+	}
+
+	return challenge.Challenge(), nil
+}
+
+// verifyWebAuthnChallenge verifies a challenge generated with [newWebAuthnChallenge].
+//
+// The challenge is consumed (aka. deleted) on the first verification
+// attempt regardless of its result so that it couldn't be replayed.
+func verifyWebAuthnChallenge(app core.App, challenge string, collection *core.Collection, record *core.Record) error {
+	model, err := app.ConsumeWebAuthnChallenge(collection.Id, challenge)
+	if err != nil {
+		return fmt.Errorf("missing or already used challenge: %w", err)
+	}
+
+	if model.HasExpired(collection.WebAuthn.DurationTime()) {
+		return errors.New("the challenge has expired")
+	}
+
+	expectedType := core.WebAuthnChallengeTypeAuthentication
+	if record != nil {
+		expectedType = core.WebAuthnChallengeTypeRegistration
+	}
+
+	if model.Type() != expectedType {
+		return errors.New("invalid challenge type")
+	}
+
+	if record != nil && model.RecordRef() != record.Id {
+		return errors.New("the challenge is for a different record")
+	}
+
+	return nil
+}
@@ -0,0 +1,443 @@
+package apis_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/backendPB/tools/webauthn"
+)
+
+const (
+	testWebAuthnRPId   = "localhost"
+	testWebAuthnOrigin = "http://localhost:8090"
+)
+
+func enableUsersWebAuthn(t testing.TB, app *tests.TestApp) *core.Collection {
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users.WebAuthn.Enabled = true
+	users.WebAuthn.RPId = testWebAuthnRPId
+	users.WebAuthn.Origins = []string{testWebAuthnOrigin}
+	users.WebAuthn.UserVerification = core.WebAuthnUserVerificationPreferred
+	users.WebAuthn.Duration = 300
+
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	return users
+}
+
+// saveTestWebAuthnChallenge stores a users WebAuthn challenge in the
+// same way as the request-webauthn* endpoints.
+//
+// recordId is set only for the registration challenges.
+func saveTestWebAuthnChallenge(t testing.TB, app core.App, challenge string, recordId string) *core.WebAuthnChallenge {
+	m := core.NewWebAuthnChallenge(app)
+	m.SetCollectionRef("_pb_users_auth_")
+	m.SetChallenge(challenge)
+	m.SetType(core.WebAuthnChallengeTypeAuthentication)
+
+	if recordId != "" {
+		m.SetType(core.WebAuthnChallengeTypeRegistration)
+		m.SetRecordRef(recordId)
+	}
+
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+// testPasskey is a minimal Ed25519 software authenticator
+// used to generate WebAuthn registration and assertion payloads.
+type testPasskey struct {
+	credentialId []byte
+	privateKey   ed25519.PrivateKey
+	coseKey      []byte
+}
+
+func newTestPasskey(t testing.TB) *testPasskey {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testPasskey{
+		credentialId: []byte("test_passkey_credential"),
+		privateKey:   priv,
+		// {1: 1 (OKP), 3: -8 (EdDSA), -1: 6 (Ed25519), -2: pub}
+		coseKey: append([]byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21, 0x58, 0x20}, pub...),
+	}
+}
+
+// save stores the passkey as registered credential for the provided auth record.
+func (p *testPasskey) save(t testing.TB, app core.App, record *core.Record, signCount uint32) *core.WebAuthnCredential {
+	credential := core.NewWebAuthnCredential(app)
+	credential.SetCollectionRef(record.Collection().Id)
+	credential.SetRecordRef(record.Id)
+	credential.SetCredentialId(webauthn.EncodeBase64URL(p.credentialId))
+	credential.SetPublicKey(webauthn.EncodeBase64URL(p.coseKey))
+	credential.SetAlgorithm(int(webauthn.AlgEdDSA))
+	credential.SetSignCount(signCount)
+
+	if err := app.Save(credential); err != nil {
+		t.Fatal(err)
+	}
+
+	return credential
+}
+
+func (p *testPasskey) authData(signCount uint32, withCredential bool) []byte {
+	rpIdHash := sha256.Sum256([]byte(testWebAuthnRPId))
+
+	flags := webauthn.FlagUserPresent | webauthn.FlagUserVerified
+	if withCredential {
+		flags |= webauthn.FlagAttestedCredentialData
+	}
+
+	result := append([]byte(nil), rpIdHash[:]...)
+	result = append(result, flags)
+	result = binary.BigEndian.AppendUint32(result, signCount)
+
+	if withCredential {
+		result = append(result, make([]byte, 16)...) // zero AAGUID
+		result = binary.BigEndian.AppendUint16(result, uint16(len(p.credentialId)))
+		result = append(result, p.credentialId...)
+		result = append(result, p.coseKey...)
+	}
+
+	return result
+}
+
+func (p *testPasskey) clientData(t testing.TB, typ string, challenge string) []byte {
+	raw, err := json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": challenge,
+		"origin":    testWebAuthnOrigin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func (p *testPasskey) registrationBody(t testing.TB, challenge string) string {
+	authData := p.authData(0, true)
+
+	// {"fmt": "none", "attStmt": {}, "authData": authData}
+	attestation := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e'}
+	attestation = append(attestation, 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0)
+	attestation = append(attestation, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x58, byte(len(authData)))
+	attestation = append(attestation, authData...)
+
+	body, err := json.Marshal(map[string]any{
+		"name":              "test passkey",
+		"clientDataJSON":    webauthn.EncodeBase64URL(p.clientData(t, webauthn.ClientDataTypeCreate, challenge)),
+		"attestationObject": webauthn.EncodeBase64URL(attestation),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+func (p *testPasskey) assertionBody(t testing.TB, challenge string, signCount uint32) string {
+	clientData := p.clientData(t, webauthn.ClientDataTypeGet, challenge)
+	clientDataHash := sha256.Sum256(clientData)
+
+	authData := p.authData(signCount, false)
+
+	signature := ed25519.Sign(p.privateKey, append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	body, err := json.Marshal(map[string]any{
+		"id":                webauthn.EncodeBase64URL(p.credentialId),
+		"clientDataJSON":    webauthn.EncodeBase64URL(clientData),
+		"authenticatorData": webauthn.EncodeBase64URL(authData),
+		"signature":         webauthn.EncodeBase64URL(signature),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+// -------------------------------------------------------------------
+
+func TestRecordAuthWithWebAuthn(t *testing.T) {
+	t.Parallel()
+
+	passkey := newTestPasskey(t)
+	otherPasskey := newTestPasskey(t)
+
+	const (
+		validChallenge        = "test_valid_challenge"
+		missingChallenge      = "test_missing_challenge"
+		expiredChallenge      = "test_expired_challenge"
+		registrationChallenge = "test_registration_challenge"
+	)
+
+	setupWebAuthn := func(t testing.TB, app *tests.TestApp) *core.Collection {
+		collection := enableUsersWebAuthn(t, app)
+
+		saveTestWebAuthnChallenge(t, app, validChallenge, "")
+		saveTestWebAuthnChallenge(t, app, registrationChallenge, "4q1xlclmfloku33")
+
+		expired := saveTestWebAuthnChallenge(t, app, expiredChallenge, "")
+		expired.SetRaw("created", types.NowDateTime().Add(-10*time.Minute))
+		if err := app.Save(expired); err != nil {
+			t.Fatal(err)
+		}
+
+		return collection
+	}
+
+	savePasskey := func(p *testPasskey, signCount uint32, disableMFA bool) func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			collection := setupWebAuthn(t, app)
+
+			if disableMFA {
+				collection.MFA.Enabled = false
+				if err := app.Save(collection); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p.save(t, app, user, signCount)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "not an auth collection",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/demo1/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, validChallenge, 1)),
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "auth collection with disabled webauthn",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, validChallenge, 1)),
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "invalid body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(`{"id`),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersWebAuthn(t, app)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "empty body",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(``),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersWebAuthn(t, app)
+			},
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"data":{`,
+				`"id":{"code":"validation_required"`,
+				`"clientDataJSON":{"code":"validation_required"`,
+				`"authenticatorData":{"code":"validation_required"`,
+				`"signature":{"code":"validation_required"`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:            "missing challenge",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, missingChallenge, 1)),
+			BeforeTestFunc:  savePasskey(passkey, 0, true),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "expired challenge",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, expiredChallenge, 1)),
+			BeforeTestFunc:  savePasskey(passkey, 0, true),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "challenge with different type",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, registrationChallenge, 1)),
+			BeforeTestFunc:  savePasskey(passkey, 0, true),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "missing credential",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(passkey.assertionBody(t, validChallenge, 1)),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				setupWebAuthn(t, app)
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "credential with different public key",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, validChallenge, 1)),
+			BeforeTestFunc:  savePasskey(otherPasskey, 0, true),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "sign count rollback",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, validChallenge, 3)),
+			BeforeTestFunc:  savePasskey(passkey, 5, true),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "authenticator without counter and required sign count",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(passkey.assertionBody(t, validChallenge, 0)),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				savePasskey(passkey, 0, true)(t, app, e)
+
+				users, err := app.FindCollectionByNameOrId("users")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				users.WebAuthn.RequireSignCount = true
+				if err := app.Save(users); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:            "valid assertion (enabled MFA)",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-webauthn",
+			Body:            strings.NewReader(passkey.assertionBody(t, validChallenge, 1)),
+			BeforeTestFunc:  savePasskey(passkey, 0, false),
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"mfaId":"`},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithWebAuthnRequest": 1,
+				"OnRecordAuthRequest":             1,
+			},
+		},
+		{
+			Name:           "valid assertion (disabled MFA)",
+			Method:         http.MethodPost,
+			URL:            "/api/collections/users/auth-with-webauthn",
+			Body:           strings.NewReader(passkey.assertionBody(t, validChallenge, 6)),
+			BeforeTestFunc: savePasskey(passkey, 5, true),
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"token":"`,
+				`"record":{`,
+				`"email":"test@example.com"`,
+			},
+			NotExpectedContent: []string{
+				`"meta":`,
+				// hidden fields
+				`"tokenKey"`,
+				`"password"`,
+			},
+			ExpectedEvents: map[string]int{
+				"OnRecordAuthWithWebAuthnRequest": 1,
+				"OnRecordAuthRequest":             1,
+				"OnRecordEnrich":                  1,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				credential, err := app.FindWebAuthnCredentialByCredentialId("_pb_users_auth_", webauthn.EncodeBase64URL(passkey.credentialId))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if v := credential.SignCount(); v != 6 {
+					t.Fatalf("Expected the credential sign count to be updated to 6, got %d", v)
+				}
+
+				if _, err := app.ConsumeWebAuthnChallenge("_pb_users_auth_", validChallenge); err == nil {
+					t.Fatal("Expected the challenge to be consumed")
+				}
+			},
+		},
+
+		// rate limit checks
+		// -----------------------------------------------------------
+		{
+			Name:   "RateLimit rule - users:authWithWebAuthn",
+			Method: http.MethodPost,
+			URL:    "/api/collections/users/auth-with-webauthn",
+			Body:   strings.NewReader(passkey.assertionBody(t, validChallenge, 1)),
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				enableUsersWebAuthn(t, app)
+
+				app.Settings().RateLimits.Enabled = true
+				app.Settings().RateLimits.Rules = []core.RateLimitRule{
+					{MaxRequests: 100, Label: "abc"},
+					{MaxRequests: 100, Label: "*:authWithWebAuthn"},
+					{MaxRequests: 0, Label: "users:authWithWebAuthn"},
+				}
+			},
+			ExpectedStatus:  429,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
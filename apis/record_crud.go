@@ -27,10 +27,17 @@ import (
 func bindRecordCrudApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
 	subGroup := rg.Group("/collections/{collection}/records").Unbind(DefaultRateLimitMiddlewareId)
 	subGroup.GET("", recordsList)
+	subGroup.GET("/aggregate", recordsAggregate)
+	subGroup.GET("/export", recordsExport)
+	subGroup.POST("/import", recordsImport).Bind(BodyLimit(0), RequireSuperuserAuth())
 	subGroup.GET("/{id}", recordView)
 	subGroup.POST("", recordCreate(nil)).Bind(dynamicCollectionBodyLimit(""))
 	subGroup.PATCH("/{id}", recordUpdate(nil)).Bind(dynamicCollectionBodyLimit(""))
 	subGroup.DELETE("/{id}", recordDelete(nil))
+	subGroup.POST("/{id}/restore", recordRestore).Bind(RequireSuperuserAuth())
+	subGroup.DELETE("/{id}/purge", recordPurge).Bind(RequireSuperuserAuth())
+	subGroup.GET("/{id}/revisions", recordRevisionsList).Bind(RequireSuperuserAuth())
+	subGroup.POST("/{id}/revisions/{revisionId}/restore", recordRevisionRestore).Bind(RequireSuperuserAuth())
 }
 
 func recordsList(e *core.RequestEvent) error {
@@ -59,9 +66,12 @@ func recordsList(e *core.RequestEvent) error {
 		return err
 	}
 
-	query := e.App.RecordQuery(collection)
+	// read from a replica (if configured)
+	readApp := e.App.ReplicaApp(core.ReplicaSessionKey(e))
 
-	fieldsResolver := core.NewRecordFieldResolver(e.App, collection, requestInfo, true)
+	query := readApp.RecordQuery(collection)
+
+	fieldsResolver := core.NewRecordFieldResolver(readApp, collection, requestInfo, true)
 
 	if !requestInfo.HasSuperuserAuth() && collection.ListRule != nil && *collection.ListRule != "" {
 		expr, err := search.FilterData(*collection.ListRule).BuildExpr(fieldsResolver)
@@ -159,9 +169,12 @@ func recordView(e *core.RequestEvent) error {
 		return e.ForbiddenError("Only superusers can perform this action.", nil)
 	}
 
+	// read from a replica (if configured)
+	readApp := e.App.ReplicaApp(core.ReplicaSessionKey(e))
+
 	ruleFunc := func(q *dbx.SelectQuery) error {
 		if !requestInfo.HasSuperuserAuth() && collection.ViewRule != nil && *collection.ViewRule != "" {
-			resolver := core.NewRecordFieldResolver(e.App, collection, requestInfo, true)
+			resolver := core.NewRecordFieldResolver(readApp, collection, requestInfo, true)
 			expr, err := search.FilterData(*collection.ViewRule).BuildExpr(resolver)
 			if err != nil {
 				return err
@@ -172,7 +185,7 @@ func recordView(e *core.RequestEvent) error {
 		return nil
 	}
 
-	record, fetchErr := e.App.FindRecordById(collection, recordId, ruleFunc)
+	record, fetchErr := readApp.FindRecordById(collection, recordId, ruleFunc)
 	if fetchErr != nil || record == nil {
 		return firstApiError(err, e.NotFoundError("", fetchErr))
 	}
@@ -239,6 +252,7 @@ func recordCreate(optFinalizer func(data any) error) func(e *core.RequestEvent)
 		requestInfo.Body = data
 
 		form := forms.NewRecordUpsert(e.App, record)
+		form.SetContext(core.ContextWithRequestEvent(e.Request.Context(), e))
 		if hasSuperuserAuth {
 			form.GrantSuperuserAccess()
 		}
@@ -435,7 +449,14 @@ func recordUpdate(optFinalizer func(data any) error) func(e *core.RequestEvent)
 			return firstApiError(err, e.NotFoundError("", err))
 		}
 
+		// check the individual field update rules (if any)
+		err = checkFieldUpdateRules(e.App, record, requestInfo, data)
+		if err != nil {
+			return e.BadRequestError("Failed to update record.", err)
+		}
+
 		form := forms.NewRecordUpsert(e.App, record)
+		form.SetContext(core.ContextWithRequestEvent(e.Request.Context(), e))
 		if hasSuperuserAuth {
 			form.GrantSuperuserAccess()
 		}
@@ -556,7 +577,8 @@ func recordDelete(optFinalizer func(data any) error) func(e *core.RequestEvent)
 		event.Record = record
 
 		hookErr := e.App.OnRecordDeleteRequest().Trigger(event, func(e *core.RecordRequestEvent) error {
-			if err := e.App.Delete(e.Record); err != nil {
+			ctx := core.ContextWithRequestEvent(e.Request.Context(), e.RequestEvent)
+			if err := e.App.DeleteWithContext(ctx, e.Record); err != nil {
 				return firstApiError(err, e.BadRequestError("Failed to delete record. Make sure that the record is not part of a required relation reference.", err))
 			}
 
@@ -0,0 +1,415 @@
+package apis
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/search"
+	"github.com/hanzoai/dbx"
+	"github.com/spf13/cast"
+)
+
+// URL aggregate query params
+const (
+	aggregateQueryParam = "aggregate"
+	groupByQueryParam   = "groupBy"
+)
+
+const (
+	// maxAggregateExprs specifies the max allowed aggregate expressions in a single request.
+	maxAggregateExprs = 20
+
+	// maxAggregateGroupByFields specifies the max allowed groupBy fields in a single request.
+	maxAggregateGroupByFields = 5
+
+	// maxAggregateGroups specifies the max number of returned aggregate groups.
+	maxAggregateGroups = 1000
+)
+
+var aggregateExprRegex = regexp.MustCompile(`^(count|sum|avg|min|max)\((\w*)\)$`)
+
+// aggregateExpr defines a single parsed aggregate expression, e.g. "sum(total)".
+type aggregateExpr struct {
+	field core.Field
+	fn    string
+	key   string
+}
+
+func recordsAggregate(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	err = checkCollectionRateLimit(e, collection, "list")
+	if err != nil {
+		return err
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	if collection.ListRule == nil && !requestInfo.HasSuperuserAuth() {
+		return e.ForbiddenError("Only superusers can perform this action.", nil)
+	}
+
+	// forbid users and guests to query special filter/sort fields
+	err = checkForSuperuserOnlyRuleFields(requestInfo)
+	if err != nil {
+		return err
+	}
+
+	exprs, err := parseAggregateExprs(collection, requestInfo)
+	if err != nil {
+		return e.BadRequestError("Invalid aggregate expression.", err)
+	}
+
+	groupBy, err := parseAggregateGroupBy(collection, requestInfo, exprs)
+	if err != nil {
+		return e.BadRequestError("Invalid groupBy field.", err)
+	}
+
+	// build the same records query as the one used in the list API
+	// and use it as a subquery to avoid double counting the join rows
+	// -------------------------------------------------------------------
+	recordsQuery := e.App.RecordQuery(collection)
+
+	fieldsResolver := core.NewRecordFieldResolver(e.App, collection, requestInfo, true)
+
+	if !requestInfo.HasSuperuserAuth() && collection.ListRule != nil && *collection.ListRule != "" {
+		expr, err := search.FilterData(*collection.ListRule).BuildExpr(fieldsResolver)
+		if err != nil {
+			return err
+		}
+		recordsQuery.AndWhere(expr)
+	}
+
+	// hidden fields are searchable only by superusers
+	fieldsResolver.SetAllowHiddenFields(requestInfo.HasSuperuserAuth())
+
+	if filter := requestInfo.Query[search.FilterQueryParam]; filter != "" {
+		if len(filter) > search.MaxFilterLength {
+			return e.BadRequestError("", search.ErrFilterLengthLimit)
+		}
+
+		expr, err := search.FilterData(filter).BuildExprWithLimit(fieldsResolver, search.DefaultFilterExprLimit)
+		if err != nil {
+			return e.BadRequestError("Invalid filter.", err)
+		}
+		recordsQuery.AndWhere(expr)
+	}
+
+	if err := fieldsResolver.UpdateQuery(recordsQuery); err != nil {
+		return e.BadRequestError("", err)
+	}
+
+	recordsQuery.Distinct(false).Select(collection.Name + ".id").OrderBy( /* reset */ )
+
+	if !requestInfo.HasSuperuserAuth() {
+		fields := make([]core.Field, 0, len(groupBy)+len(exprs))
+		fields = append(fields, groupBy...)
+		for _, expr := range exprs {
+			if expr.field != nil {
+				fields = append(fields, expr.field)
+			}
+		}
+
+		err = checkAggregateFieldViewRules(e.App, collection, requestInfo, recordsQuery, fields)
+		if err != nil {
+			return e.BadRequestError("Invalid aggregate field.", err)
+		}
+	}
+
+	// build the aggregate query
+	// -------------------------------------------------------------------
+	selects := make([]string, 0, len(groupBy)+len(exprs))
+	groupByCols := make([]string, 0, len(groupBy))
+	for i, f := range groupBy {
+		col := "[[" + collection.Name + "." + f.GetName() + "]]"
+		selects = append(selects, col+" as [[g"+cast.ToString(i)+"]]")
+		groupByCols = append(groupByCols, col)
+	}
+	for i, expr := range exprs {
+		col := "[[" + collection.Name + ".id]]"
+		if expr.field != nil {
+			col = "[[" + collection.Name + "." + expr.field.GetName() + "]]"
+		}
+		selects = append(selects, strings.ToUpper(expr.fn)+"("+col+") as [[a"+cast.ToString(i)+"]]")
+	}
+
+	rows := []dbx.NullStringMap{}
+
+	err = e.App.RecordQuery(collection).
+		Select(selects...).
+		AndWhere(&subQueryInExp{col: collection.Name + ".id", query: recordsQuery}).
+		GroupBy(groupByCols...).
+		OrderBy(groupByCols...).
+		Limit(maxAggregateGroups).
+		All(&rows)
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to aggregate the collection records.", err))
+	}
+
+	// normalize the result
+	// -------------------------------------------------------------------
+	items := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		item := make(map[string]any, len(groupBy)+len(exprs))
+
+		for j, f := range groupBy {
+			item[f.GetName()] = aggregateValue(f, row["g"+cast.ToString(j)])
+		}
+
+		for j, expr := range exprs {
+			raw := row["a"+cast.ToString(j)]
+
+			switch expr.fn {
+			case "count":
+				item[expr.key] = cast.ToInt(raw.String)
+			case "sum", "avg":
+				if raw.Valid {
+					item[expr.key] = cast.ToFloat64(raw.String)
+				} else {
+					item[expr.key] = nil
+				}
+			default:
+				item[expr.key] = aggregateValue(expr.field, raw)
+			}
+		}
+
+		items[i] = item
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// aggregateValue normalizes a single raw aggregate db value based on its field type.
+func aggregateValue(field core.Field, raw dbx.NullString) any {
+	if !raw.Valid {
+		return nil
+	}
+
+	v, err := field.PrepareValue(nil, raw.String)
+	if err != nil {
+		return raw.String
+	}
+
+	return v
+}
+
+func parseAggregateExprs(collection *core.Collection, requestInfo *core.RequestInfo) ([]*aggregateExpr, error) {
+	rawExprs := splitAggregateParam(requestInfo.Query[aggregateQueryParam])
+	if len(rawExprs) == 0 {
+		rawExprs = []string{"count()"}
+	}
+
+	if len(rawExprs) > maxAggregateExprs {
+		return nil, errors.New("too many aggregate expressions")
+	}
+
+	result := make([]*aggregateExpr, 0, len(rawExprs))
+	keys := map[string]struct{}{}
+
+	for _, raw := range rawExprs {
+		match := aggregateExprRegex.FindStringSubmatch(raw)
+		if len(match) != 3 {
+			return nil, errors.New("unsupported aggregate expression " + raw)
+		}
+
+		expr := &aggregateExpr{fn: match[1], key: match[1]}
+
+		if match[2] != "" {
+			field, err := findAggregateField(collection, requestInfo, match[2])
+			if err != nil {
+				return nil, err
+			}
+
+			if (expr.fn == "sum" || expr.fn == "avg") && field.Type() != core.FieldTypeNumber {
+				return nil, errors.New(expr.fn + " is supported only for number fields")
+			}
+
+			expr.field = field
+			expr.key += "_" + field.GetName()
+		} else if expr.fn != "count" {
+			return nil, errors.New(expr.fn + " requires a field argument")
+		}
+
+		if _, ok := keys[expr.key]; ok {
+			return nil, errors.New("duplicated aggregate expression " + raw)
+		}
+		keys[expr.key] = struct{}{}
+
+		result = append(result, expr)
+	}
+
+	return result, nil
+}
+
+func parseAggregateGroupBy(collection *core.Collection, requestInfo *core.RequestInfo, exprs []*aggregateExpr) ([]core.Field, error) {
+	names := splitAggregateParam(requestInfo.Query[groupByQueryParam])
+
+	if len(names) > maxAggregateGroupByFields {
+		return nil, errors.New("too many groupBy fields")
+	}
+
+	result := make([]core.Field, 0, len(names))
+
+	for _, name := range names {
+		field, err := findAggregateField(collection, requestInfo, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if mv, ok := field.(core.MultiValuer); ok && mv.IsMultiple() {
+			return nil, errors.New("multiple value fields cannot be grouped")
+		}
+
+		for _, expr := range exprs {
+			if expr.key == name {
+				return nil, errors.New("groupBy field " + name + " conflicts with an aggregate expression")
+			}
+		}
+
+		result = append(result, field)
+	}
+
+	return result, nil
+}
+
+// findAggregateField returns the collection field that could be used
+// in the aggregate and groupBy expressions.
+//
+// Only direct collection fields are allowed to avoid double counting
+// the relation join rows (hidden fields are allowed only for superusers).
+//
+// Note that the field view rules are checked separately against the
+// aggregated records with checkAggregateFieldViewRules.
+func findAggregateField(collection *core.Collection, requestInfo *core.RequestInfo, name string) (core.Field, error) {
+	field := collection.Fields.GetByName(name)
+	if field == nil {
+		return nil, errors.New("unknown field " + name)
+	}
+
+	if field.Type() == core.FieldTypePassword ||
+		field.Type() == core.FieldTypeJSON ||
+		field.Type() == core.FieldTypeGeoPoint ||
+		(field.GetHidden() && !requestInfo.HasSuperuserAuth()) {
+		return nil, errors.New("field " + name + " cannot be aggregated")
+	}
+
+	return field, nil
+}
+
+// checkAggregateFieldViewRules returns an error if the requester can't view
+// any of the provided fields in at least one of the records matching recordsQuery.
+//
+// The field view rules are evaluated the same way as in hideFieldsByViewRules
+// (the aggregate of a field must not expose values that would be hidden in the list API).
+func checkAggregateFieldViewRules(
+	app core.App,
+	collection *core.Collection,
+	requestInfo *core.RequestInfo,
+	recordsQuery *dbx.SelectQuery,
+	fields []core.Field,
+) error {
+	checked := map[string]struct{}{}
+
+	for _, field := range fields {
+		name := field.GetName()
+
+		if _, ok := checked[name]; ok {
+			continue
+		}
+		checked[name] = struct{}{}
+
+		rule, ok := collection.FieldRules[name]
+		if !ok || !rule.HasViewRule() {
+			continue
+		}
+
+		resolver := core.NewRecordFieldResolver(app, collection, requestInfo, true)
+
+		expr, err := search.FilterData(*rule.ViewRule).BuildExpr(resolver)
+		if err != nil {
+			// the field is hidden from all records in case of an invalid rule
+			return errors.New("field " + name + " cannot be aggregated")
+		}
+
+		allowedQuery := app.RecordQuery(collection).Select(collection.Name + ".id")
+		if err := resolver.UpdateQuery(allowedQuery); err != nil {
+			return err
+		}
+		allowedQuery.AndWhere(expr)
+
+		var exists int
+		err = app.RecordQuery(collection).
+			Select("(1)").
+			AndWhere(&subQueryInExp{col: collection.Name + ".id", query: recordsQuery}).
+			AndWhere(dbx.Not(&subQueryInExp{col: collection.Name + ".id", query: allowedQuery})).
+			Limit(1).
+			Row(&exists)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if exists > 0 {
+			return errors.New("field " + name + " is not viewable in all aggregated records")
+		}
+	}
+
+	return nil
+}
+
+func splitAggregateParam(raw string) []string {
+	result := []string{}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			result = append(result, part)
+		}
+	}
+
+	return result
+}
+
+// subQueryInExp defines a "col IN (subquery)" dbx expression.
+type subQueryInExp struct {
+	query *dbx.SelectQuery
+	col   string
+}
+
+// Build converts the expression into a SQL fragment.
+//
+// Implements [dbx.Expression] interface.
+func (e *subQueryInExp) Build(db *dbx.DB, params dbx.Params) string {
+	info := e.query.Info()
+
+	for k, v := range info.Params {
+		params[k] = v
+	}
+
+	qb := db.QueryBuilder()
+
+	clauses := []string{
+		qb.BuildSelect(info.Selects, info.Distinct, info.SelectOption),
+		qb.BuildFrom(info.From),
+		qb.BuildJoin(info.Join, params),
+		qb.BuildWhere(info.Where, params),
+	}
+
+	parts := make([]string, 0, len(clauses))
+	for _, clause := range clauses {
+		if clause != "" {
+			parts = append(parts, clause)
+		}
+	}
+
+	return "[[" + e.col + "]] IN (" + strings.Join(parts, " ") + ")"
+}
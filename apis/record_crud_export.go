@@ -0,0 +1,284 @@
+package apis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/picker"
+	"github.com/hanzoai/backendPB/tools/search"
+)
+
+// Supported records export/import data formats.
+const (
+	recordsDataFormatCSV    = "csv"
+	recordsDataFormatNDJSON = "ndjson"
+)
+
+// recordsExportBatchSize specifies the number of records that are
+// loaded and enriched at once during the records export.
+const recordsExportBatchSize = 500
+
+func recordsExport(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	err = checkCollectionRateLimit(e, collection, "list")
+	if err != nil {
+		return err
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	if collection.ListRule == nil && !requestInfo.HasSuperuserAuth() {
+		return e.ForbiddenError("Only superusers can perform this action.", nil)
+	}
+
+	// forbid users and guests to query special filter/sort fields
+	err = checkForSuperuserOnlyRuleFields(requestInfo)
+	if err != nil {
+		return err
+	}
+
+	format := requestInfo.Query["format"]
+	if format == "" {
+		format = recordsDataFormatNDJSON
+	}
+	if format != recordsDataFormatCSV && format != recordsDataFormatNDJSON {
+		return e.BadRequestError("Unsupported export format (expected csv or ndjson).", nil)
+	}
+
+	// read from a replica (if configured)
+	readApp := e.App.ReplicaApp(core.ReplicaSessionKey(e))
+
+	fetchBatch := func(cursor string) ([]*core.Record, string, error) {
+		query := readApp.RecordQuery(collection)
+
+		fieldsResolver := core.NewRecordFieldResolver(readApp, collection, requestInfo, true)
+
+		if !requestInfo.HasSuperuserAuth() && collection.ListRule != nil && *collection.ListRule != "" {
+			expr, err := search.FilterData(*collection.ListRule).BuildExpr(fieldsResolver)
+			if err != nil {
+				return nil, "", err
+			}
+			query.AndWhere(expr)
+		}
+
+		// hidden fields are searchable only by superusers
+		fieldsResolver.SetAllowHiddenFields(requestInfo.HasSuperuserAuth())
+
+		records := []*core.Record{}
+
+		result, err := search.NewProvider(fieldsResolver).
+			Query(query).
+			SkipTotal(true).
+			PerPage(recordsExportBatchSize).
+			Cursor(cursor).
+			Sort(search.ParseSortFromString(requestInfo.Query[search.SortQueryParam])).
+			AddFilter(search.FilterData(requestInfo.Query[search.FilterQueryParam])).
+			Exec(&records)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return records, result.NextCursor, nil
+	}
+
+	// load the first batch before writing the response headers
+	// so that the filter and sort errors could be still reported
+	records, cursor, err := fetchBatch("")
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	rawFields := requestInfo.Query[fieldsQueryParam]
+
+	var writeRecord func(data map[string]any) error
+
+	var csvWriter *csv.Writer
+
+	if format == recordsDataFormatCSV {
+		columns := recordsExportColumns(collection, requestInfo)
+
+		csvWriter = csv.NewWriter(e.Response)
+
+		writeRecord = func(data map[string]any) error {
+			row := make([]string, len(columns))
+			for i, col := range columns {
+				cell, err := recordsExportCSVValue(data[col])
+				if err != nil {
+					return err
+				}
+				row[i] = cell
+			}
+
+			return csvWriter.Write(row)
+		}
+
+		e.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		e.Response.Header().Set("Content-Disposition", `attachment; filename="`+collection.Name+`.csv"`)
+		e.Response.WriteHeader(http.StatusOK)
+
+		if err := csvWriter.Write(columns); err != nil {
+			return err
+		}
+	} else {
+		encoder := json.NewEncoder(e.Response)
+
+		writeRecord = func(data map[string]any) error {
+			return encoder.Encode(data)
+		}
+
+		e.Response.Header().Set("Content-Type", "application/x-ndjson")
+		e.Response.Header().Set("Content-Disposition", `attachment; filename="`+collection.Name+`.ndjson"`)
+		e.Response.WriteHeader(http.StatusOK)
+	}
+
+	for {
+		if err := EnrichRecords(e, records); err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			data, err := recordsExportData(record, rawFields)
+			if err != nil {
+				return err
+			}
+
+			if err := writeRecord(data); err != nil {
+				return err
+			}
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+		}
+
+		if err := e.Flush(); err != nil {
+			return err
+		}
+
+		if cursor == "" {
+			return nil
+		}
+
+		if err := e.Request.Context().Err(); err != nil {
+			return err
+		}
+
+		records, cursor, err = fetchBatch(cursor)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// recordsExportData returns the public json normalized record data
+// (optionally limited to the specified "fields" query parameter value).
+func recordsExportData(record *core.Record, rawFields string) (map[string]any, error) {
+	var source any = record
+
+	if rawFields != "" {
+		picked, err := picker.Pick(record, rawFields)
+		if err != nil {
+			return nil, err
+		}
+		source = picked
+	}
+
+	raw, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	data := map[string]any{}
+
+	return data, decoder.Decode(&data)
+}
+
+// recordsExportColumns returns the CSV export header columns.
+//
+// By default all non-hidden collection fields are exported
+// (+ "expand" if the expand query parameter is set).
+// If the "fields" query parameter is set, only its top level keys are used.
+func recordsExportColumns(collection *core.Collection, requestInfo *core.RequestInfo) []string {
+	defaultColumns := make([]string, 0, len(collection.Fields)+1)
+	for _, f := range collection.Fields {
+		if f.GetHidden() || f.Type() == core.FieldTypePassword {
+			continue
+		}
+		defaultColumns = append(defaultColumns, f.GetName())
+	}
+	if requestInfo.Query[expandQueryParam] != "" {
+		defaultColumns = append(defaultColumns, core.FieldNameExpand)
+	}
+
+	rawFields := requestInfo.Query[fieldsQueryParam]
+	if rawFields == "" {
+		return defaultColumns
+	}
+
+	columns := []string{}
+	existing := map[string]struct{}{}
+
+	add := func(col string) {
+		if _, ok := existing[col]; ok {
+			return
+		}
+		existing[col] = struct{}{}
+		columns = append(columns, col)
+	}
+
+	for _, field := range strings.Split(rawFields, ",") {
+		// strip the modifier and the nested keys
+		field, _, _ = strings.Cut(strings.TrimSpace(field), ":")
+		field, _, _ = strings.Cut(field, ".")
+
+		switch field {
+		case "":
+			continue
+		case "*":
+			for _, col := range defaultColumns {
+				add(col)
+			}
+		default:
+			add(field)
+		}
+	}
+
+	return columns
+}
+
+// recordsExportCSVValue converts a single json normalized value into a CSV cell.
+//
+// Arrays and objects are stored as json encoded strings.
+func recordsExportCSVValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	}
+}
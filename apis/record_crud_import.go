@@ -0,0 +1,330 @@
+package apis
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/forms"
+	"github.com/hanzoai/backendPB/tools/dbutils"
+	"github.com/hanzoai/backendPB/tools/router"
+)
+
+const (
+	// recordsImportChunkSize specifies the number of rows that are
+	// imported within a single transaction.
+	recordsImportChunkSize = 100
+
+	// maxRecordsImportErrors specifies the max number of reported row errors.
+	maxRecordsImportErrors = 1000
+)
+
+// recordsImportResult defines the records import response.
+type recordsImportResult struct {
+	Errors  []*recordsImportRowError `json:"errors"`
+	Created int                      `json:"created"`
+	Updated int                      `json:"updated"`
+	Failed  int                      `json:"failed"`
+}
+
+// recordsImportRowError defines a single failed import row.
+type recordsImportRowError struct {
+	Error *router.ApiError `json:"error"`
+	Id    string           `json:"id"`
+	Row   int              `json:"row"`
+}
+
+// recordsImportReader defines a streaming import rows reader.
+//
+// Next returns [io.EOF] when there are no more rows.
+type recordsImportReader interface {
+	Next() (map[string]any, error)
+}
+
+// recordsImport imports the records from the submitted CSV or NDJSON data.
+//
+// The data could be submitted either as multipart/form-data "file" field
+// or directly as request body.
+//
+// Supported query parameters:
+//   - format - "csv" or "ndjson" (if not set it is resolved from the file extension or the body content type)
+//   - upsert - "id" or the name of a field with single column unique index;
+//     if set, the rows matching an existing record are updated instead of created
+func recordsImport(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	if collection.IsView() {
+		return e.BadRequestError("View collections don't support records import.", nil)
+	}
+
+	query := e.Request.URL.Query()
+
+	upsert := query.Get("upsert")
+	if upsert != "" && upsert != core.FieldNameId {
+		if collection.Fields.GetByName(upsert) == nil || !dbutils.HasSingleColumnUniqueIndex(upsert, collection.Indexes) {
+			return e.BadRequestError("The upsert field must be id or a field with single column unique index.", nil)
+		}
+	}
+
+	src, format, err := recordsImportSource(e, query.Get("format"))
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to read the submitted data.", err))
+	}
+	defer src.Close()
+
+	var reader recordsImportReader
+	switch format {
+	case recordsDataFormatCSV:
+		reader = newCSVImportReader(collection, src)
+	case recordsDataFormatNDJSON:
+		reader = &ndjsonImportReader{decoder: json.NewDecoder(src)}
+	default:
+		return e.BadRequestError("Unsupported import format (expected csv or ndjson).", nil)
+	}
+
+	result := &recordsImportResult{Errors: []*recordsImportRowError{}}
+
+	addError := func(row int, id string, err error) {
+		result.Failed++
+		if len(result.Errors) < maxRecordsImportErrors {
+			result.Errors = append(result.Errors, &recordsImportRowError{
+				Row:   row,
+				Id:    id,
+				Error: router.ToApiError(err),
+			})
+		}
+	}
+
+	var row int
+	var done bool
+
+	for !done {
+		if err := e.Request.Context().Err(); err != nil {
+			return err
+		}
+
+		err := e.App.RunInTransaction(func(txApp core.App) error {
+			for i := 0; i < recordsImportChunkSize; i++ {
+				data, err := reader.Next()
+				if err == io.EOF {
+					done = true
+					return nil
+				}
+
+				row++
+
+				if err != nil {
+					// malformed data -> abort the import
+					addError(row, "", err)
+					done = true
+					return nil
+				}
+
+				id, isNew, err := importRecordRow(e, txApp, collection, data, upsert)
+				if err != nil {
+					addError(row, id, err)
+					continue
+				}
+
+				if isNew {
+					result.Created++
+				} else {
+					result.Updated++
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return firstApiError(err, e.BadRequestError("Failed to import the records.", err))
+		}
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// importRecordRow creates or updates (if upsert is set) a single record from the row data
+// using the same form and validations as the regular record create and update actions.
+func importRecordRow(e *core.RequestEvent, txApp core.App, collection *core.Collection, data map[string]any, upsert string) (string, bool, error) {
+	id, _ := data[core.FieldNameId].(string)
+
+	var record *core.Record
+
+	if upsert != "" {
+		if value := data[upsert]; value != nil && value != "" {
+			existing, err := txApp.FindFirstRecordByData(collection, upsert, value)
+			if err == nil {
+				record = existing
+			}
+		}
+	}
+
+	if record == nil {
+		record = core.NewRecord(collection)
+	} else {
+		delete(data, core.FieldNameId)
+		id = record.Id
+	}
+
+	// files cannot be imported
+	for _, f := range collection.Fields {
+		if f.Type() == core.FieldTypeFile {
+			delete(data, f.GetName())
+		}
+	}
+
+	if collection.IsAuth() {
+		if _, ok := data[core.FieldNamePassword+"Confirm"]; !ok && data[core.FieldNamePassword] != nil {
+			data[core.FieldNamePassword+"Confirm"] = data[core.FieldNamePassword]
+		}
+	}
+
+	isNew := record.IsNew()
+
+	form := forms.NewRecordUpsert(txApp, record)
+	form.SetContext(core.ContextWithRequestEvent(e.Request.Context(), e))
+	form.GrantSuperuserAccess()
+	form.Load(data)
+
+	if err := form.Submit(); err != nil {
+		return id, isNew, err
+	}
+
+	return record.Id, isNew, nil
+}
+
+// recordsImportSource returns the submitted import data reader and its resolved format.
+func recordsImportSource(e *core.RequestEvent, format string) (io.ReadCloser, string, error) {
+	contentType, _, _ := mime.ParseMediaType(e.Request.Header.Get("Content-Type"))
+
+	if contentType == "multipart/form-data" {
+		f, header, err := e.Request.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+
+		if format == "" {
+			switch strings.ToLower(filepath.Ext(header.Filename)) {
+			case ".csv":
+				format = recordsDataFormatCSV
+			case ".ndjson", ".jsonl":
+				format = recordsDataFormatNDJSON
+			}
+		}
+
+		return f, format, nil
+	}
+
+	if format == "" {
+		switch contentType {
+		case "text/csv":
+			format = recordsDataFormatCSV
+		case "application/x-ndjson", "application/jsonl":
+			format = recordsDataFormatNDJSON
+		}
+	}
+
+	return e.Request.Body, format, nil
+}
+
+// -------------------------------------------------------------------
+
+var _ recordsImportReader = (*ndjsonImportReader)(nil)
+
+type ndjsonImportReader struct {
+	decoder *json.Decoder
+}
+
+// Next implements [recordsImportReader.Next].
+func (r *ndjsonImportReader) Next() (map[string]any, error) {
+	data := map[string]any{}
+
+	err := r.decoder.Decode(&data)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid ndjson row: %w", err)
+	}
+
+	return data, err
+}
+
+// -------------------------------------------------------------------
+
+var _ recordsImportReader = (*csvImportReader)(nil)
+
+type csvImportReader struct {
+	collection *core.Collection
+	reader     *csv.Reader
+	header     []string
+}
+
+func newCSVImportReader(collection *core.Collection, src io.Reader) *csvImportReader {
+	reader := csv.NewReader(src)
+	reader.ReuseRecord = true
+
+	return &csvImportReader{
+		collection: collection,
+		reader:     reader,
+	}
+}
+
+// Next implements [recordsImportReader.Next].
+//
+// Array and object field values are expected to be json encoded
+// (the same as in the CSV records export).
+func (r *csvImportReader) Next() (map[string]any, error) {
+	if r.header == nil {
+		header, err := r.reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("missing csv header row")
+			}
+			return nil, err
+		}
+
+		r.header = make([]string, len(header))
+		for i, h := range header {
+			r.header[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		}
+	}
+
+	cells, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]any, len(cells))
+
+	for i, cell := range cells {
+		name := r.header[i]
+
+		field := r.collection.Fields.GetByName(name)
+		if field == nil {
+			data[name] = cell
+			continue
+		}
+
+		mv, isMultiple := field.(core.MultiValuer)
+		if (field.Type() == core.FieldTypeGeoPoint || (isMultiple && mv.IsMultiple())) &&
+			(strings.HasPrefix(cell, "[") || strings.HasPrefix(cell, "{")) {
+			var decoded any
+			if err := json.Unmarshal([]byte(cell), &decoded); err == nil {
+				data[name] = decoded
+				continue
+			}
+		}
+
+		data[name] = cell
+	}
+
+	return data, nil
+}
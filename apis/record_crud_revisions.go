@@ -0,0 +1,68 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/search"
+	"github.com/hanzoai/dbx"
+)
+
+func recordRevisionsList(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	revisionsCollection, err := e.App.FindCachedCollectionByNameOrId(core.CollectionNameRevisions)
+	if err != nil {
+		return e.NotFoundError("Missing revisions collection.", err)
+	}
+
+	requestInfo, err := e.RequestInfo()
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	query := e.App.RecordQuery(revisionsCollection).AndWhere(dbx.HashExp{
+		core.CollectionNameRevisions + ".collectionRef": collection.Id,
+		core.CollectionNameRevisions + ".recordRef":     e.Request.PathValue("id"),
+	})
+
+	fieldsResolver := core.NewRecordFieldResolver(e.App, revisionsCollection, requestInfo, true)
+
+	revisions := []*core.Record{}
+	result, err := search.NewProvider(fieldsResolver).
+		Query(query).
+		ParseAndExec(e.Request.URL.Query().Encode(), &revisions)
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("", err))
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func recordRevisionRestore(e *core.RequestEvent) error {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return e.NotFoundError("Missing collection context.", err)
+	}
+
+	revision, err := e.App.FindRevisionById(e.Request.PathValue("revisionId"))
+	if err != nil ||
+		revision.CollectionRef() != collection.Id ||
+		revision.RecordRef() != e.Request.PathValue("id") {
+		return e.NotFoundError("", err)
+	}
+
+	record, err := e.App.RestoreRecordRevision(revision)
+	if err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to restore record revision.", err))
+	}
+
+	if err := EnrichRecord(e, record); err != nil {
+		return firstApiError(err, e.InternalServerError("Failed to enrich record", err))
+	}
+
+	return e.JSON(http.StatusOK, record)
+}
@@ -0,0 +1,60 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/hanzoai/backendPB/core"
+)
+
+func recordRestore(e *core.RequestEvent) error {
+	collection, err := findSoftDeleteCollection(e)
+	if err != nil {
+		return err
+	}
+
+	record, err := e.App.FindSoftDeletedRecordById(collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	if err := e.App.RestoreRecord(record); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to restore record.", err))
+	}
+
+	if err := EnrichRecord(e, record); err != nil {
+		return firstApiError(err, e.InternalServerError("Failed to enrich record", err))
+	}
+
+	return e.JSON(http.StatusOK, record)
+}
+
+func recordPurge(e *core.RequestEvent) error {
+	collection, err := findSoftDeleteCollection(e)
+	if err != nil {
+		return err
+	}
+
+	record, err := e.App.FindSoftDeletedRecordById(collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	if err := e.App.PurgeRecord(record); err != nil {
+		return firstApiError(err, e.BadRequestError("Failed to purge record. Make sure that the record is not part of a required relation reference.", err))
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func findSoftDeleteCollection(e *core.RequestEvent) (*core.Collection, error) {
+	collection, err := e.App.FindCachedCollectionByNameOrId(e.Request.PathValue("collection"))
+	if err != nil || collection == nil {
+		return nil, e.NotFoundError("Missing collection context.", err)
+	}
+
+	if !collection.SoftDelete.Enabled {
+		return nil, e.BadRequestError("The collection doesn't have soft delete enabled.", nil)
+	}
+
+	return collection, nil
+}
@@ -116,6 +116,57 @@ func TestRecordCrudList(t *testing.T) {
 				"OnRecordEnrich":       3,
 			},
 		},
+		{
+			Name:           "public collection (cursor first page)",
+			Method:         http.MethodGet,
+			URL:            "/api/collections/demo2/records?perPage=2&sort=id&cursor=",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"page":1`,
+				`"perPage":2`,
+				`"totalItems":3`,
+				`"id":"0yxhwia2amd8gec"`,
+				`"id":"achvryl401bhse3"`,
+				`"nextCursor":"WyJhY2h2cnlsNDAxYmhzZTMiXQ"`,
+			},
+			NotExpectedContent: []string{
+				`"id":"llvuca81nly1qls"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                    0,
+				"OnRecordsListRequest": 1,
+				"OnRecordEnrich":       2,
+			},
+		},
+		{
+			Name:           "public collection (cursor last page)",
+			Method:         http.MethodGet,
+			URL:            "/api/collections/demo2/records?perPage=2&sort=id&cursor=WyJhY2h2cnlsNDAxYmhzZTMiXQ",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"totalItems":3`,
+				`"items":[{`,
+				`"id":"llvuca81nly1qls"`,
+			},
+			NotExpectedContent: []string{
+				`"id":"0yxhwia2amd8gec"`,
+				`"id":"achvryl401bhse3"`,
+				`"nextCursor"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                    0,
+				"OnRecordsListRequest": 1,
+				"OnRecordEnrich":       1,
+			},
+		},
+		{
+			Name:            "public collection (invalid cursor)",
+			Method:          http.MethodGet,
+			URL:             "/api/collections/demo2/records?cursor=invalid",
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
 		{
 			Name:   "authorized as superuser trying to access nil rule collection (aka. need superuser auth)",
 			Method: http.MethodGet,
@@ -3518,3 +3569,109 @@ func TestRecordCrudUpdate(t *testing.T) {
 		scenario.Test(t)
 	}
 }
+
+func TestRecordCrudListFullTextSearch(t *testing.T) {
+	t.Parallel()
+
+	setupFullTextSearchCollection := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		collection := core.NewBaseCollection("fts_test")
+		collection.ListRule = types.Pointer("title @@ 'lorem'")
+		collection.Fields.Add(&core.TextField{Name: "title"})
+		collection.FullTextSearch.Fields = []string{"title"}
+		if err := app.Save(collection); err != nil {
+			t.Fatal(err)
+		}
+
+		data := map[string]string{
+			"aaaaaaaaaaaaaa1": "lorem lorem lorem dolor",
+			"bbbbbbbbbbbbbb1": "lorem dolor dolor dolor",
+			"ccccccccccccccc": "ipsum dolor",
+		}
+		for id, title := range data {
+			record := core.NewRecord(collection)
+			record.Id = id
+			record.Set("title", title)
+			if err := app.Save(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "full-text search filter",
+			Method:         http.MethodGet,
+			URL:            "/api/collections/fts_test/records?filter=" + url.QueryEscape("title @@ 'dolo*'"),
+			BeforeTestFunc: setupFullTextSearchCollection,
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"totalItems":2`,
+				`"id":"aaaaaaaaaaaaaa1"`,
+				`"id":"bbbbbbbbbbbbbb1"`,
+			},
+			NotExpectedContent: []string{
+				`"id":"ccccccccccccccc"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                    0,
+				"OnRecordsListRequest": 1,
+				"OnRecordEnrich":       2,
+			},
+		},
+		{
+			Name:           "@rank sort without full-text search filter (the list rule match should be ignored)",
+			Method:         http.MethodGet,
+			URL:            "/api/collections/fts_test/records?sort=@rank",
+			BeforeTestFunc: setupFullTextSearchCollection,
+			ExpectedStatus: 400,
+			ExpectedContent: []string{
+				`"data":{}`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+		},
+		{
+			Name:           "@rank sort with full-text search filter",
+			Method:         http.MethodGet,
+			URL:            "/api/collections/fts_test/records?perPage=1&sort=@rank&filter=" + url.QueryEscape("title @@ 'dolor'"),
+			BeforeTestFunc: setupFullTextSearchCollection,
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"totalItems":2`,
+				`"id":"bbbbbbbbbbbbbb1"`,
+			},
+			NotExpectedContent: []string{
+				`"id":"aaaaaaaaaaaaaa1"`,
+				`"id":"ccccccccccccccc"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                    0,
+				"OnRecordsListRequest": 1,
+				"OnRecordEnrich":       1,
+			},
+		},
+		{
+			Name:           "@rank sort with full-text search filter (desc)",
+			Method:         http.MethodGet,
+			URL:            "/api/collections/fts_test/records?perPage=1&sort=-@rank&filter=" + url.QueryEscape("title @@ 'dolor'"),
+			BeforeTestFunc: setupFullTextSearchCollection,
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"totalItems":2`,
+				`"id":"aaaaaaaaaaaaaa1"`,
+			},
+			NotExpectedContent: []string{
+				`"id":"bbbbbbbbbbbbbb1"`,
+				`"id":"ccccccccccccccc"`,
+			},
+			ExpectedEvents: map[string]int{
+				"*":                    0,
+				"OnRecordsListRequest": 1,
+				"OnRecordEnrich":       1,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hanzoai/backendPB/core"
 	"github.com/hanzoai/backendPB/mails"
 	"github.com/hanzoai/backendPB/tools/router"
@@ -32,16 +34,87 @@ var ErrMFA = errors.New("mfa required")
 //
 // Set authMethod to empty string if you want to ignore the MFA checks and the login alerts
 // (can be also adjusted additionally via the OnRecordAuthRequest hook).
+//
+// If the auth collection has enabled sessions and authMethod is not empty,
+// a new auth session is created and its refresh token is also returned.
 func RecordAuthResponse(e *core.RequestEvent, authRecord *core.Record, authMethod string, meta any) error {
+	// new sign-ins of collections with enabled sessions receive
+	// a short-lived session bound token and a rotating refresh token
+	if authMethod != "" && authRecord.Collection().Sessions.Enabled {
+		session, refreshToken := newPendingAuthSession(e, authRecord)
+
+		token, tokenErr := authRecord.NewSessionAuthToken(session.Id)
+		if tokenErr != nil {
+			return e.InternalServerError("Failed to create auth token.", tokenErr)
+		}
+
+		return recordAuthResponse(e, authRecord, token, authMethod, meta, &pendingAuthSession{
+			session:      session,
+			refreshToken: refreshToken,
+		})
+	}
+
 	token, tokenErr := authRecord.NewAuthToken()
 	if tokenErr != nil {
 		return e.InternalServerError("Failed to create auth token.", tokenErr)
 	}
 
-	return recordAuthResponse(e, authRecord, token, authMethod, meta)
+	return recordAuthResponse(e, authRecord, token, authMethod, meta, nil)
+}
+
+// pendingAuthSession holds an auth session that is persisted
+// only after a successful auth response (eg. after the MFA checks).
+type pendingAuthSession struct {
+	session      *core.AuthSession
+	refreshToken string
+
+	// currentRefreshToken is the refresh token of an already existing session
+	// that is rotated on persist (the new one is stored as refreshToken)
+	currentRefreshToken string
+}
+
+// persist saves the pending auth session (or conditionally rotates the
+// refresh token of an already existing one) together with its request info.
+func (p *pendingAuthSession) persist(e *core.RequestEvent) error {
+	p.session.SetUserAgent(e.Request.UserAgent())
+	p.session.SetIP(e.RealIP())
+
+	if p.currentRefreshToken == "" {
+		if err := e.App.Save(p.session); err != nil {
+			return e.InternalServerError("Failed to save the auth session.", err)
+		}
+
+		return nil
+	}
+
+	refreshToken, err := e.App.RotateAuthSessionRefreshToken(p.session, p.currentRefreshToken)
+	if err != nil {
+		if errors.Is(err, core.ErrReusedRefreshToken) {
+			return revokeReusedAuthSession(e, p.session)
+		}
+
+		return e.InternalServerError("Failed to rotate the auth session refresh token.", err)
+	}
+
+	p.refreshToken = refreshToken
+
+	return nil
+}
+
+// newPendingAuthSession initializes a new unsaved auth session for the specified auth record
+// and returns it together with its plain refresh token.
+func newPendingAuthSession(e *core.RequestEvent, authRecord *core.Record) (*core.AuthSession, string) {
+	session := core.NewAuthSession(e.App)
+	session.Id = core.GenerateDefaultRandomId()
+	session.SetCollectionRef(authRecord.Collection().Id)
+	session.SetRecordRef(authRecord.Id)
+
+	refreshToken := session.RotateRefreshToken()
+
+	return session, refreshToken
 }
 
-func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token string, authMethod string, meta any) error {
+func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token string, authMethod string, meta any, pending *pendingAuthSession) error {
 	originalRequestInfo, err := e.RequestInfo()
 	if err != nil {
 		return err
@@ -83,6 +156,12 @@ func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token str
 		}
 		// ---
 
+		if pending != nil {
+			if err = pending.persist(e.RequestEvent); err != nil {
+				return err
+			}
+		}
+
 		// create a shallow copy of the cached request data and adjust it to the current auth record
 		requestInfo := *originalRequestInfo
 		requestInfo.Auth = e.Record
@@ -117,9 +196,10 @@ func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token str
 		}
 
 		result := struct {
-			Meta   any          `json:"meta,omitempty"`
-			Record *core.Record `json:"record"`
-			Token  string       `json:"token"`
+			Meta         any          `json:"meta,omitempty"`
+			Record       *core.Record `json:"record"`
+			Token        string       `json:"token"`
+			RefreshToken string       `json:"refreshToken,omitempty"`
 		}{
 			Token:  e.Token,
 			Record: e.Record,
@@ -129,6 +209,10 @@ func recordAuthResponse(e *core.RequestEvent, authRecord *core.Record, token str
 			result.Meta = e.Meta
 		}
 
+		if pending != nil {
+			result.RefreshToken = pending.refreshToken
+		}
+
 		return e.JSON(http.StatusOK, result)
 	})
 }
@@ -236,6 +320,19 @@ func checkMFA(e *core.RequestEvent, authRecord *core.Record, currentAuthMethod s
 		return "", e.BadRequestError("A different authentication method is required.", nil)
 	}
 
+	// auth records with enabled authenticator app (or all records if required by the collection)
+	// must complete the MFA with it
+	if currentAuthMethod != core.MFAMethodTOTP && authRecord.Collection().TOTP.Enabled {
+		if authRecord.Collection().MFA.RequireTOTP {
+			return "", e.BadRequestError("The authenticator app code is required to complete the MFA.", nil)
+		}
+
+		totp, _ := e.App.FindTOTPByRecord(authRecord)
+		if totp != nil && totp.Verified() {
+			return "", e.BadRequestError("The authenticator app code is required to complete the MFA.", nil)
+		}
+	}
+
 	deleteMFA()
 
 	return "", nil
@@ -265,13 +362,16 @@ func EnrichRecords(e *core.RequestEvent, records []*core.Record, defaultExpands
 		return err
 	}
 
-	return triggerRecordEnrichHooks(e.App, info, records, func() error {
+	// expand from a replica (if configured)
+	readApp := e.App.ReplicaApp(core.ReplicaSessionKey(e))
+
+	return triggerRecordEnrichHooks(readApp, info, records, func() error {
 		expands := defaultExpands
 		if param := info.Query[expandQueryParam]; param != "" {
 			expands = append(expands, strings.Split(param, ",")...)
 		}
 
-		err := defaultEnrichRecords(e.App, info, records, expands...)
+		err := defaultEnrichRecords(readApp, info, records, expands...)
 		if err != nil {
 			// only log because it is not critical
 			e.App.Logger().Warn("failed to apply default enriching", "error", err)
@@ -411,6 +511,7 @@ func expandFetch(app core.App, originalRequestInfo *core.RequestInfo) core.Expan
 //
 // Currently it enables:
 // - export of hidden fields if the current auth model is a superuser
+// - hiding of the fields whose view field rule is not satisfied
 // - email export ignoring the emailVisibity checks if the current auth model is superuser, owner or a "manager".
 //
 // Note: Expects all records to be from the same collection!
@@ -425,6 +526,8 @@ func autoResolveRecordsFlags(app core.App, records []*core.Record, requestInfo *
 			rec.Unhide(hiddenFields...)
 			rec.IgnoreEmailVisibility(true)
 		}
+	} else if err := hideFieldsByViewRules(app, records, requestInfo); err != nil {
+		return err
 	}
 
 	// additional emailVisibility checks
@@ -481,6 +584,113 @@ func autoResolveRecordsFlags(app core.App, records []*core.Record, requestInfo *
 	return nil
 }
 
+// hideFieldsByViewRules hides the record fields whose
+// collection field view rule is not satisfied for the provided request info.
+//
+// Note: Expects all records to be from the same collection!
+func hideFieldsByViewRules(app core.App, records []*core.Record, requestInfo *core.RequestInfo) error {
+	if len(records) == 0 || requestInfo.HasSuperuserAuth() {
+		return nil
+	}
+
+	collection := records[0].Collection()
+
+	recordIds := make([]any, len(records))
+	for i, rec := range records {
+		recordIds[i] = rec.Id
+	}
+
+	for name, rule := range collection.FieldRules {
+		if !rule.HasViewRule() {
+			continue
+		}
+
+		allowedIds := []string{}
+
+		query := app.RecordQuery(collection).
+			Select(app.DB().QuoteSimpleColumnName(collection.Name) + ".id").
+			AndWhere(dbx.In(app.DB().QuoteSimpleColumnName(collection.Name)+".id", recordIds...))
+
+		resolver := core.NewRecordFieldResolver(app, collection, requestInfo, true)
+		expr, err := search.FilterData(*rule.ViewRule).BuildExpr(resolver)
+		if err != nil {
+			// hide the field from all records in case of an invalid rule
+			app.Logger().Warn("Invalid field view rule", "collection", collection.Name, "field", name, "error", err)
+		} else {
+			if err := resolver.UpdateQuery(query); err != nil {
+				return err
+			}
+			query.AndWhere(expr)
+
+			if err := query.Column(&allowedIds); err != nil {
+				return err
+			}
+		}
+
+		for _, rec := range records {
+			if !slices.Contains(allowedIds, rec.Id) {
+				rec.Hide(name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFieldUpdateRules checks whether the provided request data changes
+// any of the record fields with an unsatisfied field update rule.
+//
+// Returns a validation error for each forbidden field.
+func checkFieldUpdateRules(app core.App, record *core.Record, requestInfo *core.RequestInfo, data map[string]any) error {
+	if requestInfo.HasSuperuserAuth() {
+		return nil
+	}
+
+	errs := validation.Errors{}
+
+	for name, rule := range record.Collection().FieldRules {
+		if !rule.HasUpdateRule() || !hasFieldChange(record, name, data) {
+			continue
+		}
+
+		canUpdate, err := app.CanAccessRecord(record, requestInfo, rule.UpdateRule)
+		if err != nil || !canUpdate {
+			errs[name] = validation.NewError("validation_field_update_forbidden", "You are not allowed to change this field.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// hasFieldChange loosely checks whether the provided data contains a
+// different value for the specified record field (including modifiers).
+func hasFieldChange(record *core.Record, name string, data map[string]any) bool {
+	for key, raw := range data {
+		if key != name {
+			if strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(key, "+"), "+"), "-") == name {
+				return true // modifiers always change the value
+			}
+			continue
+		}
+
+		field := record.Collection().Fields.GetByName(name)
+		if field == nil {
+			return true
+		}
+
+		newValue, err := field.PrepareValue(record, raw)
+		if err != nil || fmt.Sprint(newValue) != fmt.Sprint(record.Get(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
 var ruleQueryParams = []string{search.FilterQueryParam, search.SortQueryParam}
 var superuserOnlyRuleFields = []string{"@collection.", "@request."}
 
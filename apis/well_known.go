@@ -0,0 +1,32 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/router"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+// bindWellKnownApi registers the "/.well-known" metadata endpoints.
+func bindWellKnownApi(app core.App, rg *router.RouterGroup[*core.RequestEvent]) {
+	sub := rg.Group("/.well-known")
+	sub.GET("/openid-configuration", oidcDiscovery)
+	sub.GET("/jwks.json", authTokensJWKS)
+}
+
+// authTokensJWKS returns the public keys of all auth collections with
+// asymmetric auth token signing algorithm (including the previous rotated keys).
+func authTokensJWKS(e *core.RequestEvent) error {
+	collections, err := e.App.FindAllCollections(core.CollectionTypeAuth)
+	if err != nil {
+		return e.InternalServerError("Failed to load the auth collections.", err)
+	}
+
+	set := security.JWKSet{Keys: []security.JWK{}}
+	for _, c := range collections {
+		set.Keys = append(set.Keys, c.AuthTokenSigning.JWKs()...)
+	}
+
+	return e.JSON(http.StatusOK, set)
+}
@@ -0,0 +1,99 @@
+package apis_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func TestAuthTokensJWKS(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "HS256 auth collections only",
+			Method:          http.MethodGet,
+			URL:             "/.well-known/jwks.json",
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`{"keys":[]}`},
+			ExpectedEvents:  map[string]int{"*": 0},
+		},
+		{
+			Name:   "RS256 and EdDSA auth collections",
+			Method: http.MethodGet,
+			URL:    "/.well-known/jwks.json",
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				users, err := app.FindCollectionByNameOrId("users")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// RS256 -> EdDSA (the RS256 key remains as previous key)
+				users.AuthTokenSigning.Algorithm = core.AuthTokenAlgorithmRS256
+				if err = app.Save(users); err != nil {
+					t.Fatal(err)
+				}
+				users.AuthTokenSigning.Algorithm = core.AuthTokenAlgorithmEdDSA
+				if err = app.Save(users); err != nil {
+					t.Fatal(err)
+				}
+
+				clients, err := app.FindCollectionByNameOrId("clients")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				clients.AuthTokenSigning.Algorithm = core.AuthTokenAlgorithmRS256
+				if err = app.Save(clients); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				`"kty":"OKP"`,
+				`"kty":"RSA"`,
+			},
+			NotExpectedContent: []string{
+				// private key params
+				`"d":`,
+			},
+			ExpectedEvents: map[string]int{"*": 0},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expected := map[string]security.JWK{}
+				for _, name := range []string{"users", "clients"} {
+					collection, err := app.FindCollectionByNameOrId(name)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					for _, jwk := range collection.AuthTokenSigning.JWKs() {
+						expected[jwk.Kid] = jwk
+					}
+				}
+
+				set := security.JWKSet{}
+				if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+					t.Fatal(err)
+				}
+
+				if len(set.Keys) != 3 || len(expected) != 3 {
+					t.Fatalf("Expected 3 keys, got %d (expected %d)", len(set.Keys), len(expected))
+				}
+
+				for _, jwk := range set.Keys {
+					if expected[jwk.Kid] != jwk {
+						t.Fatalf("Unexpected JWK %#v", jwk)
+					}
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
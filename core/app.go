@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hanzoai/backendPB/tools/cron"
+	"github.com/hanzoai/backendPB/tools/dbutils"
 	"github.com/hanzoai/backendPB/tools/filesystem"
 	"github.com/hanzoai/backendPB/tools/hook"
 	"github.com/hanzoai/backendPB/tools/mailer"
@@ -121,6 +122,22 @@ type App interface {
 	// NB! This feature is experimental and currently is expected to work only on UNIX based systems.
 	RestoreBackup(ctx context.Context, name string) error
 
+	// RestoreBackupPointInTime restores the data db to its state at the specified
+	// time from the continuous backup stream (see SyncBackupStream) and restarts
+	// the current running application process.
+	//
+	// Only the data db is restored. The auxiliary db and the uploaded files
+	// are not part of the backup stream and are left unchanged.
+	//
+	// NB! This feature is experimental and currently is expected to work only on UNIX based systems.
+	RestoreBackupPointInTime(ctx context.Context, pointInTime time.Time) error
+
+	// SyncBackupStream uploads to the backups filesystem the data db changes
+	// since the last sync allowing point-in-time restore.
+	//
+	// It is called periodically when the app.Settings().Backups.Stream is enabled.
+	SyncBackupStream(ctx context.Context) error
+
 	// Restart restarts (aka. replaces) the current running application process.
 	//
 	// NB! It relies on execve which is supported only on UNIX based systems.
@@ -140,6 +157,10 @@ type App interface {
 	// DB methods
 	// ---------------------------------------------------------------
 
+	// DBDialect returns the db engine specific SQL dialect of the app data and auxiliary dbs
+	// (default to [dbutils.SQLiteDialect]).
+	DBDialect() dbutils.Dialect
+
 	// DB returns the default app data db instance (hb_data/data.db).
 	DB() dbx.Builder
 
@@ -174,6 +195,26 @@ type App interface {
 	// In a transaction the AuxNonconcurrentDB() and AuxNonconcurrentDB() refer to the same *dbx.TX instance.
 	AuxNonconcurrentDB() dbx.Builder
 
+	// ReplicaDB returns the next configured read-only data db replica (round-robin).
+	//
+	// It fallbacks to DB() if there are no replicas or the app is transactional.
+	ReplicaDB() dbx.Builder
+
+	// ReplicaApp returns an app instance which DB() read queries are executed
+	// on a read-only data db replica (writes and transactions still use the primary).
+	//
+	// The current app is returned as it is if there are no replicas, the app is transactional
+	// or the specified session (see [ReplicaSessionKey]) had a recent write.
+	ReplicaApp(sessionKey string) App
+
+	// TrackReplicaWrite marks the specified session as recently written
+	// so that its reads are routed to the primary for the read-your-writes window.
+	TrackReplicaWrite(sessionKey string)
+
+	// HasRecentReplicaWrite reports whether the specified session had
+	// a write in the current read-your-writes window.
+	HasRecentReplicaWrite(sessionKey string) bool
+
 	// HasTable checks if a table (or view) with the provided name exists (case insensitive).
 	// in the current app.DB() instance.
 	HasTable(tableName string) bool
@@ -329,6 +370,13 @@ type App interface {
 	// DeleteOldLogs delete all requests that are created before createdBefore.
 	DeleteOldLogs(createdBefore time.Time) error
 
+	// CronRunQuery returns a new CronRun select query.
+	CronRunQuery() *dbx.SelectQuery
+
+	// FindLastCronRuns returns the most recent run of each cron job
+	// indexed by the job id.
+	FindLastCronRuns() (map[string]*CronRun, error)
+
 	// ---------------------------------------------------------------
 
 	// CollectionQuery returns a new Collection select query.
@@ -428,6 +476,17 @@ type App interface {
 	// but accept marshaled json array as import data (usually used for the autogenerated snapshots).
 	ImportCollectionsByMarshaledJSON(rawSliceOfMaps []byte, deleteMissing bool) error
 
+	// ExportCollectionsArchive creates a zip archive at dest with the schema,
+	// records and files of the specified collections.
+	ExportCollectionsArchive(ctx context.Context, dest string, collectionNamesOrIds ...string) error
+
+	// ImportCollectionsArchive imports the collections archive at src
+	// created with [App.ExportCollectionsArchive].
+	//
+	// Archive records whose ids already exist are skipped and reported
+	// in the result conflicts.
+	ImportCollectionsArchive(ctx context.Context, src string) (*CollectionsArchiveImportResult, error)
+
 	// SyncRecordTableSchema compares the two provided collections
 	// and applies the necessary related record table changes.
 	//
@@ -490,6 +549,46 @@ type App interface {
 
 	// ---------------------------------------------------------------
 
+	// FindAllWebAuthnCredentialsByRecord returns all WebAuthnCredential models
+	// linked to the provided auth record (in DESC order).
+	FindAllWebAuthnCredentialsByRecord(authRecord *Record) ([]*WebAuthnCredential, error)
+
+	// FindWebAuthnCredentialByCredentialId returns a single WebAuthnCredential model
+	// by its auth collection and base64url encoded credential id.
+	FindWebAuthnCredentialByCredentialId(collectionId string, credentialId string) (*WebAuthnCredential, error)
+
+	// ConsumeWebAuthnChallenge finds and deletes the WebAuthn challenge
+	// by its auth collection and base64url encoded challenge value.
+	//
+	// It returns an error if the challenge is missing or it was already
+	// consumed by another request (aka. each challenge could be verified at most once).
+	ConsumeWebAuthnChallenge(collectionId string, challenge string) (*WebAuthnChallenge, error)
+
+	// DeleteExpiredWebAuthnChallenges deletes the expired WebAuthn challenges for all auth collections.
+	DeleteExpiredWebAuthnChallenges() error
+
+	// ---------------------------------------------------------------
+
+	// FindTOTPByRecord returns the authenticator app (TOTP) enrollment
+	// model linked to the provided auth record.
+	FindTOTPByRecord(authRecord *Record) (*TOTP, error)
+
+	// ConsumeTOTPCode validates the provided authenticator app code and
+	// persists its time step so that it couldn't be reused.
+	//
+	// It returns [ErrInvalidTOTPCode] if the code is invalid or it was
+	// already used by another (concurrent) request.
+	ConsumeTOTPCode(totp *TOTP, code string) error
+
+	// ConsumeTOTPRecoveryCode validates the provided single use
+	// recovery code and removes it from the stored recovery codes.
+	//
+	// It returns [ErrInvalidTOTPCode] if the code is invalid or it was
+	// already used by another (concurrent) request.
+	ConsumeTOTPRecoveryCode(totp *TOTP, code string) error
+
+	// ---------------------------------------------------------------
+
 	// FindAllAuthOriginsByRecord returns all AuthOrigin models linked to the provided auth record (in DESC order).
 	FindAllAuthOriginsByRecord(authRecord *Record) ([]*AuthOrigin, error)
 
@@ -510,13 +609,143 @@ type App interface {
 
 	// ---------------------------------------------------------------
 
+	// FindAllAuthSessionsByRecord returns all AuthSession models linked to
+	// the provided auth record (ordered by their last refresh time in DESC order).
+	FindAllAuthSessionsByRecord(authRecord *Record) ([]*AuthSession, error)
+
+	// FindAuthSessionById returns a single AuthSession model by its id.
+	FindAuthSessionById(id string) (*AuthSession, error)
+
+	// FindAuthSessionByRefreshToken returns a single AuthSession model
+	// by any of its current or already rotated plain refresh tokens.
+	FindAuthSessionByRefreshToken(refreshToken string) (*AuthSession, error)
+
+	// RotateAuthSessionRefreshToken replaces the provided current session
+	// refresh token with a new one and returns the new plain refresh token.
+	//
+	// It returns [ErrReusedRefreshToken] if the token was already rotated
+	// (including by another concurrent request).
+	RotateAuthSessionRefreshToken(session *AuthSession, refreshToken string) (string, error)
+
+	// DeleteAllAuthSessionsByRecord deletes all AuthSession models associated with the provided record.
+	//
+	// Returns a combined error with the failed deletes.
+	DeleteAllAuthSessionsByRecord(authRecord *Record) error
+
+	// DeleteExpiredAuthSessions deletes the expired auth sessions
+	// based on the "sessions.duration" option of their auth collection.
+	DeleteExpiredAuthSessions() error
+
+	// ---------------------------------------------------------------
+
+	// FindAllRevisionsByRecord returns all Revision models linked to the provided record
+	// (ordered from the newest to the oldest).
+	FindAllRevisionsByRecord(record *Record) ([]*Revision, error)
+
+	// FindRevisionById returns a single Revision model by its id.
+	FindRevisionById(id string) (*Revision, error)
+
+	// RestoreRecordRevision restores the revision record to the fields data
+	// snapshot of the provided revision and returns the saved record.
+	//
+	// If the revision record doesn't exist anymore it is recreated with the same id
+	// (auth records are recreated with a random password).
+	// A soft deleted revision record is also restored.
+	//
+	// Note that the file, password and autodate fields are not restored.
+	RestoreRecordRevision(revision *Revision) (*Record, error)
+
+	// ---------------------------------------------------------------
+
+	// FindWebhookById returns a single Webhook model by its id.
+	FindWebhookById(id string) (*Webhook, error)
+
+	// FindAllWebhookDeliveriesByWebhook returns all WebhookDelivery models
+	// linked to the provided webhook (ordered from the newest to the oldest).
+	FindAllWebhookDeliveriesByWebhook(webhook *Webhook) ([]*WebhookDelivery, error)
+
+	// DispatchWebhookDeliveries sends all due pending webhook deliveries.
+	//
+	// Individual delivery failures are rescheduled with exponential backoff
+	// and are not returned as error.
+	DispatchWebhookDeliveries() error
+
+	// DeliverWebhook makes a single delivery attempt for the pending webhook delivery with the specified id.
+	//
+	// The delivery is skipped (without error) if it is not pending,
+	// not due yet or it is currently processed by another dispatcher.
+	//
+	// On failure the delivery is rescheduled with exponential backoff or it is
+	// marked as failed if the max allowed attempts are reached.
+	DeliverWebhook(deliveryId string) error
+
+	// ---------------------------------------------------------------
+
+	// FindOIDCClientById returns a single OIDCClient model by its id.
+	FindOIDCClientById(id string) (*OIDCClient, error)
+
+	// FindOIDCAuthCodeByCode returns a single OIDCAuthCode model by its plain authorization code.
+	FindOIDCAuthCodeByCode(code string) (*OIDCAuthCode, error)
+
+	// ConsumeOIDCAuthCode finds and deletes the OIDCAuthCode model
+	// by its plain authorization code.
+	//
+	// It returns [sql.ErrNoRows] if the code is missing or it was
+	// already consumed by another (concurrent) request.
+	ConsumeOIDCAuthCode(code string) (*OIDCAuthCode, error)
+
+	// DeleteExpiredOIDCAuthCodes deletes the expired (aka. older than [OIDCAuthCodeDuration])
+	// OAuth2/OIDC provider authorization codes.
+	DeleteExpiredOIDCAuthCodes() error
+
+	// ---------------------------------------------------------------
+
+	// FindJobById returns a single Job model by its id.
+	FindJobById(id string) (*Job, error)
+
+	// RegisterJobHandler registers a background job handler for the specified job name.
+	//
+	// If a handler with the same name already exists it will be replaced.
+	RegisterJobHandler(name string, handler JobHandlerFunc)
+
+	// Enqueue persists a new pending background job for the specified job
+	// handler name with a json serializable payload.
+	//
+	// If options.UniqueKey is set and there is already a pending or running
+	// job with the same key, the existing job is returned instead.
+	//
+	// When called with a transactional app, the job is persisted as part of the transaction.
+	Enqueue(name string, payload any, optOptions ...JobOptions) (*Job, error)
+
+	// RetryJob resets a failed or canceled job so that it could be processed again.
+	RetryJob(job *Job) error
+
+	// CancelJob cancels a pending job.
+	//
+	// Running jobs cannot be canceled.
+	CancelJob(job *Job) error
+
+	// ProcessJobs runs synchronously all due jobs until there are no more.
+	//
+	// It is usually used in tests or when the job workers are disabled.
+	ProcessJobs() error
+
+	// ---------------------------------------------------------------
+
 	// RecordQuery returns a new Record select query from a collection model, id or name.
 	//
 	// In case a collection id or name is provided and that collection doesn't
 	// actually exists, the generated query will be created with a cancelled context
 	// and will fail once an executor (Row(), One(), All(), etc.) is called.
+	//
+	// If the collection has soft delete enabled, the soft deleted records
+	// are excluded from the query results (see also [App.RecordQueryWithSoftDeleted]).
 	RecordQuery(collectionModelOrIdentifier any) *dbx.SelectQuery
 
+	// RecordQueryWithSoftDeleted is similar to [App.RecordQuery] but
+	// doesn't exclude the soft deleted records from the query results.
+	RecordQueryWithSoftDeleted(collectionModelOrIdentifier any) *dbx.SelectQuery
+
 	// FindRecordById finds the Record model by its id.
 	FindRecordById(collectionModelOrIdentifier any, recordId string, optFilters ...func(q *dbx.SelectQuery) error) (*Record, error)
 
@@ -604,6 +833,9 @@ type App interface {
 	//
 	// Optionally specify a list of validTypes to check tokens only from those types.
 	//
+	// The OAuth2/OIDC provider access tokens are accepted only if
+	// [TokenTypeOIDCAccess] is explicitly listed in validTypes.
+	//
 	// Returns an error if the JWT is invalid, expired or not associated to an auth collection record.
 	FindAuthRecordByToken(token string, validTypes ...string) (*Record, error)
 
@@ -612,6 +844,30 @@ type App interface {
 	// Returns an error if it is not an auth collection or the record is not found.
 	FindAuthRecordByEmail(collectionModelOrIdentifier any, email string) (*Record, error)
 
+	// FindSoftDeletedRecordById finds a soft deleted Record model by its id.
+	//
+	// Returns sql.ErrNoRows if the record is missing or it is not soft deleted.
+	FindSoftDeletedRecordById(collectionModelOrIdentifier any, recordId string) (*Record, error)
+
+	// RestoreRecord clears the "deleted" field of a soft deleted record
+	// making it again available in the default record queries.
+	//
+	// The restore is persisted with [App.SaveNoValidate] and triggers the regular record update hooks.
+	//
+	// Note that the references that were cascade deleted together with
+	// the record are not restored automatically.
+	RestoreRecord(record *Record) error
+
+	// PurgeRecord permanently deletes the provided record from the database
+	// regardless of its collection soft delete option.
+	//
+	// Note that the record relation references are handled the same way as with [App.Delete].
+	PurgeRecord(record *Record) error
+
+	// PurgeOldSoftDeletedRecords permanently deletes all records from the
+	// provided collection that were soft deleted before deletedBefore.
+	PurgeOldSoftDeletedRecords(collectionModelOrIdentifier any, deletedBefore time.Time) error
+
 	// CanAccessRecord checks if a record is allowed to be accessed by the
 	// specified requestInfo and accessRule.
 	//
@@ -632,6 +888,24 @@ type App interface {
 	//	if ok, _ := app.CanAccessRecord(record, requestInfo, rule); ok { ... }
 	CanAccessRecord(record *Record, requestInfo *RequestInfo, accessRule *string) (bool, error)
 
+	// CanAccessRealtimeChannel checks if the realtime channel with the
+	// specified name is allowed to be accessed by the specified requestInfo and accessRule.
+	//
+	// The rule is evaluated the same way as the collection rules with
+	// the special "name" field holding the channel name.
+	//
+	// Rule and db checks are ignored in case requestInfo.Auth is a superuser.
+	//
+	// The method always return false on invalid rule or db query error.
+	//
+	// Example:
+	//
+	//	requestInfo, _ := e.RequestInfo()
+	//	channel, _ := app.Settings().Realtime.FindChannel("rooms:abc")
+	//
+	//	if ok, _ := app.CanAccessRealtimeChannel("rooms:abc", requestInfo, channel.JoinRule); ok { ... }
+	CanAccessRealtimeChannel(name string, requestInfo *RequestInfo, accessRule *string) (bool, error)
+
 	// ExpandRecord expands the relations of a single Record model.
 	//
 	// If optFetchFunc is not set, then a default function will be used
@@ -1396,6 +1670,28 @@ type App interface {
 	// triggered and called only if their event data origin matches the tags.
 	OnRecordAuthWithOTPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithOTPRequestEvent]
 
+	// OnRecordAuthWithWebAuthnRequest hook is triggered on each Record
+	// auth with WebAuthn (passkey) API request.
+	//
+	// The hook is triggered after the assertion signature verification
+	// and before the credential sign count update.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithWebAuthnRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithWebAuthnRequestEvent]
+
+	// OnRecordAuthWithTOTPRequest hook is triggered on each Record
+	// auth with authenticator app (TOTP) API request.
+	//
+	// The hook is triggered after the code verification and before
+	// the enrollment "lastUsedStep" and recovery codes update.
+	//
+	// If the optional "tags" list (Collection ids or names) is specified,
+	// then all event handlers registered via the created hook will be
+	// triggered and called only if their event data origin matches the tags.
+	OnRecordAuthWithTOTPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithTOTPRequestEvent]
+
 	// ---------------------------------------------------------------
 	// Record CRUD API event hooks
 	// ---------------------------------------------------------------
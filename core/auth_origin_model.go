@@ -170,6 +170,11 @@ func recordRefHooks[T RecordProxy](app App, collectionName string, optCollection
 				return e.Next()
 			}
 
+			// the system collection could be created on demand and may not exist yet
+			if _, err := e.App.FindCachedCollectionByNameOrId(collectionName); err != nil {
+				return e.Next()
+			}
+
 			originalApp := e.App
 			txErr := e.App.RunInTransaction(func(txApp App) error {
 				e.App = txApp
@@ -199,13 +204,22 @@ func recordRefHooks[T RecordProxy](app App, collectionName string, optCollection
 	})
 
 	// delete on record ref delete
+	//
+	// note: the refs of soft deleted records are kept so that they
+	// could be still used after the record restore
 	app.OnRecordDeleteExecute().Bind(&hook.Handler[*RecordEvent]{
 		Func: func(e *RecordEvent) error {
 			if e.Record.Collection().Name == collectionName ||
+				e.Record.isSoftDelete() ||
 				(len(optCollectionTypes) > 0 && !slices.Contains(optCollectionTypes, e.Record.Collection().Type)) {
 				return e.Next()
 			}
 
+			// the system collection could be created on demand and may not exist yet
+			if _, err := e.App.FindCachedCollectionByNameOrId(collectionName); err != nil {
+				return e.Next()
+			}
+
 			originalApp := e.App
 			txErr := e.App.RunInTransaction(func(txApp App) error {
 				e.App = txApp
@@ -0,0 +1,314 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+const CollectionNameAuthSessions = "_authSessions"
+
+const authSessionRefreshTokenFamilyLength = 20
+
+var (
+	_ Model        = (*AuthSession)(nil)
+	_ PreValidator = (*AuthSession)(nil)
+	_ RecordProxy  = (*AuthSession)(nil)
+)
+
+// AuthSession defines a Record proxy for working with the
+// server tracked auth sessions collection.
+//
+// Each session has a single valid refresh token that is rotated on every use
+// and the use of any of its already rotated refresh tokens revokes the session.
+type AuthSession struct {
+	*Record
+}
+
+// NewAuthSession instantiates and returns a new blank *AuthSession model.
+//
+// Example usage:
+//
+//	session := core.NewAuthSession(app)
+//	session.SetRecordRef(user.Id)
+//	session.SetCollectionRef(user.Collection().Id)
+//	refreshToken := session.RotateRefreshToken()
+//	app.Save(session)
+func NewAuthSession(app App) *AuthSession {
+	m := &AuthSession{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameAuthSessions)
+	if err != nil {
+		// this is just to make tests easier since the sessions collection is created on demand
+		// (note: the loaded record is further checked on AuthSession.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *AuthSession) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameAuthSessions {
+		return errors.New("missing or invalid auth session ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *AuthSession) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *AuthSession) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" record field value.
+func (m *AuthSession) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *AuthSession) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *AuthSession) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *AuthSession) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// UserAgent returns the "userAgent" record field value.
+func (m *AuthSession) UserAgent() string {
+	return m.GetString("userAgent")
+}
+
+// SetUserAgent updates the "userAgent" record field value
+// (long user agents are truncated to 300 characters).
+func (m *AuthSession) SetUserAgent(userAgent string) {
+	if len(userAgent) > 300 {
+		userAgent = userAgent[:300]
+	}
+
+	m.Set("userAgent", userAgent)
+}
+
+// IP returns the "ip" record field value.
+func (m *AuthSession) IP() string {
+	return m.GetString("ip")
+}
+
+// SetIP updates the "ip" record field value.
+func (m *AuthSession) SetIP(ip string) {
+	m.Set("ip", ip)
+}
+
+// RotateRefreshToken generates and returns a new plain session refresh token.
+//
+// All refresh tokens of a session share the same randomly generated "family"
+// prefix so that the already rotated tokens could be still linked to their session
+// for reuse detection (see [AuthSession.IsReusedRefreshToken]).
+//
+// Only the sha256 hash of the new token is stored.
+//
+// Note that this method only updates the model fields. Use
+// [BaseApp.RotateAuthSessionRefreshToken] to rotate the refresh token
+// of an existing session with a conditional update.
+func (m *AuthSession) RotateRefreshToken() string {
+	family := m.GetString("refreshTokenFamily")
+	if family == "" {
+		family = security.RandomString(authSessionRefreshTokenFamilyLength)
+		m.Set("refreshTokenFamily", family)
+	}
+
+	token := family + "." + security.RandomString(50)
+
+	m.Set("refreshTokenHash", security.SHA256(token))
+
+	return token
+}
+
+// ValidateRefreshToken reports whether the provided plain token
+// matches with the current session refresh token.
+func (m *AuthSession) ValidateRefreshToken(token string) bool {
+	return token != "" && security.Equal(security.SHA256(token), m.GetString("refreshTokenHash"))
+}
+
+// IsReusedRefreshToken reports whether the provided plain token is from
+// the session refresh tokens family but it is not the current one
+// (aka. it is an already rotated session refresh token).
+func (m *AuthSession) IsReusedRefreshToken(token string) bool {
+	family := m.GetString("refreshTokenFamily")
+
+	return family != "" &&
+		security.Equal(refreshTokenFamily(token), family) &&
+		!m.ValidateRefreshToken(token)
+}
+
+// Created returns the "created" record field value.
+func (m *AuthSession) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value
+// (aka. the last session refresh time).
+func (m *AuthSession) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// HasExpired checks if the session is expired, aka. whether it has been
+// more than maxElapsed time since its last refresh.
+func (m *AuthSession) HasExpired(maxElapsed time.Duration) bool {
+	return time.Since(m.Updated().Time()) > maxElapsed
+}
+
+// -------------------------------------------------------------------
+
+// newAuthSessionsCollection returns the default auth sessions system collection definition.
+func newAuthSessionsCollection() *Collection {
+	col := NewBaseCollection(CollectionNameAuthSessions)
+	col.System = true
+
+	col.Fields.Add(&TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "refreshTokenFamily",
+		System:   true,
+		Required: true,
+		Hidden:   true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "refreshTokenHash",
+		System:   true,
+		Required: true,
+		Hidden:   true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "userAgent",
+		System: true,
+		Max:    300,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "ip",
+		System: true,
+		Max:    255,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_authSessions_refreshTokenHash", true, "refreshTokenHash", "")
+	col.AddIndex("idx_authSessions_refreshTokenFamily", true, "refreshTokenFamily", "")
+	col.AddIndex("idx_authSessions_collection_record", false, "collectionRef, recordRef", "")
+
+	return col
+}
+
+func (app *BaseApp) registerAuthSessionHooks() {
+	recordRefHooks[*AuthSession](app, CollectionNameAuthSessions, CollectionTypeAuth)
+
+	// run on every hour to cleanup the expired sessions
+	app.Cron().Add("__pbAuthSessionsCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredAuthSessions(); err != nil {
+			app.Logger().Warn("Failed to delete expired auth sessions", "error", err)
+		}
+	})
+
+	// create the auth sessions system collection on demand
+	collectionSaveHandler := &hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			if !e.Collection.IsAuth() || !e.Collection.Sessions.Enabled {
+				return nil
+			}
+
+			if _, err := e.App.FindCachedCollectionByNameOrId(CollectionNameAuthSessions); err == nil {
+				return nil // already exists
+			}
+
+			return e.App.Save(newAuthSessionsCollection())
+		},
+	}
+	app.OnCollectionCreateExecute().Bind(collectionSaveHandler)
+	app.OnCollectionUpdateExecute().Bind(collectionSaveHandler)
+
+	// revoke all auth record sessions on tokenKey change
+	// (e.g. on password or email change)
+	app.OnRecordUpdateExecute().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			if !e.Record.Collection().IsAuth() {
+				return e.Next()
+			}
+
+			// the system collection could be created on demand and may not exist yet
+			if _, err := e.App.FindCachedCollectionByNameOrId(CollectionNameAuthSessions); err != nil {
+				return e.Next()
+			}
+
+			originalApp := e.App
+			txErr := e.App.RunInTransaction(func(txApp App) error {
+				e.App = txApp
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				// note: the tokenKey could be also regenerated right before the db write
+				if e.Record.Original().TokenKey() == e.Record.TokenKey() {
+					return nil
+				}
+
+				return txApp.DeleteAllAuthSessionsByRecord(e.Record)
+			})
+			e.App = originalApp
+
+			return txErr
+		},
+		Priority: 99,
+	})
+}
+
+// refreshTokenFamily returns the family prefix of the provided plain refresh token
+// (or empty string if the token is not in the expected format).
+func refreshTokenFamily(token string) string {
+	family, _, ok := strings.Cut(token, ".")
+	if !ok || len(family) != authSessionRefreshTokenFamilyLength {
+		return ""
+	}
+
+	return family
+}
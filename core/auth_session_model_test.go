@@ -0,0 +1,422 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+func enableTestAuthSessions(t *testing.T, app core.App, collectionName string) *core.Collection {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.Sessions.Enabled = true
+
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	return collection
+}
+
+func newTestAuthSession(t *testing.T, app core.App, record *core.Record) (*core.AuthSession, string) {
+	session := core.NewAuthSession(app)
+	session.SetRecordRef(record.Id)
+	session.SetCollectionRef(record.Collection().Id)
+
+	refreshToken := session.RotateRefreshToken()
+
+	if err := app.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	return session, refreshToken
+}
+
+func TestAuthSessionsCollectionOnDemand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if _, err := app.FindCollectionByNameOrId(core.CollectionNameAuthSessions); err == nil {
+		t.Fatal("Expected the auth sessions collection to not exist")
+	}
+
+	users := enableTestAuthSessions(t, app, "users")
+
+	// existing auth collections should have the missing sessions options initialized on save
+	if users.Sessions.AccessTokenDuration != 900 || users.Sessions.Duration != 2592000 {
+		t.Fatalf("Expected the default sessions options to be initialized, got %#v", users.Sessions)
+	}
+
+	sessions, err := app.FindCollectionByNameOrId(core.CollectionNameAuthSessions)
+	if err != nil {
+		t.Fatalf("Expected the auth sessions collection to be created, got %v", err)
+	}
+
+	if !sessions.System {
+		t.Fatal("Expected the auth sessions collection to be system")
+	}
+
+	for _, name := range []string{"refreshTokenFamily", "refreshTokenHash"} {
+		if field := sessions.Fields.GetByName(name); field == nil || !field.GetHidden() {
+			t.Fatalf("Expected hidden %s field, got %v", name, field)
+		}
+	}
+}
+
+func TestNewAuthSession(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	// missing collection
+	if m := core.NewAuthSession(app); m.Collection().Name == core.CollectionNameAuthSessions {
+		t.Fatal("Expected invalid placeholder collection")
+	}
+
+	enableTestAuthSessions(t, app, "users")
+
+	m := core.NewAuthSession(app)
+
+	if m.Collection().Name != core.CollectionNameAuthSessions {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameAuthSessions, m.Collection().Name)
+	}
+}
+
+func TestAuthSessionProxyRecord(t *testing.T) {
+	t.Parallel()
+
+	record := core.NewRecord(core.NewBaseCollection("test"))
+	record.Id = "test_id"
+
+	m := core.AuthSession{}
+	m.SetProxyRecord(record)
+
+	if m.ProxyRecord() == nil || m.ProxyRecord().Id != record.Id {
+		t.Fatalf("Expected proxy record with id %q, got %v", record.Id, m.ProxyRecord())
+	}
+}
+
+func TestAuthSessionFields(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	m := core.NewAuthSession(app)
+
+	scenarios := []struct {
+		field  string
+		setter func(string)
+		getter func() string
+	}{
+		{"collectionRef", m.SetCollectionRef, m.CollectionRef},
+		{"recordRef", m.SetRecordRef, m.RecordRef},
+		{"userAgent", m.SetUserAgent, m.UserAgent},
+		{"ip", m.SetIP, m.IP},
+	}
+
+	for _, s := range scenarios {
+		for i, testValue := range []string{"test_1", "test2", ""} {
+			t.Run(fmt.Sprintf("%s_%d_%q", s.field, i, testValue), func(t *testing.T) {
+				s.setter(testValue)
+
+				if v := s.getter(); v != testValue {
+					t.Fatalf("Expected getter %q, got %q", testValue, v)
+				}
+
+				if v := m.GetString(s.field); v != testValue {
+					t.Fatalf("Expected field value %q, got %q", testValue, v)
+				}
+			})
+		}
+	}
+
+	m.SetUserAgent(strings.Repeat("a", 400))
+	if v := m.UserAgent(); len(v) != 300 {
+		t.Fatalf("Expected the user agent to be truncated to 300 characters, got %d", len(v))
+	}
+
+	now := types.NowDateTime()
+
+	m.SetRaw("created", now.Add(-1*time.Hour))
+	if v := m.Created(); !v.Equal(now.Add(-1 * time.Hour)) {
+		t.Fatalf("Expected created %v, got %v", now.Add(-1*time.Hour), v)
+	}
+
+	m.SetRaw("updated", now)
+	if v := m.Updated(); !v.Equal(now) {
+		t.Fatalf("Expected updated %v, got %v", now, v)
+	}
+}
+
+func TestAuthSessionRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	m := core.NewAuthSession(app)
+
+	if m.ValidateRefreshToken("") {
+		t.Fatal("Expected empty token to be invalid")
+	}
+
+	token1 := m.RotateRefreshToken()
+
+	family, secret, ok := strings.Cut(token1, ".")
+	if !ok || len(family) != 20 || len(secret) != 50 {
+		t.Fatalf("Expected token in the format {20 characters family}.{50 characters secret}, got %q", token1)
+	}
+
+	if raw := m.GetString("refreshTokenHash"); raw == "" || raw == token1 {
+		t.Fatalf("Expected hashed refresh token, got %q", raw)
+	}
+
+	if !m.ValidateRefreshToken(token1) {
+		t.Fatal("Expected token1 to be valid")
+	}
+
+	if m.IsReusedRefreshToken(token1) {
+		t.Fatal("Expected token1 to not be marked as reused")
+	}
+
+	token2 := m.RotateRefreshToken()
+	if token1 == token2 {
+		t.Fatal("Expected the rotated token to be different")
+	}
+
+	if !strings.HasPrefix(token2, family+".") {
+		t.Fatalf("Expected the rotated token to be from the same %q family, got %q", family, token2)
+	}
+
+	token3 := m.RotateRefreshToken()
+
+	if m.ValidateRefreshToken(token1) || m.ValidateRefreshToken(token2) {
+		t.Fatal("Expected token1 and token2 to be invalid after rotation")
+	}
+
+	// any of the already rotated tokens should be detected
+	if !m.IsReusedRefreshToken(token1) || !m.IsReusedRefreshToken(token2) {
+		t.Fatal("Expected token1 and token2 to be marked as reused")
+	}
+
+	if !m.ValidateRefreshToken(token3) {
+		t.Fatal("Expected token3 to be valid")
+	}
+
+	if m.IsReusedRefreshToken(token3) {
+		t.Fatal("Expected token3 to not be marked as reused")
+	}
+
+	// tokens from a different family
+	other := core.NewAuthSession(app)
+	otherToken := other.RotateRefreshToken()
+	for _, token := range []string{"", "invalid", family, otherToken} {
+		if m.IsReusedRefreshToken(token) {
+			t.Fatalf("Expected %q to not be marked as reused", token)
+		}
+	}
+}
+
+func TestAuthSessionHasExpired(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	now := types.NowDateTime()
+
+	m := core.NewAuthSession(app)
+	m.SetRaw("updated", now.Add(-5*time.Minute))
+
+	scenarios := []struct {
+		maxElapsed time.Duration
+		expected   bool
+	}{
+		{0 * time.Minute, true},
+		{3 * time.Minute, true},
+		{5 * time.Minute, true},
+		{6 * time.Minute, false},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%s", i, s.maxElapsed.String()), func(t *testing.T) {
+			result := m.HasExpired(s.maxElapsed)
+
+			if result != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestAuthSessionPreValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no proxy record", func(t *testing.T) {
+		m := &core.AuthSession{}
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("non-auth sessions collection", func(t *testing.T) {
+		m := &core.AuthSession{}
+		m.SetProxyRecord(core.NewRecord(core.NewBaseCollection("invalid")))
+		m.SetRecordRef(user.Id)
+		m.SetCollectionRef(user.Collection().Id)
+		m.RotateRefreshToken()
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("auth sessions collection", func(t *testing.T) {
+		m := core.NewAuthSession(app)
+		m.SetRecordRef(user.Id)
+		m.SetCollectionRef(user.Collection().Id)
+		m.RotateRefreshToken()
+
+		if err := app.Validate(m); err != nil {
+			t.Fatalf("Expected nil validation error, got %v", err)
+		}
+	})
+}
+
+func TestAuthSessionRefsDelete(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := newTestAuthSession(t, app, user)
+
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindAuthSessionById(m.Id); err == nil {
+		t.Fatal("Expected the session to be deleted with the user")
+	}
+}
+
+func TestAuthSessionDeleteOnTokenKeyChange(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name   string
+		change func(user *core.Record)
+		revoke bool
+	}{
+		{"non-tokenKey change", func(user *core.Record) { user.Set("name", "new_name") }, false},
+		{"tokenKey change", func(user *core.Record) { user.RefreshTokenKey() }, true},
+		{"password change", func(user *core.Record) { user.SetPassword("1234567890") }, true},
+		{"email change", func(user *core.Record) { user.SetEmail("new@example.com") }, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			app, _ := tests.NewTestApp()
+			defer app.Cleanup()
+
+			enableTestAuthSessions(t, app, "users")
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m1, _ := newTestAuthSession(t, app, user)
+			m2, _ := newTestAuthSession(t, app, user)
+
+			s.change(user)
+			if err := app.Save(user); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, id := range []string{m1.Id, m2.Id} {
+				_, err := app.FindAuthSessionById(id)
+
+				if exists := err == nil; exists == s.revoke {
+					t.Fatalf("Expected session %q to be revoked %v, got %v", id, s.revoke, !exists)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthSessionDeleteOnTokenKeyChangeError(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := newTestAuthSession(t, app, user)
+
+	app.OnRecordDelete(core.CollectionNameAuthSessions).BindFunc(func(e *core.RecordEvent) error {
+		return errors.New("test_delete_error")
+	})
+
+	oldTokenKey := user.TokenKey()
+
+	user.RefreshTokenKey()
+	if err := app.Save(user); err == nil {
+		t.Fatal("Expected the sessions revoke error to be returned")
+	}
+
+	if _, err := app.FindAuthSessionById(m.Id); err != nil {
+		t.Fatalf("Expected the session to remain, got %v", err)
+	}
+
+	// the record update should be rolled back together with the failed sessions revoke
+	stored, err := app.FindRecordById(user.Collection(), user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.TokenKey() != oldTokenKey {
+		t.Fatalf("Expected the tokenKey change to be rolled back, got %q", stored.TokenKey())
+	}
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// ErrReusedRefreshToken is returned when an already rotated
+// auth session refresh token is used.
+var ErrReusedRefreshToken = errors.New("already used auth session refresh token")
+
+// FindAllAuthSessionsByRecord returns all AuthSession models linked to
+// the provided auth record (ordered by their last refresh time in DESC order).
+func (app *BaseApp) FindAllAuthSessionsByRecord(authRecord *Record) ([]*AuthSession, error) {
+	result := []*AuthSession{}
+
+	err := app.RecordQuery(CollectionNameAuthSessions).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("updated DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAuthSessionById returns a single AuthSession model by its id.
+func (app *BaseApp) FindAuthSessionById(id string) (*AuthSession, error) {
+	result := &AuthSession{}
+
+	err := app.RecordQuery(CollectionNameAuthSessions).
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAuthSessionByRefreshToken returns a single AuthSession model
+// by any of its current or already rotated plain refresh tokens
+// (the session is matched by the refresh token family prefix).
+//
+// Use [AuthSession.ValidateRefreshToken] and [AuthSession.IsReusedRefreshToken]
+// to check whether the token is the current session refresh token.
+func (app *BaseApp) FindAuthSessionByRefreshToken(refreshToken string) (*AuthSession, error) {
+	family := refreshTokenFamily(refreshToken)
+	if family == "" {
+		return nil, errors.New("missing or invalid refresh token")
+	}
+
+	result := &AuthSession{}
+
+	err := app.RecordQuery(CollectionNameAuthSessions).
+		AndWhere(dbx.HashExp{"refreshTokenFamily": family}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RotateAuthSessionRefreshToken replaces the provided current session refresh token
+// with a new one and returns the new plain refresh token.
+//
+// The session "userAgent" and "ip" field values are also persisted.
+//
+// The update is conditional (only if refreshToken is still the current
+// session refresh token) and [ErrReusedRefreshToken] is returned if the
+// token was already rotated (including by another concurrent request).
+func (app *BaseApp) RotateAuthSessionRefreshToken(session *AuthSession, refreshToken string) (string, error) {
+	if !session.ValidateRefreshToken(refreshToken) {
+		return "", ErrReusedRefreshToken
+	}
+
+	originalHash := session.GetString("refreshTokenHash")
+
+	newToken := session.RotateRefreshToken()
+	now := types.NowDateTime()
+
+	res, err := app.NonconcurrentDB().Update(
+		CollectionNameAuthSessions,
+		dbx.Params{
+			"refreshTokenHash": session.GetString("refreshTokenHash"),
+			"userAgent":        session.UserAgent(),
+			"ip":               session.IP(),
+			"updated":          now.String(),
+		},
+		dbx.HashExp{
+			"id":               session.Id,
+			"refreshTokenHash": originalHash,
+		},
+	).Execute()
+	if err == nil {
+		var affected int64
+		affected, err = res.RowsAffected()
+		if err == nil && affected != 1 {
+			err = ErrReusedRefreshToken
+		}
+	}
+	if err != nil {
+		session.Set("refreshTokenHash", originalHash)
+		return "", err
+	}
+
+	session.SetRaw("updated", now)
+
+	return newToken, nil
+}
+
+// DeleteAllAuthSessionsByRecord deletes all AuthSession models associated with the provided record.
+//
+// Returns a combined error with the failed deletes.
+func (app *BaseApp) DeleteAllAuthSessionsByRecord(authRecord *Record) error {
+	// the system collection could be created on demand and may not exist yet
+	if _, err := app.FindCachedCollectionByNameOrId(CollectionNameAuthSessions); err != nil {
+		return nil
+	}
+
+	models, err := app.FindAllAuthSessionsByRecord(authRecord)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, m := range models {
+		if err := app.Delete(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// DeleteExpiredAuthSessions deletes the expired auth sessions
+// based on the "sessions.duration" option of their auth collection.
+//
+// The sessions of auth collections with disabled sessions are also deleted.
+func (app *BaseApp) DeleteExpiredAuthSessions() error {
+	// the system collection could be created on demand and may not exist yet
+	if _, err := app.FindCachedCollectionByNameOrId(CollectionNameAuthSessions); err != nil {
+		return nil
+	}
+
+	authCollections, err := app.FindAllCollections(CollectionTypeAuth)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, collection := range authCollections {
+		query := app.RecordQuery(CollectionNameAuthSessions).
+			AndWhere(dbx.HashExp{"collectionRef": collection.Id})
+
+		if collection.Sessions.Enabled {
+			minValidDate, err := types.ParseDateTime(now.Add(-1 * collection.Sessions.DurationTime()))
+			if err != nil {
+				return err
+			}
+
+			query.AndWhere(dbx.NewExp("[[updated]] < {:date}", dbx.Params{"date": minValidDate}))
+		}
+
+		items := []*Record{}
+
+		if err = query.All(&items); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err = app.Delete(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
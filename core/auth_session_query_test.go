@@ -0,0 +1,388 @@
+package core_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+func TestFindAllAuthSessionsByRecord(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+	enableTestAuthSessions(t, app, "clients")
+
+	user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client1, err := app.FindAuthRecordByEmail("clients", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s1, _ := newTestAuthSession(t, app, user1)
+	s2, _ := newTestAuthSession(t, app, user1)
+	s3, _ := newTestAuthSession(t, app, client1)
+
+	// mark s1 as the most recently refreshed session
+	s1.SetRaw("updated", types.NowDateTime().Add(1*time.Minute))
+	if err := app.Save(s1); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		record   *core.Record
+		expected []string
+	}{
+		{user1, []string{s1.Id, s2.Id}},
+		{user2, nil},
+		{client1, []string{s3.Id}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.record.Collection().Name+"_"+s.record.Id, func(t *testing.T) {
+			result, err := app.FindAllAuthSessionsByRecord(s.record)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != len(s.expected) {
+				t.Fatalf("Expected total sessions %d, got %d", len(s.expected), len(result))
+			}
+
+			for i, id := range s.expected {
+				if result[i].Id != id {
+					t.Errorf("[%d] Expected id %q, got %q", i, id, result[i].Id)
+				}
+			}
+		})
+	}
+}
+
+func TestFindAuthSessionById(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, _ := newTestAuthSession(t, app, user)
+
+	scenarios := []struct {
+		id          string
+		expectError bool
+	}{
+		{"", true},
+		{user.Id, true}, // non-session id
+		{session.Id, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.id, func(t *testing.T) {
+			result, err := app.FindAuthSessionById(s.id)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if result.Id != s.id {
+				t.Fatalf("Expected record with id %q, got %q", s.id, result.Id)
+			}
+		})
+	}
+}
+
+func TestFindAuthSessionByRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, previousToken := newTestAuthSession(t, app, user)
+
+	currentToken := session.RotateRefreshToken()
+	if err := app.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		token       string
+		expectError bool
+	}{
+		{"empty token", "", true},
+		{"missing token", "missing", true},
+		{"hash value", session.GetString("refreshTokenHash"), true},
+		{"family value", session.GetString("refreshTokenFamily"), true},
+		{"unknown family token", strings.Repeat("a", 20) + ".test", true},
+		{"current token", currentToken, false},
+		{"previous token", previousToken, false},
+		{"previous token family with different secret", strings.Split(previousToken, ".")[0] + ".test", false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := app.FindAuthSessionByRefreshToken(s.token)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if result.Id != session.Id {
+				t.Fatalf("Expected session with id %q, got %q", session.Id, result.Id)
+			}
+		})
+	}
+}
+
+func TestRotateAuthSessionRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestAuthSessions(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, token := newTestAuthSession(t, app, user)
+
+	// simulate 2 concurrent requests loading the same session
+	session1, err := app.FindAuthSessionById(session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session2, err := app.FindAuthSessionById(session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.RotateAuthSessionRefreshToken(session1, "invalid"); !errors.Is(err, core.ErrReusedRefreshToken) {
+		t.Fatalf("Expected ErrReusedRefreshToken for invalid token, got %v", err)
+	}
+
+	session1.SetUserAgent("test_ua")
+	session1.SetIP("1.2.3.4")
+
+	newToken, err := app.RotateAuthSessionRefreshToken(session1, token)
+	if err != nil {
+		t.Fatalf("Expected the refresh token to be rotated, got %v", err)
+	}
+
+	if newToken == token || !session1.ValidateRefreshToken(newToken) {
+		t.Fatalf("Expected new valid refresh token, got %q", newToken)
+	}
+
+	originalHash := session2.GetString("refreshTokenHash")
+
+	if _, err := app.RotateAuthSessionRefreshToken(session2, token); !errors.Is(err, core.ErrReusedRefreshToken) {
+		t.Fatalf("Expected ErrReusedRefreshToken for the concurrently rotated token, got %v", err)
+	}
+
+	if v := session2.GetString("refreshTokenHash"); v != originalHash {
+		t.Fatalf("Expected the failed rotation to revert the refresh token hash, got %q", v)
+	}
+
+	stored, err := app.FindAuthSessionById(session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stored.ValidateRefreshToken(newToken) {
+		t.Fatal("Expected the persisted refresh token to be the new one")
+	}
+
+	if !stored.IsReusedRefreshToken(token) {
+		t.Fatal("Expected the old refresh token to be marked as reused")
+	}
+
+	if stored.UserAgent() != "test_ua" || stored.IP() != "1.2.3.4" {
+		t.Fatalf("Expected the session user agent and ip to be updated, got %q and %q", stored.UserAgent(), stored.IP())
+	}
+}
+
+func TestDeleteAllAuthSessionsByRecord(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing auth sessions collection", func(t *testing.T) {
+		app, _ := tests.NewTestApp()
+		defer app.Cleanup()
+
+		user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := app.DeleteAllAuthSessionsByRecord(user); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	})
+
+	t.Run("existing auth sessions", func(t *testing.T) {
+		app, _ := tests.NewTestApp()
+		defer app.Cleanup()
+
+		enableTestAuthSessions(t, app, "users")
+
+		user1, err := app.FindAuthRecordByEmail("users", "test@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s1, _ := newTestAuthSession(t, app, user1)
+		s2, _ := newTestAuthSession(t, app, user1)
+		s3, _ := newTestAuthSession(t, app, user2)
+
+		deletedIds := []string{}
+		app.OnRecordDelete().BindFunc(func(e *core.RecordEvent) error {
+			deletedIds = append(deletedIds, e.Record.Id)
+			return e.Next()
+		})
+
+		if err := app.DeleteAllAuthSessionsByRecord(user1); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{s1.Id, s2.Id}
+
+		if len(deletedIds) != len(expected) {
+			t.Fatalf("Expected deleted ids\n%v\ngot\n%v", expected, deletedIds)
+		}
+
+		for _, id := range expected {
+			if !slices.Contains(deletedIds, id) {
+				t.Errorf("Expected to find deleted id %q in %v", id, deletedIds)
+			}
+		}
+
+		if _, err := app.FindAuthSessionById(s3.Id); err != nil {
+			t.Fatalf("Expected the user2 session to remain, got %v", err)
+		}
+	})
+}
+
+func TestDeleteExpiredAuthSessions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing auth sessions collection", func(t *testing.T) {
+		app, _ := tests.NewTestApp()
+		defer app.Cleanup()
+
+		if err := app.DeleteExpiredAuthSessions(); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	})
+
+	t.Run("existing auth sessions", func(t *testing.T) {
+		app, _ := tests.NewTestApp()
+		defer app.Cleanup()
+
+		users := enableTestAuthSessions(t, app, "users")
+		users.Sessions.Duration = 3600
+		if err := app.Save(users); err != nil {
+			t.Fatal(err)
+		}
+
+		clients := enableTestAuthSessions(t, app, "clients")
+
+		user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := app.FindAuthRecordByEmail("clients", "test@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		now := types.NowDateTime()
+
+		valid, _ := newTestAuthSession(t, app, user)
+
+		expired, _ := newTestAuthSession(t, app, user)
+		expired.SetRaw("updated", now.Add(-2*time.Hour))
+		if err := app.Save(expired); err != nil {
+			t.Fatal(err)
+		}
+
+		// disabled collection sessions should be always deleted
+		disabled, _ := newTestAuthSession(t, app, client)
+		clients.Sessions.Enabled = false
+		if err := app.Save(clients); err != nil {
+			t.Fatal(err)
+		}
+
+		deletedIds := []string{}
+		app.OnRecordDelete().BindFunc(func(e *core.RecordEvent) error {
+			deletedIds = append(deletedIds, e.Record.Id)
+			return e.Next()
+		})
+
+		if err := app.DeleteExpiredAuthSessions(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{expired.Id, disabled.Id}
+
+		if len(deletedIds) != len(expected) {
+			t.Fatalf("Expected deleted ids\n%v\ngot\n%v", expected, deletedIds)
+		}
+
+		for _, id := range expected {
+			if !slices.Contains(deletedIds, id) {
+				t.Errorf("Expected to find deleted id %q in %v", id, deletedIds)
+			}
+		}
+
+		if _, err := app.FindAuthSessionById(valid.Id); err != nil {
+			t.Fatalf("Expected the valid session to remain, got %v", err)
+		}
+	})
+}
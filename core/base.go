@@ -12,11 +12,13 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/hanzoai/backendPB/tools/cron"
+	"github.com/hanzoai/backendPB/tools/dbutils"
 	"github.com/hanzoai/backendPB/tools/filesystem"
 	"github.com/hanzoai/backendPB/tools/hook"
 	"github.com/hanzoai/backendPB/tools/logger"
@@ -35,6 +37,10 @@ const (
 	DefaultAuxMaxIdleConns  int           = 3
 	DefaultQueryTimeout     time.Duration = 30 * time.Second
 
+	// DefaultReplicaReadYourWritesWindow is the default duration after a write
+	// during which the reads of the same session are routed to the primary data db.
+	DefaultReplicaReadYourWritesWindow time.Duration = 5 * time.Second
+
 	LocalStorageDirName       string = "storage"
 	LocalBackupsDirName       string = "backups"
 	LocalTempDirName          string = ".hb_temp_to_delete" // temp hb_data sub directory that will be deleted on each app.Bootstrap()
@@ -53,6 +59,7 @@ type DBConnectFunc func(dbPath string) (*dbx.DB, error)
 // BaseAppConfig defines a BaseApp configuration option
 type BaseAppConfig struct {
 	DBConnect        DBConnectFunc
+	DBDialect        dbutils.Dialect
 	DataDir          string
 	EncryptionEnv    string
 	QueryTimeout     time.Duration
@@ -61,6 +68,27 @@ type BaseAppConfig struct {
 	AuxMaxOpenConns  int
 	AuxMaxIdleConns  int
 	IsDev            bool
+
+	// Version is the app version stored in the generated backups manifest.
+	Version string
+
+	// DataReplicas is an optional list with read-only data db replicas
+	// (e.g. LiteFS or Litestream restored followers) that are used
+	// by the record list, view and expand API queries.
+	//
+	// The writes and transactions are always executed on the primary data db.
+	DataReplicas []string
+
+	// ReplicaDBConnect is the read-only connection initialization function of the data db replicas.
+	//
+	// Default to DefaultReplicaDBConnect.
+	ReplicaDBConnect DBConnectFunc
+
+	// ReplicaReadYourWritesWindow specifies for how long after a write the reads
+	// of the same session (auth token or guest ip) are routed to the primary data db.
+	//
+	// Default to DefaultReplicaReadYourWritesWindow.
+	ReplicaReadYourWritesWindow time.Duration
 }
 
 // ensures that the BaseApp implements the App interface.
@@ -72,6 +100,8 @@ type BaseApp struct {
 	txInfo              *txAppInfo
 	store               *store.Store[string, any]
 	cron                *cron.Cron
+	jobs                *jobQueue
+	backupStream        *backupStream
 	settings            *Settings
 	subscriptionsBroker *subscriptions.Broker
 	logger              *slog.Logger
@@ -79,6 +109,9 @@ type BaseApp struct {
 	nonconcurrentDB     dbx.Builder
 	auxConcurrentDB     dbx.Builder
 	auxNonconcurrentDB  dbx.Builder
+	replicaDBs          []dbx.Builder
+	replicaCounter      *atomic.Uint64
+	replicaWrites       *store.Store[string, time.Time]
 
 	// app event hooks
 	onBootstrap     *hook.Hook[*BootstrapEvent]
@@ -168,6 +201,8 @@ type BaseApp struct {
 	onRecordConfirmEmailChangeRequest   *hook.Hook[*RecordConfirmEmailChangeRequestEvent]
 	onRecordRequestOTPRequest           *hook.Hook[*RecordCreateOTPRequestEvent]
 	onRecordAuthWithOTPRequest          *hook.Hook[*RecordAuthWithOTPRequestEvent]
+	onRecordAuthWithWebAuthnRequest     *hook.Hook[*RecordAuthWithWebAuthnRequestEvent]
+	onRecordAuthWithTOTPRequest         *hook.Hook[*RecordAuthWithTOTPRequestEvent]
 
 	// record crud API event hooks
 	onRecordsListRequest  *hook.Hook[*RecordsListRequestEvent]
@@ -195,7 +230,11 @@ func NewBaseApp(config BaseAppConfig) *BaseApp {
 	app := &BaseApp{
 		settings:            newDefaultSettings(),
 		store:               store.New[string, any](nil),
+		replicaCounter:      new(atomic.Uint64),
+		replicaWrites:       store.New[string, time.Time](nil),
 		cron:                cron.New(),
+		jobs:                newJobQueue(),
+		backupStream:        newBackupStream(),
 		subscriptionsBroker: subscriptions.NewBroker(),
 		config:              &config,
 	}
@@ -204,6 +243,12 @@ func NewBaseApp(config BaseAppConfig) *BaseApp {
 	if app.config.DBConnect == nil {
 		app.config.DBConnect = DefaultDBConnect
 	}
+	if app.config.DBDialect == nil {
+		app.config.DBDialect = dbutils.SQLiteDialect{}
+	}
+	if app.config.ReplicaDBConnect == nil {
+		app.config.ReplicaDBConnect = DefaultReplicaDBConnect
+	}
 	if app.config.DataMaxOpenConns <= 0 {
 		app.config.DataMaxOpenConns = DefaultDataMaxOpenConns
 	}
@@ -219,6 +264,9 @@ func NewBaseApp(config BaseAppConfig) *BaseApp {
 	if app.config.QueryTimeout <= 0 {
 		app.config.QueryTimeout = DefaultQueryTimeout
 	}
+	if app.config.ReplicaReadYourWritesWindow <= 0 {
+		app.config.ReplicaReadYourWritesWindow = DefaultReplicaReadYourWritesWindow
+	}
 
 	app.initHooks()
 	app.registerBaseHooks()
@@ -316,6 +364,8 @@ func (app *BaseApp) initHooks() {
 	app.onRecordConfirmEmailChangeRequest = &hook.Hook[*RecordConfirmEmailChangeRequestEvent]{}
 	app.onRecordRequestOTPRequest = &hook.Hook[*RecordCreateOTPRequestEvent]{}
 	app.onRecordAuthWithOTPRequest = &hook.Hook[*RecordAuthWithOTPRequestEvent]{}
+	app.onRecordAuthWithWebAuthnRequest = &hook.Hook[*RecordAuthWithWebAuthnRequestEvent]{}
+	app.onRecordAuthWithTOTPRequest = &hook.Hook[*RecordAuthWithTOTPRequestEvent]{}
 
 	// record crud API event hooks
 	app.onRecordsListRequest = &hook.Hook[*RecordsListRequestEvent]{}
@@ -459,6 +509,15 @@ func (app *BaseApp) ResetBootstrapState() error {
 		*db = nil
 	}
 
+	for _, db := range app.replicaDBs {
+		if v, ok := db.(closer); ok {
+			if err := v.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	app.replicaDBs = nil
+
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
@@ -466,6 +525,11 @@ func (app *BaseApp) ResetBootstrapState() error {
 	return nil
 }
 
+// DBDialect returns the db engine specific SQL dialect of the app data and auxiliary dbs.
+func (app *BaseApp) DBDialect() dbutils.Dialect {
+	return app.config.DBDialect
+}
+
 // DB returns the default app data db instance (hb_data/data.db).
 func (app *BaseApp) DB() dbx.Builder {
 	return app.concurrentDB
@@ -1043,6 +1107,14 @@ func (app *BaseApp) OnRecordAuthWithOTPRequest(tags ...string) *hook.TaggedHook[
 	return hook.NewTaggedHook(app.onRecordAuthWithOTPRequest, tags...)
 }
 
+func (app *BaseApp) OnRecordAuthWithWebAuthnRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithWebAuthnRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithWebAuthnRequest, tags...)
+}
+
+func (app *BaseApp) OnRecordAuthWithTOTPRequest(tags ...string) *hook.TaggedHook[*RecordAuthWithTOTPRequestEvent] {
+	return hook.NewTaggedHook(app.onRecordAuthWithTOTPRequest, tags...)
+}
+
 // -------------------------------------------------------------------
 // Record CRUD API event hooks
 // -------------------------------------------------------------------
@@ -1136,7 +1208,7 @@ func (app *BaseApp) initDataDB() error {
 	app.concurrentDB = concurrentDB
 	app.nonconcurrentDB = nonconcurrentDB
 
-	return nil
+	return app.initReplicaDBs(concurrentDB)
 }
 
 var sqlLogReplacements = map[string]string{
@@ -1192,6 +1264,8 @@ func (app *BaseApp) initAuxDB() error {
 }
 
 func (app *BaseApp) registerBaseHooks() {
+	app.registerReplicaHooks()
+
 	deletePrefix := func(prefix string) error {
 		fs, err := app.NewFilesystem()
 		if err != nil {
@@ -1211,6 +1285,11 @@ func (app *BaseApp) registerBaseHooks() {
 	app.OnModelAfterDeleteSuccess().Bind(&hook.Handler[*ModelEvent]{
 		Id: "__pbFilesManagerDelete__",
 		Func: func(e *ModelEvent) error {
+			// keep the files of the soft deleted records
+			if r, ok := e.Model.(*Record); ok && r.isSoftDelete() {
+				return e.Next()
+			}
+
 			if m, ok := e.Model.(FilesManager); ok && m.BaseFilesPath() != "" {
 				// ensure that there is a trailing slash so that the list iterator could start walking from the prefix dir
 				// (https://github.com/hanzoai/backendPBcussions/5246#discussioncomment-10128955)
@@ -1244,32 +1323,57 @@ func (app *BaseApp) registerBaseHooks() {
 		Priority: 999,
 	})
 
-	app.Cron().Add("__pbDBOptimize__", "0 0 * * *", func() {
-		_, execErr := app.NonconcurrentDB().NewQuery("PRAGMA wal_checkpoint(TRUNCATE)").Execute()
-		if execErr != nil {
-			app.Logger().Warn("Failed to run periodic PRAGMA wal_checkpoint for the main DB", slog.String("error", execErr.Error()))
+	// persist the cron jobs run history
+	app.Cron().OnRun(func(run cron.Run) {
+		if !app.IsBootstrapped() {
+			return
 		}
 
-		_, execErr = app.AuxNonconcurrentDB().NewQuery("PRAGMA wal_checkpoint(TRUNCATE)").Execute()
-		if execErr != nil {
-			app.Logger().Warn("Failed to run periodic PRAGMA wal_checkpoint for the auxiliary DB", slog.String("error", execErr.Error()))
+		if err := app.saveCronRun(run); err != nil {
+			app.Logger().Warn("Failed to save cron job run", "jobId", run.JobId, "error", err)
 		}
+	})
+
+	app.Cron().Add("__pbDBOptimize__", "0 0 * * *", func() {
+		if checkpoint := app.DBDialect().CheckpointQuery(); checkpoint != "" {
+			_, execErr := app.NonconcurrentDB().NewQuery(checkpoint).Execute()
+			if execErr != nil {
+				app.Logger().Warn("Failed to run periodic wal checkpoint for the main DB", slog.String("error", execErr.Error()))
+			}
 
-		_, execErr = app.DB().NewQuery("PRAGMA optimize").Execute()
-		if execErr != nil {
-			app.Logger().Warn("Failed to run periodic PRAGMA optimize", slog.String("error", execErr.Error()))
+			_, execErr = app.AuxNonconcurrentDB().NewQuery(checkpoint).Execute()
+			if execErr != nil {
+				app.Logger().Warn("Failed to run periodic wal checkpoint for the auxiliary DB", slog.String("error", execErr.Error()))
+			}
+		}
+
+		if optimize := app.DBDialect().OptimizeQuery(); optimize != "" {
+			_, execErr := app.DB().NewQuery(optimize).Execute()
+			if execErr != nil {
+				app.Logger().Warn("Failed to run periodic db optimize", slog.String("error", execErr.Error()))
+			}
 		}
 	})
 
 	app.registerSettingsHooks()
 	app.registerAutobackupHooks()
+	app.registerBackupStreamHooks()
 	app.registerCollectionHooks()
 	app.registerRecordHooks()
+	app.registerSoftDeleteHooks()
+	app.registerRevisionHooks()
+	app.registerWebhookHooks()
+	app.registerOIDCHooks()
+	app.registerJobHooks()
 	app.registerSuperuserHooks()
 	app.registerExternalAuthHooks()
 	app.registerMFAHooks()
 	app.registerOTPHooks()
+	app.registerWebAuthnCredentialHooks()
+	app.registerWebAuthnChallengeHooks()
+	app.registerTOTPHooks()
 	app.registerAuthOriginHooks()
+	app.registerAuthSessionHooks()
 }
 
 // getLoggerMinLevel returns the logger min level based on the
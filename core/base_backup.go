@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"time"
 
@@ -78,10 +79,12 @@ func (app *BaseApp) CreateBackup(ctx context.Context, name string) error {
 			return txApp.AuxRunInTransaction(func(txApp App) error {
 				// run manual checkpoint and truncate the WAL files
 				// (errors are ignored because it is not that important and the PRAGMA may not be supported by the used driver)
-				txApp.DB().NewQuery("PRAGMA wal_checkpoint(TRUNCATE)").Execute()
-				txApp.AuxDB().NewQuery("PRAGMA wal_checkpoint(TRUNCATE)").Execute()
+				if checkpoint := txApp.DBDialect().CheckpointQuery(); checkpoint != "" {
+					txApp.DB().NewQuery(checkpoint).Execute()
+					txApp.AuxDB().NewQuery(checkpoint).Execute()
+				}
 
-				return archive.Create(txApp.DataDir(), tempPath, e.Exclude...)
+				return archive.CreateWithManifest(txApp.DataDir(), tempPath, app.backupManifestMeta(txApp), e.Exclude...)
 			})
 		})
 		if createErr != nil {
@@ -89,6 +92,18 @@ func (app *BaseApp) CreateBackup(ctx context.Context, name string) error {
 		}
 		defer os.Remove(tempPath)
 
+		// encrypt the archive (if configured)
+		if key := e.App.Settings().Backups.EncryptionKey; key != "" {
+			encryptedPath := tempPath + "_encrypted"
+			defer os.Remove(encryptedPath)
+
+			if err := encryptBackupArchive(tempPath, encryptedPath, key); err != nil {
+				return fmt.Errorf("failed to encrypt the backup: %w", err)
+			}
+
+			tempPath = encryptedPath
+		}
+
 		// persist the backup in the backups filesystem
 		// ---
 		fsys, err := e.App.NewBackupsFilesystem()
@@ -147,6 +162,33 @@ func (app *BaseApp) CreateBackup(ctx context.Context, name string) error {
 // it is possible the restore to fail during the `os.Rename` operations
 // (see https://github.com/hanzoai/backendPBues/4647).
 func (app *BaseApp) RestoreBackup(ctx context.Context, name string) error {
+	return app.restoreBackup(ctx, name, time.Time{})
+}
+
+// RestoreBackupPointInTime restores the data db to its state at the
+// specified time from the continuous backup stream (see [BaseApp.SyncBackupStream])
+// and restarts the current running application process.
+//
+// The restore follows the same steps as [BaseApp.RestoreBackup] but
+// only the "data.db" files of hb_data are replaced.
+//
+// NB! The auxiliary db (hb_data/auxiliary.db) and the uploaded files are
+// not part of the backup stream and they are left unchanged, aka. they
+// will contain their latest state and not the one at pointInTime.
+// If you need them restored too, use a regular backup with [BaseApp.RestoreBackup].
+func (app *BaseApp) RestoreBackupPointInTime(ctx context.Context, pointInTime time.Time) error {
+	if pointInTime.IsZero() {
+		return errors.New("missing point in time to restore")
+	}
+
+	return app.restoreBackup(ctx, StreamBackupsPrefix+pointInTime.UTC().Format(streamBackupTimeFormat), pointInTime)
+}
+
+// restoreBackup implements [BaseApp.RestoreBackup] and [BaseApp.RestoreBackupPointInTime].
+//
+// If pointInTime is not zero, the data db is reconstructed from the backup stream
+// and name is used only as identifier in the backup hooks.
+func (app *BaseApp) restoreBackup(ctx context.Context, name string, pointInTime time.Time) error {
 	if app.Store().Has(StoreKeyActiveBackup) {
 		return errors.New("try again later - another backup/restore operation has already been started")
 	}
@@ -158,6 +200,7 @@ func (app *BaseApp) RestoreBackup(ctx context.Context, name string) error {
 	event.App = app
 	event.Context = ctx
 	event.Name = name
+	event.PointInTime = pointInTime
 	// default root dir entries to exclude from the backup restore
 	event.Exclude = []string{LocalBackupsDirName, LocalTempDirName, LocalAutocertCacheDirName}
 
@@ -181,15 +224,33 @@ func (app *BaseApp) RestoreBackup(ctx context.Context, name string) error {
 
 		fsys.SetContext(e.Context)
 
-		if ok, _ := fsys.Exists(name); !ok {
-			return fmt.Errorf("missing or invalid backup file %q to restore", name)
-		}
-
 		extractedDataDir := filepath.Join(localTempDir, "hb_restore_"+security.PseudorandomString(8))
 		defer os.RemoveAll(extractedDataDir)
 
-		// extract the zip
-		if e.App.Settings().Backups.S3.Enabled {
+		exclude := e.Exclude
+
+		if !e.PointInTime.IsZero() {
+			// reconstruct the data db from the backup stream
+			if err := restoreBackupStream(fsys, e.PointInTime, extractedDataDir); err != nil {
+				return err
+			}
+
+			// replace only the data db files
+			entries, err := os.ReadDir(e.App.DataDir())
+			if err != nil {
+				return err
+			}
+			dataFiles := []string{"data.db", "data.db-wal", "data.db-shm", "data.db-journal"}
+			exclude = append([]string{}, exclude...)
+			for _, entry := range entries {
+				if !slices.Contains(dataFiles, entry.Name()) {
+					exclude = append(exclude, entry.Name())
+				}
+			}
+		} else if ok, _ := fsys.Exists(name); !ok {
+			return fmt.Errorf("missing or invalid backup file %q to restore", name)
+		} else if e.App.Settings().Backups.S3.Enabled {
+			// extract the zip
 			br, err := fsys.GetFile(name)
 			if err != nil {
 				return err
@@ -209,7 +270,7 @@ func (app *BaseApp) RestoreBackup(ctx context.Context, name string) error {
 				return err
 			}
 
-			err = archive.Extract(tempZip.Name(), extractedDataDir)
+			err = extractBackupArchive(e.App, tempZip.Name(), extractedDataDir, localTempDir)
 			if err != nil {
 				return err
 			}
@@ -230,7 +291,7 @@ func (app *BaseApp) RestoreBackup(ctx context.Context, name string) error {
 			// since the blob reader currently doesn't implement ReaderAt
 			zipPath := filepath.Join(app.DataDir(), LocalBackupsDirName, filepath.Base(name))
 
-			err = archive.Extract(zipPath, extractedDataDir)
+			err = extractBackupArchive(e.App, zipPath, extractedDataDir, localTempDir)
 			if err != nil {
 				return err
 			}
@@ -246,21 +307,21 @@ func (app *BaseApp) RestoreBackup(ctx context.Context, name string) error {
 		// that will hold the old data between dirs replace
 		// (the temp dir will be automatically removed on the next app start)
 		oldTempDataDir := filepath.Join(localTempDir, "old_hb_data_"+security.PseudorandomString(8))
-		if err := osutils.MoveDirContent(e.App.DataDir(), oldTempDataDir, e.Exclude...); err != nil {
+		if err := osutils.MoveDirContent(e.App.DataDir(), oldTempDataDir, exclude...); err != nil {
 			return fmt.Errorf("failed to move the current hb_data content to a temp location: %w", err)
 		}
 
 		// move the extracted archive content to the app's hb_data
-		if err := osutils.MoveDirContent(extractedDataDir, e.App.DataDir(), e.Exclude...); err != nil {
+		if err := osutils.MoveDirContent(extractedDataDir, e.App.DataDir(), exclude...); err != nil {
 			return fmt.Errorf("failed to move the extracted archive content to hb_data: %w", err)
 		}
 
 		revertDataDirChanges := func() error {
-			if err := osutils.MoveDirContent(e.App.DataDir(), extractedDataDir, e.Exclude...); err != nil {
+			if err := osutils.MoveDirContent(e.App.DataDir(), extractedDataDir, exclude...); err != nil {
 				return fmt.Errorf("failed to revert the extracted dir change: %w", err)
 			}
 
-			if err := osutils.MoveDirContent(oldTempDataDir, e.App.DataDir(), e.Exclude...); err != nil {
+			if err := osutils.MoveDirContent(oldTempDataDir, e.App.DataDir(), exclude...); err != nil {
 				return fmt.Errorf("failed to revert old hb_data dir change: %w", err)
 			}
 
@@ -280,6 +341,111 @@ func (app *BaseApp) RestoreBackup(ctx context.Context, name string) error {
 	})
 }
 
+// backupManifestMeta returns the meta information stored in the backup archive manifest.
+func (app *BaseApp) backupManifestMeta(txApp App) map[string]string {
+	meta := map[string]string{
+		"version": app.config.Version,
+		"created": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	// the last applied system migration is used to detect backups from newer app versions
+	last, err := NewMigrationsRunner(txApp, SystemMigrations).lastAppliedMigrations(1)
+	if err == nil && len(last) > 0 {
+		meta["migration"] = last[0]
+	}
+
+	return meta
+}
+
+// encryptBackupArchive encrypts the src backup archive and saves it in dest.
+func encryptBackupArchive(src string, dest string, key string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	destFile, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(security.EncryptStream(destFile, srcFile, key), destFile.Close())
+}
+
+// extractBackupArchive extracts the (optionally encrypted) backup archive
+// at src into dest and verifies its content with the archive manifest.
+func extractBackupArchive(app App, src string, dest string, tempDir string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, 16)
+	n, _ := io.ReadFull(f, header)
+	isEncrypted := security.IsEncryptedStream(header[:n])
+
+	key := app.Settings().Backups.EncryptionKey
+
+	// with configured encryption key accept only encrypted (and therefore verifiable) backups
+	// so that a replaced plain archive couldn't be restored
+	if !isEncrypted && key != "" {
+		return errors.New("the backup is not encrypted but a backups encryption key is configured")
+	}
+
+	if isEncrypted {
+		if key == "" {
+			return errors.New("the backup is encrypted but there is no backups encryption key configured")
+		}
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		decrypted, err := os.CreateTemp(tempDir, "hb_restore_decrypted")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(decrypted.Name())
+		defer decrypted.Close()
+
+		if err := security.DecryptStream(decrypted, f, key); err != nil {
+			return fmt.Errorf("failed to decrypt the backup: %w", err)
+		}
+
+		if err := decrypted.Close(); err != nil {
+			return err
+		}
+
+		src = decrypted.Name()
+	}
+
+	if err := archive.Extract(src, dest); err != nil {
+		return err
+	}
+
+	manifest, err := archive.VerifyManifest(dest)
+	if err != nil {
+		// allow restoring plain backups created before the manifest introduction
+		// (only when there is no encryption key configured)
+		if errors.Is(err, archive.ErrMissingManifest) && !isEncrypted && key == "" {
+			app.Logger().Warn("[RestoreBackup] The backup doesn't have a manifest and its integrity can't be verified")
+			return nil
+		}
+
+		return fmt.Errorf("the backup integrity check failed: %w", err)
+	}
+
+	if migration := manifest.Meta["migration"]; migration != "" && !slices.ContainsFunc(SystemMigrations.Items(), func(m *Migration) bool {
+		return m.File == migration
+	}) {
+		return fmt.Errorf("the backup was created with a newer or different app version (%s)", manifest.Meta["version"])
+	}
+
+	return os.Remove(filepath.Join(dest, archive.ManifestName))
+}
+
 // registerAutobackupHooks registers the autobackup app serve hooks.
 func (app *BaseApp) registerAutobackupHooks() {
 	const jobId = "__pbAutoBackup__"
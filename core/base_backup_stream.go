@@ -0,0 +1,447 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/archive"
+	"github.com/hanzoai/backendPB/tools/dbutils"
+	"github.com/hanzoai/backendPB/tools/filesystem"
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/dbx"
+)
+
+// StreamBackupsPrefix is the backups filesystem key prefix of the continuous backup files.
+//
+// The files are stored as "@stream/{generation}/{time}.hbpd", where each generation
+// starts with a full data db copy followed by the changed db pages since the previous sync.
+const StreamBackupsPrefix = "@stream/"
+
+const (
+	streamBackupTickInterval = 1 * time.Second
+
+	// streamBackupTimeFormat is a fixed width (aka. lexicographically sortable) time format.
+	streamBackupTimeFormat = "20060102150405.000000000"
+
+	streamBackupExt = ".hbpd"
+
+	// maxStreamBackupSegments is the number of generation files after
+	// which a new generation (with a full data db copy) is started.
+	maxStreamBackupSegments = 1000
+
+	// maxBackupStreamBusySkips is the number of consecutive skipped (busy)
+	// syncs after which the sync is forced.
+	maxBackupStreamBusySkips = 10
+)
+
+// errBackupStreamBusy is returned when the data db couldn't be synced
+// at the moment and the operation should be retried later.
+var errBackupStreamBusy = errors.New("try again later - the backup stream is busy")
+
+// backupStream holds the continuous backup sync state and worker.
+type backupStream struct {
+	// the last synced db state
+	mu         sync.Mutex
+	generation string
+	segments   int
+	hashes     []archive.PageHash
+
+	workerMu sync.Mutex
+	wg       sync.WaitGroup
+	cancel   context.CancelFunc
+}
+
+func newBackupStream() *backupStream {
+	return &backupStream{}
+}
+
+// SyncBackupStream uploads to the backups filesystem the data db
+// pages changed since the last sync (or a full data db copy if this is
+// the first sync of the current process).
+//
+// It is called periodically when app.Settings().Backups.Stream is enabled,
+// but it could be also called manually (e.g. before a risky operation).
+//
+// The changes are collected from a consistent data db snapshot
+// (created with the SQLite online backup API), meaning that the
+// concurrent writes are not blocked while the db pages are compared.
+//
+// NB! The continuous backup covers only the data db (hb_data/data.db).
+// The uploaded files and the auxiliary db should be backed up separately
+// (e.g. with the regular backups or by using S3 storage).
+func (app *BaseApp) SyncBackupStream(ctx context.Context) error {
+	return app.syncBackupStream(ctx, false)
+}
+
+// syncBackupStream implements [BaseApp.SyncBackupStream].
+//
+// If force is set, the sync is executed even if there is an active backup operation.
+func (app *BaseApp) syncBackupStream(ctx context.Context, force bool) error {
+	if app.DBDialect().Name() != dbutils.DialectSQLite {
+		return errors.New("the backup stream is supported only with SQLite")
+	}
+
+	if !force && app.Store().Has(StoreKeyActiveBackup) {
+		return errBackupStreamBusy
+	}
+
+	db, ok := app.DB().(*dbx.DB)
+	if !ok {
+		return errors.New("the backup stream can't be synced within a transaction")
+	}
+
+	s := app.backupStream
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// make sure that the special temp directory exists
+	// note: it needs to be inside the current hb_data to avoid "cross-device link" errors
+	localTempDir := filepath.Join(app.DataDir(), LocalTempDirName)
+	if err := os.MkdirAll(localTempDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create a temp dir: %w", err)
+	}
+
+	tempPath := filepath.Join(localTempDir, "hb_stream_"+security.PseudorandomString(6))
+	defer os.Remove(tempPath)
+
+	snapshotPath := tempPath + "_snapshot"
+	defer func() {
+		for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+			os.Remove(snapshotPath + suffix)
+		}
+	}()
+
+	isNewGeneration := s.generation == "" || s.segments >= maxStreamBackupSegments
+
+	prevHashes := s.hashes
+	if isNewGeneration {
+		prevHashes = nil
+	}
+
+	// create a point-in-time copy of the data db
+	if err := snapshotDB(ctx, db, snapshotPath); err != nil {
+		return fmt.Errorf("failed to create a data db snapshot: %w", err)
+	}
+	syncTime := time.Now().UTC()
+
+	var pageSize int
+	if err := db.NewQuery("PRAGMA page_size").WithContext(ctx).Row(&pageSize); err != nil {
+		return err
+	}
+
+	// collect the changed pages from the snapshot
+	// (outside of any transaction so that the writes are not blocked)
+	hashes, changed, err := writeBackupStreamDiff(tempPath, snapshotPath, pageSize, prevHashes)
+	if err != nil {
+		return err
+	}
+
+	if !isNewGeneration && changed == 0 && len(hashes) == len(prevHashes) {
+		return nil // no changes
+	}
+
+	generation := s.generation
+	if isNewGeneration {
+		generation = syncTime.Format(streamBackupTimeFormat)
+	}
+
+	// persist the changes in the backups filesystem
+	// ---
+	fsys, err := app.NewBackupsFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	fsys.SetContext(ctx)
+
+	file, err := filesystem.NewFileFromPath(tempPath)
+	if err != nil {
+		return err
+	}
+	file.Name = StreamBackupsPrefix + generation + "/" + syncTime.Format(streamBackupTimeFormat) + streamBackupExt
+	file.OriginalName = filepath.Base(file.Name)
+
+	if err := fsys.UploadFile(file, file.Name); err != nil {
+		return err
+	}
+
+	s.hashes = hashes
+	s.segments++
+	if isNewGeneration {
+		s.generation = generation
+		s.segments = 1
+
+		app.deleteOldBackupStreamGenerations(fsys)
+	}
+
+	return nil
+}
+
+// writeBackupStreamDiff writes in dstPath the srcPath db pages
+// that are different from prevHashes.
+func writeBackupStreamDiff(dstPath string, srcPath string, pageSize int, prevHashes []archive.PageHash) ([]archive.PageHash, int, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hashes, changed, err := archive.WritePageDiff(dst, src, pageSize, prevHashes)
+
+	return hashes, changed, errors.Join(err, dst.Close())
+}
+
+// vacuumInto creates a compacted copy of the db at dstPath.
+func vacuumInto(ctx context.Context, db dbx.Builder, dstPath string) error {
+	_, err := db.NewQuery("VACUUM INTO {:path}").
+		WithContext(ctx).
+		Bind(dbx.Params{"path": dstPath}).
+		Execute()
+
+	return err
+}
+
+// deleteOldBackupStreamGenerations removes the generations exceeding
+// app.Settings().Backups.Stream.MaxKeep (if set).
+func (app *BaseApp) deleteOldBackupStreamGenerations(fsys *filesystem.System) {
+	maxKeep := app.Settings().Backups.Stream.MaxKeep
+	if maxKeep <= 0 {
+		return // no explicit limit
+	}
+
+	files, err := fsys.List(StreamBackupsPrefix)
+	if err != nil {
+		app.Logger().Error(
+			"[Backup stream] Failed to list the stream generations",
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	generations := []string{}
+	for _, f := range files {
+		generation, _, ok := strings.Cut(strings.TrimPrefix(f.Key, StreamBackupsPrefix), "/")
+		if ok && (len(generations) == 0 || generations[len(generations)-1] != generation) {
+			generations = append(generations, generation)
+		}
+	}
+
+	if maxKeep >= len(generations) {
+		return // nothing to remove
+	}
+
+	// sort desc
+	sort.Sort(sort.Reverse(sort.StringSlice(generations)))
+
+	for _, generation := range generations[maxKeep:] {
+		if errs := fsys.DeletePrefix(StreamBackupsPrefix + generation + "/"); len(errs) > 0 {
+			app.Logger().Error(
+				"[Backup stream] Failed to remove old stream generation",
+				slog.String("generation", generation),
+				slog.String("error", errors.Join(errs...).Error()),
+			)
+		}
+	}
+}
+
+// restoreBackupStream reconstructs in destDir the data db state
+// at the specified point in time from the stream generation files.
+func restoreBackupStream(fsys *filesystem.System, pointInTime time.Time, destDir string) error {
+	files, err := fsys.List(StreamBackupsPrefix)
+	if err != nil {
+		return err
+	}
+
+	target := pointInTime.UTC().Format(streamBackupTimeFormat)
+
+	// find the latest generation with files before the target time
+	var generation string
+	keys := map[string][]string{}
+	for _, f := range files {
+		gen, name, ok := strings.Cut(strings.TrimPrefix(f.Key, StreamBackupsPrefix), "/")
+		if !ok || !strings.HasSuffix(name, streamBackupExt) || strings.TrimSuffix(name, streamBackupExt) > target {
+			continue
+		}
+
+		keys[gen] = append(keys[gen], f.Key)
+
+		if gen > generation {
+			generation = gen
+		}
+	}
+
+	if generation == "" {
+		return fmt.Errorf("no stream backup available for %s", pointInTime.UTC().Format(time.RFC3339))
+	}
+
+	sort.Strings(keys[generation])
+
+	// the generation must start with the full data db copy
+	if keys[generation][0] != StreamBackupsPrefix+generation+"/"+generation+streamBackupExt {
+		return fmt.Errorf("the stream backup generation %q is incomplete", generation)
+	}
+
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(filepath.Join(destDir, "data.db"))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	for _, key := range keys[generation] {
+		br, err := fsys.GetFile(key)
+		if err != nil {
+			return err
+		}
+
+		err = archive.ApplyPageDiff(dst, br)
+		br.Close()
+		if err != nil {
+			return fmt.Errorf("failed to apply %q: %w", key, err)
+		}
+	}
+
+	return dst.Close()
+}
+
+// registerBackupStreamHooks registers the continuous backup app serve hooks.
+func (app *BaseApp) registerBackupStreamHooks() {
+	app.OnServe().Bind(&hook.Handler[*ServeEvent]{
+		Id: "__pbBackupStreamStart__",
+		Func: func(e *ServeEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			app.startBackupStreamWorker()
+
+			return nil
+		},
+		Priority: 999,
+	})
+
+	// sync the last changes before closing the db connections
+	app.OnTerminate().Bind(&hook.Handler[*TerminateEvent]{
+		Id: "__pbBackupStreamStop__",
+		Func: func(e *TerminateEvent) error {
+			if app.stopBackupStreamWorker() && app.Settings().Backups.Stream.Enabled {
+				if err := app.SyncBackupStream(context.Background()); err != nil {
+					app.Logger().Warn("[Backup stream] Failed to sync the last changes", slog.String("error", err.Error()))
+				}
+			}
+
+			return e.Next()
+		},
+		Priority: -997,
+	})
+}
+
+func (app *BaseApp) startBackupStreamWorker() {
+	s := app.backupStream
+
+	s.workerMu.Lock()
+	defer s.workerMu.Unlock()
+
+	if s.cancel != nil {
+		return // already started
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		app.runBackupStreamWorker(ctx)
+	}()
+}
+
+// stopBackupStreamWorker stops the backup stream worker
+// and reports whether it was running.
+func (app *BaseApp) stopBackupStreamWorker() bool {
+	s := app.backupStream
+
+	s.workerMu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.workerMu.Unlock()
+
+	if cancel == nil {
+		return false // not started
+	}
+
+	cancel()
+
+	s.wg.Wait()
+
+	return true
+}
+
+func (app *BaseApp) runBackupStreamWorker(ctx context.Context) {
+	ticker := time.NewTicker(streamBackupTickInterval)
+	defer ticker.Stop()
+
+	var lastSync time.Time
+	var busySkips int
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// the settings are checked on each tick so that changes are applied without restart
+		config := app.Settings().Backups.Stream
+		if !config.Enabled || time.Since(lastSync) < time.Duration(config.Interval)*time.Second {
+			continue
+		}
+
+		lastSync = time.Now()
+
+		// the sync reads from a db snapshot and it is safe to run alongside the
+		// other backup operations but we still give them a chance to complete first
+		force := busySkips >= maxBackupStreamBusySkips
+		if force {
+			app.Logger().Warn(
+				"[Backup stream] Forcing sync after too many skipped attempts",
+				slog.Int("skips", busySkips),
+			)
+		}
+
+		err := app.syncBackupStream(ctx, force)
+		switch {
+		case err == nil:
+			busySkips = 0
+		case errors.Is(err, errBackupStreamBusy):
+			busySkips++
+			app.Logger().Debug(
+				"[Backup stream] Sync skipped because of an active backup operation",
+				slog.Int("skips", busySkips),
+			)
+		case errors.Is(err, context.Canceled):
+			// the worker is stopping
+		default:
+			app.Logger().Error("[Backup stream] Failed to sync the data db changes", slog.String("error", err.Error()))
+		}
+	}
+}
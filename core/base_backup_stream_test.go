@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupStream(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{
+		DataDir: t.TempDir(),
+	})
+	defer app.ResetBootstrapState()
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := app.NewBackupsFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	countFiles := func(t *testing.T) int {
+		files, err := fsys.List(StreamBackupsPrefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(files)
+	}
+
+	sync := func(t *testing.T) time.Time {
+		if err := app.SyncBackupStream(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return time.Now()
+	}
+
+	exec := func(t *testing.T, query string) {
+		if _, err := app.NonconcurrentDB().NewQuery(query).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	beforeAll := time.Now()
+
+	exec(t, "CREATE TABLE stream_test (name TEXT)")
+	t0 := sync(t)
+
+	exec(t, "INSERT INTO stream_test (name) VALUES ('a')")
+	t1 := sync(t)
+
+	// no changes
+	sync(t)
+	if total := countFiles(t); total != 2 {
+		t.Fatalf("Expected 2 stream files, got %d", total)
+	}
+
+	exec(t, "INSERT INTO stream_test (name) VALUES ('b')")
+	t2 := sync(t)
+
+	// start a new generation
+	app.Settings().Backups.Stream.MaxKeep = 2
+	app.backupStream.segments = maxStreamBackupSegments
+	exec(t, "INSERT INTO stream_test (name) VALUES ('c')")
+	t3 := sync(t)
+
+	if total := countFiles(t); total != 4 {
+		t.Fatalf("Expected 4 stream files, got %d", total)
+	}
+
+	scenarios := []struct {
+		name          string
+		pointInTime   time.Time
+		expectError   bool
+		expectedTotal int
+	}{
+		{"before the first sync", beforeAll, true, 0},
+		{"after the table creation", t0, false, 0},
+		{"after the first insert", t1, false, 1},
+		{"after the second insert", t2, false, 2},
+		{"new generation", t3, false, 3},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			err := restoreBackupStream(fsys, s.pointInTime, dir)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			db, err := DefaultDBConnect(filepath.Join(dir, "data.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			var total int
+			if err := db.NewQuery("SELECT count(*) FROM stream_test").Row(&total); err != nil {
+				t.Fatal(err)
+			}
+
+			if total != s.expectedTotal {
+				t.Fatalf("Expected %d rows, got %d", s.expectedTotal, total)
+			}
+		})
+	}
+
+	// exceed the max kept generations
+	app.Settings().Backups.Stream.MaxKeep = 1
+	app.backupStream.segments = maxStreamBackupSegments
+	t4 := sync(t)
+
+	if total := countFiles(t); total != 1 {
+		t.Fatalf("Expected only the last generation file, got %d", total)
+	}
+
+	if err := restoreBackupStream(fsys, t2, t.TempDir()); err == nil {
+		t.Fatal("Expected the old generation to be deleted")
+	}
+
+	if err := restoreBackupStream(fsys, t4, t.TempDir()); err != nil {
+		t.Fatalf("Expected the last generation to be restorable, got %v", err)
+	}
+}
+
+func TestBackupStreamActiveBackup(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{
+		DataDir: t.TempDir(),
+	})
+	defer app.ResetBootstrapState()
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Store().Set(StoreKeyActiveBackup, "test")
+	defer app.Store().Remove(StoreKeyActiveBackup)
+
+	if err := app.SyncBackupStream(context.Background()); !errors.Is(err, errBackupStreamBusy) {
+		t.Fatalf("Expected errBackupStreamBusy, got %v", err)
+	}
+
+	// the forced sync shouldn't be affected by the active backup
+	// since the changes are collected from a db snapshot
+	if err := app.syncBackupStream(context.Background(), true); err != nil {
+		t.Fatalf("Expected the forced sync to succeed, got %v", err)
+	}
+
+	if app.backupStream.generation == "" {
+		t.Fatal("Expected a new stream generation to be started")
+	}
+}
+
+func TestRestoreBackupPointInTimeZero(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{
+		DataDir: t.TempDir(),
+	})
+
+	if err := app.RestoreBackupPointInTime(context.Background(), time.Time{}); err == nil {
+		t.Fatal("Expected error for zero point in time")
+	}
+}
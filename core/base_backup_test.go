@@ -1,11 +1,13 @@
 package core_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/hanzoai/backendPB/tests"
 	"github.com/hanzoai/backendPB/tools/archive"
 	"github.com/hanzoai/backendPB/tools/list"
+	"github.com/hanzoai/backendPB/tools/security"
 )
 
 func TestCreateBackup(t *testing.T) {
@@ -110,6 +113,104 @@ func TestRestoreBackup(t *testing.T) {
 	}
 }
 
+func TestRestoreBackupVerification(t *testing.T) {
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	backupsDir := filepath.Join(app.DataDir(), core.LocalBackupsDirName)
+
+	// encrypted backups
+	// ---
+	const encryptionKey = "test_encryption_key"
+
+	app.Settings().Backups.EncryptionKey = encryptionKey
+
+	if err := app.CreateBackup(context.Background(), "encrypted.zip"); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := os.ReadFile(filepath.Join(backupsDir, "encrypted.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !security.IsEncryptedStream(encrypted) {
+		t.Fatal("Expected the backup to be encrypted")
+	}
+
+	tampered := bytes.Clone(encrypted)
+	tampered[len(tampered)/2] ^= 1
+	if err := os.WriteFile(filepath.Join(backupsDir, "tampered.zip"), tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// plain backups with modified content
+	// ---
+	app.Settings().Backups.EncryptionKey = ""
+
+	if err := app.CreateBackup(context.Background(), "plain.zip"); err != nil {
+		t.Fatal(err)
+	}
+
+	extractedDir := t.TempDir()
+	if err := archive.Extract(filepath.Join(backupsDir, "plain.zip"), extractedDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(extractedDir, "storage", "new.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Create(extractedDir, filepath.Join(backupsDir, "modified.zip")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(extractedDir, "storage", "new.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	manifestPath := filepath.Join(extractedDir, archive.ManifestName)
+	rawManifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	futureManifest := regexp.MustCompile(`"migration":"[^"]+"`).ReplaceAll(rawManifest, []byte(`"migration":"9999999999_future.go"`))
+	if err := os.WriteFile(manifestPath, futureManifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Create(extractedDir, filepath.Join(backupsDir, "future.zip")); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name          string
+		backup        string
+		encryptionKey string
+		expectedError string
+	}{
+		{"encrypted backup without key", "encrypted.zip", "", "no backups encryption key"},
+		{"encrypted backup with wrong key", "encrypted.zip", "another_encryption_key", "failed to decrypt"},
+		{"tampered encrypted backup", "tampered.zip", encryptionKey, "failed to decrypt"},
+		{"plain backup with configured key", "plain.zip", encryptionKey, "not encrypted"},
+		{"backup with modified content", "modified.zip", "", "integrity check failed"},
+		{"backup from newer app version", "future.zip", "", "newer or different app version"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			app.Settings().Backups.EncryptionKey = s.encryptionKey
+
+			err := app.RestoreBackup(context.Background(), s.backup)
+			if err == nil || !strings.Contains(err.Error(), s.expectedError) {
+				t.Fatalf("Expected error containing %q, got %v", s.expectedError, err)
+			}
+
+			// the current data dir should remain unchanged
+			if _, err := os.Stat(filepath.Join(app.DataDir(), "data.db")); err != nil {
+				t.Fatalf("Expected the current data.db to be kept, got %v", err)
+			}
+		})
+	}
+}
+
 // -------------------------------------------------------------------
 
 func verifyBackupContent(app core.App, path string) error {
@@ -132,6 +233,7 @@ func verifyBackupContent(app core.App, path string) error {
 		"auxiliary.db-shm",
 		"auxiliary.db-wal",
 		".gitignore",
+		`\.manifest\.json`,
 	}
 
 	entries, err := os.ReadDir(dir)
@@ -12,6 +12,7 @@ import (
 
 	"github.com/hanzoai/backendPB/core"
 	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/dbutils"
 	"github.com/hanzoai/backendPB/tools/logger"
 	"github.com/hanzoai/backendPB/tools/mailer"
 	"github.com/hanzoai/dbx"
@@ -54,6 +55,10 @@ func TestNewBaseApp(t *testing.T) {
 	if app.Cron() == nil {
 		t.Fatal("expected Cron to be set, got nil")
 	}
+
+	if name := app.DBDialect().Name(); name != dbutils.DialectSQLite {
+		t.Fatalf("expected the default DBDialect to be %q, got %q", dbutils.DialectSQLite, name)
+	}
 }
 
 func TestBaseAppBootstrap(t *testing.T) {
@@ -0,0 +1,462 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/archive"
+	"github.com/hanzoai/backendPB/tools/filesystem"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/dbx"
+	"github.com/spf13/cast"
+)
+
+const (
+	// collectionsArchiveType is the manifest type of the archives
+	// created with [BaseApp.ExportCollectionsArchive].
+	collectionsArchiveType = "collections"
+
+	collectionsArchiveSchemaFile = "collections.json"
+	collectionsArchiveRecordsDir = "records"
+	collectionsArchiveFilesDir   = "files"
+
+	collectionsArchiveBatchSize = 500
+)
+
+// CollectionsArchiveImportResult defines the result of a collections archive import.
+type CollectionsArchiveImportResult struct {
+	// Collections is a list with the names of the imported collections.
+	Collections []string `json:"collections"`
+
+	// Conflicts lists the archive records that were skipped
+	// because they already exist or violate a unique constraint.
+	Conflicts []CollectionsArchiveConflict `json:"conflicts"`
+
+	// Records is the total number of the inserted records.
+	Records int `json:"records"`
+
+	// Files is the total number of the uploaded record files.
+	Files int `json:"files"`
+}
+
+// CollectionsArchiveConflict defines a single skipped archive record.
+type CollectionsArchiveConflict struct {
+	Collection string `json:"collection"`
+	RecordId   string `json:"recordId"`
+	Message    string `json:"message"`
+}
+
+// ExportCollectionsArchive creates a zip archive at dest with the schema,
+// records and files of the specified collections.
+//
+// The archive could be restored in another app instance with [BaseApp.ImportCollectionsArchive].
+//
+// The records are exported within a transaction, meaning that new writes
+// will be temporary "blocked" until all records are exported.
+//
+// Note that the view collections are exported without records and that the
+// collections referenced in the relation fields are expected to be also part
+// of the archive or to already exist in the import app instance.
+func (app *BaseApp) ExportCollectionsArchive(ctx context.Context, dest string, collectionNamesOrIds ...string) error {
+	if len(collectionNamesOrIds) == 0 {
+		return errors.New("no collections to export")
+	}
+
+	// make sure that the special temp directory exists
+	// note: it needs to be inside the current hb_data to avoid "cross-device link" errors
+	localTempDir := filepath.Join(app.DataDir(), LocalTempDirName)
+	if err := os.MkdirAll(localTempDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create a temp dir: %w", err)
+	}
+
+	exportDir := filepath.Join(localTempDir, "hb_export_"+security.PseudorandomString(8))
+	defer os.RemoveAll(exportDir)
+
+	recordsDir := filepath.Join(exportDir, collectionsArchiveRecordsDir)
+	if err := os.MkdirAll(recordsDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	collections := make([]*Collection, 0, len(collectionNamesOrIds))
+
+	err := app.RunInTransaction(func(txApp App) error {
+		for _, nameOrId := range collectionNamesOrIds {
+			collection, err := txApp.FindCollectionByNameOrId(nameOrId)
+			if err != nil {
+				return fmt.Errorf("failed to find collection %q: %w", nameOrId, err)
+			}
+
+			collections = append(collections, collection)
+
+			if collection.IsView() {
+				continue
+			}
+
+			recordsPath := filepath.Join(recordsDir, collection.Id+".ndjson")
+			if err := exportCollectionRecords(txApp, collection, recordsPath); err != nil {
+				return fmt.Errorf("failed to export %q records: %w", collection.Name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rawCollections, err := json.Marshal(collections)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(exportDir, collectionsArchiveSchemaFile), rawCollections, 0644); err != nil {
+		return err
+	}
+
+	// copy the collections files (excluding the thumbs)
+	// ---
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	fsys.SetContext(ctx)
+
+	for _, collection := range collections {
+		if collection.IsView() {
+			continue
+		}
+
+		files, err := fsys.List(collection.BaseFilesPath() + "/")
+		if err != nil {
+			return err
+		}
+
+		for _, f := range files {
+			if strings.Contains(f.Key, "/thumbs_") {
+				continue
+			}
+
+			if err := downloadFile(fsys, f.Key, filepath.Join(exportDir, collectionsArchiveFilesDir, filepath.FromSlash(f.Key))); err != nil {
+				return fmt.Errorf("failed to export file %q: %w", f.Key, err)
+			}
+		}
+	}
+
+	meta := map[string]string{
+		"type":    collectionsArchiveType,
+		"version": app.config.Version,
+		"created": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	return archive.CreateWithManifest(exportDir, dest, meta)
+}
+
+// ImportCollectionsArchive imports the collections archive at src
+// created with [BaseApp.ExportCollectionsArchive].
+//
+// The archived collections are imported in a single transaction together
+// with their records (keeping their original ids so that the relations remain valid)
+// and after that the records files are uploaded.
+//
+// Existing collections are updated with the archived schema (without deleting missing fields).
+// Archive records whose ids already exist (or that violate a unique constraint) are
+// skipped and reported in the result conflicts.
+//
+// Note that the records are inserted as they are, without triggering the record hooks.
+func (app *BaseApp) ImportCollectionsArchive(ctx context.Context, src string) (*CollectionsArchiveImportResult, error) {
+	// make sure that the special temp directory exists
+	// note: it needs to be inside the current hb_data to avoid "cross-device link" errors
+	localTempDir := filepath.Join(app.DataDir(), LocalTempDirName)
+	if err := os.MkdirAll(localTempDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create a temp dir: %w", err)
+	}
+
+	importDir := filepath.Join(localTempDir, "hb_import_"+security.PseudorandomString(8))
+	defer os.RemoveAll(importDir)
+
+	if err := archive.Extract(src, importDir); err != nil {
+		return nil, err
+	}
+
+	manifest, err := archive.VerifyManifest(importDir)
+	if err != nil {
+		return nil, fmt.Errorf("the archive integrity check failed: %w", err)
+	}
+
+	if manifest.Meta["type"] != collectionsArchiveType {
+		return nil, errors.New("the archive is not a collections archive")
+	}
+
+	rawCollections, err := os.ReadFile(filepath.Join(importDir, collectionsArchiveSchemaFile))
+	if err != nil {
+		return nil, err
+	}
+
+	toImport := []map[string]any{}
+	if err := json.Unmarshal(rawCollections, &toImport); err != nil {
+		return nil, err
+	}
+
+	result := &CollectionsArchiveImportResult{
+		Collections: []string{},
+		Conflicts:   []CollectionsArchiveConflict{},
+	}
+
+	// collectionId -> imported record ids
+	importedIds := map[string]map[string]struct{}{}
+
+	err = app.RunInTransaction(func(txApp App) error {
+		// check for collection name conflicts
+		for _, data := range toImport {
+			name := cast.ToString(data["name"])
+
+			existing, err := txApp.FindCollectionByNameOrId(name)
+			if err == nil && existing.Id != cast.ToString(data["id"]) {
+				return fmt.Errorf("collection name %q is already used by another collection (%s)", name, existing.Id)
+			}
+		}
+
+		if err := txApp.ImportCollections(toImport, false); err != nil {
+			return err
+		}
+
+		for _, data := range toImport {
+			collection, err := txApp.FindCollectionByNameOrId(cast.ToString(data["id"]))
+			if err != nil {
+				return err
+			}
+
+			result.Collections = append(result.Collections, collection.Name)
+
+			if collection.IsView() {
+				continue
+			}
+
+			ids, conflicts, err := importCollectionRecords(
+				txApp,
+				collection,
+				filepath.Join(importDir, collectionsArchiveRecordsDir, collection.Id+".ndjson"),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to import %q records: %w", collection.Name, err)
+			}
+
+			importedIds[collection.Id] = ids
+			result.Records += len(ids)
+			result.Conflicts = append(result.Conflicts, conflicts...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// upload the files of the imported records
+	// ---
+	filesDir := filepath.Join(importDir, collectionsArchiveFilesDir)
+	if _, err := os.Stat(filesDir); err != nil {
+		return result, nil // no files
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return result, err
+	}
+	defer fsys.Close()
+
+	fsys.SetContext(ctx)
+
+	err = filepath.WalkDir(filesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(filesDir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		// skip the files of the conflicted records
+		parts := strings.SplitN(key, "/", 3)
+		if len(parts) != 3 {
+			return nil
+		}
+		if _, ok := importedIds[parts[0]][parts[1]]; !ok {
+			return nil
+		}
+
+		file, err := filesystem.NewFileFromPath(path)
+		if err != nil {
+			return err
+		}
+		file.Name = parts[2]
+		file.OriginalName = parts[2]
+
+		if err := fsys.UploadFile(file, key); err != nil {
+			return fmt.Errorf("failed to upload file %q: %w", key, err)
+		}
+
+		result.Files++
+
+		return nil
+	})
+
+	return result, err
+}
+
+// exportCollectionRecords writes all collection records as new line
+// delimited json objects (with their raw db values) to the dest file.
+func exportCollectionRecords(app App, collection *Collection, dest string) error {
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+
+	var lastId string
+
+	for {
+		records := []*Record{}
+
+		err := app.RecordQuery(collection).
+			AndWhere(dbx.NewExp("[[id]] > {:lastId}", dbx.Params{"lastId": lastId})).
+			OrderBy("id ASC").
+			Limit(collectionsArchiveBatchSize).
+			All(&records)
+		if err != nil {
+			return errors.Join(err, f.Close())
+		}
+
+		for _, record := range records {
+			data, err := record.DBExport(app)
+			if err != nil {
+				return errors.Join(err, f.Close())
+			}
+
+			if err := encoder.Encode(data); err != nil {
+				return errors.Join(err, f.Close())
+			}
+		}
+
+		if len(records) < collectionsArchiveBatchSize {
+			break
+		}
+
+		lastId = records[len(records)-1].Id
+	}
+
+	return errors.Join(w.Flush(), f.Close())
+}
+
+// importCollectionRecords inserts the records from the src file created with
+// [exportCollectionRecords] and returns the inserted ids and the skipped conflicts.
+func importCollectionRecords(app App, collection *Collection, src string) (map[string]struct{}, []CollectionsArchiveConflict, error) {
+	ids := map[string]struct{}{}
+	conflicts := []CollectionsArchiveConflict{}
+
+	f, err := os.Open(src)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ids, conflicts, nil
+		}
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+
+	for {
+		data := map[string]any{}
+
+		err := decoder.Decode(&data)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		record := NewRecord(collection)
+		record.Load(data)
+
+		if record.Id == "" {
+			return nil, nil, errors.New("missing record id")
+		}
+
+		var exists bool
+		err = app.DB().Select("(1)").
+			From(collection.Name).
+			Where(dbx.HashExp{"id": record.Id}).
+			Limit(1).
+			Row(&exists)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, err
+		}
+
+		if exists {
+			conflicts = append(conflicts, CollectionsArchiveConflict{
+				Collection: collection.Name,
+				RecordId:   record.Id,
+				Message:    "A record with the same id already exists.",
+			})
+			continue
+		}
+
+		exported, err := record.DBExport(app)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		_, err = app.DB().Insert(collection.Name, dbx.Params(exported)).Execute()
+		if err != nil {
+			conflicts = append(conflicts, CollectionsArchiveConflict{
+				Collection: collection.Name,
+				RecordId:   record.Id,
+				Message:    "Failed to insert the record: " + err.Error(),
+			})
+			continue
+		}
+
+		ids[record.Id] = struct{}{}
+	}
+
+	return ids, conflicts, nil
+}
+
+// downloadFile saves the file with the specified key from fsys to the dest local path.
+func downloadFile(fsys *filesystem.System, key string, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return err
+	}
+
+	br, err := fsys.GetFile(key)
+	if err != nil {
+		return err
+	}
+	defer br.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, br)
+
+	return errors.Join(err, f.Close())
+}
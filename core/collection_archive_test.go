@@ -0,0 +1,176 @@
+package core_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/archive"
+	"github.com/hanzoai/backendPB/tools/filesystem"
+)
+
+func TestCollectionsArchive(t *testing.T) {
+	t.Parallel()
+
+	app1, _ := tests.NewTestApp()
+	defer app1.Cleanup()
+
+	app2, _ := tests.NewTestApp()
+	defer app2.Cleanup()
+
+	users, err := app1.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := core.NewBaseCollection("archive_test")
+	collection.Fields.Add(
+		&core.TextField{Name: "title"},
+		&core.RelationField{Name: "user", CollectionId: users.Id, MaxSelect: 1},
+		&core.FileField{Name: "file", MaxSelect: 1, MaxSize: 1000},
+	)
+	if err := app1.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := filesystem.NewFileFromBytes([]byte("test"), "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record1 := core.NewRecord(collection)
+	record1.Set("title", "a")
+	record1.Set("user", "4q1xlclmfloku33")
+	record1.Set("file", file)
+	if err := app1.Save(record1); err != nil {
+		t.Fatal(err)
+	}
+
+	record2 := core.NewRecord(collection)
+	record2.Set("title", "b")
+	if err := app1.Save(record2); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "export.zip")
+
+	if err := app1.ExportCollectionsArchive(context.Background(), dest, "missing"); err == nil {
+		t.Fatal("Expected export error for missing collection")
+	}
+
+	if err := app1.ExportCollectionsArchive(context.Background(), dest, collection.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("first import", func(t *testing.T) {
+		result, err := app2.ImportCollectionsArchive(context.Background(), dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Collections) != 1 || result.Collections[0] != collection.Name {
+			t.Fatalf("Expected collections [%s], got %v", collection.Name, result.Collections)
+		}
+
+		if result.Records != 2 {
+			t.Fatalf("Expected 2 records, got %d", result.Records)
+		}
+
+		if result.Files != 1 {
+			t.Fatalf("Expected 1 file, got %d", result.Files)
+		}
+
+		if len(result.Conflicts) != 0 {
+			t.Fatalf("Expected no conflicts, got %v", result.Conflicts)
+		}
+
+		imported, err := app2.FindRecordById(collection.Name, record1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v := imported.GetString("title"); v != "a" {
+			t.Fatalf("Expected title %q, got %q", "a", v)
+		}
+
+		if v := imported.GetString("user"); v != "4q1xlclmfloku33" {
+			t.Fatalf("Expected user %q, got %q", "4q1xlclmfloku33", v)
+		}
+
+		fsys, err := app2.NewFilesystem()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fsys.Close()
+
+		exists, err := fsys.Exists(imported.BaseFilesPath() + "/" + imported.GetString("file"))
+		if err != nil || !exists {
+			t.Fatalf("Expected the record file to be uploaded (%v)", err)
+		}
+	})
+
+	t.Run("import with existing records", func(t *testing.T) {
+		toDelete, err := app2.FindRecordById(collection.Name, record2.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := app2.Delete(toDelete); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := app2.ImportCollectionsArchive(context.Background(), dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Records != 1 {
+			t.Fatalf("Expected 1 record, got %d", result.Records)
+		}
+
+		if result.Files != 0 {
+			t.Fatalf("Expected 0 files, got %d", result.Files)
+		}
+
+		if len(result.Conflicts) != 1 || result.Conflicts[0].RecordId != record1.Id {
+			t.Fatalf("Expected 1 conflict for record %q, got %v", record1.Id, result.Conflicts)
+		}
+
+		if _, err := app2.FindRecordById(collection.Name, record2.Id); err != nil {
+			t.Fatalf("Expected the deleted record to be restored, got %v", err)
+		}
+	})
+
+	t.Run("collection name conflict", func(t *testing.T) {
+		app3, _ := tests.NewTestApp()
+		defer app3.Cleanup()
+
+		if err := app3.Save(core.NewBaseCollection(collection.Name)); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := app3.ImportCollectionsArchive(context.Background(), dest)
+		if err == nil || !strings.Contains(err.Error(), "already used") {
+			t.Fatalf("Expected collection name conflict error, got %v", err)
+		}
+	})
+
+	t.Run("non collections archive", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "test.txt"), []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		invalid := filepath.Join(t.TempDir(), "invalid.zip")
+		if err := archive.CreateWithManifest(dir, invalid, map[string]string{"type": "backup"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := app2.ImportCollectionsArchive(context.Background(), invalid); err == nil {
+			t.Fatal("Expected error for non collections archive")
+		}
+	})
+}
@@ -380,6 +380,7 @@ type baseCollection struct {
 // Collection defines the table, fields and various options related to a set of records.
 type Collection struct {
 	baseCollection
+	collectionBaseOptions
 	collectionAuthOptions
 	collectionViewOptions
 }
@@ -510,10 +511,17 @@ func (m *Collection) unmarshalRawOptions() error {
 	case CollectionTypeView:
 		return json.Unmarshal(raw, &m.collectionViewOptions)
 	case CollectionTypeAuth:
-		return json.Unmarshal(raw, &m.collectionAuthOptions)
+		if err := json.Unmarshal(raw, &m.collectionBaseOptions); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &m.collectionAuthOptions); err != nil {
+			return err
+		}
+		m.AuthTokenSigning.refreshKeys()
+		return nil
+	default:
+		return json.Unmarshal(raw, &m.collectionBaseOptions)
 	}
-
-	return nil
 }
 
 // UnmarshalJSON implements the [json.Unmarshaler] interface.
@@ -556,8 +564,9 @@ func (m Collection) MarshalJSON() ([]byte, error) {
 	case CollectionTypeAuth:
 		alias := struct {
 			baseCollection
+			collectionBaseOptions
 			collectionAuthOptions
-		}{m.baseCollection, m.collectionAuthOptions}
+		}{m.baseCollection, m.collectionBaseOptions, m.collectionAuthOptions}
 
 		// ensure that it is always returned as array
 		if alias.OAuth2.Providers == nil {
@@ -566,17 +575,35 @@ func (m Collection) MarshalJSON() ([]byte, error) {
 
 		// hide secret keys from the serialization
 		alias.AuthToken.Secret = ""
+		alias.AuthTokenSigning.PrivateKey = ""
+		alias.AuthTokenSigning.PreviousPrivateKey = ""
 		alias.FileToken.Secret = ""
 		alias.PasswordResetToken.Secret = ""
 		alias.EmailChangeToken.Secret = ""
 		alias.VerificationToken.Secret = ""
+		alias.TOTP.Secret = ""
 		for i := range alias.OAuth2.Providers {
 			alias.OAuth2.Providers[i].ClientSecret = ""
 		}
 
+		// ensure that it is always returned as array
+		if alias.FullTextSearch.Fields == nil {
+			alias.FullTextSearch.Fields = []string{}
+		}
+
 		return json.Marshal(alias)
 	default:
-		return json.Marshal(m.baseCollection)
+		alias := struct {
+			baseCollection
+			collectionBaseOptions
+		}{m.baseCollection, m.collectionBaseOptions}
+
+		// ensure that it is always returned as array
+		if alias.FullTextSearch.Fields == nil {
+			alias.FullTextSearch.Fields = []string{}
+		}
+
+		return json.Marshal(alias)
 	}
 }
 
@@ -613,7 +640,16 @@ func (m *Collection) DBExport(app App) (map[string]any, error) {
 			return nil, err
 		}
 	case CollectionTypeAuth:
-		if raw, err := types.ParseJSONRaw(m.collectionAuthOptions); err == nil {
+		if raw, err := types.ParseJSONRaw(struct {
+			collectionBaseOptions
+			collectionAuthOptions
+		}{m.collectionBaseOptions, m.collectionAuthOptions}); err == nil {
+			result["options"] = raw
+		} else {
+			return nil, err
+		}
+	case CollectionTypeBase:
+		if raw, err := types.ParseJSONRaw(m.collectionBaseOptions); err == nil {
 			result["options"] = raw
 		} else {
 			return nil, err
@@ -716,6 +752,10 @@ func onCollectionDeleteExecute(e *CollectionEvent) error {
 			if err := txApp.DeleteTable(e.Collection.Name); err != nil {
 				return err
 			}
+
+			if err := dropCollectionFullTextSearch(txApp, e.Collection); err != nil {
+				return err
+			}
 		}
 
 		if !e.Collection.disableIntegrityChecks {
@@ -809,6 +849,12 @@ func onCollectionSave(e *CollectionEvent) error {
 
 	if e.Collection.IsAuth() {
 		e.Collection.unsetMissingOAuth2MappedFields()
+		e.Collection.initMissingTOTPOptions()
+		e.Collection.initMissingSessionsOptions()
+		if err := e.Collection.initAuthTokenSigningOptions(); err != nil {
+			return err
+		}
+		e.Collection.AuthTokenSigning.refreshKeys()
 	}
 
 	e.Collection.updateGeneratedIdIfExists(e.App)
@@ -908,6 +954,8 @@ func (c *Collection) initDefaultFields() {
 	switch c.Type {
 	case CollectionTypeBase:
 		c.initIdField()
+		c.initDeletedField()
+		c.initSoftDeleteUniqueIndexes()
 	case CollectionTypeAuth:
 		c.initIdField()
 		c.initPasswordField()
@@ -915,6 +963,8 @@ func (c *Collection) initDefaultFields() {
 		c.initEmailField()
 		c.initEmailVisibilityField()
 		c.initVerifiedField()
+		c.initDeletedField()
+		c.initSoftDeleteUniqueIndexes()
 	case CollectionTypeView:
 		// view fields are autogenerated
 	}
@@ -949,6 +999,84 @@ func (c *Collection) initIdField() {
 	}
 }
 
+func (c *Collection) initDeletedField() {
+	field, _ := c.Fields.GetByName(FieldNameDeleted).(*AutodateField)
+
+	if !c.SoftDelete.Enabled {
+		// remove the previously enforced system field
+		if field != nil && field.System && !field.OnCreate && !field.OnUpdate {
+			c.Fields.RemoveById(field.Id)
+		}
+		return
+	}
+
+	if field == nil {
+		if c.Fields.GetByName(FieldNameDeleted) != nil {
+			return // non-autodate field with the same name (will be reported by the options validator)
+		}
+
+		// load default field
+		c.Fields.Add(&AutodateField{
+			Name:   FieldNameDeleted,
+			System: true,
+		})
+	} else {
+		// enforce system defaults
+		field.System = true
+		field.OnCreate = false
+		field.OnUpdate = false
+	}
+}
+
+// softDeleteIndexCondition is the partial index condition that excludes
+// the soft deleted records from the collection unique indexes.
+var softDeleteIndexCondition = "`" + FieldNameDeleted + "` = ''"
+
+// initSoftDeleteUniqueIndexes makes the collection unique indexes partial
+// when soft delete is enabled so that the soft deleted records don't
+// block reusing their unique values (eg. the auth record email).
+//
+// The condition is removed again when the soft delete is disabled
+// (which is allowed only if there are no soft deleted records).
+func (c *Collection) initSoftDeleteUniqueIndexes() {
+	andCondition := " AND " + softDeleteIndexCondition
+
+	for i, raw := range c.Indexes {
+		parsed := dbutils.ParseIndex(raw)
+		if !parsed.Unique || !parsed.IsValid() {
+			continue
+		}
+
+		where := parsed.Where
+		hasCondition := where == softDeleteIndexCondition ||
+			(strings.HasPrefix(where, "(") && strings.HasSuffix(where, ")"+andCondition))
+
+		if c.SoftDelete.Enabled {
+			if hasCondition {
+				continue
+			}
+
+			if where == "" {
+				parsed.Where = softDeleteIndexCondition
+			} else {
+				parsed.Where = "(" + where + ")" + andCondition
+			}
+		} else {
+			if !hasCondition {
+				continue
+			}
+
+			if where == softDeleteIndexCondition {
+				parsed.Where = ""
+			} else {
+				parsed.Where = strings.TrimSuffix(strings.TrimPrefix(where, "("), ")"+andCondition)
+			}
+		}
+
+		c.Indexes[i] = parsed.Build()
+	}
+}
+
 func (c *Collection) initPasswordField() {
 	field, _ := c.Fields.GetByName(FieldNamePassword).(*PasswordField)
 	if field == nil {
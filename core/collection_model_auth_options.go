@@ -1,6 +1,9 @@
 package core
 
 import (
+	"crypto"
+	"errors"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -44,6 +47,65 @@ func (m *Collection) unsetMissingOAuth2MappedFields() {
 	}
 }
 
+// initMissingTOTPOptions loads the default TOTP options
+// of auth collections created before the TOTP support.
+func (m *Collection) initMissingTOTPOptions() {
+	if !m.IsAuth() {
+		return
+	}
+
+	if m.TOTP.Secret == "" {
+		m.TOTP.Secret = security.RandomString(50)
+	}
+
+	if m.TOTP.Digits == 0 {
+		m.TOTP.Digits = security.DefaultTOTPDigits
+	}
+
+	if m.TOTP.Period == 0 {
+		m.TOTP.Period = security.DefaultTOTPPeriod
+	}
+}
+
+// initMissingSessionsOptions loads the default sessions options
+// of auth collections created before the auth sessions support.
+func (m *Collection) initMissingSessionsOptions() {
+	if !m.IsAuth() {
+		return
+	}
+
+	if m.Sessions.AccessTokenDuration == 0 {
+		m.Sessions.AccessTokenDuration = 900 // 15min
+	}
+
+	if m.Sessions.Duration == 0 {
+		m.Sessions.Duration = 2592000 // 30 days
+	}
+}
+
+// initAuthTokenSigningOptions loads the default auth token signing options
+// of the auth collections created before the asymmetric auth tokens support
+// and generates a new signing key if missing or not matching with the configured algorithm.
+func (m *Collection) initAuthTokenSigningOptions() error {
+	if !m.IsAuth() {
+		return nil
+	}
+
+	if m.AuthTokenSigning.Algorithm == "" {
+		m.AuthTokenSigning.Algorithm = AuthTokenAlgorithmHS256
+	}
+
+	if !m.AuthTokenSigning.IsAsymmetric() {
+		return nil
+	}
+
+	if _, jwk, err := m.AuthTokenSigning.SigningKey(); err == nil && jwk.Alg == m.AuthTokenSigning.Algorithm {
+		return nil // valid key
+	}
+
+	return m.AuthTokenSigning.RotateKey()
+}
+
 func (m *Collection) setDefaultAuthOptions() {
 	m.collectionAuthOptions = collectionAuthOptions{
 		VerificationTemplate:       defaultVerificationTemplate,
@@ -68,10 +130,29 @@ func (m *Collection) setDefaultAuthOptions() {
 			Length:        8,
 			EmailTemplate: defaultOTPTemplate,
 		},
+		WebAuthn: WebAuthnConfig{
+			Enabled:          false,
+			Duration:         300, // 5min
+			UserVerification: WebAuthnUserVerificationPreferred,
+		},
+		TOTP: TOTPConfig{
+			Enabled: false,
+			Secret:  security.RandomString(50),
+			Digits:  security.DefaultTOTPDigits,
+			Period:  security.DefaultTOTPPeriod,
+		},
+		Sessions: SessionsConfig{
+			Enabled:             false,
+			AccessTokenDuration: 900,     // 15min
+			Duration:            2592000, // 30 days
+		},
 		AuthToken: TokenConfig{
 			Secret:   security.RandomString(50),
 			Duration: 604800, // 7 days
 		},
+		AuthTokenSigning: AuthTokenSigningConfig{
+			Algorithm: AuthTokenAlgorithmHS256,
+		},
 		PasswordResetToken: TokenConfig{
 			Secret:   security.RandomString(50),
 			Duration: 1800, // 30min
@@ -131,13 +212,24 @@ type collectionAuthOptions struct {
 	// OTP defines options related to the One-time password authentication (OTP).
 	OTP OTPConfig `form:"otp" json:"otp"`
 
+	// WebAuthn defines options related to the passkeys (WebAuthn) authentication.
+	WebAuthn WebAuthnConfig `form:"webauthn" json:"webauthn"`
+
+	// TOTP defines options related to the authenticator app (TOTP) MFA factor.
+	TOTP TOTPConfig `form:"totp" json:"totp"`
+
+	// Sessions defines options related to the server tracked
+	// auth sessions with rotating refresh tokens.
+	Sessions SessionsConfig `form:"sessions" json:"sessions"`
+
 	// Various token configurations
 	// ---
-	AuthToken          TokenConfig `form:"authToken" json:"authToken"`
-	PasswordResetToken TokenConfig `form:"passwordResetToken" json:"passwordResetToken"`
-	EmailChangeToken   TokenConfig `form:"emailChangeToken" json:"emailChangeToken"`
-	VerificationToken  TokenConfig `form:"verificationToken" json:"verificationToken"`
-	FileToken          TokenConfig `form:"fileToken" json:"fileToken"`
+	AuthToken          TokenConfig            `form:"authToken" json:"authToken"`
+	AuthTokenSigning   AuthTokenSigningConfig `form:"authTokenSigning" json:"authTokenSigning"`
+	PasswordResetToken TokenConfig            `form:"passwordResetToken" json:"passwordResetToken"`
+	EmailChangeToken   TokenConfig            `form:"emailChangeToken" json:"emailChangeToken"`
+	VerificationToken  TokenConfig            `form:"verificationToken" json:"verificationToken"`
+	FileToken          TokenConfig            `form:"fileToken" json:"fileToken"`
 
 	// Default email templates
 	// ---
@@ -163,8 +255,12 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		validation.Field(&o.PasswordAuth),
 		validation.Field(&o.OAuth2),
 		validation.Field(&o.OTP),
+		validation.Field(&o.WebAuthn),
+		validation.Field(&o.TOTP),
+		validation.Field(&o.Sessions),
 		validation.Field(&o.MFA),
 		validation.Field(&o.AuthToken),
+		validation.Field(&o.AuthTokenSigning),
 		validation.Field(&o.PasswordResetToken),
 		validation.Field(&o.EmailChangeToken),
 		validation.Field(&o.VerificationToken),
@@ -192,6 +288,12 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 		if o.OTP.Enabled {
 			authsEnabled++
 		}
+		if o.WebAuthn.Enabled {
+			authsEnabled++
+		}
+		if o.TOTP.Enabled {
+			authsEnabled++
+		}
 		if authsEnabled < 2 {
 			return validation.Errors{
 				"mfa": validation.Errors{
@@ -200,6 +302,14 @@ func (o *collectionAuthOptions) validate(cv *collectionValidator) error {
 			}
 		}
 
+		if o.MFA.RequireTOTP && !o.TOTP.Enabled {
+			return validation.Errors{
+				"mfa": validation.Errors{
+					"requireTOTP": validation.NewError("validation_mfa_totp_disabled", "The authenticator app (TOTP) auth must be enabled."),
+				},
+			}
+		}
+
 		if o.MFA.Rule != "" {
 			mfaRuleValidators := []validation.RuleFunc{
 				cv.checkRule,
@@ -308,6 +418,218 @@ func (c TokenConfig) DurationTime() time.Duration {
 
 // -------------------------------------------------------------------
 
+// Supported auth token signing algorithms.
+const (
+	AuthTokenAlgorithmHS256 = "HS256"
+	AuthTokenAlgorithmRS256 = "RS256"
+	AuthTokenAlgorithmEdDSA = "EdDSA"
+)
+
+type AuthTokenSigningConfig struct {
+	// Algorithm specifies the auth tokens signing algorithm.
+	//
+	// HS256 (default if empty) tokens are signed with the record tokenKey and the authToken secret.
+	//
+	// RS256 and EdDSA tokens are signed with PrivateKey and could be verified
+	// by 3rd party services with the public keys from /.well-known/jwks.json.
+	Algorithm string `form:"algorithm" json:"algorithm"`
+
+	// PrivateKey is the PEM encoded private key used for signing the new auth tokens.
+	//
+	// It is auto generated for the asymmetric algorithms (see also [AuthTokenSigningConfig.RotateKey]).
+	PrivateKey string `form:"privateKey" json:"privateKey,omitempty"`
+
+	// PreviousPrivateKey is the PEM encoded private key before the last rotation.
+	//
+	// It is no longer used for signing but the auth tokens
+	// issued with it are still accepted until their expiration.
+	PreviousPrivateKey string `form:"previousPrivateKey" json:"previousPrivateKey,omitempty"`
+
+	// keys caches the parsed PrivateKey and PreviousPrivateKey
+	// (it is refreshed on collection load and save).
+	keys *authTokenSigningKeys
+}
+
+// Validate makes AuthTokenSigningConfig validatable by implementing [validation.Validatable] interface.
+func (c AuthTokenSigningConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(
+			&c.Algorithm,
+			validation.In(AuthTokenAlgorithmHS256, AuthTokenAlgorithmRS256, AuthTokenAlgorithmEdDSA),
+		),
+		validation.Field(
+			&c.PrivateKey,
+			validation.When(c.IsAsymmetric(), validation.Required),
+			validation.By(checkPrivateKeyPEM),
+			validation.By(c.checkPrivateKeyAlgorithm),
+		),
+		validation.Field(&c.PreviousPrivateKey, validation.By(checkPrivateKeyPEM)),
+	)
+}
+
+func (c AuthTokenSigningConfig) checkPrivateKeyAlgorithm(value any) error {
+	if !c.IsAsymmetric() {
+		return nil // not used
+	}
+
+	_, jwk, err := c.SigningKey()
+	if err != nil {
+		return nil // parse errors are checked separately
+	}
+
+	if jwk.Alg != c.Algorithm {
+		return validation.NewError("validation_private_key_algorithm_mismatch", "The private key doesn't match with the signing algorithm.")
+	}
+
+	return nil
+}
+
+// IsAsymmetric reports whether the auth tokens are signed with asymmetric private key (aka. RS256 or EdDSA).
+func (c AuthTokenSigningConfig) IsAsymmetric() bool {
+	return c.Algorithm == AuthTokenAlgorithmRS256 || c.Algorithm == AuthTokenAlgorithmEdDSA
+}
+
+// RotateKey generates a new private key for the configured algorithm
+// and moves the current one to PreviousPrivateKey.
+//
+// The auth tokens signed with the older PreviousPrivateKey are invalidated.
+func (c *AuthTokenSigningConfig) RotateKey() error {
+	var newKey string
+	var err error
+
+	switch c.Algorithm {
+	case AuthTokenAlgorithmRS256:
+		newKey, err = security.GeneratePrivateKeyPEM()
+	case AuthTokenAlgorithmEdDSA:
+		newKey, err = security.GenerateEd25519PrivateKeyPEM()
+	default:
+		return errors.New("key rotation is supported only for the RS256 and EdDSA algorithms")
+	}
+	if err != nil {
+		return err
+	}
+
+	c.PreviousPrivateKey = c.PrivateKey
+	c.PrivateKey = newKey
+
+	return nil
+}
+
+// SigningKey returns the current signing private key and its public JWK.
+func (c AuthTokenSigningConfig) SigningKey() (crypto.Signer, security.JWK, error) {
+	current := c.parsedKeys().current
+
+	return current.key, current.jwk, current.err
+}
+
+// VerificationKey returns the public key of the current or previous
+// signing private key matching with the provided key id.
+func (c AuthTokenSigningConfig) VerificationKey(kid string) (crypto.PublicKey, error) {
+	keys := c.parsedKeys()
+
+	for _, parsed := range []parsedSigningKey{keys.current, keys.previous} {
+		if parsed.err == nil && parsed.jwk.Kid == kid {
+			return parsed.key.Public(), nil
+		}
+	}
+
+	return nil, errors.New("missing verification key with kid " + kid)
+}
+
+// JWKs returns the public JWKs of the current and previous signing keys.
+//
+// Returns an empty slice for the HS256 algorithm.
+func (c AuthTokenSigningConfig) JWKs() []security.JWK {
+	result := []security.JWK{}
+
+	if !c.IsAsymmetric() {
+		return result
+	}
+
+	keys := c.parsedKeys()
+
+	for _, parsed := range []parsedSigningKey{keys.current, keys.previous} {
+		if parsed.err == nil {
+			result = append(result, parsed.jwk)
+		}
+	}
+
+	return result
+}
+
+// refreshKeys parses the current signing keys and replaces the keys cache.
+func (c *AuthTokenSigningConfig) refreshKeys() {
+	c.keys = newAuthTokenSigningKeys(c.PrivateKey, c.PreviousPrivateKey)
+}
+
+// parsedKeys returns the cached parsed signing keys.
+//
+// If the keys were changed after the last cache refresh
+// (e.g. on rotation before the collection save) they are parsed on the fly.
+func (c AuthTokenSigningConfig) parsedKeys() *authTokenSigningKeys {
+	if c.keys != nil && c.keys.privateKey == c.PrivateKey && c.keys.previousPrivateKey == c.PreviousPrivateKey {
+		return c.keys
+	}
+
+	return newAuthTokenSigningKeys(c.PrivateKey, c.PreviousPrivateKey)
+}
+
+// authTokenSigningKeys holds the parsed auth token signing keys
+// together with the PEM values they were parsed from.
+type authTokenSigningKeys struct {
+	privateKey         string
+	previousPrivateKey string
+	current            parsedSigningKey
+	previous           parsedSigningKey
+}
+
+type parsedSigningKey struct {
+	key crypto.Signer
+	jwk security.JWK
+	err error
+}
+
+func newAuthTokenSigningKeys(privateKey string, previousPrivateKey string) *authTokenSigningKeys {
+	result := &authTokenSigningKeys{
+		privateKey:         privateKey,
+		previousPrivateKey: previousPrivateKey,
+	}
+
+	result.current.key, result.current.jwk, result.current.err = parseSigningKey(privateKey)
+	result.previous.key, result.previous.jwk, result.previous.err = parseSigningKey(previousPrivateKey)
+
+	return result
+}
+
+func checkPrivateKeyPEM(value any) error {
+	v, _ := value.(string)
+	if v == "" {
+		return nil // nothing to check
+	}
+
+	if _, err := security.ParsePrivateKeyPEM(v); err != nil {
+		return validation.NewError("validation_invalid_private_key", "Invalid or unsupported PEM encoded private key.")
+	}
+
+	return nil
+}
+
+func parseSigningKey(pemKey string) (crypto.Signer, security.JWK, error) {
+	key, err := security.ParsePrivateKeyPEM(pemKey)
+	if err != nil {
+		return nil, security.JWK{}, err
+	}
+
+	jwk, err := security.NewJWK(key.Public())
+	if err != nil {
+		return nil, security.JWK{}, err
+	}
+
+	return key, jwk, nil
+}
+
+// -------------------------------------------------------------------
+
 type OTPConfig struct {
 	Enabled bool `form:"enabled" json:"enabled"`
 
@@ -342,6 +664,152 @@ func (c OTPConfig) DurationTime() time.Duration {
 
 // -------------------------------------------------------------------
 
+// WebAuthn user verification requirements.
+const (
+	WebAuthnUserVerificationRequired    = "required"
+	WebAuthnUserVerificationPreferred   = "preferred"
+	WebAuthnUserVerificationDiscouraged = "discouraged"
+)
+
+var webauthnRPIdRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9\-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9\-]*[a-z0-9])?)*$`)
+
+type WebAuthnConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Duration specifies how long the registration and authentication
+	// challenges to be valid (in seconds).
+	Duration int64 `form:"duration" json:"duration"`
+
+	// RPId is the relying party identifier - the app domain without
+	// scheme and port (eg. "example.com").
+	//
+	// Registered passkeys are scoped to the RPId so changing it
+	// will invalidate all existing collection credentials.
+	RPId string `form:"rpId" json:"rpId"`
+
+	// RPName is the human-friendly relying party name shown by the authenticators.
+	RPName string `form:"rpName" json:"rpName"`
+
+	// Origins is the list of the allowed client origins
+	// (eg. "https://example.com", "android:apk-key-hash:...").
+	Origins []string `form:"origins" json:"origins"`
+
+	// UserVerification specifies the authenticator user verification
+	// requirement ("required", "preferred" or "discouraged").
+	//
+	// Only "required" enforces the user verification flag on the server.
+	UserVerification string `form:"userVerification" json:"userVerification"`
+
+	// RequireSignCount rejects the authenticators that don't implement
+	// sign counters (aka. always report 0) and therefore can't be checked for cloning.
+	//
+	// Note that most synced passkeys don't implement sign counters.
+	RequireSignCount bool `form:"requireSignCount" json:"requireSignCount"`
+}
+
+// Validate makes WebAuthnConfig validatable by implementing [validation.Validatable] interface.
+func (c WebAuthnConfig) Validate() error {
+	if !c.Enabled {
+		return nil // no need to validate
+	}
+
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Duration, validation.Required, validation.Min(10), validation.Max(3600)),
+		validation.Field(&c.RPId, validation.Required, validation.Length(1, 253), validation.Match(webauthnRPIdRegex)),
+		validation.Field(&c.RPName, validation.Length(0, 255)),
+		validation.Field(&c.Origins, validation.Required, validation.Each(validation.Required, validation.Length(1, 255))),
+		validation.Field(
+			&c.UserVerification,
+			validation.Required,
+			validation.In(
+				WebAuthnUserVerificationRequired,
+				WebAuthnUserVerificationPreferred,
+				WebAuthnUserVerificationDiscouraged,
+			),
+		),
+	)
+}
+
+// DurationTime returns the current Duration as [time.Duration].
+func (c WebAuthnConfig) DurationTime() time.Duration {
+	return time.Duration(c.Duration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
+type TOTPConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Secret is used together with the app encryption env key
+	// to encrypt the stored authenticator app secrets.
+	//
+	// It is auto generated and changing it will invalidate
+	// all existing authenticator app enrollments of the collection.
+	Secret string `form:"secret" json:"secret,omitempty"`
+
+	// Issuer is the optional provider name shown in the authenticator apps
+	// (if empty, fallbacks to the application name).
+	Issuer string `form:"issuer" json:"issuer"`
+
+	// Digits specifies the length of the authenticator codes (6 or 8).
+	Digits int `form:"digits" json:"digits"`
+
+	// Period specifies the authenticator code time step (in seconds).
+	Period int64 `form:"period" json:"period"`
+}
+
+// Validate makes TOTPConfig validatable by implementing [validation.Validatable] interface.
+func (c TOTPConfig) Validate() error {
+	if !c.Enabled {
+		return nil // no need to validate
+	}
+
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Secret, validation.Required, validation.Length(30, 255)),
+		validation.Field(&c.Issuer, validation.Length(0, 255)),
+		validation.Field(&c.Digits, validation.Required, validation.In(6, 8)),
+		validation.Field(&c.Period, validation.Required, validation.Min(15), validation.Max(120)),
+	)
+}
+
+// -------------------------------------------------------------------
+
+type SessionsConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// AccessTokenDuration specifies how long the short-lived
+	// session access tokens to be valid (in seconds).
+	AccessTokenDuration int64 `form:"accessTokenDuration" json:"accessTokenDuration"`
+
+	// Duration specifies how long a session could remain
+	// unused before its refresh token expires (in seconds).
+	Duration int64 `form:"duration" json:"duration"`
+}
+
+// Validate makes SessionsConfig validatable by implementing [validation.Validatable] interface.
+func (c SessionsConfig) Validate() error {
+	if !c.Enabled {
+		return nil // no need to validate
+	}
+
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.AccessTokenDuration, validation.Required, validation.Min(10), validation.Max(86400)),
+		validation.Field(&c.Duration, validation.Required, validation.Min(60), validation.Max(94670856)),
+	)
+}
+
+// AccessTokenDurationTime returns the current AccessTokenDuration as [time.Duration].
+func (c SessionsConfig) AccessTokenDurationTime() time.Duration {
+	return time.Duration(c.AccessTokenDuration) * time.Second
+}
+
+// DurationTime returns the current Duration as [time.Duration].
+func (c SessionsConfig) DurationTime() time.Duration {
+	return time.Duration(c.Duration) * time.Second
+}
+
+// -------------------------------------------------------------------
+
 type MFAConfig struct {
 	Enabled bool `form:"enabled" json:"enabled"`
 
@@ -352,6 +820,13 @@ type MFAConfig struct {
 	//
 	// Leave it empty to enable MFA for everyone.
 	Rule string `form:"rule" json:"rule"`
+
+	// RequireTOTP requires the authenticator app (TOTP) code as
+	// second factor for all records (and not only for the enrolled ones).
+	//
+	// Note that the records without a confirmed authenticator app
+	// enrollment will not be able to complete the MFA.
+	RequireTOTP bool `form:"requireTOTP" json:"requireTOTP"`
 }
 
 // Validate makes MFAConfig validatable by implementing [validation.Validatable] interface.
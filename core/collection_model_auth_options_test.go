@@ -11,6 +11,7 @@ import (
 	"github.com/hanzoai/backendPB/core"
 	"github.com/hanzoai/backendPB/tests"
 	"github.com/hanzoai/backendPB/tools/auth"
+	"github.com/hanzoai/backendPB/tools/security"
 	"github.com/hanzoai/backendPB/tools/types"
 )
 
@@ -168,6 +169,61 @@ func TestCollectionAuthOptionsValidate(t *testing.T) {
 			expectedErrors: []string{"otp"},
 		},
 
+		// webauthn
+		{
+			name: "trigger webauthn validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.WebAuthn = core.WebAuthnConfig{
+					Enabled:  true,
+					Duration: -10,
+				}
+				return c, nil
+			},
+			expectedErrors: []string{"webauthn"},
+		},
+
+		// totp
+		{
+			name: "trigger totp validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.TOTP = core.TOTPConfig{
+					Enabled: true,
+					Digits:  -1,
+				}
+				return c, nil
+			},
+			expectedErrors: []string{"totp"},
+		},
+
+		// sessions
+		{
+			name: "trigger sessions validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.Sessions = core.SessionsConfig{
+					Enabled:             true,
+					AccessTokenDuration: -1,
+				}
+				return c, nil
+			},
+			expectedErrors: []string{"sessions"},
+		},
+
+		// authTokenSigning
+		{
+			name: "trigger authTokenSigning validations",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.AuthTokenSigning = core.AuthTokenSigningConfig{
+					Algorithm: "invalid",
+				}
+				return c, nil
+			},
+			expectedErrors: []string{"authTokenSigning"},
+		},
+
 		// mfa
 		{
 			name: "trigger mfa validations",
@@ -205,6 +261,59 @@ func TestCollectionAuthOptionsValidate(t *testing.T) {
 			},
 			expectedErrors: []string{},
 		},
+		{
+			name: "mfa enabled with webauthn as second auth method",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.MFA.Enabled = true
+				c.PasswordAuth.Enabled = true
+				c.OTP.Enabled = false
+				c.OAuth2.Enabled = false
+				c.WebAuthn.Enabled = true
+				c.WebAuthn.RPId = "example.com"
+				c.WebAuthn.Origins = []string{"https://example.com"}
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
+		{
+			name: "mfa enabled with totp as second auth method",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.MFA.Enabled = true
+				c.PasswordAuth.Enabled = true
+				c.OTP.Enabled = false
+				c.OAuth2.Enabled = false
+				c.TOTP.Enabled = true
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
+		{
+			name: "mfa with required totp and disabled totp auth",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.MFA.Enabled = true
+				c.MFA.RequireTOTP = true
+				c.PasswordAuth.Enabled = true
+				c.OTP.Enabled = true
+				c.TOTP.Enabled = false
+				return c, nil
+			},
+			expectedErrors: []string{"mfa"},
+		},
+		{
+			name: "mfa with required totp and enabled totp auth",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.MFA.Enabled = true
+				c.MFA.RequireTOTP = true
+				c.PasswordAuth.Enabled = true
+				c.TOTP.Enabled = true
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
 		{
 			name: "mfa disabled with invalid rule",
 			collection: func(app core.App) (*core.Collection, error) {
@@ -475,6 +584,340 @@ func TestTokenConfigDurationTime(t *testing.T) {
 	}
 }
 
+func TestAuthTokenSigningConfigValidate(t *testing.T) {
+	rsaKey, err := security.GeneratePrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ed25519Key, err := security.GenerateEd25519PrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name           string
+		config         core.AuthTokenSigningConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.AuthTokenSigningConfig{},
+			[]string{},
+		},
+		{
+			"invalid algorithm",
+			core.AuthTokenSigningConfig{Algorithm: "HS512"},
+			[]string{"algorithm"},
+		},
+		{
+			"HS256 without private key",
+			core.AuthTokenSigningConfig{Algorithm: core.AuthTokenAlgorithmHS256},
+			[]string{},
+		},
+		{
+			"RS256 without private key",
+			core.AuthTokenSigningConfig{Algorithm: core.AuthTokenAlgorithmRS256},
+			[]string{"privateKey"},
+		},
+		{
+			"invalid private keys",
+			core.AuthTokenSigningConfig{
+				Algorithm:          core.AuthTokenAlgorithmRS256,
+				PrivateKey:         "invalid",
+				PreviousPrivateKey: "invalid",
+			},
+			[]string{"privateKey", "previousPrivateKey"},
+		},
+		{
+			"private key not matching the algorithm",
+			core.AuthTokenSigningConfig{
+				Algorithm:  core.AuthTokenAlgorithmRS256,
+				PrivateKey: ed25519Key,
+			},
+			[]string{"privateKey"},
+		},
+		{
+			"valid RS256 key",
+			core.AuthTokenSigningConfig{
+				Algorithm:  core.AuthTokenAlgorithmRS256,
+				PrivateKey: rsaKey,
+			},
+			[]string{},
+		},
+		{
+			"valid EdDSA key with previous RS256 key",
+			core.AuthTokenSigningConfig{
+				Algorithm:          core.AuthTokenAlgorithmEdDSA,
+				PrivateKey:         ed25519Key,
+				PreviousPrivateKey: rsaKey,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestAuthTokenSigningConfigIsAsymmetric(t *testing.T) {
+	scenarios := []struct {
+		algorithm string
+		expected  bool
+	}{
+		{"", false},
+		{"invalid", false},
+		{core.AuthTokenAlgorithmHS256, false},
+		{core.AuthTokenAlgorithmRS256, true},
+		{core.AuthTokenAlgorithmEdDSA, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.algorithm, func(t *testing.T) {
+			config := core.AuthTokenSigningConfig{Algorithm: s.algorithm}
+
+			if v := config.IsAsymmetric(); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestAuthTokenSigningConfigRotateKey(t *testing.T) {
+	config := core.AuthTokenSigningConfig{Algorithm: core.AuthTokenAlgorithmHS256}
+	if err := config.RotateKey(); err == nil {
+		t.Fatal("Expected HS256 key rotation error, got nil")
+	}
+
+	config.Algorithm = core.AuthTokenAlgorithmRS256
+	if err := config.RotateKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.PrivateKey == "" || config.PreviousPrivateKey != "" {
+		t.Fatalf("Expected only new private key, got %#v", config)
+	}
+
+	_, jwk1, err := config.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jwk1.Alg != core.AuthTokenAlgorithmRS256 {
+		t.Fatalf("Expected RS256 key, got %q", jwk1.Alg)
+	}
+
+	firstKey := config.PrivateKey
+
+	config.Algorithm = core.AuthTokenAlgorithmEdDSA
+	if err := config.RotateKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.PreviousPrivateKey != firstKey || config.PrivateKey == firstKey {
+		t.Fatal("Expected the old key to be moved as previous key")
+	}
+
+	_, jwk2, err := config.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jwk2.Alg != core.AuthTokenAlgorithmEdDSA {
+		t.Fatalf("Expected EdDSA key, got %q", jwk2.Alg)
+	}
+
+	// verification keys
+	for _, kid := range []string{jwk1.Kid, jwk2.Kid} {
+		if _, err := config.VerificationKey(kid); err != nil {
+			t.Fatalf("Expected verification key for kid %q, got %v", kid, err)
+		}
+	}
+
+	if _, err := config.VerificationKey("missing"); err == nil {
+		t.Fatal("Expected missing verification key error, got nil")
+	}
+
+	// JWKs
+	jwks := config.JWKs()
+	if len(jwks) != 2 || jwks[0] != jwk2 || jwks[1] != jwk1 {
+		t.Fatalf("Expected the current and previous keys JWKs, got %#v", jwks)
+	}
+
+	config.Algorithm = core.AuthTokenAlgorithmHS256
+	if v := config.JWKs(); len(v) != 0 {
+		t.Fatalf("Expected no HS256 JWKs, got %#v", v)
+	}
+}
+
+func TestAuthTokenSigningKeyInitOnCollectionSave(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HS256 (existing collection without the option)
+	if err = app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if collection.AuthTokenSigning.Algorithm != core.AuthTokenAlgorithmHS256 || collection.AuthTokenSigning.PrivateKey != "" {
+		t.Fatalf("Expected HS256 algorithm without private key, got %#v", collection.AuthTokenSigning)
+	}
+
+	// RS256
+	collection.AuthTokenSigning.Algorithm = core.AuthTokenAlgorithmRS256
+	if err = app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey := collection.AuthTokenSigning.PrivateKey
+	if rsaKey == "" {
+		t.Fatal("Expected RS256 private key to be generated")
+	}
+
+	// resave with the same algorithm
+	if err = app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if collection.AuthTokenSigning.PrivateKey != rsaKey {
+		t.Fatal("Expected the RS256 private key to remain unchanged")
+	}
+
+	// algorithm change
+	collection.AuthTokenSigning.Algorithm = core.AuthTokenAlgorithmEdDSA
+	if err = app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if collection.AuthTokenSigning.PreviousPrivateKey != rsaKey {
+		t.Fatal("Expected the RS256 private key to be moved as previous key")
+	}
+
+	_, jwk, err := collection.AuthTokenSigning.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jwk.Alg != core.AuthTokenAlgorithmEdDSA {
+		t.Fatalf("Expected EdDSA private key, got %q", jwk.Alg)
+	}
+
+	// the private keys should be persisted but not serialized
+	reloaded, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded.AuthTokenSigning.Algorithm != collection.AuthTokenSigning.Algorithm ||
+		reloaded.AuthTokenSigning.PrivateKey != collection.AuthTokenSigning.PrivateKey ||
+		reloaded.AuthTokenSigning.PreviousPrivateKey != collection.AuthTokenSigning.PreviousPrivateKey {
+		t.Fatalf("Expected the auth token signing options to be persisted, got %#v", reloaded.AuthTokenSigning)
+	}
+
+	raw, err := json.Marshal(reloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if str := string(raw); !strings.Contains(str, `"authTokenSigning":{"algorithm":"EdDSA"}`) {
+		t.Fatalf("Expected the private keys to be hidden, got\n%s", str)
+	}
+}
+
+func TestAuthTokenSigningKeysCache(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.AuthTokenSigning.Algorithm = core.AuthTokenAlgorithmRS256
+	if err = app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := app.FindCachedCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key1, jwk1, err := cached.AuthTokenSigning.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key2, _, err := cached.AuthTokenSigning.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key1 != key2 {
+		t.Fatal("Expected the parsed signing key to be cached")
+	}
+
+	// rotation without save
+	if err = collection.AuthTokenSigning.RotateKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rotatedJWK, err := collection.AuthTokenSigning.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rotatedJWK.Kid == jwk1.Kid {
+		t.Fatal("Expected the stale cached key to be ignored after the rotation")
+	}
+
+	if _, err = collection.AuthTokenSigning.VerificationKey(jwk1.Kid); err != nil {
+		t.Fatalf("Expected the previous key to be available for verification, got %v", err)
+	}
+
+	// save should refresh the cache
+	if err = app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err = app.FindCachedCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key3, jwk3, err := cached.AuthTokenSigning.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key4, _, err := cached.AuthTokenSigning.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jwk3.Kid != rotatedJWK.Kid {
+		t.Fatalf("Expected the cached key to be the rotated one %q, got %q", rotatedJWK.Kid, jwk3.Kid)
+	}
+
+	if key3 != key4 {
+		t.Fatal("Expected the parsed rotated signing key to be cached")
+	}
+}
+
 func TestAuthAlertConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -613,6 +1056,222 @@ func TestOTPConfigDurationTime(t *testing.T) {
 	}
 }
 
+func TestWebAuthnConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.WebAuthnConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.WebAuthnConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.WebAuthnConfig{Enabled: true},
+			[]string{"duration", "rpId", "origins", "userVerification"},
+		},
+		{
+			"invalid duration (< 10)",
+			core.WebAuthnConfig{
+				Enabled:          true,
+				Duration:         9,
+				RPId:             "example.com",
+				Origins:          []string{"https://example.com"},
+				UserVerification: core.WebAuthnUserVerificationPreferred,
+			},
+			[]string{"duration"},
+		},
+		{
+			"invalid duration (> 3600)",
+			core.WebAuthnConfig{
+				Enabled:          true,
+				Duration:         3601,
+				RPId:             "example.com",
+				Origins:          []string{"https://example.com"},
+				UserVerification: core.WebAuthnUserVerificationPreferred,
+			},
+			[]string{"duration"},
+		},
+		{
+			"invalid rpId, origins and userVerification",
+			core.WebAuthnConfig{
+				Enabled:          true,
+				Duration:         100,
+				RPId:             "https://example.com:8090",
+				Origins:          []string{""},
+				UserVerification: "invalid",
+			},
+			[]string{"rpId", "origins", "userVerification"},
+		},
+		{
+			"valid data",
+			core.WebAuthnConfig{
+				Enabled:          true,
+				Duration:         3600,
+				RPId:             "localhost",
+				RPName:           "test",
+				Origins:          []string{"http://localhost:8090"},
+				UserVerification: core.WebAuthnUserVerificationRequired,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestWebAuthnConfigDurationTime(t *testing.T) {
+	scenarios := []struct {
+		config   core.WebAuthnConfig
+		expected time.Duration
+	}{
+		{core.WebAuthnConfig{}, 0 * time.Second},
+		{core.WebAuthnConfig{Duration: 1234}, 1234 * time.Second},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%d", i, s.config.Duration), func(t *testing.T) {
+			result := s.config.DurationTime()
+
+			if result != s.expected {
+				t.Fatalf("Expected duration %d, got %d", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestTOTPConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.TOTPConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.TOTPConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.TOTPConfig{Enabled: true},
+			[]string{"secret", "digits", "period"},
+		},
+		{
+			"invalid data",
+			core.TOTPConfig{
+				Enabled: true,
+				Secret:  strings.Repeat("a", 29),
+				Issuer:  strings.Repeat("a", 256),
+				Digits:  7,
+				Period:  14,
+			},
+			[]string{"secret", "issuer", "digits", "period"},
+		},
+		{
+			"invalid period (> 120)",
+			core.TOTPConfig{
+				Enabled: true,
+				Secret:  strings.Repeat("a", 30),
+				Digits:  6,
+				Period:  121,
+			},
+			[]string{"period"},
+		},
+		{
+			"valid data",
+			core.TOTPConfig{
+				Enabled: true,
+				Secret:  strings.Repeat("a", 30),
+				Issuer:  "test",
+				Digits:  8,
+				Period:  60,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestSessionsConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.SessionsConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value (disabled)",
+			core.SessionsConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.SessionsConfig{Enabled: true},
+			[]string{"accessTokenDuration", "duration"},
+		},
+		{
+			"invalid data (lower bounds)",
+			core.SessionsConfig{Enabled: true, AccessTokenDuration: 9, Duration: 59},
+			[]string{"accessTokenDuration", "duration"},
+		},
+		{
+			"invalid data (upper bounds)",
+			core.SessionsConfig{Enabled: true, AccessTokenDuration: 86401, Duration: 94670857},
+			[]string{"accessTokenDuration", "duration"},
+		},
+		{
+			"valid data",
+			core.SessionsConfig{Enabled: true, AccessTokenDuration: 86400, Duration: 94670856},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestSessionsConfigDurationTime(t *testing.T) {
+	scenarios := []struct {
+		config              core.SessionsConfig
+		expectedAccessToken time.Duration
+		expected            time.Duration
+	}{
+		{core.SessionsConfig{}, 0 * time.Second, 0 * time.Second},
+		{core.SessionsConfig{AccessTokenDuration: 123, Duration: 1234}, 123 * time.Second, 1234 * time.Second},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%d", i, s.config.Duration), func(t *testing.T) {
+			if v := s.config.AccessTokenDurationTime(); v != s.expectedAccessToken {
+				t.Fatalf("Expected access token duration %d, got %d", s.expectedAccessToken, v)
+			}
+
+			if v := s.config.DurationTime(); v != s.expected {
+				t.Fatalf("Expected duration %d, got %d", s.expected, v)
+			}
+		})
+	}
+}
+
 func TestMFAConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
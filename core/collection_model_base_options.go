@@ -1,11 +1,146 @@
 package core
 
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/tools/list"
+)
+
 var _ optionsValidator = (*collectionBaseOptions)(nil)
 
 // collectionBaseOptions defines the options for the "base" type collection.
+//
+// Note that the same options are also available for the "auth" type collections.
 type collectionBaseOptions struct {
+	// FullTextSearch defines the collection records full-text search index options.
+	FullTextSearch FullTextSearchConfig `form:"fullTextSearch" json:"fullTextSearch"`
+
+	// SoftDelete defines the collection records soft delete options.
+	SoftDelete SoftDeleteConfig `form:"softDelete" json:"softDelete"`
+
+	// Revisions defines the collection records revision history options.
+	Revisions RevisionsConfig `form:"revisions" json:"revisions"`
+
+	// FieldRules defines optional per-field API rules indexed by the field name.
+	//
+	// The field rules are evaluated in addition to the collection API rules
+	// and they are ignored for superusers.
+	FieldRules map[string]FieldRule `form:"fieldRules" json:"fieldRules"`
 }
 
 func (o *collectionBaseOptions) validate(cv *collectionValidator) error {
+	err := validation.Validate(o.FullTextSearch.Fields, validation.By(cv.checkFullTextSearchFields))
+	if err != nil {
+		return validation.Errors{
+			"fullTextSearch": validation.Errors{
+				"fields": err,
+			},
+		}
+	}
+
+	err = validation.ValidateStruct(&o.SoftDelete,
+		validation.Field(&o.SoftDelete.Enabled, validation.By(cv.checkSoftDeleteToggle)),
+		validation.Field(&o.SoftDelete.MaxDays, validation.Min(0)),
+	)
+	if err != nil {
+		return validation.Errors{"softDelete": err}
+	}
+
+	if len(o.FieldRules) > 0 {
+		fieldRulesErrs := validation.Errors{}
+		for name, rule := range o.FieldRules {
+			err := validation.ValidateStruct(&rule,
+				validation.Field(&rule.ViewRule, validation.By(cv.checkRule)),
+				validation.Field(&rule.UpdateRule, validation.By(cv.checkRule)),
+			)
+			if err == nil {
+				err = cv.checkFieldRuleName(name)
+			}
+			if err != nil {
+				fieldRulesErrs[name] = err
+			}
+		}
+		if len(fieldRulesErrs) > 0 {
+			return validation.Errors{"fieldRules": fieldRulesErrs}
+		}
+	}
+
 	return nil
 }
+
+// -------------------------------------------------------------------
+
+type FullTextSearchConfig struct {
+	// Fields is a list of text or editor field names that will be
+	// indexed in the collection FTS5 virtual table.
+	//
+	// Leave it empty to disable the full-text search index.
+	Fields []string `form:"fields" json:"fields"`
+}
+
+// IsEnabled reports whether the full-text search index is enabled.
+func (c FullTextSearchConfig) IsEnabled() bool {
+	return len(c.Fields) > 0
+}
+
+// HasField checks whether the specified field name is part of the full-text search index.
+func (c FullTextSearchConfig) HasField(name string) bool {
+	return list.ExistInSlice(name, c.Fields)
+}
+
+// -------------------------------------------------------------------
+
+type SoftDeleteConfig struct {
+	// Enabled makes the record deletes only mark the record as deleted
+	// by setting its system "deleted" autodate field.
+	//
+	// The soft deleted records are excluded from the default record
+	// queries and could be restored or permanently purged by superusers.
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// MaxDays specifies how many days to keep the soft deleted records
+	// before permanently purging them.
+	//
+	// Set to 0 to keep the soft deleted records until manually purged.
+	MaxDays int `form:"maxDays" json:"maxDays"`
+}
+
+// -------------------------------------------------------------------
+
+type RevisionsConfig struct {
+	// Enabled stores a field-level diff and data snapshot in the
+	// "_revisions" system collection after each record create, update and delete.
+	//
+	// The "_revisions" collection is created automatically when
+	// the option is enabled for the first time.
+	Enabled bool `form:"enabled" json:"enabled"`
+}
+
+// -------------------------------------------------------------------
+
+type FieldRule struct {
+	// ViewRule is an optional filter expression that must be satisfied
+	// in order the field value to be included in the API responses
+	// (including expands and realtime messages).
+	//
+	// Leave it nil or empty to apply only the collection rules.
+	//
+	// For example, to show a "salary" field only to the HR users
+	// you could set it to "@request.auth.role = 'hr'".
+	ViewRule *string `form:"viewRule" json:"viewRule"`
+
+	// UpdateRule is an optional filter expression that must be satisfied
+	// in order the field value to be changed with the update API.
+	//
+	// Leave it nil or empty to apply only the collection rules.
+	UpdateRule *string `form:"updateRule" json:"updateRule"`
+}
+
+// HasViewRule reports whether the field rule has a non-empty view rule.
+func (r FieldRule) HasViewRule() bool {
+	return r.ViewRule != nil && *r.ViewRule != ""
+}
+
+// HasUpdateRule reports whether the field rule has a non-empty update rule.
+func (r FieldRule) HasUpdateRule() bool {
+	return r.UpdateRule != nil && *r.UpdateRule != ""
+}
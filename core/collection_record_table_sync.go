@@ -9,7 +9,6 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hanzoai/backendPB/tools/dbutils"
 	"github.com/hanzoai/backendPB/tools/security"
-	"github.com/hanzoai/dbx"
 )
 
 // SyncRecordTableSchema compares the two provided collections
@@ -43,7 +42,11 @@ func (app *BaseApp) SyncRecordTableSchema(newCollection *Collection, oldCollecti
 				return err
 			}
 
-			return createCollectionIndexes(txApp, newCollection)
+			if err := createCollectionIndexes(txApp, newCollection); err != nil {
+				return err
+			}
+
+			return createCollectionFullTextSearch(txApp, newCollection)
 		}
 
 		// update
@@ -69,6 +72,19 @@ func (app *BaseApp) SyncRecordTableSchema(newCollection *Collection, oldCollecti
 			}
 		}
 
+		// note: the full-text search table and triggers are always recreated
+		// on fields change because the triggers reference the table columns
+		needFullTextSearchUpdate := needTableRename ||
+			oldFields.String() != newFields.String() ||
+			strings.Join(oldCollection.FullTextSearch.Fields, ",") != strings.Join(newCollection.FullTextSearch.Fields, ",")
+
+		if needFullTextSearchUpdate {
+			// drop the old full-text search table and triggers (if any)
+			if err := dropCollectionFullTextSearch(txApp, oldCollection); err != nil {
+				return err
+			}
+		}
+
 		// check for renamed table
 		if needTableRename {
 			_, err := txApp.DB().RenameTable("{{"+oldTableName+"}}", "{{"+newTableName+"}}").Execute()
@@ -133,7 +149,13 @@ func (app *BaseApp) SyncRecordTableSchema(newCollection *Collection, oldCollecti
 		}
 
 		if needIndexesUpdate {
-			return createCollectionIndexes(txApp, newCollection)
+			if err := createCollectionIndexes(txApp, newCollection); err != nil {
+				return err
+			}
+		}
+
+		if needFullTextSearchUpdate {
+			return createCollectionFullTextSearch(txApp, newCollection)
 		}
 
 		return nil
@@ -144,9 +166,11 @@ func (app *BaseApp) SyncRecordTableSchema(newCollection *Collection, oldCollecti
 
 	// run optimize per the SQLite recommendations
 	// (https://www.sqlite.org/pragma.html#pragma_optimize)
-	_, optimizeErr := app.DB().NewQuery("PRAGMA optimize").Execute()
-	if optimizeErr != nil {
-		app.Logger().Warn("Failed to run PRAGMA optimize after record table sync", slog.String("error", optimizeErr.Error()))
+	if optimize := app.DBDialect().OptimizeQuery(); optimize != "" {
+		_, optimizeErr := app.DB().NewQuery(optimize).Execute()
+		if optimizeErr != nil {
+			app.Logger().Warn("Failed to run db optimize after record table sync", slog.String("error", optimizeErr.Error()))
+		}
 	}
 
 	return nil
@@ -187,11 +211,7 @@ func normalizeSingleVsMultipleFieldChanges(app App, newCollection *Collection, o
 				Name string `db:"name"`
 				SQL  string `db:"sql"`
 			}{}
-			err := txApp.DB().Select("name", "sql").
-				From("sqlite_master").
-				AndWhere(dbx.NewExp("sql is not null")).
-				AndWhere(dbx.HashExp{"type": "view"}).
-				All(&views)
+			err := txApp.DB().NewQuery(txApp.DBDialect().ViewsQuery()).All(&views)
 			if err != nil {
 				return err
 			}
@@ -217,61 +237,26 @@ func normalizeSingleVsMultipleFieldChanges(app App, newCollection *Collection, o
 				return err
 			}
 
-			var copyQuery *dbx.Query
+			var copyExpr string
 
 			if !isOldMultiple && isNewMultiple {
 				// single -> multiple (convert to array)
-				copyQuery = txApp.DB().NewQuery(fmt.Sprintf(
-					`UPDATE {{%s}} set [[%s]] = (
-							CASE
-								WHEN COALESCE([[%s]], '') = ''
-								THEN '[]'
-								ELSE (
-									CASE
-										WHEN json_valid([[%s]]) AND json_type([[%s]]) == 'array'
-										THEN [[%s]]
-										ELSE json_array([[%s]])
-									END
-								)
-							END
-						)`,
-					newCollection.Name,
-					originalName,
-					oldTempName,
-					oldTempName,
-					oldTempName,
-					oldTempName,
-					oldTempName,
-				))
+				copyExpr = txApp.DBDialect().ToJSONArray(oldTempName)
 			} else {
 				// multiple -> single (keep only the last element)
 				//
 				// note: for file fields the actual file objects are not
 				// deleted allowing additional custom handling via migration
-				copyQuery = txApp.DB().NewQuery(fmt.Sprintf(
-					`UPDATE {{%s}} set [[%s]] = (
-						CASE
-							WHEN COALESCE([[%s]], '[]') = '[]'
-							THEN ''
-							ELSE (
-								CASE
-									WHEN json_valid([[%s]]) AND json_type([[%s]]) == 'array'
-									THEN COALESCE(json_extract([[%s]], '$[#-1]'), '')
-									ELSE [[%s]]
-								END
-							)
-						END
-					)`,
-					newCollection.Name,
-					originalName,
-					oldTempName,
-					oldTempName,
-					oldTempName,
-					oldTempName,
-					oldTempName,
-				))
+				copyExpr = txApp.DBDialect().JSONArrayLast(oldTempName)
 			}
 
+			copyQuery := txApp.DB().NewQuery(fmt.Sprintf(
+				"UPDATE {{%s}} set [[%s]] = %s",
+				newCollection.Name,
+				originalName,
+				copyExpr,
+			))
+
 			// copy the normalized values
 			_, err = copyQuery.Execute()
 			if err != nil {
@@ -361,3 +346,100 @@ func createCollectionIndexes(app App, collection *Collection) error {
 		return nil
 	})
 }
+
+// fullTextSearchTableName returns the name of the collection FTS5 virtual table.
+//
+// Note that the collection id is used so that the table doesn't have
+// to be renamed together with the collection.
+func fullTextSearchTableName(collection *Collection) string {
+	return "_fts_" + collection.Id
+}
+
+func dropCollectionFullTextSearch(app App, collection *Collection) error {
+	if collection.IsView() {
+		return nil // views don't have full-text search indexes
+	}
+
+	tableName := fullTextSearchTableName(collection)
+
+	return app.RunInTransaction(func(txApp App) error {
+		for _, suffix := range []string{"_ai", "_ad", "_au"} {
+			_, err := txApp.DB().NewQuery(fmt.Sprintf("DROP TRIGGER IF EXISTS [[%s]]", tableName+suffix)).Execute()
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := txApp.DB().NewQuery(fmt.Sprintf("DROP TABLE IF EXISTS {{%s}}", tableName)).Execute()
+
+		return err
+	})
+}
+
+func createCollectionFullTextSearch(app App, collection *Collection) error {
+	if collection.IsView() || !collection.FullTextSearch.IsEnabled() {
+		return nil // nothing to create
+	}
+
+	tableName := fullTextSearchTableName(collection)
+
+	cols := make([]string, len(collection.FullTextSearch.Fields))
+	newCols := make([]string, len(collection.FullTextSearch.Fields))
+	oldCols := make([]string, len(collection.FullTextSearch.Fields))
+	for i, name := range collection.FullTextSearch.Fields {
+		cols[i] = "[[" + name + "]]"
+		newCols[i] = "new.[[" + name + "]]"
+		oldCols[i] = "old.[[" + name + "]]"
+	}
+	colsStr := strings.Join(cols, ",")
+	newColsStr := strings.Join(newCols, ",")
+	oldColsStr := strings.Join(oldCols, ",")
+
+	return app.RunInTransaction(func(txApp App) error {
+		// external content table (https://www.sqlite.org/fts5.html#external_content_tables)
+		_, err := txApp.DB().NewQuery(fmt.Sprintf(
+			"CREATE VIRTUAL TABLE {{%s}} USING fts5(%s, content='%s', content_rowid='rowid', tokenize='unicode61 remove_diacritics 2')",
+			tableName,
+			colsStr,
+			collection.Name,
+		)).Execute()
+		if err != nil {
+			return fmt.Errorf("failed to create full-text search table - %w", err)
+		}
+
+		triggers := []string{
+			fmt.Sprintf(
+				"CREATE TRIGGER [[%s_ai]] AFTER INSERT ON {{%s}} BEGIN INSERT INTO {{%s}} (rowid, %s) VALUES (new.rowid, %s); END",
+				tableName, collection.Name, tableName, colsStr, newColsStr,
+			),
+			fmt.Sprintf(
+				"CREATE TRIGGER [[%s_ad]] AFTER DELETE ON {{%s}} BEGIN INSERT INTO {{%s}} ({{%s}}, rowid, %s) VALUES ('delete', old.rowid, %s); END",
+				tableName, collection.Name, tableName, tableName, colsStr, oldColsStr,
+			),
+			fmt.Sprintf(
+				"CREATE TRIGGER [[%s_au]] AFTER UPDATE ON {{%s}} BEGIN INSERT INTO {{%s}} ({{%s}}, rowid, %s) VALUES ('delete', old.rowid, %s); INSERT INTO {{%s}} (rowid, %s) VALUES (new.rowid, %s); END",
+				tableName, collection.Name, tableName, tableName, colsStr, oldColsStr, tableName, colsStr, newColsStr,
+			),
+		}
+		for _, trigger := range triggers {
+			if _, err := txApp.DB().NewQuery(trigger).Execute(); err != nil {
+				return fmt.Errorf("failed to create full-text search trigger - %w", err)
+			}
+		}
+
+		// index the existing records
+		return rebuildCollectionFullTextSearch(txApp, collection)
+	})
+}
+
+func rebuildCollectionFullTextSearch(app App, collection *Collection) error {
+	if collection.IsView() || !collection.FullTextSearch.IsEnabled() || !app.DBDialect().SupportsFullTextSearch() {
+		return nil // nothing to rebuild
+	}
+
+	tableName := fullTextSearchTableName(collection)
+
+	_, err := app.DB().NewQuery(fmt.Sprintf("INSERT INTO {{%s}} ({{%s}}) VALUES ('rebuild')", tableName, tableName)).Execute()
+
+	return err
+}
@@ -115,6 +115,90 @@ func TestSyncRecordTableSchema(t *testing.T) {
 	}
 }
 
+func TestSyncRecordTableSchemaFullTextSearch(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection := core.NewBaseCollection("fts_test")
+	collection.Fields.Add(&core.TextField{Name: "title"})
+	collection.Fields.Add(&core.EditorField{Name: "content"})
+	collection.FullTextSearch.Fields = []string{"title"}
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	ftsTable := "_fts_" + collection.Id
+
+	if !app.HasTable(ftsTable) {
+		t.Fatalf("Expected table %s to exist", ftsTable)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("title", "Lorem ipsum dolor")
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	totalMatches := func(column string, query string) int {
+		var total int
+
+		err := app.DB().Select("count(*)").
+			From(ftsTable).
+			AndWhere(dbx.NewExp("[["+ftsTable+"."+column+"]] MATCH {:query}", dbx.Params{"query": query})).
+			Row(&total)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return total
+	}
+
+	if total := totalMatches("title", "ipsum"); total != 1 {
+		t.Fatalf("Expected 1 match after insert, got %d", total)
+	}
+
+	// update
+	record.Set("title", "abc")
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+	if total := totalMatches("title", "ipsum"); total != 0 {
+		t.Fatalf("Expected 0 matches after update, got %d", total)
+	}
+
+	// change the indexed fields (the existing records should be reindexed)
+	record.Set("content", "<p>Lorem ipsum</p>")
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+	collection.FullTextSearch.Fields = []string{"title", "content"}
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+	if total := totalMatches("content", "ipsum"); total != 1 {
+		t.Fatalf("Expected 1 match after the index change, got %d", total)
+	}
+
+	// delete
+	if err := app.Delete(record); err != nil {
+		t.Fatal(err)
+	}
+	if total := totalMatches("content", "ipsum"); total != 0 {
+		t.Fatalf("Expected 0 matches after delete, got %d", total)
+	}
+
+	// disable
+	collection.FullTextSearch.Fields = nil
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+	if app.HasTable(ftsTable) {
+		t.Fatalf("Expected table %s to be deleted", ftsTable)
+	}
+}
+
 func getTotalViews(app core.App) (int, error) {
 	var total int
 
@@ -431,6 +431,14 @@ func (validator *collectionValidator) ensureNoSystemFieldsChange(value any) erro
 
 		newField := fields.GetById(oldField.GetId())
 
+		// the soft delete field is removed together with the option
+		if newField == nil &&
+			oldField.GetName() == FieldNameDeleted &&
+			validator.original.SoftDelete.Enabled &&
+			!validator.new.SoftDelete.Enabled {
+			continue
+		}
+
 		if newField == nil || oldField.GetName() != newField.GetName() {
 			return validation.NewError("validation_system_field_change", "System fields cannot be deleted or renamed.")
 		}
@@ -465,6 +473,92 @@ func (cv *collectionValidator) checkFieldsForUniqueIndex(value any) error {
 	return nil
 }
 
+func (cv *collectionValidator) checkFullTextSearchFields(value any) error {
+	names, ok := value.([]string)
+	if !ok {
+		return validators.ErrUnsupportedValueType
+	}
+
+	if len(names) == 0 {
+		return nil // nothing to check
+	}
+
+	if !cv.app.DBDialect().SupportsFullTextSearch() {
+		return validation.NewError("validation_fts_not_supported", "The full-text search is not supported by the current db engine.")
+	}
+
+	if len(list.ToUniqueStringSlice(names)) != len(names) {
+		return validation.NewError("validation_duplicated_fts_fields", "The full-text search fields must be unique.")
+	}
+
+	for _, name := range names {
+		field := cv.new.Fields.GetByName(name)
+		if field == nil {
+			return validation.NewError("validation_missing_field", "Invalid or missing field {{.fieldName}}").
+				SetParams(map[string]any{"fieldName": name})
+		}
+
+		if field.Type() != FieldTypeText && field.Type() != FieldTypeEditor {
+			return validation.NewError("validation_invalid_fts_field", "The field {{.fieldName}} must be a text or editor field.").
+				SetParams(map[string]any{"fieldName": name})
+		}
+	}
+
+	return nil
+}
+
+func (cv *collectionValidator) checkSoftDeleteToggle(value any) error {
+	enabled, ok := value.(bool)
+	if !ok {
+		return validators.ErrUnsupportedValueType
+	}
+
+	if enabled {
+		if _, ok := cv.new.Fields.GetByName(FieldNameDeleted).(*AutodateField); !ok {
+			return validation.NewError(
+				"validation_soft_delete_field_conflict",
+				"Soft delete requires the {{.fieldName}} field name to be reserved for the system autodate field.",
+			).SetParams(map[string]any{"fieldName": FieldNameDeleted})
+		}
+
+		return nil
+	}
+
+	if cv.original.IsNew() || !cv.original.SoftDelete.Enabled {
+		return nil // nothing to check
+	}
+
+	var exists int
+	_ = cv.app.RecordQueryWithSoftDeleted(cv.original).
+		Select("(1)").
+		AndWhere(dbx.Not(dbx.HashExp{cv.original.Name + "." + FieldNameDeleted: ""})).
+		Limit(1).
+		Row(&exists)
+	if exists > 0 {
+		return validation.NewError(
+			"validation_soft_deleted_records",
+			"Restore or purge the soft deleted records before disabling the soft delete option.",
+		)
+	}
+
+	return nil
+}
+
+func (cv *collectionValidator) checkFieldRuleName(name string) error {
+	field := cv.new.Fields.GetByName(name)
+	if field == nil {
+		return validation.NewError("validation_missing_field", "Invalid or missing field {{.fieldName}}").
+			SetParams(map[string]any{"fieldName": name})
+	}
+
+	if name == FieldNameId || field.Type() == FieldTypePassword {
+		return validation.NewError("validation_invalid_field_rule_field", "Field rules are not allowed for the {{.fieldName}} field.").
+			SetParams(map[string]any{"fieldName": name})
+	}
+
+	return nil
+}
+
 // note: value could be either *string or string
 func (validator *collectionValidator) checkRule(value any) error {
 	var vStr string
@@ -554,14 +648,16 @@ func (cv *collectionValidator) checkIndexes(value any) error {
 
 		// ensure that the index name is not used in another collection
 		var usedTblName string
-		_ = cv.app.DB().Select("tbl_name").
-			From("sqlite_master").
-			AndWhere(dbx.HashExp{"type": "index"}).
-			AndWhere(dbx.NewExp("LOWER([[tbl_name]])!=LOWER({:oldName})", dbx.Params{"oldName": cv.original.Name})).
-			AndWhere(dbx.NewExp("LOWER([[tbl_name]])!=LOWER({:newName})", dbx.Params{"newName": cv.new.Name})).
-			AndWhere(dbx.NewExp("LOWER([[name]])=LOWER({:indexName})", dbx.Params{"indexName": parsed.IndexName})).
-			Limit(1).
-			Row(&usedTblName)
+		var indexTables []string
+		_ = cv.app.DB().NewQuery(cv.app.DBDialect().IndexTableQuery()).
+			Bind(dbx.Params{"indexName": parsed.IndexName}).
+			Column(&indexTables)
+		for _, tblName := range indexTables {
+			if !strings.EqualFold(tblName, cv.original.Name) && !strings.EqualFold(tblName, cv.new.Name) {
+				usedTblName = tblName
+				break
+			}
+		}
 		if usedTblName != "" {
 			return validation.Errors{
 				strconv.Itoa(i): validation.NewError(
@@ -681,10 +777,13 @@ func (cv *collectionValidator) checkIndexes(value any) error {
 func (validator *collectionValidator) validateOptions() error {
 	switch validator.new.Type {
 	case CollectionTypeAuth:
-		return validator.new.collectionAuthOptions.validate(validator)
+		return validators.JoinValidationErrors(
+			validator.new.collectionBaseOptions.validate(validator),
+			validator.new.collectionAuthOptions.validate(validator),
+		)
 	case CollectionTypeView:
 		return validator.new.collectionViewOptions.validate(validator)
+	default:
+		return validator.new.collectionBaseOptions.validate(validator)
 	}
-
-	return nil
 }
@@ -889,6 +889,65 @@ func TestCollectionValidate(t *testing.T) {
 			},
 			expectedErrors: []string{},
 		},
+		{
+			name: "base with invalid field rules",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewBaseCollection("new_base")
+				c.Fields.Add(&core.NumberField{Name: "number"})
+				c.FieldRules = map[string]core.FieldRule{
+					"number":  {ViewRule: types.Pointer("missing = 1")},
+					"missing": {UpdateRule: types.Pointer("")},
+				}
+				return c, nil
+			},
+			expectedErrors: []string{"fieldRules"},
+		},
+		{
+			name: "base with valid field rules",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewBaseCollection("new_base")
+				c.Fields.Add(&core.NumberField{Name: "number"})
+				c.FieldRules = map[string]core.FieldRule{
+					"number": {
+						ViewRule:   types.Pointer("@request.auth.id != '' && number > 1"),
+						UpdateRule: types.Pointer("@request.body.number < 10"),
+					},
+				}
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
+		{
+			name: "base with invalid full-text search fields",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewBaseCollection("new_base")
+				c.Fields.Add(&core.NumberField{Name: "number"})
+				c.FullTextSearch.Fields = []string{"number", "missing"}
+				return c, nil
+			},
+			expectedErrors: []string{"fullTextSearch"},
+		},
+		{
+			name: "auth with duplicated full-text search fields",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewAuthCollection("new_auth")
+				c.Fields.Add(&core.TextField{Name: "title"})
+				c.FullTextSearch.Fields = []string{"title", "title"}
+				return c, nil
+			},
+			expectedErrors: []string{"fullTextSearch"},
+		},
+		{
+			name: "base with valid full-text search fields",
+			collection: func(app core.App) (*core.Collection, error) {
+				c := core.NewBaseCollection("new_base")
+				c.Fields.Add(&core.TextField{Name: "title"})
+				c.Fields.Add(&core.EditorField{Name: "content"})
+				c.FullTextSearch.Fields = []string{"title", "content"}
+				return c, nil
+			},
+			expectedErrors: []string{},
+		},
 	}
 
 	for _, s := range scenarios {
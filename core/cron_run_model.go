@@ -0,0 +1,24 @@
+package core
+
+import "github.com/hanzoai/backendPB/tools/types"
+
+var (
+	_ Model = (*CronRun)(nil)
+)
+
+const CronRunsTableName = "_cronRuns"
+
+// CronRun defines a single persisted cron job run history entry.
+type CronRun struct {
+	BaseModel
+
+	Started  types.DateTime `db:"started" json:"started"`
+	JobId    string         `db:"jobId" json:"jobId"`
+	Trigger  string         `db:"trigger" json:"trigger"`
+	Error    string         `db:"error" json:"error"`
+	Duration int64          `db:"duration" json:"duration"` // in ms
+}
+
+func (m *CronRun) TableName() string {
+	return CronRunsTableName
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"github.com/hanzoai/backendPB/tools/cron"
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// MaxCronRunsPerJob is the max number of run history entries
+// that are kept for a single cron job.
+const MaxCronRunsPerJob = 100
+
+// CronRunQuery returns a new CronRun select query.
+func (app *BaseApp) CronRunQuery() *dbx.SelectQuery {
+	return app.AuxModelQuery(&CronRun{})
+}
+
+// FindLastCronRuns returns the most recent run of each cron job
+// indexed by the job id.
+func (app *BaseApp) FindLastCronRuns() (map[string]*CronRun, error) {
+	runs := []*CronRun{}
+
+	err := app.CronRunQuery().
+		AndWhere(dbx.NewExp("[[started]] = (SELECT MAX([[r.started]]) FROM {{_cronRuns}} r WHERE [[r.jobId]] = {{_cronRuns}}.[[jobId]])")).
+		All(&runs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*CronRun, len(runs))
+	for _, run := range runs {
+		result[run.JobId] = run
+	}
+
+	return result, nil
+}
+
+// saveCronRun persists the provided cron job run result and trims
+// the job history to the last [MaxCronRunsPerJob] entries.
+//
+// For better performance the run is stored with plain SQL statements,
+// aka. no model hook events will be fired.
+func (app *BaseApp) saveCronRun(run cron.Run) error {
+	model := &CronRun{
+		JobId:    run.JobId,
+		Trigger:  run.Trigger,
+		Duration: run.Duration.Milliseconds(),
+	}
+	model.Id = GenerateDefaultRandomId()
+	model.Started, _ = types.ParseDateTime(run.Started)
+	if run.Error != nil {
+		model.Error = run.Error.Error()
+	}
+
+	err := app.auxNonconcurrentDB.Model(model).Insert()
+	if err != nil {
+		return err
+	}
+
+	_, err = app.auxNonconcurrentDB.NewQuery(`
+		DELETE FROM {{_cronRuns}}
+		WHERE [[jobId]] = {:jobId} AND [[id]] NOT IN (
+			SELECT [[id]] FROM {{_cronRuns}}
+			WHERE [[jobId]] = {:jobId}
+			ORDER BY [[started]] DESC
+			LIMIT {:limit}
+		)
+	`).Bind(dbx.Params{"jobId": run.JobId, "limit": MaxCronRunsPerJob}).Execute()
+
+	return err
+}
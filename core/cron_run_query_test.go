@@ -0,0 +1,60 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/dbx"
+)
+
+func TestCronRunsHistory(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	app.Cron().RemoveAll()
+	app.Cron().AddWithError("test1", "* * * * *", func() error {
+		return errors.New("test_error")
+	})
+	app.Cron().Add("test2", "* * * * *", func() {})
+
+	for i := 0; i < core.MaxCronRunsPerJob+5; i++ {
+		for _, j := range app.Cron().Jobs() {
+			if j.Id() == "test1" || i == 0 {
+				j.Run()
+			}
+		}
+	}
+
+	var total1, total2 int
+	app.CronRunQuery().Select("count(*)").AndWhere(dbx.HashExp{"jobId": "test1"}).Row(&total1)
+	app.CronRunQuery().Select("count(*)").AndWhere(dbx.HashExp{"jobId": "test2"}).Row(&total2)
+
+	if total1 != core.MaxCronRunsPerJob {
+		t.Fatalf("Expected the test1 history to be trimmed to %d runs, got %d", core.MaxCronRunsPerJob, total1)
+	}
+
+	if total2 != 1 {
+		t.Fatalf("Expected 1 test2 run, got %d", total2)
+	}
+
+	lastRuns, err := app.FindLastCronRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lastRuns) != 2 {
+		t.Fatalf("Expected 2 last runs, got %d", len(lastRuns))
+	}
+
+	if v := lastRuns["test1"]; v == nil || v.Error != "test_error" || v.Trigger != "manual" {
+		t.Fatalf("Invalid test1 last run %#v", v)
+	}
+
+	if v := lastRuns["test2"]; v == nil || v.Error != "" {
+		t.Fatalf("Invalid test2 last run %#v", v)
+	}
+}
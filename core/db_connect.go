@@ -20,3 +20,17 @@ func DefaultDBConnect(dbPath string) (*dbx.DB, error) {
 
 	return db, nil
 }
+
+// DefaultReplicaDBConnect opens a read-only connection to a data db replica.
+func DefaultReplicaDBConnect(dbPath string) (*dbx.DB, error) {
+	// Note: the replica is opened in read-only mode and without the journal pragmas
+	// because they could attempt to write (the WAL is managed by the primary).
+	pragmas := "?mode=ro&_pragma=busy_timeout(10000)&_pragma=query_only(ON)&_pragma=foreign_keys(ON)&_pragma=temp_store(MEMORY)&_pragma=cache_size(-16000)"
+
+	db, err := dbx.Open("sqlite", "file:"+dbPath+pragmas)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -7,3 +7,7 @@ import "github.com/hanzoai/dbx"
 func DefaultDBConnect(dbPath string) (*dbx.DB, error) {
 	panic("DBConnect config option must be set when the no_default_driver tag is used!")
 }
+
+func DefaultReplicaDBConnect(dbPath string) (*dbx.DB, error) {
+	panic("ReplicaDBConnect config option must be set when the no_default_driver tag is used!")
+}
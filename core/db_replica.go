@@ -0,0 +1,188 @@
+package core
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/dbx"
+)
+
+// maxReplicaSessions is the number of tracked write sessions
+// after which the expired ones are removed.
+const maxReplicaSessions = 1000
+
+// ReplicaDB returns the next read-only data db replica (round-robin).
+//
+// It fallbacks to app.DB() if there are no replicas configured
+// or when the app is transactional.
+func (app *BaseApp) ReplicaDB() dbx.Builder {
+	if len(app.replicaDBs) == 0 || app.IsTransactional() {
+		return app.DB()
+	}
+
+	i := app.replicaCounter.Add(1)
+
+	return app.replicaDBs[i%uint64(len(app.replicaDBs))]
+}
+
+// ReplicaApp returns an app wrapper which DB() and record read helpers
+// (RecordQuery, FindRecordById, FindRecordsByIds and therefore the record expand)
+// use a read-only data db replica.
+//
+// All other methods (incl. the writes, validations and transactions)
+// are delegated to the current app and executed on the primary data db.
+//
+// The current app is returned as it is if there are no replicas configured, the app is transactional
+// or the specified session had a write in the last BaseAppConfig.ReplicaReadYourWritesWindow.
+func (app *BaseApp) ReplicaApp(sessionKey string) App {
+	if len(app.replicaDBs) == 0 || app.IsTransactional() || app.HasRecentReplicaWrite(sessionKey) {
+		return app
+	}
+
+	return &replicaApp{BaseApp: app, replicaDB: app.ReplicaDB()}
+}
+
+// replicaApp wraps a BaseApp and routes its concurrent read queries to a data db replica.
+type replicaApp struct {
+	*BaseApp
+	replicaDB dbx.Builder
+}
+
+// DB returns the read-only data db replica of the app wrapper.
+func (app *replicaApp) DB() dbx.Builder {
+	return app.replicaDB
+}
+
+// RecordQuery is similar to [BaseApp.RecordQuery] but uses the data db replica.
+func (app *replicaApp) RecordQuery(collectionModelOrIdentifier any) *dbx.SelectQuery {
+	return app.recordQuery(app.replicaDB, collectionModelOrIdentifier, false)
+}
+
+// RecordQueryWithSoftDeleted is similar to [BaseApp.RecordQueryWithSoftDeleted] but uses the data db replica.
+func (app *replicaApp) RecordQueryWithSoftDeleted(collectionModelOrIdentifier any) *dbx.SelectQuery {
+	return app.recordQuery(app.replicaDB, collectionModelOrIdentifier, true)
+}
+
+// FindRecordById is similar to [BaseApp.FindRecordById] but uses the data db replica.
+func (app *replicaApp) FindRecordById(
+	collectionModelOrIdentifier any,
+	recordId string,
+	optFilters ...func(q *dbx.SelectQuery) error,
+) (*Record, error) {
+	return app.findRecordById(app.replicaDB, collectionModelOrIdentifier, recordId, optFilters...)
+}
+
+// FindRecordsByIds is similar to [BaseApp.FindRecordsByIds] but uses the data db replica.
+func (app *replicaApp) FindRecordsByIds(
+	collectionModelOrIdentifier any,
+	recordIds []string,
+	optFilters ...func(q *dbx.SelectQuery) error,
+) ([]*Record, error) {
+	return app.findRecordsByIds(app.replicaDB, collectionModelOrIdentifier, recordIds, optFilters...)
+}
+
+// TrackReplicaWrite marks the specified session as recently written
+// so that its reads are routed to the primary data db
+// for the duration of the read-your-writes window.
+//
+// The sessions of the non-safe (POST, PUT, PATCH, DELETE) api requests and
+// the model writes with attached [RequestEvent] context are tracked automatically.
+func (app *BaseApp) TrackReplicaWrite(sessionKey string) {
+	if len(app.replicaDBs) == 0 || sessionKey == "" {
+		return
+	}
+
+	now := time.Now()
+
+	// remove the expired sessions
+	if app.replicaWrites.Length() >= maxReplicaSessions {
+		for key, lastWrite := range app.replicaWrites.GetAll() {
+			if now.Sub(lastWrite) > app.config.ReplicaReadYourWritesWindow {
+				app.replicaWrites.Remove(key)
+			}
+		}
+	}
+
+	app.replicaWrites.Set(sessionKey, now)
+}
+
+// HasRecentReplicaWrite reports whether the specified session had a write
+// in the last BaseAppConfig.ReplicaReadYourWritesWindow.
+func (app *BaseApp) HasRecentReplicaWrite(sessionKey string) bool {
+	if sessionKey == "" {
+		return false
+	}
+
+	lastWrite, ok := app.replicaWrites.GetOk(sessionKey)
+
+	return ok && time.Since(lastWrite) <= app.config.ReplicaReadYourWritesWindow
+}
+
+// ReplicaSessionKey returns the read-your-writes session key of the provided request event
+// (the hash of the auth token for authenticated requests or the client ip for guests).
+func ReplicaSessionKey(e *RequestEvent) string {
+	if e == nil {
+		return ""
+	}
+
+	if e.Auth != nil {
+		token := strings.TrimPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+		if token != "" {
+			return "token:" + security.SHA256(token)
+		}
+	}
+
+	return "ip:" + e.RealIP()
+}
+
+// initReplicaDBs opens the configured read-only data db replicas
+// using the same pool and log settings as the primary concurrent db.
+func (app *BaseApp) initReplicaDBs(primary *dbx.DB) error {
+	for _, path := range app.config.DataReplicas {
+		db, err := app.config.ReplicaDBConnect(path)
+		if err != nil {
+			return err
+		}
+		db.DB().SetMaxOpenConns(app.config.DataMaxOpenConns)
+		db.DB().SetMaxIdleConns(app.config.DataMaxIdleConns)
+		db.DB().SetConnMaxIdleTime(3 * time.Minute)
+		db.QueryLogFunc = primary.QueryLogFunc
+		db.ExecLogFunc = primary.ExecLogFunc
+
+		app.replicaDBs = append(app.replicaDBs, db)
+	}
+
+	return nil
+}
+
+func (app *BaseApp) registerReplicaHooks() {
+	trackWrite := func(e *ModelEvent) error {
+		if len(app.replicaDBs) > 0 {
+			if re := RequestEventFromContext(e.Context); re != nil {
+				app.TrackReplicaWrite(ReplicaSessionKey(re))
+			}
+		}
+
+		return e.Next()
+	}
+
+	app.OnModelAfterCreateSuccess().Bind(&hook.Handler[*ModelEvent]{
+		Id:       "__pbReplicaTrackCreate__",
+		Func:     trackWrite,
+		Priority: -99,
+	})
+
+	app.OnModelAfterUpdateSuccess().Bind(&hook.Handler[*ModelEvent]{
+		Id:       "__pbReplicaTrackUpdate__",
+		Func:     trackWrite,
+		Priority: -99,
+	})
+
+	app.OnModelAfterDeleteSuccess().Bind(&hook.Handler[*ModelEvent]{
+		Id:       "__pbReplicaTrackDelete__",
+		Func:     trackWrite,
+		Priority: -99,
+	})
+}
@@ -0,0 +1,158 @@
+package core_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func TestReplicaAppWithoutReplicas(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if app.ReplicaDB() != app.DB() {
+		t.Fatal("Expected ReplicaDB() to fallback to DB()")
+	}
+
+	app.TrackReplicaWrite("test")
+
+	if app.HasRecentReplicaWrite("test") {
+		t.Fatal("Expected the write to not be tracked without replicas")
+	}
+
+	if readApp := app.ReplicaApp("test"); readApp.DB() != app.DB() {
+		t.Fatal("Expected ReplicaApp() to use the primary db")
+	}
+}
+
+func TestReplicaApp(t *testing.T) {
+	const testDataDir = "./hb_replica_app_test_data_dir/"
+	defer os.RemoveAll(testDataDir)
+
+	app := core.NewBaseApp(core.BaseAppConfig{
+		DataDir: testDataDir,
+		// for the test purposes use the same db file as replica
+		DataReplicas:                []string{filepath.Join(testDataDir, "data.db")},
+		ReplicaReadYourWritesWindow: 100 * time.Millisecond,
+	})
+	defer app.ResetBootstrapState()
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.ReplicaDB() == app.DB() {
+		t.Fatal("Expected ReplicaDB() to be different from DB()")
+	}
+
+	readApp := app.ReplicaApp("test")
+	if readApp.DB() == app.DB() {
+		t.Fatal("Expected ReplicaApp() to use the replica db")
+	}
+	if readApp.NonconcurrentDB() != app.NonconcurrentDB() {
+		t.Fatal("Expected ReplicaApp() writes to use the primary db")
+	}
+
+	if _, err := readApp.DB().NewQuery("CREATE TABLE replica_test (a TEXT)").Execute(); err == nil {
+		t.Fatal("Expected the replica db to be read-only")
+	}
+
+	if _, err := readApp.NonconcurrentDB().NewQuery("CREATE TABLE replica_test (a TEXT)").Execute(); err != nil {
+		t.Fatalf("Expected the primary db writes to succeed, got %v", err)
+	}
+
+	readApp.RunInTransaction(func(txApp core.App) error {
+		if txApp.DB() != txApp.NonconcurrentDB() {
+			t.Fatal("Expected the transaction to be on the primary db")
+		}
+
+		if txApp.ReplicaApp("test") != txApp {
+			t.Fatal("Expected ReplicaApp() of a transactional app to return the same app")
+		}
+
+		return nil
+	})
+
+	// read-your-writes
+	app.TrackReplicaWrite("test")
+
+	if !app.HasRecentReplicaWrite("test") {
+		t.Fatal("Expected the write to be tracked")
+	}
+
+	if readApp := app.ReplicaApp("test"); readApp.DB() != app.DB() {
+		t.Fatal("Expected ReplicaApp() to use the primary db after a recent write")
+	}
+
+	if readApp := app.ReplicaApp("other"); readApp.DB() == app.DB() {
+		t.Fatal("Expected ReplicaApp() of another session to use the replica db")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	if app.HasRecentReplicaWrite("test") {
+		t.Fatal("Expected the write window to be expired")
+	}
+
+	if readApp := app.ReplicaApp("test"); readApp.DB() == app.DB() {
+		t.Fatal("Expected ReplicaApp() to use the replica db after the write window")
+	}
+}
+
+func TestReplicaSessionKey(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newEvent := func(auth *core.Record, token string) *core.RequestEvent {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "1.2.3.4:80"
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+
+		e := new(core.RequestEvent)
+		e.App = app
+		e.Request = req
+		e.Auth = auth
+
+		return e
+	}
+
+	scenarios := []struct {
+		name     string
+		event    *core.RequestEvent
+		expected string
+	}{
+		{"nil event", nil, ""},
+		{"guest", newEvent(nil, ""), "ip:1.2.3.4"},
+		{"guest with unverified token", newEvent(nil, "abc"), "ip:1.2.3.4"},
+		{"auth", newEvent(user, "abc"), "token:" + security.SHA256("abc")},
+		{"auth with Bearer prefix", newEvent(user, "Bearer abc"), "token:" + security.SHA256("abc")},
+		{"auth of another session", newEvent(user, "def"), "token:" + security.SHA256("def")},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := core.ReplicaSessionKey(s.event)
+
+			if result != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, result)
+			}
+		})
+	}
+}
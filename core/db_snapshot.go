@@ -0,0 +1,60 @@
+//go:build !no_default_driver
+
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hanzoai/dbx"
+	"modernc.org/sqlite"
+)
+
+// snapshotDB creates a consistent copy of the provided SQLite db at dstPath
+// using the online backup API (aka. without blocking the writes and
+// preserving the db pages layout).
+//
+// It fallbacks to "VACUUM INTO" if the db driver doesn't support
+// the backup API (e.g. when a custom DBConnect is used).
+func snapshotDB(ctx context.Context, db *dbx.DB, dstPath string) error {
+	conn, err := db.DB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var isSupported bool
+
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(interface {
+			NewBackup(dstUri string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return nil
+		}
+
+		isSupported = true
+
+		backup, err := c.NewBackup(dstPath)
+		if err != nil {
+			return err
+		}
+
+		for {
+			more, err := backup.Step(-1)
+			if err != nil {
+				return errors.Join(err, backup.Finish())
+			}
+			if !more {
+				break
+			}
+		}
+
+		return backup.Finish()
+	})
+	if err != nil || isSupported {
+		return err
+	}
+
+	return vacuumInto(ctx, db, dstPath)
+}
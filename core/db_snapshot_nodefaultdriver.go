@@ -0,0 +1,15 @@
+//go:build no_default_driver
+
+package core
+
+import (
+	"context"
+
+	"github.com/hanzoai/dbx"
+)
+
+// snapshotDB creates a consistent copy of the provided SQLite db at dstPath
+// using "VACUUM INTO".
+func snapshotDB(ctx context.Context, db *dbx.DB, dstPath string) error {
+	return vacuumInto(ctx, db, dstPath)
+}
@@ -11,7 +11,7 @@ import (
 func (app *BaseApp) TableColumns(tableName string) ([]string, error) {
 	columns := []string{}
 
-	err := app.DB().NewQuery("SELECT name FROM PRAGMA_TABLE_INFO({:tableName})").
+	err := app.DB().NewQuery("SELECT [[name]] FROM (" + app.DBDialect().TableInfoQuery() + ") {{_tableInfo}}").
 		Bind(dbx.Params{"tableName": tableName}).
 		Column(&columns)
 
@@ -30,11 +30,11 @@ type TableInfoRow struct {
 	DefaultValue sql.NullString `db:"dflt_value"`
 }
 
-// TableInfo returns the "table_info" pragma result for the specified table.
+// TableInfo returns the "table_info" pragma like result for the specified table.
 func (app *BaseApp) TableInfo(tableName string) ([]*TableInfoRow, error) {
 	info := []*TableInfoRow{}
 
-	err := app.DB().NewQuery("SELECT * FROM PRAGMA_TABLE_INFO({:tableName})").
+	err := app.DB().NewQuery(app.DBDialect().TableInfoQuery()).
 		Bind(dbx.Params{"tableName": tableName}).
 		All(&info)
 	if err != nil {
@@ -59,13 +59,8 @@ func (app *BaseApp) TableIndexes(tableName string) (map[string]string, error) {
 		Sql  string
 	}{}
 
-	err := app.DB().Select("name", "sql").
-		From("sqlite_master").
-		AndWhere(dbx.NewExp("sql is not null")).
-		AndWhere(dbx.HashExp{
-			"type":     "index",
-			"tbl_name": tableName,
-		}).
+	err := app.DB().NewQuery(app.DBDialect().TableIndexesQuery()).
+		Bind(dbx.Params{"tableName": tableName}).
 		All(&indexes)
 	if err != nil {
 		return nil, err
@@ -110,11 +105,8 @@ func (app *BaseApp) AuxHasTable(tableName string) bool {
 func (app *BaseApp) hasTable(db dbx.Builder, tableName string) bool {
 	var exists bool
 
-	err := db.Select("(1)").
-		From("sqlite_schema").
-		AndWhere(dbx.HashExp{"type": []any{"table", "view"}}).
-		AndWhere(dbx.NewExp("LOWER([[name]])=LOWER({:tableName})", dbx.Params{"tableName": tableName})).
-		Limit(1).
+	err := db.NewQuery(app.DBDialect().HasTableQuery()).
+		Bind(dbx.Params{"tableName": tableName}).
 		Row(&exists)
 
 	return err == nil && exists
@@ -122,8 +114,26 @@ func (app *BaseApp) hasTable(db dbx.Builder, tableName string) bool {
 
 // Vacuum executes VACUUM on the current app.DB() instance
 // in order to reclaim unused data db disk space.
+//
+// Note that VACUUM could change the records rowid and because of that
+// the collections full-text search indexes are rebuilt after it.
 func (app *BaseApp) Vacuum() error {
-	return app.vacuum(app.DB())
+	if err := app.vacuum(app.DB()); err != nil {
+		return err
+	}
+
+	collections, err := app.FindAllCollections(CollectionTypeBase, CollectionTypeAuth)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range collections {
+		if err := rebuildCollectionFullTextSearch(app, c); err != nil {
+			return fmt.Errorf("failed to rebuild %q full-text search index: %w", c.Name, err)
+		}
+	}
+
+	return nil
 }
 
 // AuxVacuum executes VACUUM on the current app.AuxDB() instance
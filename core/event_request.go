@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"maps"
 	"net/netip"
 	"strings"
@@ -193,3 +194,27 @@ func (info *RequestInfo) Clone() *RequestInfo {
 
 	return clone
 }
+
+// -------------------------------------------------------------------
+
+type requestEventContextKey struct{}
+
+// ContextWithRequestEvent returns a copy of ctx with the provided RequestEvent attached to it.
+//
+// It is usually used to associate the model operations with the request that triggered them
+// (e.g. to access the acting auth record from the model hooks).
+func ContextWithRequestEvent(ctx context.Context, e *RequestEvent) context.Context {
+	return context.WithValue(ctx, requestEventContextKey{}, e)
+}
+
+// RequestEventFromContext returns the RequestEvent attached to ctx
+// with [ContextWithRequestEvent] (if any).
+func RequestEventFromContext(ctx context.Context) *RequestEvent {
+	if ctx == nil {
+		return nil
+	}
+
+	e, _ := ctx.Value(requestEventContextKey{}).(*RequestEvent)
+
+	return e
+}
@@ -95,6 +95,10 @@ type BackupEvent struct {
 	Context context.Context
 	Name    string   // the name of the backup to create/restore.
 	Exclude []string // list of dir entries to exclude from the backup create/restore.
+
+	// PointInTime is the time to restore the data db to from the backup stream
+	// (it is zero for the regular backups).
+	PointInTime time.Time
 }
 
 type ServeEvent struct {
@@ -488,6 +492,29 @@ type RecordAuthWithOTPRequestEvent struct {
 	OTP    *OTP
 }
 
+type RecordAuthWithWebAuthnRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record     *Record
+	Credential *WebAuthnCredential
+}
+
+type RecordAuthWithTOTPRequestEvent struct {
+	hook.Event
+	*RequestEvent
+	baseCollectionEventData
+
+	Record *Record
+	TOTP   *TOTP
+	MFA    *MFA
+
+	// IsRecoveryCode indicates whether the submitted code
+	// is a single use recovery code.
+	IsRecoveryCode bool
+}
+
 type RecordAuthRequestEvent struct {
 	hook.Event
 	*RequestEvent
@@ -134,18 +134,21 @@ func (f *AutodateField) ValidateSettings(ctx context.Context, app App, collectio
 		}
 	}
 
+	// the soft delete field value is managed only by the record delete and restore operations
+	isSoftDeleteField := f.System && f.Name == FieldNameDeleted && collection.SoftDelete.Enabled
+
 	return validation.ValidateStruct(f,
 		validation.Field(&f.Id, validation.By(DefaultFieldIdValidationRule)),
 		validation.Field(&f.Name, validation.By(DefaultFieldNameValidationRule)),
 		validation.Field(
 			&f.OnCreate,
 			validation.When(f.System, validation.By(validators.Equal(oldOnCreate))),
-			validation.Required.Error("either onCreate or onUpdate must be enabled").When(!f.OnUpdate),
+			validation.Required.Error("either onCreate or onUpdate must be enabled").When(!f.OnUpdate && !isSoftDeleteField),
 		),
 		validation.Field(
 			&f.OnUpdate,
 			validation.When(f.System, validation.By(validators.Equal(oldOnUpdate))),
-			validation.Required.Error("either onCreate or onUpdate must be enabled").When(!f.OnCreate),
+			validation.Required.Error("either onCreate or onUpdate must be enabled").When(!f.OnCreate && !isSoftDeleteField),
 		),
 	)
 }
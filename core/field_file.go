@@ -188,7 +188,7 @@ func (f *FileField) IsMultiple() bool {
 // ColumnType implements [Field.ColumnType] interface method.
 func (f *FileField) ColumnType(app App) string {
 	if f.IsMultiple() {
-		return "JSON DEFAULT '[]' NOT NULL"
+		return app.DBDialect().JSONColumnType() + " DEFAULT '[]' NOT NULL"
 	}
 
 	return "TEXT DEFAULT '' NOT NULL"
@@ -151,7 +151,7 @@ func (f *RelationField) IsMultiple() bool {
 // ColumnType implements [Field.ColumnType] interface method.
 func (f *RelationField) ColumnType(app App) string {
 	if f.IsMultiple() {
-		return "JSON DEFAULT '[]' NOT NULL"
+		return app.DBDialect().JSONColumnType() + " DEFAULT '[]' NOT NULL"
 	}
 
 	return "TEXT DEFAULT '' NOT NULL"
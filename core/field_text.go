@@ -144,7 +144,7 @@ func (f *TextField) ColumnType(app App) string {
 		// note: the default is just a last resort fallback to avoid empty
 		// string values in case the record was inserted with raw sql and
 		// it is not actually used when operating with the db abstraction
-		return "TEXT PRIMARY KEY DEFAULT ('r'||lower(hex(randomblob(7)))) NOT NULL"
+		return app.DBDialect().PrimaryKeyColumnType()
 	}
 
 	return "TEXT DEFAULT '' NOT NULL"
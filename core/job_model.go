@@ -0,0 +1,58 @@
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+var (
+	_ Model = (*Job)(nil)
+)
+
+const JobsTableName = "_jobs"
+
+const (
+	JobStatusPending  = "pending"
+	JobStatusRunning  = "running"
+	JobStatusDone     = "done"
+	JobStatusFailed   = "failed"
+	JobStatusCanceled = "canceled"
+)
+
+// Job defines a single persisted background job.
+type Job struct {
+	BaseModel
+
+	Name        string         `db:"name" json:"name"`
+	Payload     types.JSONRaw  `db:"payload" json:"payload"`
+	Status      string         `db:"status" json:"status"`
+	Priority    int            `db:"priority" json:"priority"`
+	Attempts    int            `db:"attempts" json:"attempts"`
+	MaxAttempts int            `db:"maxAttempts" json:"maxAttempts"`
+	UniqueKey   string         `db:"uniqueKey" json:"uniqueKey"`
+	LastError   string         `db:"lastError" json:"lastError"`
+	RunAt       types.DateTime `db:"runAt" json:"runAt"`
+	Created     types.DateTime `db:"created" json:"created"`
+	Updated     types.DateTime `db:"updated" json:"updated"`
+}
+
+func (m *Job) TableName() string {
+	return JobsTableName
+}
+
+// UnmarshalPayload unmarshals the job json payload into the provided result.
+//
+// Example:
+//
+//	data := struct{
+//	    Email string `json:"email"`
+//	}{}
+//	err := job.UnmarshalPayload(&data)
+func (m *Job) UnmarshalPayload(result any) error {
+	if len(m.Payload) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(m.Payload, result)
+}
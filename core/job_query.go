@@ -0,0 +1,21 @@
+package core
+
+import (
+	"github.com/hanzoai/dbx"
+)
+
+// FindJobById returns a single Job model by its id.
+func (app *BaseApp) FindJobById(id string) (*Job, error) {
+	job := &Job{}
+
+	err := app.ModelQuery(job).
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
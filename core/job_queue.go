@@ -0,0 +1,469 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/store"
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// DefaultJobMaxAttempts is the default max number of job run attempts
+// used when [JobOptions.MaxAttempts] is not set.
+const DefaultJobMaxAttempts = 3
+
+const (
+	jobPollInterval = 1 * time.Second
+
+	jobMinRetryDelay = 10 * time.Second
+	jobMaxRetryDelay = 1 * time.Hour
+
+	// jobMaxErrorLength is the max number of characters from the job error to store.
+	jobMaxErrorLength = 500
+)
+
+// JobHandlerFunc defines a background job handler function.
+//
+// The provided context is canceled when the job timeout is reached
+// or when the app is terminating.
+type JobHandlerFunc func(ctx context.Context, job *Job) error
+
+// JobOptions defines the optional job enqueue settings.
+type JobOptions struct {
+	// Delay postpones the first job run with the specified duration.
+	Delay time.Duration `json:"delay"`
+
+	// Priority specifies the job processing order
+	// (jobs with higher priority are processed first).
+	Priority int `json:"priority"`
+
+	// MaxAttempts is the max number of job run attempts before marking
+	// the job as failed (default to [DefaultJobMaxAttempts]).
+	MaxAttempts int `json:"maxAttempts"`
+
+	// UniqueKey prevents enqueuing a new job if there is already a
+	// pending or running job with the same key.
+	UniqueKey string `json:"uniqueKey"`
+}
+
+// jobQueue holds the registered job handlers and the worker pool state.
+type jobQueue struct {
+	handlers *store.Store[string, JobHandlerFunc]
+	wakeup   chan struct{}
+
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+func newJobQueue() *jobQueue {
+	return &jobQueue{
+		handlers: store.New[string, JobHandlerFunc](nil),
+		wakeup:   make(chan struct{}, 1),
+	}
+}
+
+// notify wakes up a single idle worker (if any).
+func (q *jobQueue) notify() {
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// jobRetryDelay returns the exponential backoff delay before the next job attempt.
+func jobRetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	// cap the exponent to prevent overflow
+	if attempts > 20 {
+		return jobMaxRetryDelay
+	}
+
+	delay := jobMinRetryDelay * time.Duration(1<<(attempts-1))
+
+	return min(delay, jobMaxRetryDelay)
+}
+
+func (app *BaseApp) registerJobHooks() {
+	// start the workers after the server routes are registered
+	app.OnServe().Bind(&hook.Handler[*ServeEvent]{
+		Id: "__pbJobsStart__",
+		Func: func(e *ServeEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			app.startJobWorkers(app.Settings().Jobs.Workers)
+
+			return nil
+		},
+		Priority: 999,
+	})
+
+	// wait for the running jobs to complete before closing the db connections
+	app.OnTerminate().Bind(&hook.Handler[*TerminateEvent]{
+		Id: "__pbJobsStop__",
+		Func: func(e *TerminateEvent) error {
+			app.stopJobWorkers()
+
+			return e.Next()
+		},
+		Priority: -998,
+	})
+}
+
+// RegisterJobHandler registers a background job handler for the specified job name.
+//
+// If a handler with the same name already exists it will be replaced.
+//
+// Example:
+//
+//	app.RegisterJobHandler("sendWelcomeEmail", func(ctx context.Context, job *core.Job) error {
+//	    data := struct{
+//	        Email string `json:"email"`
+//	    }{}
+//	    if err := job.UnmarshalPayload(&data); err != nil {
+//	        return err
+//	    }
+//	    // ...
+//	    return nil
+//	})
+func (app *BaseApp) RegisterJobHandler(name string, handler JobHandlerFunc) {
+	app.jobs.handlers.Set(name, handler)
+}
+
+// Enqueue persists a new pending background job for the specified job
+// handler name with a json serializable payload.
+//
+// If options.UniqueKey is set and there is already a pending or running
+// job with the same key, the existing job is returned instead.
+//
+// When called with a transactional app, the job is persisted as part of the transaction.
+//
+// Example:
+//
+//	app.Enqueue("sendWelcomeEmail", map[string]any{"email": "test@example.com"}, core.JobOptions{
+//	    Delay:     5 * time.Minute,
+//	    UniqueKey: "welcome_test@example.com",
+//	})
+func (app *BaseApp) Enqueue(name string, payload any, optOptions ...JobOptions) (*Job, error) {
+	if name == "" {
+		return nil, errors.New("missing job name")
+	}
+
+	var options JobOptions
+	if len(optOptions) > 0 {
+		options = optOptions[0]
+	}
+
+	if options.UniqueKey != "" {
+		existing := &Job{}
+
+		err := app.ModelQuery(existing).
+			AndWhere(dbx.HashExp{
+				"uniqueKey": options.UniqueKey,
+				"status":    []any{JobStatusPending, JobStatusRunning},
+			}).
+			Limit(1).
+			One(existing)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	rawPayload, err := types.ParseJSONRaw(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the job payload: %w", err)
+	}
+
+	now := types.NowDateTime()
+
+	job := &Job{}
+	job.Id = GenerateDefaultRandomId()
+	job.Name = name
+	job.Payload = rawPayload
+	job.Status = JobStatusPending
+	job.Priority = options.Priority
+	job.MaxAttempts = options.MaxAttempts
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultJobMaxAttempts
+	}
+	job.UniqueKey = options.UniqueKey
+	job.RunAt = now.Add(options.Delay)
+	job.Created = now
+	job.Updated = now
+
+	if err := app.Save(job); err != nil {
+		return nil, err
+	}
+
+	app.jobs.notify()
+
+	return job, nil
+}
+
+// RetryJob resets a failed or canceled job so that it could be processed again.
+func (app *BaseApp) RetryJob(job *Job) error {
+	if job.Status != JobStatusFailed && job.Status != JobStatusCanceled {
+		return errors.New("only failed or canceled jobs can be retried")
+	}
+
+	now := types.NowDateTime()
+
+	job.Status = JobStatusPending
+	job.Attempts = 0
+	job.LastError = ""
+	job.RunAt = now
+	job.Updated = now
+
+	if err := app.Save(job); err != nil {
+		return err
+	}
+
+	app.jobs.notify()
+
+	return nil
+}
+
+// CancelJob cancels a pending job.
+//
+// Running jobs cannot be canceled.
+func (app *BaseApp) CancelJob(job *Job) error {
+	if job.Status != JobStatusPending {
+		return errors.New("only pending jobs can be canceled")
+	}
+
+	job.Status = JobStatusCanceled
+	job.Updated = types.NowDateTime()
+
+	return app.Save(job)
+}
+
+// ProcessJobs runs synchronously all due jobs until there are no more.
+//
+// It is usually used in tests or when the job workers are disabled.
+func (app *BaseApp) ProcessJobs() error {
+	for {
+		ok, err := app.runNextJob(context.Background())
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return nil
+		}
+	}
+}
+
+func (app *BaseApp) startJobWorkers(total int) {
+	app.jobs.mu.Lock()
+	defer app.jobs.mu.Unlock()
+
+	if app.jobs.cancel != nil || total <= 0 {
+		return // already started or disabled
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.jobs.cancel = cancel
+
+	for i := 0; i < total; i++ {
+		app.jobs.wg.Add(1)
+		go func() {
+			defer app.jobs.wg.Done()
+			app.runJobWorker(ctx)
+		}()
+	}
+}
+
+func (app *BaseApp) stopJobWorkers() {
+	app.jobs.mu.Lock()
+	cancel := app.jobs.cancel
+	app.jobs.cancel = nil
+	app.jobs.mu.Unlock()
+
+	if cancel == nil {
+		return // not started
+	}
+
+	cancel()
+
+	app.jobs.wg.Wait()
+}
+
+func (app *BaseApp) runJobWorker(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// process the due jobs until there is none
+		for ctx.Err() == nil {
+			ok, err := app.runNextJob(ctx)
+			if err != nil {
+				app.Logger().Warn("Failed to process the next job", slog.String("error", err.Error()))
+				break
+			}
+
+			if !ok {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-app.jobs.wakeup:
+		}
+	}
+}
+
+// runNextJob claims and runs the next due job.
+//
+// Returns false if there are no due jobs.
+func (app *BaseApp) runNextJob(ctx context.Context) (bool, error) {
+	job, err := app.claimNextJob()
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	runErr := app.runJob(ctx, job)
+
+	now := types.NowDateTime()
+
+	params := dbx.Params{"updated": now.String()}
+
+	if runErr == nil {
+		params["status"] = JobStatusDone
+		params["lastError"] = ""
+	} else {
+		params["lastError"] = truncateJobError(runErr.Error())
+
+		if job.Attempts >= job.MaxAttempts {
+			params["status"] = JobStatusFailed
+		} else {
+			params["status"] = JobStatusPending
+			params["runAt"] = now.Add(jobRetryDelay(job.Attempts)).String()
+		}
+	}
+
+	_, err = app.NonconcurrentDB().Update(JobsTableName, params, dbx.HashExp{"id": job.Id}).Execute()
+	if err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// claimNextJob marks the next due job as running and returns it.
+//
+// Running jobs with expired lease (eg. because of app crash)
+// are also considered due and are claimed again.
+//
+// Returns nil if there are no due jobs.
+func (app *BaseApp) claimNextJob() (*Job, error) {
+	timeout := app.jobTimeout()
+
+	for {
+		now := types.NowDateTime()
+
+		job := &Job{}
+
+		err := app.ModelQuery(job).
+			AndWhere(dbx.HashExp{"status": []any{JobStatusPending, JobStatusRunning}}).
+			AndWhere(dbx.NewExp("[[runAt]] <= {:now}", dbx.Params{"now": now.String()})).
+			OrderBy("priority DESC", "runAt ASC").
+			Limit(1).
+			One(job)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		params := dbx.Params{"updated": now.String()}
+
+		// the job was interrupted on its last attempt
+		interrupted := job.Status == JobStatusRunning && job.Attempts >= job.MaxAttempts
+		if interrupted {
+			params["status"] = JobStatusFailed
+			params["lastError"] = "the job was interrupted or its timeout was reached"
+		} else {
+			params["status"] = JobStatusRunning
+			params["attempts"] = job.Attempts + 1
+			// lease the job so that it is not claimed again while running
+			params["runAt"] = now.Add(timeout + time.Minute).String()
+		}
+
+		// conditional update to ensure that the same job is not claimed by multiple workers
+		claim, err := app.NonconcurrentDB().Update(
+			JobsTableName,
+			params,
+			dbx.HashExp{"id": job.Id, "status": job.Status, "runAt": job.RunAt.String()},
+		).Execute()
+		if err != nil {
+			return nil, err
+		}
+
+		if affected, _ := claim.RowsAffected(); affected == 0 || interrupted {
+			continue // claimed by another worker or failed
+		}
+
+		job.Status = JobStatusRunning
+		job.Attempts++
+
+		return job, nil
+	}
+}
+
+// runJob executes the registered job handler recovering from any panics.
+func (app *BaseApp) runJob(ctx context.Context, job *Job) (err error) {
+	handler, ok := app.jobs.handlers.GetOk(job.Name)
+	if !ok || handler == nil {
+		return fmt.Errorf("missing %q job handler", job.Name)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panic: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, app.jobTimeout())
+	defer cancel()
+
+	return handler(ctx, job)
+}
+
+func (app *BaseApp) jobTimeout() time.Duration {
+	timeout := time.Duration(app.Settings().Jobs.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+
+	return timeout
+}
+
+func truncateJobError(str string) string {
+	if len(str) > jobMaxErrorLength {
+		return str[:jobMaxErrorLength] + "..."
+	}
+
+	return str
+}
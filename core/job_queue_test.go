@@ -0,0 +1,203 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+)
+
+func TestEnqueue(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if _, err := app.Enqueue("", nil); err == nil {
+		t.Fatal("Expected error for missing job name")
+	}
+
+	job, err := app.Enqueue("test", map[string]any{"a": 1}, core.JobOptions{
+		Priority:  2,
+		UniqueKey: "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := app.FindJobById(job.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Name != "test" ||
+		stored.Status != core.JobStatusPending ||
+		stored.Priority != 2 ||
+		stored.MaxAttempts != core.DefaultJobMaxAttempts ||
+		stored.Payload.String() != `{"a":1}` {
+		t.Fatalf("Invalid stored job %#v", stored)
+	}
+
+	// unique key
+	duplicated, err := app.Enqueue("test", nil, core.JobOptions{UniqueKey: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duplicated.Id != job.Id {
+		t.Fatalf("Expected the existing job %q to be returned, got %q", job.Id, duplicated.Id)
+	}
+}
+
+func TestEnqueueInTransaction(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	var jobId string
+
+	txErr := app.RunInTransaction(func(txApp core.App) error {
+		job, err := txApp.Enqueue("test", nil)
+		if err != nil {
+			return err
+		}
+		jobId = job.Id
+
+		return errors.New("rollback")
+	})
+	if txErr == nil {
+		t.Fatal("Expected transaction error")
+	}
+
+	if _, err := app.FindJobById(jobId); err == nil {
+		t.Fatal("Expected the job to be rolled back together with the transaction")
+	}
+}
+
+func TestProcessJobs(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	calls := []string{}
+
+	app.RegisterJobHandler("ok", func(ctx context.Context, job *core.Job) error {
+		data := struct {
+			Name string `json:"name"`
+		}{}
+		if err := job.UnmarshalPayload(&data); err != nil {
+			return err
+		}
+
+		calls = append(calls, data.Name)
+
+		return nil
+	})
+
+	app.RegisterJobHandler("error", func(ctx context.Context, job *core.Job) error {
+		return errors.New("test error")
+	})
+
+	app.RegisterJobHandler("panic", func(ctx context.Context, job *core.Job) error {
+		panic("test panic")
+	})
+
+	low, _ := app.Enqueue("ok", map[string]any{"name": "low"})
+	high, _ := app.Enqueue("ok", map[string]any{"name": "high"}, core.JobOptions{Priority: 10})
+	delayed, _ := app.Enqueue("ok", map[string]any{"name": "delayed"}, core.JobOptions{Delay: time.Hour})
+	failed, _ := app.Enqueue("error", nil, core.JobOptions{MaxAttempts: 1})
+	retried, _ := app.Enqueue("error", nil, core.JobOptions{MaxAttempts: 2})
+	panicked, _ := app.Enqueue("panic", nil, core.JobOptions{MaxAttempts: 1})
+	missing, _ := app.Enqueue("missing", nil, core.JobOptions{MaxAttempts: 1})
+
+	if err := app.ProcessJobs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(calls, []string{"high", "low"}) {
+		t.Fatalf("Expected the jobs to be processed by priority, got %v", calls)
+	}
+
+	scenarios := []struct {
+		job            *core.Job
+		expectedStatus string
+		expectedTries  int
+		expectedError  bool
+	}{
+		{low, core.JobStatusDone, 1, false},
+		{high, core.JobStatusDone, 1, false},
+		{delayed, core.JobStatusPending, 0, false},
+		{failed, core.JobStatusFailed, 1, true},
+		{retried, core.JobStatusPending, 1, true},
+		{panicked, core.JobStatusFailed, 1, true},
+		{missing, core.JobStatusFailed, 1, true},
+	}
+
+	for _, s := range scenarios {
+		job, err := app.FindJobById(s.job.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.Status != s.expectedStatus {
+			t.Errorf("[%s] Expected status %q, got %q", job.Name, s.expectedStatus, job.Status)
+		}
+
+		if job.Attempts != s.expectedTries {
+			t.Errorf("[%s] Expected %d attempts, got %d", job.Name, s.expectedTries, job.Attempts)
+		}
+
+		if hasErr := job.LastError != ""; hasErr != s.expectedError {
+			t.Errorf("[%s] Expected hasErr %v, got %v (%q)", job.Name, s.expectedError, hasErr, job.LastError)
+		}
+	}
+
+	// the retried job should be rescheduled in the future
+	retriedJob, _ := app.FindJobById(retried.Id)
+	if !retriedJob.RunAt.Time().After(time.Now()) {
+		t.Fatalf("Expected the retried job to be rescheduled, got %v", retriedJob.RunAt)
+	}
+}
+
+func TestRetryAndCancelJob(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	job, err := app.Enqueue("test", nil, core.JobOptions{Delay: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.RetryJob(job); err == nil {
+		t.Fatal("Expected error when retrying a pending job")
+	}
+
+	if err := app.CancelJob(job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.CancelJob(job); err == nil {
+		t.Fatal("Expected error when canceling an already canceled job")
+	}
+
+	canceled, _ := app.FindJobById(job.Id)
+	if canceled.Status != core.JobStatusCanceled {
+		t.Fatalf("Expected status %q, got %q", core.JobStatusCanceled, canceled.Status)
+	}
+
+	if err := app.RetryJob(canceled); err != nil {
+		t.Fatal(err)
+	}
+
+	retried, _ := app.FindJobById(job.Id)
+	if retried.Status != core.JobStatusPending || retried.Attempts != 0 || retried.RunAt.Time().After(time.Now()) {
+		t.Fatalf("Expected pending job ready to run, got %#v", retried)
+	}
+}
@@ -13,6 +13,8 @@ const (
 	MFAMethodPassword = "password"
 	MFAMethodOAuth2   = "oauth2"
 	MFAMethodOTP      = "otp"
+	MFAMethodWebAuthn = "webauthn"
+	MFAMethodTOTP     = "totp"
 )
 
 const CollectionNameMFAs = "_mfas"
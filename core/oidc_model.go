@@ -0,0 +1,401 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+const (
+	CollectionNameOIDCClients   = "_oidcClients"
+	CollectionNameOIDCAuthCodes = "_oidcAuthCodes"
+)
+
+// OIDCAuthCodeDuration is the max allowed time between the
+// authorization code creation and its exchange for tokens.
+const OIDCAuthCodeDuration = 5 * time.Minute
+
+var (
+	_ Model        = (*OIDCClient)(nil)
+	_ PreValidator = (*OIDCClient)(nil)
+	_ RecordProxy  = (*OIDCClient)(nil)
+
+	_ Model        = (*OIDCAuthCode)(nil)
+	_ PreValidator = (*OIDCAuthCode)(nil)
+	_ RecordProxy  = (*OIDCAuthCode)(nil)
+)
+
+// OIDCClient defines a Record proxy for working with the
+// OAuth2/OIDC provider registered client apps collection.
+type OIDCClient struct {
+	*Record
+}
+
+// NewOIDCClient instantiates and returns a new blank *OIDCClient model.
+//
+// Example usage:
+//
+//	client := core.NewOIDCClient(app)
+//	client.SetName("CRM")
+//	client.SetRedirectURIs([]string{"https://crm.example.com/oauth2/callback"})
+//	app.Save(client)
+func NewOIDCClient(app App) *OIDCClient {
+	m := &OIDCClient{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameOIDCClients)
+	if err != nil {
+		// this is just to make tests easier since the clients collection is created on demand
+		// (note: the loaded record is further checked on OIDCClient.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *OIDCClient) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameOIDCClients {
+		return errors.New("missing or invalid oidc client ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *OIDCClient) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *OIDCClient) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// Name returns the "name" record field value.
+func (m *OIDCClient) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *OIDCClient) SetName(name string) {
+	m.Set("name", name)
+}
+
+// Secret returns the "secret" record field value.
+func (m *OIDCClient) Secret() string {
+	return m.GetString("secret")
+}
+
+// SetSecret updates the "secret" record field value.
+func (m *OIDCClient) SetSecret(secret string) {
+	m.Set("secret", secret)
+}
+
+// ValidateSecret reports whether the provided secret matches with the client one.
+func (m *OIDCClient) ValidateSecret(secret string) bool {
+	return secret != "" && security.Equal(secret, m.Secret())
+}
+
+// Public returns the "public" record field value.
+//
+// Public clients (e.g. SPA and mobile apps) can't keep their secret
+// confidential and are not required to authenticate on the token endpoint.
+func (m *OIDCClient) Public() bool {
+	return m.GetBool("public")
+}
+
+// SetPublic updates the "public" record field value.
+func (m *OIDCClient) SetPublic(public bool) {
+	m.Set("public", public)
+}
+
+// RedirectURIs returns the "redirectURIs" record field value.
+func (m *OIDCClient) RedirectURIs() []string {
+	uris := []string{}
+	m.UnmarshalJSONField("redirectURIs", &uris)
+	return uris
+}
+
+// SetRedirectURIs updates the "redirectURIs" record field value.
+func (m *OIDCClient) SetRedirectURIs(uris []string) {
+	m.Set("redirectURIs", uris)
+}
+
+// HasRedirectURI reports whether the provided uri exactly matches
+// one of the client registered redirect uris.
+func (m *OIDCClient) HasRedirectURI(uri string) bool {
+	return uri != "" && slices.Contains(m.RedirectURIs(), uri)
+}
+
+// Created returns the "created" record field value.
+func (m *OIDCClient) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *OIDCClient) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// -------------------------------------------------------------------
+
+// OIDCAuthCode defines a Record proxy for working with the
+// OAuth2/OIDC provider authorization codes collection.
+type OIDCAuthCode struct {
+	*Record
+}
+
+// NewOIDCAuthCode instantiates and returns a new blank *OIDCAuthCode model.
+func NewOIDCAuthCode(app App) *OIDCAuthCode {
+	m := &OIDCAuthCode{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameOIDCAuthCodes)
+	if err != nil {
+		// this is just to make tests easier since the auth codes collection is created on demand
+		// (note: the loaded record is further checked on OIDCAuthCode.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *OIDCAuthCode) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameOIDCAuthCodes {
+		return errors.New("missing or invalid oidc auth code ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *OIDCAuthCode) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *OIDCAuthCode) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// ClientRef returns the "clientRef" record field value.
+func (m *OIDCAuthCode) ClientRef() string {
+	return m.GetString("clientRef")
+}
+
+// SetClientRef updates the "clientRef" record field value.
+func (m *OIDCAuthCode) SetClientRef(clientId string) {
+	m.Set("clientRef", clientId)
+}
+
+// CollectionRef returns the "collectionRef" record field value.
+func (m *OIDCAuthCode) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *OIDCAuthCode) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *OIDCAuthCode) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *OIDCAuthCode) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// SetCode stores the sha256 hash of the provided plain authorization code
+// (the plain code is never persisted).
+func (m *OIDCAuthCode) SetCode(code string) {
+	m.Set("codeHash", security.SHA256(code))
+}
+
+// RedirectURI returns the "redirectURI" record field value.
+func (m *OIDCAuthCode) RedirectURI() string {
+	return m.GetString("redirectURI")
+}
+
+// SetRedirectURI updates the "redirectURI" record field value.
+func (m *OIDCAuthCode) SetRedirectURI(uri string) {
+	m.Set("redirectURI", uri)
+}
+
+// Scope returns the "scope" record field value
+// (space separated list with the granted scopes).
+func (m *OIDCAuthCode) Scope() string {
+	return m.GetString("scope")
+}
+
+// SetScope updates the "scope" record field value.
+func (m *OIDCAuthCode) SetScope(scope string) {
+	m.Set("scope", scope)
+}
+
+// Nonce returns the "nonce" record field value.
+func (m *OIDCAuthCode) Nonce() string {
+	return m.GetString("nonce")
+}
+
+// SetNonce updates the "nonce" record field value.
+func (m *OIDCAuthCode) SetNonce(nonce string) {
+	m.Set("nonce", nonce)
+}
+
+// CodeChallenge returns the "codeChallenge" record field value
+// (the PKCE S256 code challenge).
+func (m *OIDCAuthCode) CodeChallenge() string {
+	return m.GetString("codeChallenge")
+}
+
+// SetCodeChallenge updates the "codeChallenge" record field value.
+func (m *OIDCAuthCode) SetCodeChallenge(challenge string) {
+	m.Set("codeChallenge", challenge)
+}
+
+// ValidateCodeVerifier reports whether the provided PKCE code verifier
+// matches with the stored S256 code challenge.
+func (m *OIDCAuthCode) ValidateCodeVerifier(verifier string) bool {
+	return verifier != "" && security.Equal(security.S256Challenge(verifier), m.CodeChallenge())
+}
+
+// Created returns the "created" record field value.
+func (m *OIDCAuthCode) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *OIDCAuthCode) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// HasExpired checks if the authorization code is expired, aka. whether it has been
+// more than maxElapsed time since its creation.
+func (m *OIDCAuthCode) HasExpired(maxElapsed time.Duration) bool {
+	return time.Since(m.Created().Time()) > maxElapsed
+}
+
+// -------------------------------------------------------------------
+
+// newOIDCClientsCollection returns the default oidc clients system collection definition.
+func newOIDCClientsCollection() *Collection {
+	col := NewBaseCollection(CollectionNameOIDCClients)
+	col.System = true
+
+	col.Fields.Add(&TextField{
+		Name:     "name",
+		System:   true,
+		Required: true,
+		Max:      255,
+	})
+	col.Fields.Add(&TextField{
+		Name:                "secret",
+		System:              true,
+		Required:            true,
+		Min:                 30,
+		Max:                 255,
+		AutogeneratePattern: "[a-zA-Z0-9]{50}",
+	})
+	col.Fields.Add(&BoolField{
+		Name:   "public",
+		System: true,
+	})
+	col.Fields.Add(&JSONField{
+		Name:     "redirectURIs",
+		System:   true,
+		Required: true,
+		MaxSize:  10000,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+
+	return col
+}
+
+// newOIDCAuthCodesCollection returns the default oidc authorization codes system collection definition.
+func newOIDCAuthCodesCollection(clientsCollectionId string) *Collection {
+	col := NewBaseCollection(CollectionNameOIDCAuthCodes)
+	col.System = true
+
+	col.Fields.Add(&RelationField{
+		Name:          "clientRef",
+		System:        true,
+		Required:      true,
+		MaxSelect:     1,
+		CollectionId:  clientsCollectionId,
+		CascadeDelete: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "codeHash",
+		System:   true,
+		Required: true,
+		Hidden:   true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "redirectURI",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "scope",
+		System: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "nonce",
+		System: true,
+		Hidden: true,
+		Max:    255,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "codeChallenge",
+		System:   true,
+		Required: true,
+		Hidden:   true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_oidcAuthCodes_codeHash", true, "codeHash", "")
+
+	return col
+}
@@ -0,0 +1,511 @@
+package core_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+func enableTestOIDC(t *testing.T, app core.App) {
+	// the provider requires asymmetric auth token signing
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.AuthTokenSigning.Algorithm = core.AuthTokenAlgorithmRS256
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Settings().OIDC.Enabled = true
+	app.Settings().OIDC.AuthCollection = "users"
+	app.Settings().OIDC.LoginURL = "https://example.com/login"
+	app.Settings().OIDC.TokenDuration = 3600
+
+	if err := app.Save(app.Settings()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestOIDCClient(t *testing.T, app core.App) *core.OIDCClient {
+	client := core.NewOIDCClient(app)
+	client.SetName("test")
+	client.SetRedirectURIs([]string{"https://example.com/callback"})
+
+	if err := app.Save(client); err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestOIDCCollectionsOnDemand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	for _, name := range []string{core.CollectionNameOIDCClients, core.CollectionNameOIDCAuthCodes} {
+		if _, err := app.FindCollectionByNameOrId(name); err == nil {
+			t.Fatalf("Expected the %s collection to not exist", name)
+		}
+	}
+
+	enableTestOIDC(t, app)
+
+	for _, name := range []string{core.CollectionNameOIDCClients, core.CollectionNameOIDCAuthCodes} {
+		collection, err := app.FindCollectionByNameOrId(name)
+		if err != nil {
+			t.Fatalf("Expected the %s collection to be created, got %v", name, err)
+		}
+
+		if !collection.System {
+			t.Fatalf("Expected the %s collection to be system", name)
+		}
+	}
+}
+
+func TestNewOIDCClient(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	// missing collection
+	if m := core.NewOIDCClient(app); m.Collection().Name == core.CollectionNameOIDCClients {
+		t.Fatal("Expected invalid placeholder collection")
+	}
+
+	enableTestOIDC(t, app)
+
+	m := core.NewOIDCClient(app)
+
+	if m.Collection().Name != core.CollectionNameOIDCClients {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameOIDCClients, m.Collection().Name)
+	}
+}
+
+func TestOIDCClientProxyRecord(t *testing.T) {
+	t.Parallel()
+
+	record := core.NewRecord(core.NewBaseCollection("test"))
+	record.Id = "test_id"
+
+	m := core.OIDCClient{}
+	m.SetProxyRecord(record)
+
+	if m.ProxyRecord() == nil || m.ProxyRecord().Id != record.Id {
+		t.Fatalf("Expected proxy record with id %q, got %v", record.Id, m.ProxyRecord())
+	}
+}
+
+func TestOIDCClientFields(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestOIDC(t, app)
+
+	m := core.NewOIDCClient(app)
+
+	scenarios := []struct {
+		field  string
+		setter func(string)
+		getter func() string
+	}{
+		{"name", m.SetName, m.Name},
+		{"secret", m.SetSecret, m.Secret},
+	}
+
+	for _, s := range scenarios {
+		for i, testValue := range []string{"test_1", "test2", ""} {
+			t.Run(fmt.Sprintf("%s_%d_%q", s.field, i, testValue), func(t *testing.T) {
+				s.setter(testValue)
+
+				if v := s.getter(); v != testValue {
+					t.Fatalf("Expected getter %q, got %q", testValue, v)
+				}
+
+				if v := m.GetString(s.field); v != testValue {
+					t.Fatalf("Expected field value %q, got %q", testValue, v)
+				}
+			})
+		}
+	}
+
+	m.SetPublic(true)
+	if !m.Public() {
+		t.Fatal("Expected public true")
+	}
+
+	uris := []string{"https://example.com/a", "com.example.app:/callback"}
+	m.SetRedirectURIs(uris)
+	if v := m.RedirectURIs(); !slices.Equal(v, uris) {
+		t.Fatalf("Expected redirect uris %v, got %v", uris, v)
+	}
+}
+
+func TestOIDCClientValidateSecret(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestOIDC(t, app)
+
+	m := core.NewOIDCClient(app)
+
+	if m.ValidateSecret("") {
+		t.Fatal("Expected empty secret to fail")
+	}
+
+	m.SetSecret("test_secret")
+
+	if m.ValidateSecret("") || m.ValidateSecret("invalid") {
+		t.Fatal("Expected invalid secrets to fail")
+	}
+
+	if !m.ValidateSecret("test_secret") {
+		t.Fatal("Expected valid secret")
+	}
+}
+
+func TestOIDCClientHasRedirectURI(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestOIDC(t, app)
+
+	m := core.NewOIDCClient(app)
+	m.SetRedirectURIs([]string{"https://example.com/callback"})
+
+	scenarios := []struct {
+		uri      string
+		expected bool
+	}{
+		{"", false},
+		{"https://example.com", false},
+		{"https://example.com/callback/", false},
+		{"https://example.com/callback?a=1", false},
+		{"https://example.com/callback", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.uri, func(t *testing.T) {
+			if v := m.HasRedirectURI(s.uri); v != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, v)
+			}
+		})
+	}
+}
+
+func TestOIDCClientValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	t.Run("no proxy record", func(t *testing.T) {
+		m := &core.OIDCClient{}
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	enableTestOIDC(t, app)
+
+	scenarios := []struct {
+		name        string
+		uris        []string
+		expectError bool
+	}{
+		{"missing redirect uris", nil, true},
+		{"relative redirect uri", []string{"/callback"}, true},
+		{"redirect uri with fragment", []string{"https://example.com/callback#test"}, true},
+		{"valid redirect uris", []string{"https://example.com/callback", "com.example.app:/callback"}, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			m := core.NewOIDCClient(app)
+			m.SetName("test")
+			if s.uris != nil {
+				m.SetRedirectURIs(s.uris)
+			}
+
+			err := app.Validate(m)
+
+			if hasErr := err != nil; hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+		})
+	}
+}
+
+func TestOIDCClientSecretAutogenerate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestOIDC(t, app)
+
+	client := newTestOIDCClient(t, app)
+
+	if len(client.Secret()) != 50 {
+		t.Fatalf("Expected autogenerated 50 characters secret, got %q", client.Secret())
+	}
+
+	found, err := app.FindOIDCClientById(client.Id)
+	if err != nil || found.Secret() != client.Secret() {
+		t.Fatalf("Expected to find client %q, got %v (%v)", client.Id, found, err)
+	}
+}
+
+func TestOIDCAuthCodeFields(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestOIDC(t, app)
+
+	m := core.NewOIDCAuthCode(app)
+
+	scenarios := []struct {
+		field  string
+		setter func(string)
+		getter func() string
+	}{
+		{"clientRef", m.SetClientRef, m.ClientRef},
+		{"collectionRef", m.SetCollectionRef, m.CollectionRef},
+		{"recordRef", m.SetRecordRef, m.RecordRef},
+		{"redirectURI", m.SetRedirectURI, m.RedirectURI},
+		{"scope", m.SetScope, m.Scope},
+		{"nonce", m.SetNonce, m.Nonce},
+		{"codeChallenge", m.SetCodeChallenge, m.CodeChallenge},
+	}
+
+	for _, s := range scenarios {
+		for i, testValue := range []string{"test_1", "test2", ""} {
+			t.Run(fmt.Sprintf("%s_%d_%q", s.field, i, testValue), func(t *testing.T) {
+				s.setter(testValue)
+
+				if v := s.getter(); v != testValue {
+					t.Fatalf("Expected getter %q, got %q", testValue, v)
+				}
+
+				if v := m.GetString(s.field); v != testValue {
+					t.Fatalf("Expected field value %q, got %q", testValue, v)
+				}
+			})
+		}
+	}
+
+	m.SetCode("test")
+	if v := m.GetString("codeHash"); v != security.SHA256("test") {
+		t.Fatalf("Expected the code hash to be stored, got %q", v)
+	}
+}
+
+func TestOIDCAuthCodeValidateCodeVerifier(t *testing.T) {
+	t.Parallel()
+
+	m := core.OIDCAuthCode{}
+	m.SetProxyRecord(core.NewRecord(core.NewBaseCollection("test")))
+
+	verifier := security.RandomString(50)
+	m.SetCodeChallenge(security.S256Challenge(verifier))
+
+	if m.ValidateCodeVerifier("") || m.ValidateCodeVerifier("invalid") {
+		t.Fatal("Expected invalid code verifiers to fail")
+	}
+
+	if !m.ValidateCodeVerifier(verifier) {
+		t.Fatal("Expected valid code verifier")
+	}
+}
+
+func TestOIDCAuthCodeHasExpired(t *testing.T) {
+	t.Parallel()
+
+	m := core.OIDCAuthCode{}
+	m.SetProxyRecord(core.NewRecord(core.NewBaseCollection("test")))
+	m.SetRaw("created", types.NowDateTime().Add(-2*time.Minute))
+
+	if !m.HasExpired(time.Minute) {
+		t.Fatal("Expected the auth code to be expired")
+	}
+
+	if m.HasExpired(5 * time.Minute) {
+		t.Fatal("Expected the auth code to not be expired")
+	}
+}
+
+func TestOIDCAuthCodeRefsDelete(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestOIDC(t, app)
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestOIDCClient(t, app)
+
+	newAuthCode := func(code string) *core.OIDCAuthCode {
+		m := core.NewOIDCAuthCode(app)
+		m.SetClientRef(client.Id)
+		m.SetCollectionRef(user.Collection().Id)
+		m.SetRecordRef(user.Id)
+		m.SetCode(code)
+		m.SetRedirectURI("https://example.com/callback")
+		m.SetCodeChallenge(security.S256Challenge("test"))
+
+		if err := app.Save(m); err != nil {
+			t.Fatal(err)
+		}
+
+		return m
+	}
+
+	m1 := newAuthCode("test1")
+	newAuthCode("test2")
+
+	found, err := app.FindOIDCAuthCodeByCode("test1")
+	if err != nil || found.Id != m1.Id {
+		t.Fatalf("Expected to find auth code %q, got %v (%v)", m1.Id, found, err)
+	}
+
+	// delete with the user
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindOIDCAuthCodeByCode("test1"); err == nil {
+		t.Fatal("Expected the auth code to be deleted with the user")
+	}
+
+	// delete with the client
+	user2, err := app.FindAuthRecordByEmail("users", "test2@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user = user2
+	m3 := newAuthCode("test3")
+
+	if err := app.Delete(client); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindOIDCAuthCodeByCode("test3"); err == nil {
+		t.Fatalf("Expected the auth code %q to be deleted with the client", m3.Id)
+	}
+}
+
+func TestConsumeOIDCAuthCode(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestOIDC(t, app)
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestOIDCClient(t, app)
+
+	m := core.NewOIDCAuthCode(app)
+	m.SetClientRef(client.Id)
+	m.SetCollectionRef(user.Collection().Id)
+	m.SetRecordRef(user.Id)
+	m.SetCode("test")
+	m.SetRedirectURI("https://example.com/callback")
+	m.SetCodeChallenge(security.S256Challenge("test"))
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.ConsumeOIDCAuthCode("missing"); err == nil {
+		t.Fatal("Expected error for missing auth code")
+	}
+
+	consumed, err := app.ConsumeOIDCAuthCode("test")
+	if err != nil || consumed.Id != m.Id {
+		t.Fatalf("Expected to consume auth code %q, got %v (%v)", m.Id, consumed, err)
+	}
+
+	if _, err := app.ConsumeOIDCAuthCode("test"); err == nil {
+		t.Fatal("Expected the auth code to be consumable only once")
+	}
+
+	if _, err := app.FindOIDCAuthCodeByCode("test"); err == nil {
+		t.Fatal("Expected the consumed auth code to be deleted")
+	}
+}
+
+func TestDeleteExpiredOIDCAuthCodes(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	// missing collection
+	if err := app.DeleteExpiredOIDCAuthCodes(); err != nil {
+		t.Fatalf("Expected nil error for missing collection, got %v", err)
+	}
+
+	enableTestOIDC(t, app)
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestOIDCClient(t, app)
+
+	now := types.NowDateTime()
+
+	for i, created := range []types.DateTime{now, now.Add(-core.OIDCAuthCodeDuration - time.Minute)} {
+		m := core.NewOIDCAuthCode(app)
+		m.SetClientRef(client.Id)
+		m.SetCollectionRef(user.Collection().Id)
+		m.SetRecordRef(user.Id)
+		m.SetCode(fmt.Sprintf("test%d", i))
+		m.SetRedirectURI("https://example.com/callback")
+		m.SetCodeChallenge(security.S256Challenge("test"))
+		m.SetRaw("created", created)
+		if err := app.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.DeleteExpiredOIDCAuthCodes(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindOIDCAuthCodeByCode("test0"); err != nil {
+		t.Fatalf("Expected the non-expired auth code to remain, got %v", err)
+	}
+
+	if _, err := app.FindOIDCAuthCodeByCode("test1"); err == nil {
+		t.Fatal("Expected the expired auth code to be deleted")
+	}
+}
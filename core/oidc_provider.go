@@ -0,0 +1,96 @@
+package core
+
+import (
+	"net/url"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hanzoai/backendPB/tools/hook"
+)
+
+// Supported OAuth2/OIDC provider scopes.
+const (
+	OIDCScopeOpenId  = "openid"
+	OIDCScopeProfile = "profile"
+	OIDCScopeEmail   = "email"
+)
+
+// OIDCScopes is the list with all supported OAuth2/OIDC provider scopes.
+var OIDCScopes = []string{OIDCScopeOpenId, OIDCScopeProfile, OIDCScopeEmail}
+
+func (app *BaseApp) registerOIDCHooks() {
+	// create the oidc system collections on demand
+	settingsSaveHandler := &hook.Handler[*ModelEvent]{
+		Func: func(e *ModelEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			s, ok := e.Model.(*Settings)
+			if !ok || !s.OIDC.Enabled {
+				return nil
+			}
+
+			return createOIDCCollections(e.App)
+		},
+	}
+	app.OnModelAfterCreateSuccess(paramsTable).Bind(settingsSaveHandler)
+	app.OnModelAfterUpdateSuccess(paramsTable).Bind(settingsSaveHandler)
+
+	recordRefHooks[*OIDCAuthCode](app, CollectionNameOIDCAuthCodes, CollectionTypeAuth)
+
+	app.OnRecordValidate(CollectionNameOIDCClients).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			client := &OIDCClient{}
+			client.SetProxyRecord(e.Record)
+
+			err := validation.Validate(client.RedirectURIs(), validation.Required, validation.Each(validation.By(checkOIDCRedirectURI)))
+			if err != nil {
+				return validation.Errors{"redirectURIs": err}
+			}
+
+			return e.Next()
+		},
+		Priority: 99,
+	})
+
+	// run on every hour to cleanup the expired authorization codes
+	app.Cron().Add("__pbOIDCAuthCodesCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredOIDCAuthCodes(); err != nil {
+			app.Logger().Warn("Failed to delete expired OIDC authorization codes", "error", err)
+		}
+	})
+}
+
+// createOIDCCollections creates the oidc provider system collections (if missing).
+func createOIDCCollections(app App) error {
+	clients, err := app.FindCollectionByNameOrId(CollectionNameOIDCClients)
+	if err != nil {
+		clients = newOIDCClientsCollection()
+		if err := app.Save(clients); err != nil {
+			return err
+		}
+	}
+
+	if _, err := app.FindCollectionByNameOrId(CollectionNameOIDCAuthCodes); err == nil {
+		return nil // already exists
+	}
+
+	return app.Save(newOIDCAuthCodesCollection(clients.Id))
+}
+
+// checkOIDCRedirectURI checks whether the value is an absolute redirect uri
+// without fragment as required by [RFC 6749].
+//
+// Custom schemes are allowed to support native apps (e.g. "com.example.app:/callback").
+//
+// [RFC 6749]: https://datatracker.ietf.org/doc/html/rfc6749#section-3.1.2
+func checkOIDCRedirectURI(value any) error {
+	v, _ := value.(string)
+
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme == "" || u.Fragment != "" || len(v) > 2000 {
+		return validation.NewError("validation_invalid_redirect_uri", "Must be an absolute URI without fragment.")
+	}
+
+	return nil
+}
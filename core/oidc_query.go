@@ -0,0 +1,103 @@
+package core
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// FindOIDCClientById returns a single OIDCClient model by its id.
+func (app *BaseApp) FindOIDCClientById(id string) (*OIDCClient, error) {
+	result := &OIDCClient{}
+
+	err := app.RecordQuery(CollectionNameOIDCClients).
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindOIDCAuthCodeByCode returns a single OIDCAuthCode model by its plain authorization code.
+func (app *BaseApp) FindOIDCAuthCodeByCode(code string) (*OIDCAuthCode, error) {
+	result := &OIDCAuthCode{}
+
+	err := app.RecordQuery(CollectionNameOIDCAuthCodes).
+		AndWhere(dbx.HashExp{"codeHash": security.SHA256(code)}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ConsumeOIDCAuthCode finds and deletes the OIDCAuthCode model
+// by its plain authorization code.
+//
+// The code is removed with a conditional delete and [sql.ErrNoRows]
+// is returned if it is missing or it was already consumed by another
+// (concurrent) request, ensuring that each code is exchanged at most once.
+func (app *BaseApp) ConsumeOIDCAuthCode(code string) (*OIDCAuthCode, error) {
+	result, err := app.FindOIDCAuthCodeByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := app.NonconcurrentDB().Delete(CollectionNameOIDCAuthCodes, dbx.HashExp{"id": result.Id}).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected != 1 {
+		return nil, sql.ErrNoRows
+	}
+
+	return result, nil
+}
+
+// DeleteExpiredOIDCAuthCodes deletes the expired (aka. older than [OIDCAuthCodeDuration])
+// OAuth2/OIDC provider authorization codes.
+func (app *BaseApp) DeleteExpiredOIDCAuthCodes() error {
+	// the system collection could be created on demand and may not exist yet
+	if _, err := app.FindCachedCollectionByNameOrId(CollectionNameOIDCAuthCodes); err != nil {
+		return nil
+	}
+
+	minValidDate, err := types.ParseDateTime(time.Now().Add(-1 * OIDCAuthCodeDuration))
+	if err != nil {
+		return err
+	}
+
+	items := []*Record{}
+
+	err = app.RecordQuery(CollectionNameOIDCAuthCodes).
+		AndWhere(dbx.NewExp("[[created]] < {:date}", dbx.Params{"date": minValidDate})).
+		All(&items)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		err = app.Delete(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/hanzoai/backendPB/tools/search"
+	"github.com/hanzoai/dbx"
+)
+
+// realtimeChannelAlias is the table alias of the virtual
+// realtime channel "record" used for evaluating the channel rules.
+const realtimeChannelAlias = "_realtimeChannel"
+
+// CanAccessRealtimeChannel checks if the realtime channel with the
+// specified name is allowed to be accessed by the specified requestInfo and accessRule.
+//
+// The rule is evaluated the same way as the collection rules with
+// the special "name" field holding the channel name.
+//
+// Rule and db checks are ignored in case requestInfo.Auth is a superuser.
+//
+// The method always return false on invalid rule or db query error.
+func (app *BaseApp) CanAccessRealtimeChannel(name string, requestInfo *RequestInfo, accessRule *string) (bool, error) {
+	// superusers can access everything
+	if requestInfo.HasSuperuserAuth() {
+		return true, nil
+	}
+
+	// only superusers can access this channel
+	if accessRule == nil {
+		return false, nil
+	}
+
+	// empty public rule, aka. everyone can access
+	if *accessRule == "" {
+		return true, nil
+	}
+
+	collection := newRealtimeChannelCollection()
+
+	var exists bool
+
+	query := app.DB().
+		Select("(1)").
+		From("(SELECT {:realtimeChannelName} AS [[id]], {:realtimeChannelName} AS [[name]]) [[" + realtimeChannelAlias + "]]").
+		Bind(dbx.Params{"realtimeChannelName": name})
+
+	// parse and apply the access rule filter
+	resolver := NewRecordFieldResolver(app, collection, requestInfo, true)
+	expr, err := search.FilterData(*accessRule).BuildExpr(resolver)
+	if err != nil {
+		return false, err
+	}
+	resolver.UpdateQuery(query)
+
+	err = query.AndWhere(expr).Limit(1).Row(&exists)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// newRealtimeChannelCollection returns the virtual single row collection
+// with the channel name used for resolving the channel rules.
+func newRealtimeChannelCollection() *Collection {
+	collection := NewBaseCollection(realtimeChannelAlias)
+	collection.Fields.Add(&TextField{Name: "name"})
+
+	return collection
+}
@@ -0,0 +1,131 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+func TestCanAccessRealtimeChannel(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	superuser, err := app.FindAuthRecordByEmail(core.CollectionNameSuperusers, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		channel     string
+		requestInfo *core.RequestInfo
+		rule        *string
+		expected    bool
+		expectError bool
+	}{
+		{
+			"as superuser with nil rule",
+			"lobby",
+			&core.RequestInfo{Auth: superuser},
+			nil,
+			true,
+			false,
+		},
+		{
+			"as superuser with invalid rule",
+			"lobby",
+			&core.RequestInfo{Auth: superuser},
+			types.Pointer("id ?!@ 1"), // the filter rule should be ignored
+			true,
+			false,
+		},
+		{
+			"as guest with nil rule",
+			"lobby",
+			&core.RequestInfo{},
+			nil,
+			false,
+			false,
+		},
+		{
+			"as guest with empty rule",
+			"lobby",
+			&core.RequestInfo{},
+			types.Pointer(""),
+			true,
+			false,
+		},
+		{
+			"as guest with invalid rule",
+			"lobby",
+			&core.RequestInfo{},
+			types.Pointer("id ?!@ 1"),
+			false,
+			true,
+		},
+		{
+			"as guest with auth rule",
+			"lobby",
+			&core.RequestInfo{},
+			types.Pointer("@request.auth.id != ''"),
+			false,
+			false,
+		},
+		{
+			"as user with auth rule",
+			"lobby",
+			&core.RequestInfo{Auth: user},
+			types.Pointer("@request.auth.id != ''"),
+			true,
+			false,
+		},
+		{
+			"as user with matching channel name rule",
+			"rooms:" + user.Id,
+			&core.RequestInfo{Auth: user},
+			types.Pointer("name = 'rooms:" + user.Id + "' && @request.auth.collectionName = 'users'"),
+			true,
+			false,
+		},
+		{
+			"as user with non-matching channel name rule",
+			"rooms:abc",
+			&core.RequestInfo{Auth: user},
+			types.Pointer("name = 'rooms:" + user.Id + "'"),
+			false,
+			false,
+		},
+		{
+			"as user with @collection rule",
+			"demo1",
+			&core.RequestInfo{Auth: user},
+			types.Pointer("@collection.demo1.id ?= 'imy661ixudk5izi'"),
+			true,
+			false,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := app.CanAccessRealtimeChannel(s.channel, s.requestInfo, s.rule)
+
+			if result != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, result)
+			}
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+		})
+	}
+}
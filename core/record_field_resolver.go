@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hanzoai/backendPB/tools/inflector"
+	"github.com/hanzoai/backendPB/tools/list"
 	"github.com/hanzoai/backendPB/tools/search"
 	"github.com/hanzoai/backendPB/tools/security"
 	"github.com/hanzoai/dbx"
@@ -25,6 +27,11 @@ const (
 // ensure that `search.FieldResolver` interface is implemented
 var _ search.FieldResolver = (*RecordFieldResolver)(nil)
 
+// ensure that `search.FullTextSearchResolver` interface is implemented
+var _ search.FullTextSearchResolver = (*RecordFieldResolver)(nil)
+
+const fullTextSearchRankAlias = "__fts_rank"
+
 // RecordFieldResolver defines a custom search resolver struct for
 // managing Record model search fields.
 //
@@ -155,6 +162,134 @@ func (r *RecordFieldResolver) Resolve(fieldName string) (*search.ResolverResult,
 	return parseAndRun(fieldName, r)
 }
 
+// ResolveFullTextSearch implements `search.FullTextSearchResolver` interface.
+//
+// It matches the specified field against the collection FTS5 index.
+// The string query params are normalized to quoted FTS5 terms, meaning that
+// the search terms are always AND-ed and only the trailing "*" (prefix search)
+// is supported from the FTS5 query syntax.
+func (r *RecordFieldResolver) ResolveFullTextSearch(field string, query *search.ResolverResult) (dbx.Expression, error) {
+	if !list.ExistInSliceWithRegex(field, r.allowedFields) {
+		return nil, fmt.Errorf("failed to resolve field %q", field)
+	}
+
+	f := r.baseCollection.Fields.GetByName(field)
+	if f == nil || (isNonFilterableField(r.baseCollection, f) && !r.allowHiddenFields) {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+
+	if !r.baseCollection.FullTextSearch.HasField(field) {
+		return nil, fmt.Errorf("field %q is not part of the collection full-text search index", field)
+	}
+
+	normalized := normalizeFullTextSearchResult(query)
+
+	ftsTable := fullTextSearchTableName(r.baseCollection)
+
+	return dbx.NewExp(
+		fmt.Sprintf(
+			"[[%s.rowid]] IN (SELECT [[rowid]] FROM {{%s}} WHERE [[%s.%s]] MATCH %s)",
+			inflector.Columnify(r.baseCollection.Name),
+			ftsTable,
+			ftsTable,
+			field,
+			normalized.Identifier,
+		),
+		normalized.Params,
+	), nil
+}
+
+// ResolveFullTextSearchRank implements `search.FullTextSearchResolver` interface.
+//
+// It joins the collection FTS5 table and returns the rank of the
+// specified full-text search match.
+//
+// Note that the resolver itself doesn't track the resolved matches.
+// Use [search.NewFullTextSearchScope] to resolve the "@rank" sort field
+// against the filter expressions resolved through the scope.
+func (r *RecordFieldResolver) ResolveFullTextSearchRank(field string, query *search.ResolverResult) (*search.ResolverResult, error) {
+	if field == "" || query == nil {
+		return nil, errors.New("the @rank sort field requires a full-text search filter expression")
+	}
+
+	if !r.baseCollection.FullTextSearch.HasField(field) {
+		return nil, fmt.Errorf("field %q is not part of the collection full-text search index", field)
+	}
+
+	normalized := normalizeFullTextSearchResult(query)
+
+	r.registerJoin(
+		fullTextSearchTableName(r.baseCollection),
+		fullTextSearchRankAlias,
+		dbx.And(
+			dbx.NewExp(fmt.Sprintf(
+				"[[%s.rowid]] = [[%s.rowid]]",
+				fullTextSearchRankAlias,
+				inflector.Columnify(r.baseCollection.Name),
+			)),
+			dbx.NewExp(
+				fmt.Sprintf(
+					"[[%s.%s]] MATCH %s",
+					fullTextSearchRankAlias,
+					field,
+					normalized.Identifier,
+				),
+				normalized.Params,
+			),
+		),
+	)
+
+	return &search.ResolverResult{
+		Identifier: "[[" + fullTextSearchRankAlias + ".rank]]",
+	}, nil
+}
+
+// normalizeFullTextSearchResult returns a copy of the provided resolver result
+// with normalized FTS5 string query params.
+func normalizeFullTextSearchResult(query *search.ResolverResult) *search.ResolverResult {
+	normalized := &search.ResolverResult{
+		Identifier: query.Identifier,
+		Params:     make(dbx.Params, len(query.Params)),
+	}
+	for k, v := range query.Params {
+		if str, ok := v.(string); ok {
+			v = normalizeFullTextSearchQuery(str)
+		}
+		normalized.Params[k] = v
+	}
+
+	return normalized
+}
+
+// normalizeFullTextSearchQuery converts the raw search input into
+// a FTS5 query by quoting each term so that the user input can't
+// trigger FTS5 syntax errors (eg. "lorem ips*" -> `"lorem" "ips"*`).
+func normalizeFullTextSearchQuery(raw string) string {
+	terms := make([]string, 0, 5)
+
+	for _, term := range strings.Fields(raw) {
+		isPrefix := strings.HasSuffix(term, "*")
+
+		term = strings.Trim(term, "*")
+		if term == "" {
+			continue
+		}
+
+		term = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if isPrefix {
+			term += "*"
+		}
+
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 {
+		return `""`
+	}
+
+	return strings.Join(terms, " ")
+}
+
 func (r *RecordFieldResolver) resolveStaticRequestField(path ...string) (*search.ResolverResult, error) {
 	if len(path) == 0 {
 		return nil, errors.New("at least one path key should be provided")
@@ -391,3 +526,10 @@ func splitModifier(combined string) (string, string, error) {
 
 	return "", "", fmt.Errorf("unknown modifier in %q", combined)
 }
+
+// isNonFilterableField reports whether the specified collection field
+// could be used in filter expressions only when the hidden fields are allowed
+// (aka. hidden fields and fields with a view field rule).
+func isNonFilterableField(collection *Collection, field Field) bool {
+	return field.GetHidden() || collection.FieldRules[field.GetName()].HasViewRule()
+}
@@ -180,13 +180,18 @@ func (r *runner) processCollectionField() (*search.ResolverResult, error) {
 	r.withMultiMatch = true
 
 	// join the collection to the main query
-	r.resolver.registerJoin(inflector.Columnify(collection.Name), r.activeTableAlias, nil)
+	r.resolver.registerJoin(
+		inflector.Columnify(collection.Name),
+		r.activeTableAlias,
+		softDeleteJoinExp(collection, r.activeTableAlias, nil),
+	)
 
 	// join the collection to the multi-match subquery
 	r.multiMatchActiveTableAlias = "__mm" + r.activeTableAlias
 	r.multiMatch.joins = append(r.multiMatch.joins, &join{
 		tableName:  inflector.Columnify(collection.Name),
 		tableAlias: r.multiMatchActiveTableAlias,
+		on:         softDeleteJoinExp(collection, r.multiMatchActiveTableAlias, nil),
 	})
 
 	// leave only the collection fields
@@ -218,10 +223,10 @@ func (r *runner) processRequestAuthField() (*search.ResolverResult, error) {
 	r.resolver.registerJoin(
 		inflector.Columnify(r.activeCollectionName),
 		r.activeTableAlias,
-		dbx.HashExp{
+		softDeleteJoinExp(collection, r.activeTableAlias, dbx.HashExp{
 			// aka. __auth_users.id = :userId
 			(r.activeTableAlias + ".id"): r.resolver.requestInfo.Auth.Id,
-		},
+		}),
 	)
 
 	// join the auth collection to the multi-match subquery
@@ -231,9 +236,9 @@ func (r *runner) processRequestAuthField() (*search.ResolverResult, error) {
 		&join{
 			tableName:  inflector.Columnify(r.activeCollectionName),
 			tableAlias: r.multiMatchActiveTableAlias,
-			on: dbx.HashExp{
+			on: softDeleteJoinExp(collection, r.multiMatchActiveTableAlias, dbx.HashExp{
 				(r.multiMatchActiveTableAlias + ".id"): r.resolver.requestInfo.Auth.Id,
-			},
+			}),
 		},
 	)
 
@@ -309,7 +314,7 @@ func (r *runner) processRequestInfoEachModifier(bodyField Field) (*search.Resolv
 
 	placeholder := "dataEach" + security.PseudorandomString(6)
 	cleanFieldName := inflector.Columnify(bodyField.GetName())
-	jeTable := fmt.Sprintf("json_each({:%s})", placeholder)
+	jeTable := r.resolver.app.DBDialect().JSONEachExpr("{:" + placeholder + "}")
 	jeAlias := "__dataEach_" + cleanFieldName + "_je"
 	r.resolver.registerJoin(jeTable, jeAlias, nil)
 
@@ -324,7 +329,7 @@ func (r *runner) processRequestInfoEachModifier(bodyField Field) (*search.Resolv
 
 	if r.withMultiMatch {
 		placeholder2 := "mm" + placeholder
-		jeTable2 := fmt.Sprintf("json_each({:%s})", placeholder2)
+		jeTable2 := r.resolver.app.DBDialect().JSONEachExpr("{:" + placeholder2 + "}")
 		jeAlias2 := "__mm" + jeAlias
 
 		r.multiMatch.joins = append(r.multiMatch.joins, &join{
@@ -366,10 +371,10 @@ func (r *runner) processRequestInfoRelationField(bodyField Field) (*search.Resol
 	r.resolver.registerJoin(
 		r.activeCollectionName,
 		r.activeTableAlias,
-		dbx.In(
+		softDeleteJoinExp(dataRelCollection, r.activeTableAlias, dbx.In(
 			fmt.Sprintf("[[%s.id]]", r.activeTableAlias),
 			list.ToInterfaceSlice(dataRelIds)...,
-		),
+		)),
 	)
 
 	if relField.IsMultiple() {
@@ -383,10 +388,10 @@ func (r *runner) processRequestInfoRelationField(bodyField Field) (*search.Resol
 		&join{
 			tableName:  r.activeCollectionName,
 			tableAlias: r.multiMatchActiveTableAlias,
-			on: dbx.In(
+			on: softDeleteJoinExp(dataRelCollection, r.multiMatchActiveTableAlias, dbx.In(
 				fmt.Sprintf("[[%s.id]]", r.multiMatchActiveTableAlias),
 				list.ToInterfaceSlice(dataRelIds)...,
-			),
+			)),
 		},
 	)
 
@@ -397,6 +402,21 @@ func (r *runner) processRequestInfoRelationField(bodyField Field) (*search.Resol
 	return r.processActiveProps()
 }
 
+// softDeleteJoinExp extends the join "on" expression of the specified
+// collection table alias to exclude its soft deleted rows (if enabled).
+func softDeleteJoinExp(collection *Collection, tableAlias string, on dbx.Expression) dbx.Expression {
+	if !collection.SoftDelete.Enabled {
+		return on
+	}
+
+	notDeleted := dbx.NewExp(fmt.Sprintf("[[%s.%s]] = ''", tableAlias, FieldNameDeleted))
+	if on == nil {
+		return notDeleted
+	}
+
+	return dbx.And(on, notDeleted)
+}
+
 var viaRegex = regexp.MustCompile(`^(\w+)_via_(\w+)$`)
 
 func (r *runner) processActiveProps() (*search.ResolverResult, error) {
@@ -415,12 +435,13 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 
 		field := collection.Fields.GetByName(prop)
 
-		if field != nil && field.GetHidden() && !r.allowHiddenFields {
+		if field != nil && isNonFilterableField(collection, field) && !r.allowHiddenFields {
 			return nil, fmt.Errorf("non-filterable field %q", prop)
 		}
 
-		// json field -> treat the rest of the props as json path
-		if field != nil && field.Type() == FieldTypeJSON {
+		// json or geoPoint field -> treat the rest of the props as json path
+		// (eg. location.lon)
+		if field != nil && (field.Type() == FieldTypeJSON || field.Type() == FieldTypeGeoPoint) {
 			var jsonPath strings.Builder
 			for j, p := range r.activeProps[i+1:] {
 				if _, err := strconv.Atoi(p); err == nil {
@@ -438,11 +459,11 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 
 			result := &search.ResolverResult{
 				NoCoalesce: true,
-				Identifier: dbutils.JSONExtract(r.activeTableAlias+"."+inflector.Columnify(prop), jsonPathStr),
+				Identifier: r.resolver.app.DBDialect().JSONExtract(r.activeTableAlias+"."+inflector.Columnify(prop), jsonPathStr),
 			}
 
 			if r.withMultiMatch {
-				r.multiMatch.valueIdentifier = dbutils.JSONExtract(r.multiMatchActiveTableAlias+"."+inflector.Columnify(prop), jsonPathStr)
+				r.multiMatch.valueIdentifier = r.resolver.app.DBDialect().JSONExtract(r.multiMatchActiveTableAlias+"."+inflector.Columnify(prop), jsonPathStr)
 				result.MultiMatchSubQuery = r.multiMatch
 			}
 
@@ -512,20 +533,20 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 				r.resolver.registerJoin(
 					newCollectionName,
 					newTableAlias,
-					dbx.NewExp(fmt.Sprintf("[[%s.%s]] = [[%s.id]]", newTableAlias, cleanBackFieldName, r.activeTableAlias)),
+					softDeleteJoinExp(backCollection, newTableAlias, dbx.NewExp(fmt.Sprintf("[[%s.%s]] = [[%s.id]]", newTableAlias, cleanBackFieldName, r.activeTableAlias))),
 				)
 			} else {
 				jeAlias := r.activeTableAlias + "_" + cleanProp + "_je"
 				r.resolver.registerJoin(
 					newCollectionName,
 					newTableAlias,
-					dbx.NewExp(fmt.Sprintf(
+					softDeleteJoinExp(backCollection, newTableAlias, dbx.NewExp(fmt.Sprintf(
 						"[[%s.id]] IN (SELECT [[%s.value]] FROM %s {{%s}})",
 						r.activeTableAlias,
 						jeAlias,
-						dbutils.JSONEach(newTableAlias+"."+cleanBackFieldName),
+						r.resolver.app.DBDialect().JSONEach(newTableAlias+"."+cleanBackFieldName),
 						jeAlias,
-					)),
+					))),
 				)
 			}
 
@@ -547,7 +568,7 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 					&join{
 						tableName:  newCollectionName,
 						tableAlias: newTableAlias2,
-						on:         dbx.NewExp(fmt.Sprintf("[[%s.%s]] = [[%s.id]]", newTableAlias2, cleanBackFieldName, r.multiMatchActiveTableAlias)),
+						on:         softDeleteJoinExp(backCollection, newTableAlias2, dbx.NewExp(fmt.Sprintf("[[%s.%s]] = [[%s.id]]", newTableAlias2, cleanBackFieldName, r.multiMatchActiveTableAlias))),
 					},
 				)
 			} else {
@@ -557,13 +578,13 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 					&join{
 						tableName:  newCollectionName,
 						tableAlias: newTableAlias2,
-						on: dbx.NewExp(fmt.Sprintf(
+						on: softDeleteJoinExp(backCollection, newTableAlias2, dbx.NewExp(fmt.Sprintf(
 							"[[%s.id]] IN (SELECT [[%s.value]] FROM %s {{%s}})",
 							r.multiMatchActiveTableAlias,
 							jeAlias2,
-							dbutils.JSONEach(newTableAlias2+"."+cleanBackFieldName),
+							r.resolver.app.DBDialect().JSONEach(newTableAlias2+"."+cleanBackFieldName),
 							jeAlias2,
-						)),
+						))),
 					},
 				)
 			}
@@ -595,6 +616,8 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 		// "id" lookups optimization for single relations to avoid unnecessary joins,
 		// aka. "user.id" and "user" should produce the same query identifier
 		if !relField.IsMultiple() &&
+			// the soft deleted relations must be joined to be excluded
+			!relCollection.SoftDelete.Enabled &&
 			// the penultimate prop is "id"
 			i == totalProps-2 && r.activeProps[i+1] == FieldNameId {
 			return r.processLastProp(collection, relField.Name)
@@ -609,15 +632,15 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 			r.resolver.registerJoin(
 				inflector.Columnify(newCollectionName),
 				newTableAlias,
-				dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s]]", newTableAlias, prefixedFieldName)),
+				softDeleteJoinExp(relCollection, newTableAlias, dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s]]", newTableAlias, prefixedFieldName))),
 			)
 		} else {
 			jeAlias := r.activeTableAlias + "_" + cleanFieldName + "_je"
-			r.resolver.registerJoin(dbutils.JSONEach(prefixedFieldName), jeAlias, nil)
+			r.resolver.registerJoin(r.resolver.app.DBDialect().JSONEach(prefixedFieldName), jeAlias, nil)
 			r.resolver.registerJoin(
 				inflector.Columnify(newCollectionName),
 				newTableAlias,
-				dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s.value]]", newTableAlias, jeAlias)),
+				softDeleteJoinExp(relCollection, newTableAlias, dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s.value]]", newTableAlias, jeAlias))),
 			)
 		}
 
@@ -640,7 +663,7 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 				&join{
 					tableName:  inflector.Columnify(newCollectionName),
 					tableAlias: newTableAlias2,
-					on:         dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s]]", newTableAlias2, prefixedFieldName2)),
+					on:         softDeleteJoinExp(relCollection, newTableAlias2, dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s]]", newTableAlias2, prefixedFieldName2))),
 				},
 			)
 		} else {
@@ -648,13 +671,13 @@ func (r *runner) processActiveProps() (*search.ResolverResult, error) {
 			r.multiMatch.joins = append(
 				r.multiMatch.joins,
 				&join{
-					tableName:  dbutils.JSONEach(prefixedFieldName2),
+					tableName:  r.resolver.app.DBDialect().JSONEach(prefixedFieldName2),
 					tableAlias: jeAlias2,
 				},
 				&join{
 					tableName:  inflector.Columnify(newCollectionName),
 					tableAlias: newTableAlias2,
-					on:         dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s.value]]", newTableAlias2, jeAlias2)),
+					on:         softDeleteJoinExp(relCollection, newTableAlias2, dbx.NewExp(fmt.Sprintf("[[%s.id]] = [[%s.value]]", newTableAlias2, jeAlias2))),
 				},
 			)
 		}
@@ -680,7 +703,7 @@ func (r *runner) processLastProp(collection *Collection, prop string) (*search.R
 		return nil, fmt.Errorf("unknown field %q", name)
 	}
 
-	if field.GetHidden() && !r.allowHiddenFields {
+	if isNonFilterableField(collection, field) && !r.allowHiddenFields {
 		return nil, fmt.Errorf("non-filterable field %q", name)
 	}
 
@@ -694,12 +717,12 @@ func (r *runner) processLastProp(collection *Collection, prop string) (*search.R
 		jePair := r.activeTableAlias + "." + cleanFieldName
 
 		result := &search.ResolverResult{
-			Identifier: dbutils.JSONArrayLength(jePair),
+			Identifier: r.resolver.app.DBDialect().JSONArrayLength(jePair),
 		}
 
 		if r.withMultiMatch {
 			jePair2 := r.multiMatchActiveTableAlias + "." + cleanFieldName
-			r.multiMatch.valueIdentifier = dbutils.JSONArrayLength(jePair2)
+			r.multiMatch.valueIdentifier = r.resolver.app.DBDialect().JSONArrayLength(jePair2)
 			result.MultiMatchSubQuery = r.multiMatch
 		}
 
@@ -711,7 +734,7 @@ func (r *runner) processLastProp(collection *Collection, prop string) (*search.R
 	if modifier == eachModifier && isMultivaluer {
 		jePair := r.activeTableAlias + "." + cleanFieldName
 		jeAlias := r.activeTableAlias + "_" + cleanFieldName + "_je"
-		r.resolver.registerJoin(dbutils.JSONEach(jePair), jeAlias, nil)
+		r.resolver.registerJoin(r.resolver.app.DBDialect().JSONEach(jePair), jeAlias, nil)
 
 		result := &search.ResolverResult{
 			Identifier: fmt.Sprintf("[[%s.value]]", jeAlias),
@@ -726,7 +749,7 @@ func (r *runner) processLastProp(collection *Collection, prop string) (*search.R
 			jeAlias2 := r.multiMatchActiveTableAlias + "_" + cleanFieldName + "_je"
 
 			r.multiMatch.joins = append(r.multiMatch.joins, &join{
-				tableName:  dbutils.JSONEach(jePair2),
+				tableName:  r.resolver.app.DBDialect().JSONEach(jePair2),
 				tableAlias: jeAlias2,
 			})
 			r.multiMatch.valueIdentifier = fmt.Sprintf("[[%s.value]]", jeAlias2)
@@ -764,9 +787,9 @@ func (r *runner) processLastProp(collection *Collection, prop string) (*search.R
 	// (https://github.com/hanzoai/backendPBues/4068)
 	if field.Type() == FieldTypeJSON {
 		result.NoCoalesce = true
-		result.Identifier = dbutils.JSONExtract(r.activeTableAlias+"."+cleanFieldName, "")
+		result.Identifier = r.resolver.app.DBDialect().JSONExtract(r.activeTableAlias+"."+cleanFieldName, "")
 		if r.withMultiMatch {
-			r.multiMatch.valueIdentifier = dbutils.JSONExtract(r.multiMatchActiveTableAlias+"."+cleanFieldName, "")
+			r.multiMatch.valueIdentifier = r.resolver.app.DBDialect().JSONExtract(r.multiMatchActiveTableAlias+"."+cleanFieldName, "")
 		}
 	}
 
@@ -555,6 +555,114 @@ func TestRecordFieldResolverUpdateQuery(t *testing.T) {
 	}
 }
 
+func TestRecordFieldResolverFullTextSearch(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection, err := app.FindCollectionByNameOrId("demo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection.FullTextSearch.Fields = []string{"text"}
+
+	scenarios := []struct {
+		name        string
+		filter      string
+		sort        string
+		expectError bool
+		expectQuery string
+	}{
+		{
+			"non-indexed field",
+			"email @@ 'test'",
+			"",
+			true,
+			"",
+		},
+		{
+			"missing field",
+			"missing @@ 'test'",
+			"",
+			true,
+			"",
+		},
+		{
+			"rank without full-text search filter",
+			"text = 'test'",
+			"@rank",
+			true,
+			"",
+		},
+		{
+			"indexed field",
+			"text @@ 'test'",
+			"",
+			false,
+			"SELECT `demo1`.* FROM `demo1` WHERE [[demo1.rowid]] IN (SELECT [[rowid]] FROM {{_fts_" + collection.Id + "}} WHERE [[_fts_" + collection.Id + ".text]] MATCH {:TEST})",
+		},
+		{
+			"indexed field with rank",
+			"text @@ 'test'",
+			"@rank",
+			false,
+			"SELECT DISTINCT `demo1`.* FROM `demo1` LEFT JOIN `_fts_" + collection.Id + "` `__fts_rank` ON ([[__fts_rank.rowid]] = [[demo1.rowid]]) AND ([[__fts_rank.text]] MATCH {:TEST}) WHERE [[demo1.rowid]] IN (SELECT [[rowid]] FROM {{_fts_" + collection.Id + "}} WHERE [[_fts_" + collection.Id + ".text]] MATCH {:TEST}) ORDER BY [[__fts_rank.rank]] ASC",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			query := app.RecordQuery(collection)
+
+			r := core.NewRecordFieldResolver(app, collection, nil, false)
+
+			// full-text search expression outside of the scope (eg. API rule)
+			// shouldn't be used for the "@rank" sort
+			if _, err := search.FilterData("text @@ 'rule'").BuildExpr(r); err != nil {
+				t.Fatal(err)
+			}
+
+			scope := search.NewFullTextSearchScope(r)
+
+			expr, err := search.FilterData(s.filter).BuildExpr(scope)
+
+			if err == nil && s.sort != "" {
+				var sortExpr string
+				sortExpr, err = (&search.SortField{Name: s.sort, Direction: search.SortAsc}).BuildExpr(scope)
+				if err == nil {
+					query.AndOrderBy(sortExpr)
+				}
+			}
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if err := r.UpdateQuery(query); err != nil {
+				t.Fatal(err)
+			}
+
+			rawQuery := query.AndWhere(expr).Build().SQL()
+
+			expectQuery := strings.ReplaceAll(
+				"^"+regexp.QuoteMeta(s.expectQuery)+"$",
+				"TEST",
+				`\w+`,
+			)
+
+			if !list.ExistInSliceWithRegex(rawQuery, []string{expectQuery}) {
+				t.Fatalf("Expected query\n %v \ngot:\n %v", expectQuery, rawQuery)
+			}
+		})
+	}
+}
+
 func TestRecordFieldResolverResolveCollectionFields(t *testing.T) {
 	app, _ := tests.NewTestApp()
 	defer app.Cleanup()
@@ -46,6 +46,9 @@ type Record struct {
 	exportCustomData      bool
 	ignoreEmailVisibility bool
 	ignoreUnchangedFields bool
+
+	// forceDelete bypasses the collection soft delete option (see [BaseApp.PurgeRecord])
+	forceDelete bool
 }
 
 const systemHookIdRecord = "__pbRecordSystemHook__"
@@ -968,6 +971,13 @@ func (m *Record) GetDateTime(key string) types.DateTime {
 	return d
 }
 
+// GetGeoPoint returns the data value for "key" as a GeoPoint instance.
+func (m *Record) GetGeoPoint(key string) types.GeoPoint {
+	point := types.GeoPoint{}
+	_ = point.Scan(m.Get(key))
+	return point
+}
+
 // GetStringSlice returns the data value for "key" as a slice of non-zero unique strings.
 func (m *Record) GetStringSlice(key string) []string {
 	return list.ToUniqueStringSlice(m.Get(key))
@@ -1415,7 +1425,12 @@ func onRecordSaveExecute(e *RecordEvent) error {
 	if e.Record.Collection().IsAuth() {
 		// ensure that the token key is regenerated on password change or email change
 		if !e.Record.IsNew() {
-			lastSavedRecord, err := e.App.FindRecordById(e.Record.Collection(), e.Record.Id)
+			// note: include the soft deleted records to allow restoring them
+			lastSavedRecord := &Record{}
+			err := e.App.RecordQueryWithSoftDeleted(e.Record.Collection()).
+				AndWhere(dbx.HashExp{e.Record.Collection().Name + ".id": e.Record.Id}).
+				Limit(1).
+				One(lastSavedRecord)
 			if err != nil {
 				return err
 			}
@@ -1436,8 +1451,13 @@ func onRecordSaveExecute(e *RecordEvent) error {
 			if e.Record.Collection().Id == collection.Id {
 				continue // skip current collection (sqlite will do the check for us)
 			}
-			record, _ := e.App.FindRecordById(collection, e.Record.Id)
-			if record != nil {
+			var exists int
+			_ = e.App.RecordQueryWithSoftDeleted(collection).
+				Select("(1)").
+				AndWhere(dbx.HashExp{collection.Name + ".id": e.Record.Id}).
+				Limit(1).
+				Row(&exists)
+			if exists > 0 {
 				return validation.Errors{
 					FieldNameId: validation.NewError("validation_invalid_auth_id", "Invalid or duplicated auth record id."),
 				}
@@ -1467,17 +1487,25 @@ func onRecordDeleteExecute(e *RecordEvent) error {
 		return err
 	}
 
+	isSoftDelete := e.Record.isSoftDelete()
+
 	originalApp := e.App
 	txErr := e.App.RunInTransaction(func(txApp App) error {
 		e.App = txApp
 
 		// delete the record before the relation references to ensure that there
 		// will be no "A<->B" relations to prevent deadlock when calling DeleteRecord recursively
-		if err := e.Next(); err != nil {
+		if isSoftDelete {
+			// note: the remaining execute handlers and the db DELETE are skipped
+			// so that the record related data could be restored later
+			if err := softDeleteRecord(txApp, e.Context, e.Record); err != nil {
+				return err
+			}
+		} else if err := e.Next(); err != nil {
 			return err
 		}
 
-		return cascadeRecordDelete(txApp, e.Record, refs)
+		return cascadeRecordDelete(txApp, e.Record, refs, isSoftDelete)
 	})
 	e.App = originalApp
 
@@ -1486,8 +1514,12 @@ func onRecordDeleteExecute(e *RecordEvent) error {
 
 // cascadeRecordDelete triggers cascade deletion for the provided references.
 //
+// If onlyCascade is set, only the references from relation fields with
+// CascadeDelete that have no other active relation ids are deleted
+// and the remaining references are left untouched (used for soft deletes).
+//
 // NB! This method is expected to be called from inside of a transaction.
-func cascadeRecordDelete(app App, mainRecord *Record, refs map[*Collection][]Field) error {
+func cascadeRecordDelete(app App, mainRecord *Record, refs map[*Collection][]Field, onlyCascade bool) error {
 	// Sort the refs keys to ensure that the cascade events firing order is always the same.
 	// This is not necessary for the operation to function correctly but it helps having deterministic output during testing.
 	sortedRefKeys := make([]*Collection, 0, len(refs))
@@ -1508,19 +1540,35 @@ func cascadeRecordDelete(app App, mainRecord *Record, refs map[*Collection][]Fie
 		recordTableName := inflector.Columnify(refCollection.Name)
 
 		for _, field := range fields {
+			if relField, _ := field.(*RelationField); onlyCascade && (relField == nil || !relField.CascadeDelete) {
+				continue
+			}
+
 			prefixedFieldName := recordTableName + "." + inflector.Columnify(field.GetName())
 
-			query := app.RecordQuery(refCollection)
+			// on hard delete the soft deleted references must be also
+			// updated to prevent leaving dangling relation ids
+			var query *dbx.SelectQuery
+			if onlyCascade {
+				query = app.RecordQuery(refCollection)
+			} else {
+				query = app.RecordQueryWithSoftDeleted(refCollection)
+			}
 
 			if opt, ok := field.(MultiValuer); !ok || !opt.IsMultiple() {
 				query.AndWhere(dbx.HashExp{prefixedFieldName: mainRecord.Id})
 			} else {
 				query.AndWhere(dbx.Exists(dbx.NewExp(fmt.Sprintf(
-					`SELECT 1 FROM json_each(CASE WHEN json_valid([[%s]]) THEN [[%s]] ELSE json_array([[%s]]) END) {{__je__}} WHERE [[__je__.value]]={:jevalue}`,
-					prefixedFieldName, prefixedFieldName, prefixedFieldName,
+					`SELECT 1 FROM %s {{__je__}} WHERE [[__je__.value]]={:jevalue}`,
+					app.DBDialect().JSONEach(prefixedFieldName),
 				), dbx.Params{
 					"jevalue": mainRecord.Id,
 				})))
+
+				// exclude the references that will remain with other active relation ids
+				if onlyCascade {
+					query.AndWhere(dbx.NewExp(app.DBDialect().JSONArrayLength(prefixedFieldName) + " = 1"))
+				}
 			}
 
 			if refCollection.Id == mainRecord.Collection().Id {
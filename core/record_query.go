@@ -22,7 +22,20 @@ var recordProxyType = reflect.TypeOf((*RecordProxy)(nil)).Elem()
 // In case a collection id or name is provided and that collection doesn't
 // actually exists, the generated query will be created with a cancelled context
 // and will fail once an executor (Row(), One(), All(), etc.) is called.
+//
+// If the collection has soft delete enabled, the soft deleted records
+// are excluded from the query results (see also [BaseApp.RecordQueryWithSoftDeleted]).
 func (app *BaseApp) RecordQuery(collectionModelOrIdentifier any) *dbx.SelectQuery {
+	return app.recordQuery(app.DB(), collectionModelOrIdentifier, false)
+}
+
+// RecordQueryWithSoftDeleted is similar to [BaseApp.RecordQuery] but
+// doesn't exclude the soft deleted records from the query results.
+func (app *BaseApp) RecordQueryWithSoftDeleted(collectionModelOrIdentifier any) *dbx.SelectQuery {
+	return app.recordQuery(app.DB(), collectionModelOrIdentifier, true)
+}
+
+func (app *BaseApp) recordQuery(db dbx.Builder, collectionModelOrIdentifier any, withSoftDeleted bool) *dbx.SelectQuery {
 	var tableName string
 
 	collection, collectionErr := getCollectionByModelOrIdentifier(app, collectionModelOrIdentifier)
@@ -34,7 +47,7 @@ func (app *BaseApp) RecordQuery(collectionModelOrIdentifier any) *dbx.SelectQuer
 		tableName = "@@__invalidCollectionModelOrIdentifier"
 	}
 
-	query := app.DB().Select(app.DB().QuoteSimpleColumnName(tableName) + ".*").From(tableName)
+	query := db.Select(db.QuoteSimpleColumnName(tableName) + ".*").From(tableName)
 
 	// in case of an error attach a new context and cancel it immediately with the error
 	if collectionErr != nil {
@@ -43,6 +56,10 @@ func (app *BaseApp) RecordQuery(collectionModelOrIdentifier any) *dbx.SelectQuer
 		cancelFunc(collectionErr)
 	}
 
+	if !withSoftDeleted && collection != nil && collection.SoftDelete.Enabled {
+		query.AndWhere(dbx.HashExp{tableName + "." + FieldNameDeleted: ""})
+	}
+
 	return query.WithBuildHook(func(q *dbx.Query) {
 		q.WithExecHook(execLockRetry(app.config.QueryTimeout, defaultMaxLockRetries)).
 			WithOneHook(func(q *dbx.Query, a any, op func(b any) error) error {
@@ -211,6 +228,15 @@ func (app *BaseApp) FindRecordById(
 	collectionModelOrIdentifier any,
 	recordId string,
 	optFilters ...func(q *dbx.SelectQuery) error,
+) (*Record, error) {
+	return app.findRecordById(app.DB(), collectionModelOrIdentifier, recordId, optFilters...)
+}
+
+func (app *BaseApp) findRecordById(
+	db dbx.Builder,
+	collectionModelOrIdentifier any,
+	recordId string,
+	optFilters ...func(q *dbx.SelectQuery) error,
 ) (*Record, error) {
 	collection, err := getCollectionByModelOrIdentifier(app, collectionModelOrIdentifier)
 	if err != nil {
@@ -219,7 +245,7 @@ func (app *BaseApp) FindRecordById(
 
 	record := &Record{}
 
-	query := app.RecordQuery(collection).
+	query := app.recordQuery(db, collection, false).
 		AndWhere(dbx.HashExp{collection.Name + ".id": recordId})
 
 	// apply filter funcs (if any)
@@ -246,13 +272,22 @@ func (app *BaseApp) FindRecordsByIds(
 	collectionModelOrIdentifier any,
 	recordIds []string,
 	optFilters ...func(q *dbx.SelectQuery) error,
+) ([]*Record, error) {
+	return app.findRecordsByIds(app.DB(), collectionModelOrIdentifier, recordIds, optFilters...)
+}
+
+func (app *BaseApp) findRecordsByIds(
+	db dbx.Builder,
+	collectionModelOrIdentifier any,
+	recordIds []string,
+	optFilters ...func(q *dbx.SelectQuery) error,
 ) ([]*Record, error) {
 	collection, err := getCollectionByModelOrIdentifier(app, collectionModelOrIdentifier)
 	if err != nil {
 		return nil, err
 	}
 
-	query := app.RecordQuery(collection).
+	query := app.recordQuery(db, collection, false).
 		AndWhere(dbx.In(
 			collection.Name+".id",
 			list.ToInterfaceSlice(recordIds)...,
@@ -465,6 +500,9 @@ func (app *BaseApp) CountRecords(collectionModelOrIdentifier any, exprs ...dbx.E
 //
 // Optionally specify a list of validTypes to check tokens only from those types.
 //
+// The OAuth2/OIDC provider access tokens are accepted only if
+// [TokenTypeOIDCAccess] is explicitly listed in validTypes.
+//
 // Returns an error if the JWT is invalid, expired or not associated to an auth collection record.
 func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*Record, error) {
 	if token == "" {
@@ -489,6 +527,10 @@ func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*
 		return nil, fmt.Errorf("invalid token type %q, expects %q", tokenType, strings.Join(validTypes, ","))
 	}
 
+	if len(validTypes) == 0 && tokenType == TokenTypeOIDCAccess {
+		return nil, errors.New("the OAuth2/OIDC access tokens must be explicitly allowed")
+	}
+
 	record, err := app.FindRecordById(collectionId, id)
 	if err != nil {
 		return nil, err
@@ -498,9 +540,37 @@ func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*
 		return nil, errors.New("the token is not associated to an auth collection record")
 	}
 
+	isAuthType := tokenType == TokenTypeAuth || tokenType == TokenTypeOIDCAccess
+	if isAuthType && record.Collection().AuthTokenSigning.IsAsymmetric() {
+		err = verifyAsymmetricAuthToken(record, token, unverifiedClaims)
+	} else {
+		err = verifySymmetricToken(record, token, tokenType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// session bound auth tokens are valid only while their session exists
+	if sessionId, _ := unverifiedClaims[TokenClaimSessionId].(string); sessionId != "" && tokenType == TokenTypeAuth {
+		session, err := app.FindAuthSessionById(sessionId)
+		if err != nil {
+			return nil, errors.New("the token session is missing or has been revoked")
+		}
+
+		if session.RecordRef() != record.Id || session.CollectionRef() != record.Collection().Id {
+			return nil, errors.New("the token session is not associated to the token record")
+		}
+	}
+
+	return record, nil
+}
+
+// verifySymmetricToken verifies the signature of the provided
+// HS256 record token based on its type secret.
+func verifySymmetricToken(record *Record, token string, tokenType string) error {
 	var baseTokenKey string
 	switch tokenType {
-	case TokenTypeAuth:
+	case TokenTypeAuth, TokenTypeOIDCAccess:
 		baseTokenKey = record.Collection().AuthToken.Secret
 	case TokenTypeFile:
 		baseTokenKey = record.Collection().FileToken.Secret
@@ -511,18 +581,42 @@ func (app *BaseApp) FindAuthRecordByToken(token string, validTypes ...string) (*
 	case TokenTypeEmailChange:
 		baseTokenKey = record.Collection().EmailChangeToken.Secret
 	default:
-		return nil, errors.New("unknown token type " + tokenType)
+		return errors.New("unknown token type " + tokenType)
 	}
 
 	secret := record.TokenKey() + baseTokenKey
 
 	// verify token signature
-	_, err = security.ParseJWT(token, secret)
+	_, err := security.ParseJWT(token, secret)
+
+	return err
+}
+
+// verifyAsymmetricAuthToken verifies the signature of the provided
+// RS256 or EdDSA record auth (or OIDC access) token with the matching collection key.
+func verifyAsymmetricAuthToken(record *Record, token string, unverifiedClaims map[string]any) error {
+	header, err := security.ParseUnverifiedJWTHeader(token)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return record, nil
+	kid, _ := header["kid"].(string)
+
+	verificationKey, err := record.Collection().AuthTokenSigning.VerificationKey(kid)
+	if err != nil {
+		return err
+	}
+
+	if _, err = security.ParseSignedJWT(token, verificationKey); err != nil {
+		return err
+	}
+
+	tokenKeyHash, _ := unverifiedClaims[TokenClaimTokenKeyHash].(string)
+	if !security.Equal(tokenKeyHash, authTokenKeyHash(record)) {
+		return errors.New("the token key has been changed")
+	}
+
+	return nil
 }
 
 // FindAuthRecordByEmail finds the auth record associated with the provided email.
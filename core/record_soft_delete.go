@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// isSoftDelete reports whether deleting the current record should
+// only mark it as deleted instead of removing its db row.
+func (m *Record) isSoftDelete() bool {
+	return !m.forceDelete && m.Collection().SoftDelete.Enabled
+}
+
+// IsSoftDeleted reports whether the current record is marked as soft deleted.
+func (m *Record) IsSoftDeleted() bool {
+	return m.Collection().SoftDelete.Enabled && !m.GetDateTime(FieldNameDeleted).IsZero()
+}
+
+// IsRestored reports whether the current record state restores
+// its soft deleted original db state (aka. the "deleted" field was cleared).
+//
+// It could be used in the record update hooks to distinguish
+// a restore from a regular record update.
+func (m *Record) IsRestored() bool {
+	return m.Collection().SoftDelete.Enabled &&
+		m.GetDateTime(FieldNameDeleted).IsZero() &&
+		!m.Original().GetDateTime(FieldNameDeleted).IsZero()
+}
+
+// softDeleteRecord marks the provided record as deleted by setting its
+// "deleted" field to the current datetime.
+//
+// NB! This method is expected to be called from inside of a transaction.
+func softDeleteRecord(app App, ctx context.Context, record *Record) error {
+	now := types.NowDateTime()
+
+	err := baseLockRetry(func(attempt int) error {
+		_, err := app.NonconcurrentDB().Update(
+			record.Collection().Name,
+			dbx.Params{FieldNameDeleted: now.String()},
+			dbx.HashExp{idColumn: record.LastSavedPK()},
+		).WithContext(ctx).Execute()
+
+		return err
+	}, defaultMaxLockRetries)
+	if err != nil {
+		return err
+	}
+
+	record.SetRaw(FieldNameDeleted, now)
+
+	return nil
+}
+
+// FindSoftDeletedRecordById finds a soft deleted Record model by its id.
+//
+// Returns sql.ErrNoRows if the record is missing or it is not soft deleted.
+func (app *BaseApp) FindSoftDeletedRecordById(collectionModelOrIdentifier any, recordId string) (*Record, error) {
+	collection, err := getCollectionByModelOrIdentifier(app, collectionModelOrIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if !collection.SoftDelete.Enabled {
+		return nil, errors.New("the collection doesn't have soft delete enabled")
+	}
+
+	record := &Record{}
+
+	err = app.RecordQueryWithSoftDeleted(collection).
+		AndWhere(dbx.HashExp{collection.Name + ".id": recordId}).
+		AndWhere(dbx.Not(dbx.HashExp{collection.Name + "." + FieldNameDeleted: ""})).
+		Limit(1).
+		One(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// RestoreRecord clears the "deleted" field of a soft deleted record
+// making it again available in the default record queries.
+//
+// The restore is persisted with [App.SaveNoValidate] and triggers the regular record update hooks
+// (use [Record.IsRestored] to distinguish it from a regular update).
+// The realtime and webhook events of the change are sent with the "restore" action.
+//
+// Because the soft deleted records are excluded from the collection unique indexes,
+// the restore fails if another record already uses one of the restored record unique values.
+//
+// Note that the references that were cascade deleted together with
+// the record are not restored automatically.
+func (app *BaseApp) RestoreRecord(record *Record) error {
+	if !record.IsSoftDeleted() {
+		return errors.New("the record is not soft deleted")
+	}
+
+	record.SetRaw(FieldNameDeleted, types.DateTime{})
+
+	return app.SaveNoValidate(record)
+}
+
+// PurgeRecord permanently deletes the provided record from the database
+// regardless of its collection soft delete option.
+//
+// Note that the record relation references are handled the same way as with [App.Delete].
+func (app *BaseApp) PurgeRecord(record *Record) error {
+	record.forceDelete = true
+
+	err := app.Delete(record)
+	if err != nil {
+		record.forceDelete = false
+	}
+
+	return err
+}
+
+// PurgeOldSoftDeletedRecords permanently deletes all records from the
+// provided collection that were soft deleted before deletedBefore.
+func (app *BaseApp) PurgeOldSoftDeletedRecords(collectionModelOrIdentifier any, deletedBefore time.Time) error {
+	collection, err := getCollectionByModelOrIdentifier(app, collectionModelOrIdentifier)
+	if err != nil {
+		return err
+	}
+
+	if !collection.SoftDelete.Enabled {
+		return nil // nothing to purge
+	}
+
+	formattedDate := deletedBefore.UTC().Format(types.DefaultDateLayout)
+
+	batchSize := 500
+	records := make([]*Record, 0, batchSize)
+
+	for {
+		err := app.RecordQueryWithSoftDeleted(collection).
+			AndWhere(dbx.Not(dbx.HashExp{collection.Name + "." + FieldNameDeleted: ""})).
+			AndWhere(dbx.NewExp("[["+collection.Name+"."+FieldNameDeleted+"]] < {:date}", dbx.Params{"date": formattedDate})).
+			Limit(int64(batchSize)).
+			All(&records)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if err := app.PurgeRecord(record); err != nil {
+				return err
+			}
+		}
+
+		if len(records) < batchSize {
+			return nil // no more items
+		}
+
+		records = records[:0] // keep allocated memory
+	}
+}
+
+func (app *BaseApp) registerSoftDeleteHooks() {
+	// purge the expired soft deleted records
+	app.Cron().Add("__pbSoftDeletePurge__", "30 */6 * * *", func() {
+		collections, err := app.FindAllCollections(CollectionTypeBase, CollectionTypeAuth)
+		if err != nil {
+			app.Logger().Warn("Failed to load the collections for the soft deleted records purge", "error", err)
+			return
+		}
+
+		for _, collection := range collections {
+			if !collection.SoftDelete.Enabled || collection.SoftDelete.MaxDays <= 0 {
+				continue
+			}
+
+			purgeErr := app.PurgeOldSoftDeletedRecords(collection, time.Now().AddDate(0, 0, -1*collection.SoftDelete.MaxDays))
+			if purgeErr != nil {
+				app.Logger().Warn(
+					"Failed to purge old soft deleted records",
+					"collectionName", collection.Name,
+					"error", purgeErr,
+				)
+			}
+		}
+	})
+}
@@ -0,0 +1,424 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/dbutils"
+	"github.com/hanzoai/dbx"
+)
+
+func createSoftDeleteCollections(t *testing.T, app core.App) (*core.Collection, *core.Collection) {
+	parent := core.NewBaseCollection("soft_parent")
+	parent.SoftDelete.Enabled = true
+	parent.Fields.Add(&core.TextField{Name: "title"})
+	if err := app.Save(parent); err != nil {
+		t.Fatal(err)
+	}
+
+	child := core.NewBaseCollection("soft_child")
+	child.Fields.Add(&core.RelationField{
+		Name:          "parent",
+		CollectionId:  parent.Id,
+		MaxSelect:     1,
+		CascadeDelete: true,
+	})
+	if err := app.Save(child); err != nil {
+		t.Fatal(err)
+	}
+
+	return parent, child
+}
+
+func TestSoftDeleteCollectionField(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	parent, _ := createSoftDeleteCollections(t, app)
+
+	field, ok := parent.Fields.GetByName(core.FieldNameDeleted).(*core.AutodateField)
+	if !ok {
+		t.Fatalf("Expected %q autodate field, got %v", core.FieldNameDeleted, parent.Fields.GetByName(core.FieldNameDeleted))
+	}
+	if !field.System || field.OnCreate || field.OnUpdate {
+		t.Fatalf("Expected system field without onCreate/onUpdate, got %#v", field)
+	}
+
+	// disable
+	parent.SoftDelete.Enabled = false
+	if err := app.Save(parent); err != nil {
+		t.Fatal(err)
+	}
+	if f := parent.Fields.GetByName(core.FieldNameDeleted); f != nil {
+		t.Fatalf("Expected the %q field to be removed, got %v", core.FieldNameDeleted, f)
+	}
+}
+
+func TestSoftDeleteDisableWithDeletedRecords(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	parent, _ := createSoftDeleteCollections(t, app)
+
+	record := core.NewRecord(parent)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Delete(record); err != nil {
+		t.Fatal(err)
+	}
+
+	parent.SoftDelete.Enabled = false
+	if err := app.Save(parent); err == nil {
+		t.Fatal("Expected validation error because of the existing soft deleted records")
+	}
+}
+
+func TestSoftDeleteRecord(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	parent, child := createSoftDeleteCollections(t, app)
+
+	parentRecord := core.NewRecord(parent)
+	parentRecord.Set("title", "test")
+	if err := app.Save(parentRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	childRecord := core.NewRecord(child)
+	childRecord.Set("parent", parentRecord.Id)
+	if err := app.Save(childRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(parentRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	if !parentRecord.IsSoftDeleted() {
+		t.Fatal("Expected the record to be marked as soft deleted")
+	}
+
+	if _, err := app.FindRecordById(parent, parentRecord.Id); err == nil {
+		t.Fatal("Expected the soft deleted record to be excluded from the default queries")
+	}
+
+	total, err := app.CountRecords(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Fatalf("Expected 0 records, got %d", total)
+	}
+
+	// cascade deleted
+	if _, err := app.FindRecordById(child, childRecord.Id); err == nil {
+		t.Fatal("Expected the child record to be cascade deleted")
+	}
+
+	deleted, err := app.FindSoftDeletedRecordById(parent, parentRecord.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.RestoreRecord(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := app.FindRecordById(parent, parentRecord.Id)
+	if err != nil {
+		t.Fatalf("Expected the record to be restored, got %v", err)
+	}
+	if restored.GetString("title") != "test" || restored.IsSoftDeleted() {
+		t.Fatalf("Invalid restored record %v", restored)
+	}
+
+	if err := app.RestoreRecord(restored); err == nil {
+		t.Fatal("Expected error when restoring non soft deleted record")
+	}
+
+	// purge
+	if err := app.PurgeRecord(restored); err != nil {
+		t.Fatal(err)
+	}
+
+	var exists int
+	_ = app.RecordQueryWithSoftDeleted(parent).Select("(1)").AndWhere(dbx.HashExp{"id": parentRecord.Id}).Limit(1).Row(&exists)
+	if exists > 0 {
+		t.Fatal("Expected the record to be permanently deleted")
+	}
+}
+
+func TestHardDeleteUpdatesSoftDeletedRefs(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	parent := core.NewBaseCollection("plain_parent")
+	if err := app.Save(parent); err != nil {
+		t.Fatal(err)
+	}
+
+	child := core.NewBaseCollection("soft_ref_child")
+	child.SoftDelete.Enabled = true
+	child.Fields.Add(&core.RelationField{
+		Name:         "parent",
+		CollectionId: parent.Id,
+		MaxSelect:    1,
+	})
+	if err := app.Save(child); err != nil {
+		t.Fatal(err)
+	}
+
+	parentRecord := core.NewRecord(parent)
+	if err := app.Save(parentRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	childRecord := core.NewRecord(child)
+	childRecord.Set("parent", parentRecord.Id)
+	if err := app.Save(childRecord); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Delete(childRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(parentRecord); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := app.FindSoftDeletedRecordById(child, childRecord.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := deleted.GetString("parent"); v != "" {
+		t.Fatalf("Expected the soft deleted record parent reference to be unset, got %q", v)
+	}
+}
+
+func TestSoftDeleteRecordKeepsAuthRefs(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.SoftDelete.Enabled = true
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindRecordById(users, "4q1xlclmfloku33")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertTotalExternalAuths := func(expected int) {
+		t.Helper()
+
+		auths, err := app.FindAllExternalAuthsByRecord(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(auths) != expected {
+			t.Fatalf("Expected %d external auths, got %d", expected, len(auths))
+		}
+	}
+
+	assertTotalExternalAuths(2)
+
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	// soft delete
+	assertTotalExternalAuths(2)
+
+	// hard delete
+	if err := app.PurgeRecord(user); err != nil {
+		t.Fatal(err)
+	}
+	assertTotalExternalAuths(0)
+}
+
+func TestPurgeOldSoftDeletedRecords(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	parent, _ := createSoftDeleteCollections(t, app)
+
+	for i := 0; i < 3; i++ {
+		record := core.NewRecord(parent)
+		if err := app.Save(record); err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			if err := app.Delete(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// nothing older
+	if err := app.PurgeOldSoftDeletedRecords(parent, time.Now().Add(-1*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	assertTotalSoftDeleteRows(t, app, parent, 3)
+
+	if err := app.PurgeOldSoftDeletedRecords(parent, time.Now().Add(1*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	assertTotalSoftDeleteRows(t, app, parent, 1)
+}
+
+func assertTotalSoftDeleteRows(t *testing.T, app core.App, collection *core.Collection, expected int) {
+	var total int
+	err := app.RecordQueryWithSoftDeleted(collection).Select("count(*)").Row(&total)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != expected {
+		t.Fatalf("Expected %d total rows, got %d", expected, total)
+	}
+}
+
+func TestSoftDeleteUniqueIndexes(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.AddIndex("idx_test_unique_name", true, "name", "name != ''")
+	users.SoftDelete.Enabled = true
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, idx := range users.Indexes {
+		parsed := dbutils.ParseIndex(idx)
+		if !parsed.Unique {
+			continue
+		}
+		if !strings.HasSuffix(parsed.Where, "`deleted` = ''") {
+			t.Fatalf("Expected the unique index to exclude the soft deleted records, got %q", idx)
+		}
+	}
+
+	user, err := app.FindAuthRecordByEmail(users, "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Set("name", "test_name")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	// the unique values of the soft deleted record could be reused
+	newUser := core.NewRecord(users)
+	newUser.SetEmail("test@example.com")
+	newUser.SetPassword("1234567890")
+	newUser.Set("name", "test_name")
+	if err := app.Save(newUser); err != nil {
+		t.Fatalf("Expected the soft deleted record unique values to be reusable, got %v", err)
+	}
+
+	deleted, err := app.FindSoftDeletedRecordById(users, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RestoreRecord(deleted); err == nil {
+		t.Fatal("Expected the restore to fail because of the unique values conflict")
+	}
+
+	// disabling the soft delete should remove the partial index condition
+	if err := app.PurgeRecord(deleted); err != nil {
+		t.Fatal(err)
+	}
+	users.SoftDelete.Enabled = false
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, idx := range users.Indexes {
+		if strings.Contains(idx, "`deleted`") {
+			t.Fatalf("Expected the soft delete index condition to be removed, got %q", idx)
+		}
+		if strings.Contains(idx, "idx_test_unique_name") && dbutils.ParseIndex(idx).Where != "name != ''" {
+			t.Fatalf("Expected the original index condition to be restored, got %q", idx)
+		}
+	}
+}
+
+func TestRecordIsRestored(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	parent, _ := createSoftDeleteCollections(t, app)
+
+	record := core.NewRecord(parent)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	var updateRestored []bool
+	app.OnRecordUpdate(parent.Name).BindFunc(func(e *core.RecordEvent) error {
+		updateRestored = append(updateRestored, e.Record.IsRestored())
+		return e.Next()
+	})
+
+	// regular update
+	record.Set("title", "test")
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Delete(record); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := app.FindSoftDeletedRecordById(parent, record.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RestoreRecord(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []bool{false, true}
+	if len(updateRestored) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, updateRestored)
+	}
+	for i, v := range expected {
+		if updateRestored[i] != v {
+			t.Fatalf("Expected %v, got %v", expected, updateRestored)
+		}
+	}
+}
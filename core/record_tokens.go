@@ -15,6 +15,7 @@ const (
 	TokenTypeVerification  = "verification"
 	TokenTypePasswordReset = "passwordReset"
 	TokenTypeEmailChange   = "emailChange"
+	TokenTypeOIDCAccess    = "oidcAccess"
 )
 
 // List with commonly used record token claims
@@ -25,6 +26,11 @@ const (
 	TokenClaimEmail        = "email"
 	TokenClaimNewEmail     = "newEmail"
 	TokenClaimRefreshable  = "refreshable"
+	TokenClaimClientId     = "client_id"
+	TokenClaimAudience     = "aud"
+	TokenClaimScope        = "scope"
+	TokenClaimTokenKeyHash = "tokenKeyHash"
+	TokenClaimSessionId    = "sessionId"
 )
 
 // Common token related errors
@@ -40,15 +46,46 @@ var (
 //
 // Zero or negative duration will fallback to the duration from the auth collection settings.
 func (m *Record) NewStaticAuthToken(duration time.Duration) (string, error) {
-	return m.newAuthToken(duration, false)
+	return m.newAuthToken(TokenTypeAuth, duration, false, nil)
 }
 
 // NewAuthToken generates and returns a new record authentication token.
 func (m *Record) NewAuthToken() (string, error) {
-	return m.newAuthToken(0, true)
+	return m.newAuthToken(TokenTypeAuth, 0, true, nil)
 }
 
-func (m *Record) newAuthToken(duration time.Duration, refreshable bool) (string, error) {
+// NewOIDCAccessToken generates and returns a new record access token
+// issued by the OAuth2/OIDC provider for the specified client id and granted scope.
+//
+// The token is signed with the same key as the regular auth tokens but it has
+// its own [TokenTypeOIDCAccess] type (aka. it is not accepted as auth token by the app APIs)
+// and 3 additional claims - [TokenClaimScope], [TokenClaimAudience] and [TokenClaimClientId].
+//
+// Zero or negative duration will fallback to the duration from the auth collection settings.
+func (m *Record) NewOIDCAccessToken(clientId string, scope string, duration time.Duration) (string, error) {
+	return m.newAuthToken(TokenTypeOIDCAccess, duration, false, jwt.MapClaims{
+		TokenClaimScope:    scope,
+		TokenClaimAudience: clientId,
+		TokenClaimClientId: clientId,
+	})
+}
+
+// NewSessionAuthToken generates and returns a new short-lived record
+// authentication token bound to the specified auth session.
+//
+// Session auth tokens are non-refreshable (a new one could be obtained
+// with the session refresh token) and are valid only while the session exists.
+func (m *Record) NewSessionAuthToken(sessionId string) (string, error) {
+	if !m.Collection().IsAuth() {
+		return "", ErrNotAuthRecord
+	}
+
+	return m.newAuthToken(TokenTypeAuth, m.Collection().Sessions.AccessTokenDurationTime(), false, jwt.MapClaims{
+		TokenClaimSessionId: sessionId,
+	})
+}
+
+func (m *Record) newAuthToken(tokenType string, duration time.Duration, refreshable bool, extraClaims jwt.MapClaims) (string, error) {
 	if !m.Collection().IsAuth() {
 		return "", ErrNotAuthRecord
 	}
@@ -59,17 +96,41 @@ func (m *Record) newAuthToken(duration time.Duration, refreshable bool) (string,
 	}
 
 	claims := jwt.MapClaims{
-		TokenClaimType:         TokenTypeAuth,
+		TokenClaimType:         tokenType,
 		TokenClaimId:           m.Id,
 		TokenClaimCollectionId: m.Collection().Id,
 		TokenClaimRefreshable:  refreshable,
 	}
 
+	for k, v := range extraClaims {
+		claims[k] = v
+	}
+
 	if duration <= 0 {
 		duration = m.Collection().AuthToken.DurationTime()
 	}
 
-	return security.NewJWT(claims, key, duration)
+	signing := m.Collection().AuthTokenSigning
+	if !signing.IsAsymmetric() {
+		return security.NewJWT(claims, key, duration)
+	}
+
+	signingKey, jwk, err := signing.SigningKey()
+	if err != nil {
+		return "", errors.Join(ErrMissingSigningKey, err)
+	}
+
+	// the asymmetric tokens are not bound to the record tokenKey
+	// so we store its hash to be able to invalidate them on change
+	claims[TokenClaimTokenKeyHash] = authTokenKeyHash(m)
+
+	return security.NewSignedJWT(claims, signingKey, jwk.Kid, duration)
+}
+
+// authTokenKeyHash returns the HMAC-SHA256 hash of the auth record tokenKey
+// and its collection authToken secret used for the asymmetric auth tokens invalidation.
+func authTokenKeyHash(m *Record) string {
+	return security.HS256(m.TokenKey(), m.Collection().AuthToken.Secret)
 }
 
 // NewVerificationToken generates and returns a new record verification token.
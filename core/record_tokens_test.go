@@ -83,6 +83,148 @@ func TestNewAuthToken(t *testing.T) {
 	})
 }
 
+func TestNewOIDCAccessToken(t *testing.T) {
+	t.Parallel()
+
+	testRecordToken(t, core.TokenTypeOIDCAccess, func(record *core.Record) (string, error) {
+		return record.NewOIDCAccessToken("test_client", "openid email", 0)
+	}, map[string]any{
+		core.TokenClaimRefreshable: false,
+		core.TokenClaimClientId:    "test_client",
+		core.TokenClaimAudience:    "test_client",
+		core.TokenClaimScope:       "openid email",
+	})
+}
+
+func TestNewAuthTokenAsymmetric(t *testing.T) {
+	t.Parallel()
+
+	for _, algorithm := range []string{core.AuthTokenAlgorithmRS256, core.AuthTokenAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			app, _ := tests.NewTestApp()
+			defer app.Cleanup()
+
+			user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user.Collection().AuthTokenSigning.Algorithm = algorithm
+			if err = app.Save(user.Collection()); err != nil {
+				t.Fatal(err)
+			}
+
+			signingKey, jwk, err := user.Collection().AuthTokenSigning.SigningKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := user.NewAuthToken()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header, err := security.ParseUnverifiedJWTHeader(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if header["alg"] != algorithm || header["kid"] != jwk.Kid {
+				t.Fatalf("Expected %s alg and %s kid headers, got %v", algorithm, jwk.Kid, header)
+			}
+
+			// verify with the public key only
+			claims, err := security.ParseSignedJWT(token, signingKey.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedClaims := map[string]any{
+				core.TokenClaimId:           user.Id,
+				core.TokenClaimType:         core.TokenTypeAuth,
+				core.TokenClaimCollectionId: user.Collection().Id,
+				core.TokenClaimRefreshable:  true,
+				core.TokenClaimTokenKeyHash: security.HS256(user.TokenKey(), user.Collection().AuthToken.Secret),
+			}
+			for k, v := range expectedClaims {
+				if claims[k] != v {
+					t.Errorf("Expected claim %q with value %#v, got %#v", k, v, claims[k])
+				}
+			}
+
+			tokenRecord, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth)
+			if err != nil || tokenRecord.Id != user.Id {
+				t.Fatalf("Expected auth record %q, got %v (%v)", user.Id, tokenRecord, err)
+			}
+
+			// invalid private key
+			user.Collection().AuthTokenSigning.PrivateKey = "invalid"
+			if _, err := user.NewAuthToken(); err == nil {
+				t.Fatal("Expected invalid signing key error")
+			}
+		})
+	}
+}
+
+func TestNewSessionAuthToken(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	demo1, err := app.FindRecordById("demo1", "84nmscqy84lsi1t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := demo1.NewSessionAuthToken("test"); err == nil {
+		t.Fatal("Expected error for non-auth records")
+	}
+
+	users := enableTestAuthSessions(t, app, "users")
+	users.Sessions.AccessTokenDuration = 100
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, _ := newTestAuthSession(t, app, user)
+
+	now := time.Now()
+
+	token, err := user.NewSessionAuthToken(session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenRecord, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth)
+	if err != nil || tokenRecord.Id != user.Id {
+		t.Fatalf("Expected auth record %q, got %v (%v)", user.Id, tokenRecord, err)
+	}
+
+	claims, _ := security.ParseUnverifiedJWT(token)
+
+	if v := claims[core.TokenClaimSessionId]; v != session.Id {
+		t.Fatalf("Expected sessionId claim %q, got %#v", session.Id, v)
+	}
+
+	if v := claims[core.TokenClaimRefreshable]; v != false {
+		t.Fatalf("Expected refreshable claim false, got %#v", v)
+	}
+
+	var tolerance int64 = 1 // in sec
+
+	exp := cast.ToInt64(claims["exp"])
+	expectedExp := now.Add(100 * time.Second).Unix()
+	if exp < expectedExp-tolerance || exp > expectedExp+tolerance {
+		t.Fatalf("Expected token exp ~%d, got %d", expectedExp, exp)
+	}
+}
+
 func TestNewVerificationToken(t *testing.T) {
 	t.Parallel()
 
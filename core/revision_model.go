@@ -0,0 +1,385 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+const (
+	RevisionActionCreate = "create"
+	RevisionActionUpdate = "update"
+	RevisionActionDelete = "delete"
+)
+
+const CollectionNameRevisions = "_revisions"
+
+var (
+	_ Model        = (*Revision)(nil)
+	_ PreValidator = (*Revision)(nil)
+	_ RecordProxy  = (*Revision)(nil)
+)
+
+// Revision defines a Record proxy for working with the revisions collection.
+type Revision struct {
+	*Record
+}
+
+// NewRevision instantiates and returns a new blank *Revision model.
+//
+// Example usage:
+//
+//	revision := core.NewRevision(app)
+//	revision.SetRecordRef(record.Id)
+//	revision.SetCollectionRef(record.Collection().Id)
+//	revision.SetAction(core.RevisionActionUpdate)
+//	app.Save(revision)
+func NewRevision(app App) *Revision {
+	m := &Revision{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameRevisions)
+	if err != nil {
+		// this is just to make tests easier since the revisions collection is created on demand
+		// (note: the loaded record is further checked on Revision.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *Revision) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameRevisions {
+		return errors.New("missing or invalid revision ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *Revision) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *Revision) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *Revision) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *Revision) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *Revision) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *Revision) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Action returns the "action" record field value.
+func (m *Revision) Action() string {
+	return m.GetString("action")
+}
+
+// SetAction updates the "action" record field value.
+func (m *Revision) SetAction(action string) {
+	m.Set("action", action)
+}
+
+// Changes returns the "changes" record field value
+// in the format {"fieldName": {"old": ..., "new": ...}}.
+func (m *Revision) Changes() map[string]any {
+	result := map[string]any{}
+	_ = m.UnmarshalJSONField("changes", &result)
+	return result
+}
+
+// SetChanges updates the "changes" record field value.
+func (m *Revision) SetChanges(changes map[string]any) {
+	m.Set("changes", changes)
+}
+
+// Data returns the "data" record field value
+// (aka. the record fields data snapshot after the action).
+func (m *Revision) Data() map[string]any {
+	result := map[string]any{}
+	_ = m.UnmarshalJSONField("data", &result)
+	return result
+}
+
+// SetData updates the "data" record field value.
+func (m *Revision) SetData(data map[string]any) {
+	m.Set("data", data)
+}
+
+// AuthCollectionRef returns the "authCollectionRef" record field value.
+func (m *Revision) AuthCollectionRef() string {
+	return m.GetString("authCollectionRef")
+}
+
+// SetAuthCollectionRef updates the "authCollectionRef" record field value.
+func (m *Revision) SetAuthCollectionRef(collectionId string) {
+	m.Set("authCollectionRef", collectionId)
+}
+
+// AuthRef returns the "authRef" record field value.
+func (m *Revision) AuthRef() string {
+	return m.GetString("authRef")
+}
+
+// SetAuthRef updates the "authRef" record field value.
+func (m *Revision) SetAuthRef(authRecordId string) {
+	m.Set("authRef", authRecordId)
+}
+
+// IP returns the "ip" record field value.
+func (m *Revision) IP() string {
+	return m.GetString("ip")
+}
+
+// SetIP updates the "ip" record field value.
+func (m *Revision) SetIP(ip string) {
+	m.Set("ip", ip)
+}
+
+// UserAgent returns the "userAgent" record field value.
+func (m *Revision) UserAgent() string {
+	return m.GetString("userAgent")
+}
+
+// SetUserAgent updates the "userAgent" record field value.
+func (m *Revision) SetUserAgent(userAgent string) {
+	m.Set("userAgent", userAgent)
+}
+
+// Created returns the "created" record field value.
+func (m *Revision) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// -------------------------------------------------------------------
+
+// newRevisionsCollection returns the default revisions system collection definition.
+func newRevisionsCollection() *Collection {
+	col := NewBaseCollection(CollectionNameRevisions)
+	col.System = true
+
+	col.Fields.Add(&TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&SelectField{
+		Name:      "action",
+		System:    true,
+		Required:  true,
+		MaxSelect: 1,
+		Values:    []string{RevisionActionCreate, RevisionActionUpdate, RevisionActionDelete},
+	})
+	col.Fields.Add(&JSONField{
+		Name:   "changes",
+		System: true,
+	})
+	col.Fields.Add(&JSONField{
+		Name:   "data",
+		System: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "authCollectionRef",
+		System: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "authRef",
+		System: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "ip",
+		System: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "userAgent",
+		System: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.AddIndex("idx_revisions_collectionRef_recordRef", false, "collectionRef,recordRef", "")
+	col.AddIndex("idx_revisions_created", false, "created", "")
+
+	return col
+}
+
+// isRevisionField reports whether the field value should be stored in the record revisions.
+func isRevisionField(field Field) bool {
+	return field.Type() != FieldTypePassword && field.GetName() != FieldNameTokenKey
+}
+
+func (app *BaseApp) registerRevisionHooks() {
+	// create the revisions system collection on demand
+	collectionSaveHandler := &hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			if !e.Collection.Revisions.Enabled {
+				return nil
+			}
+
+			if _, err := e.App.FindCachedCollectionByNameOrId(CollectionNameRevisions); err == nil {
+				return nil // already exists
+			}
+
+			return e.App.Save(newRevisionsCollection())
+		},
+	}
+	app.OnCollectionCreateExecute().Bind(collectionSaveHandler)
+	app.OnCollectionUpdateExecute().Bind(collectionSaveHandler)
+
+	// persist the revisions in the same transaction as the record change
+	// (the priority is lower than the system record handlers so that soft deletes are also included)
+	app.OnRecordCreateExecute().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			return executeWithRecordRevision(e, RevisionActionCreate)
+		},
+		Priority: 98,
+	})
+
+	app.OnRecordUpdateExecute().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			return executeWithRecordRevision(e, RevisionActionUpdate)
+		},
+		Priority: 98,
+	})
+
+	app.OnRecordDeleteExecute().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			return executeWithRecordRevision(e, RevisionActionDelete)
+		},
+		Priority: 98,
+	})
+}
+
+// executeWithRecordRevision executes the record event in a transaction
+// and persists a new revision for the event record as part of the same transaction.
+//
+// A failure to save the revision rollbacks the record change.
+func executeWithRecordRevision(e *RecordEvent, action string) error {
+	collection := e.Record.Collection()
+	if !collection.Revisions.Enabled || collection.Name == CollectionNameRevisions {
+		return e.Next()
+	}
+
+	originalApp := e.App
+	txErr := e.App.RunInTransaction(func(txApp App) error {
+		e.App = txApp
+
+		// load the current db state of the record
+		// (note: the record Original() is not refreshed after save and could be stale)
+		var original *Record
+		if action == RevisionActionUpdate {
+			original = &Record{}
+			err := txApp.RecordQueryWithSoftDeleted(collection).
+				AndWhere(dbx.HashExp{collection.Name + ".id": e.Record.LastSavedPK()}).
+				Limit(1).
+				One(original)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		return saveRecordRevision(txApp, e.Context, e.Record, original, action)
+	})
+	e.App = originalApp
+
+	return txErr
+}
+
+// saveRecordRevision persists a new revision for the provided record
+// (if its collection has the revisions option enabled).
+//
+// For update revisions original must be the record db state before the update.
+func saveRecordRevision(app App, ctx context.Context, record *Record, original *Record, action string) error {
+	collection := record.Collection()
+	if !collection.Revisions.Enabled || collection.Name == CollectionNameRevisions {
+		return nil
+	}
+
+	data := make(map[string]any, len(collection.Fields))
+	changes := map[string]any{}
+
+	for _, field := range collection.Fields {
+		if !isRevisionField(field) {
+			continue
+		}
+
+		name := field.GetName()
+		newValue := record.GetRaw(name)
+
+		data[name] = newValue
+
+		switch action {
+		case RevisionActionCreate:
+			changes[name] = map[string]any{"old": nil, "new": newValue}
+		case RevisionActionUpdate:
+			oldValue := original.GetRaw(name)
+			if !areValuesEqual(oldValue, newValue) {
+				changes[name] = map[string]any{"old": oldValue, "new": newValue}
+			}
+		}
+	}
+
+	if action == RevisionActionUpdate && len(changes) == 0 {
+		return nil // nothing has changed
+	}
+
+	revision := NewRevision(app)
+	revision.SetCollectionRef(collection.Id)
+	revision.SetRecordRef(record.Id)
+	revision.SetAction(action)
+	revision.SetChanges(changes)
+	revision.SetData(data)
+
+	if requestEvent := RequestEventFromContext(ctx); requestEvent != nil {
+		if requestEvent.Auth != nil {
+			revision.SetAuthCollectionRef(requestEvent.Auth.Collection().Id)
+			revision.SetAuthRef(requestEvent.Auth.Id)
+		}
+		revision.SetIP(requestEvent.RealIP())
+		revision.SetUserAgent(requestEvent.Request.UserAgent())
+	}
+
+	if err := app.Save(revision); err != nil {
+		return fmt.Errorf("failed to save the record revision: %w", err)
+	}
+
+	return nil
+}
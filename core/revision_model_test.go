@@ -0,0 +1,220 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+)
+
+func TestRevisionsCollectionOnDemand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if _, err := app.FindCollectionByNameOrId(core.CollectionNameRevisions); err == nil {
+		t.Fatal("Expected the revisions collection to not exist")
+	}
+
+	collection := core.NewBaseCollection("rev_test")
+	collection.Revisions.Enabled = true
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := app.FindCollectionByNameOrId(core.CollectionNameRevisions)
+	if err != nil {
+		t.Fatalf("Expected the revisions collection to be created, got %v", err)
+	}
+
+	if !revisions.System {
+		t.Fatal("Expected the revisions collection to be system")
+	}
+}
+
+func TestRecordRevisions(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection := core.NewBaseCollection("rev_test")
+	collection.Revisions.Enabled = true
+	collection.Fields.Add(&core.TextField{Name: "title"})
+	collection.Fields.Add(&core.NumberField{Name: "total"})
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("title", "a")
+	record.Set("total", 1)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	record.Set("title", "b")
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	// no changes
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := app.FindAllRevisionsByRecord(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+
+	var createRevision, updateRevision *core.Revision
+	for _, r := range revisions {
+		switch r.Action() {
+		case core.RevisionActionCreate:
+			createRevision = r
+		case core.RevisionActionUpdate:
+			updateRevision = r
+		}
+	}
+
+	if createRevision == nil || updateRevision == nil {
+		t.Fatalf("Expected create and update revisions, got %v", revisions)
+	}
+
+	changes := updateRevision.Changes()
+	if len(changes) != 1 {
+		t.Fatalf("Expected only 1 changed field, got %v", changes)
+	}
+	titleChange, _ := changes["title"].(map[string]any)
+	if titleChange["old"] != "a" || titleChange["new"] != "b" {
+		t.Fatalf("Invalid title change %v", changes["title"])
+	}
+
+	// restore the create revision
+	restored, err := app.RestoreRecordRevision(createRevision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := restored.GetString("title"); v != "a" {
+		t.Fatalf("Expected restored title %q, got %q", "a", v)
+	}
+
+	// delete and recreate from revision
+	if err := app.Delete(restored); err != nil {
+		t.Fatal(err)
+	}
+
+	recreated, err := app.RestoreRecordRevision(updateRevision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recreated.Id != record.Id || recreated.GetString("title") != "b" || recreated.GetInt("total") != 1 {
+		t.Fatalf("Invalid recreated record %v", recreated)
+	}
+
+	revisions, err = app.FindAllRevisionsByRecord(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create, update, update (restore), delete, create (recreate)
+	if len(revisions) != 5 {
+		t.Fatalf("Expected 5 revisions, got %d", len(revisions))
+	}
+}
+
+func TestRecordRevisionsDisabled(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enabled := core.NewBaseCollection("rev_enabled")
+	enabled.Revisions.Enabled = true
+	if err := app.Save(enabled); err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := core.NewBaseCollection("rev_disabled")
+	if err := app.Save(disabled); err != nil {
+		t.Fatal(err)
+	}
+
+	record := core.NewRecord(disabled)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := app.FindAllRevisionsByRecord(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revisions) != 0 {
+		t.Fatalf("Expected no revisions, got %d", len(revisions))
+	}
+}
+
+func TestRecordRevisionsFailure(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	collection := core.NewBaseCollection("rev_test")
+	collection.Revisions.Enabled = true
+	collection.Fields.Add(&core.TextField{Name: "title"})
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("title", "a")
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	app.OnRecordCreate(core.CollectionNameRevisions).BindFunc(func(e *core.RecordEvent) error {
+		return errors.New("test")
+	})
+
+	// update
+	record.Set("title", "b")
+	if err := app.Save(record); err == nil {
+		t.Fatal("Expected the update to fail")
+	}
+
+	fresh, err := app.FindRecordById(collection, record.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := fresh.GetString("title"); v != "a" {
+		t.Fatalf("Expected the update to be rolled back, got title %q", v)
+	}
+
+	// delete
+	if err := app.Delete(fresh); err == nil {
+		t.Fatal("Expected the delete to fail")
+	}
+
+	if _, err := app.FindRecordById(collection, record.Id); err != nil {
+		t.Fatalf("Expected the delete to be rolled back, got %v", err)
+	}
+
+	// create
+	newRecord := core.NewRecord(collection)
+	if err := app.Save(newRecord); err == nil {
+		t.Fatal("Expected the create to fail")
+	}
+
+	if _, err := app.FindRecordById(collection, newRecord.Id); err == nil {
+		t.Fatal("Expected the create to be rolled back")
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// FindAllRevisionsByRecord returns all Revision models linked to the provided record
+// (ordered from the newest to the oldest).
+func (app *BaseApp) FindAllRevisionsByRecord(record *Record) ([]*Revision, error) {
+	result := []*Revision{}
+
+	err := app.RecordQuery(CollectionNameRevisions).
+		AndWhere(dbx.HashExp{
+			"collectionRef": record.Collection().Id,
+			"recordRef":     record.Id,
+		}).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindRevisionById returns a single Revision model by its id.
+func (app *BaseApp) FindRevisionById(id string) (*Revision, error) {
+	result := &Revision{}
+
+	err := app.RecordQuery(CollectionNameRevisions).
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RestoreRecordRevision restores the revision record to the fields data
+// snapshot of the provided revision and returns the saved record.
+//
+// If the revision record doesn't exist anymore it is recreated with the same id
+// (auth records are recreated with a random password).
+// A soft deleted revision record is also restored.
+//
+// Note that the file, password and autodate fields are not restored.
+func (app *BaseApp) RestoreRecordRevision(revision *Revision) (*Record, error) {
+	collection, err := app.FindCachedCollectionByNameOrId(revision.CollectionRef())
+	if err != nil {
+		return nil, err
+	}
+
+	if collection.IsView() {
+		return nil, errors.New("view records cannot be restored")
+	}
+
+	record := &Record{}
+
+	err = app.RecordQueryWithSoftDeleted(collection).
+		AndWhere(dbx.HashExp{collection.Name + ".id": revision.RecordRef()}).
+		Limit(1).
+		One(record)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		record = NewRecord(collection)
+		record.Id = revision.RecordRef()
+		if collection.IsAuth() {
+			record.SetRandomPassword()
+		}
+	}
+
+	data := revision.Data()
+
+	for _, field := range collection.Fields {
+		name := field.GetName()
+
+		if name == FieldNameId ||
+			!isRevisionField(field) ||
+			field.Type() == FieldTypeFile ||
+			field.Type() == FieldTypeAutodate {
+			continue
+		}
+
+		if v, ok := data[name]; ok {
+			record.Set(name, v)
+		}
+	}
+
+	if record.IsSoftDeleted() {
+		record.SetRaw(FieldNameDeleted, types.DateTime{})
+	}
+
+	if err := app.Save(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
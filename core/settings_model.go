@@ -19,6 +19,7 @@ import (
 	"github.com/hanzoai/backendPB/tools/cron"
 	"github.com/hanzoai/backendPB/tools/hook"
 	"github.com/hanzoai/backendPB/tools/mailer"
+	"github.com/hanzoai/backendPB/tools/search"
 	"github.com/hanzoai/backendPB/tools/security"
 	"github.com/hanzoai/backendPB/tools/types"
 )
@@ -128,6 +129,10 @@ type settings struct {
 	TrustedProxy TrustedProxyConfig `form:"trustedProxy" json:"trustedProxy"`
 	Batch        BatchConfig        `form:"batch" json:"batch"`
 	Logs         LogsConfig         `form:"logs" json:"logs"`
+	Webhooks     WebhooksConfig     `form:"webhooks" json:"webhooks"`
+	Jobs         JobsConfig         `form:"jobs" json:"jobs"`
+	Realtime     RealtimeConfig     `form:"realtime" json:"realtime"`
+	OIDC         OIDCConfig         `form:"oidc" json:"oidc"`
 }
 
 // Settings defines the HanzoBase app settings.
@@ -163,12 +168,28 @@ func newDefaultSettings() *Settings {
 			},
 			Backups: BackupsConfig{
 				CronMaxKeep: 3,
+				Stream: BackupsStreamConfig{
+					Interval: 10,
+					MaxKeep:  3,
+				},
 			},
 			Batch: BatchConfig{
 				Enabled:     false,
 				MaxRequests: 50,
 				Timeout:     3,
 			},
+			Webhooks: WebhooksConfig{
+				Enabled:     false,
+				MaxAttempts: 8,
+				Timeout:     10,
+			},
+			Jobs: JobsConfig{
+				Workers: 2,
+				Timeout: 600,
+			},
+			OIDC: OIDCConfig{
+				TokenDuration: 3600,
+			},
 			RateLimits: RateLimitsConfig{
 				Enabled: false, // @todo once tested enough enable by default for new installations
 				Rules: []RateLimitRule{
@@ -287,6 +308,10 @@ func (s *Settings) PostValidate(ctx context.Context, app App) error {
 		validation.Field(&s.Batch),
 		validation.Field(&s.RateLimits),
 		validation.Field(&s.TrustedProxy),
+		validation.Field(&s.Webhooks),
+		validation.Field(&s.Jobs),
+		validation.Field(&s.Realtime, validation.By(checkRealtimeChannelsRules(app))),
+		validation.Field(&s.OIDC, validation.By(checkOIDCAuthCollection(app))),
 	)
 }
 
@@ -331,6 +356,7 @@ func (s *Settings) MarshalJSON() ([]byte, error) {
 		&copy.SMTP.Password,
 		&copy.S3.Secret,
 		&copy.Backups.S3.Secret,
+		&copy.Backups.EncryptionKey,
 	}
 
 	// mask all sensitive fields
@@ -455,6 +481,19 @@ type BackupsConfig struct {
 	// This field works only when the cron config has valid cron expression.
 	CronMaxKeep int `form:"cronMaxKeep" json:"cronMaxKeep"`
 
+	// EncryptionKey is an optional secret used to encrypt the generated backups
+	// (the archive AES-256 key is derived from it).
+	//
+	// Note that the key is required to restore the encrypted backups
+	// so make sure to store it somewhere safe.
+	//
+	// Leave it empty to create plain zip backups.
+	EncryptionKey string `form:"encryptionKey" json:"encryptionKey,omitempty"`
+
+	// Stream is an optional continuous backup config that allows
+	// point-in-time restore of the app data db.
+	Stream BackupsStreamConfig `form:"stream" json:"stream"`
+
 	// S3 is an optional S3 storage config specifying where to store the app backups.
 	S3 S3Config `form:"s3" json:"s3"`
 }
@@ -463,6 +502,8 @@ type BackupsConfig struct {
 func (c BackupsConfig) Validate() error {
 	return validation.ValidateStruct(&c,
 		validation.Field(&c.S3),
+		validation.Field(&c.Stream),
+		validation.Field(&c.EncryptionKey, validation.Length(16, 255)),
 		validation.Field(&c.Cron, validation.By(checkCronExpression)),
 		validation.Field(
 			&c.CronMaxKeep,
@@ -472,6 +513,27 @@ func (c BackupsConfig) Validate() error {
 	)
 }
 
+type BackupsStreamConfig struct {
+	// Enabled enables the continuous backup of the app data db
+	// (the changed db pages are periodically uploaded to the backups storage).
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// Interval is the frequency in seconds of the db changes upload.
+	Interval int `form:"interval" json:"interval"`
+
+	// MaxKeep is the max number of stream generations (aka. full db copies
+	// with their following changes) to keep before removing older entries.
+	MaxKeep int `form:"maxKeep" json:"maxKeep"`
+}
+
+// Validate makes BackupsStreamConfig validatable by implementing [validation.Validatable] interface.
+func (c BackupsStreamConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Interval, validation.When(c.Enabled, validation.Required), validation.Min(0), validation.Max(3600)),
+		validation.Field(&c.MaxKeep, validation.When(c.Enabled, validation.Required), validation.Min(0)),
+	)
+}
+
 func checkCronExpression(value any) error {
 	v, _ := value.(string)
 	if v == "" {
@@ -488,6 +550,276 @@ func checkCronExpression(value any) error {
 
 // -------------------------------------------------------------------
 
+type WebhooksConfig struct {
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// MaxAttempts is the max number of delivery attempts (at least 1) before
+	// marking a webhook delivery as failed.
+	MaxAttempts int `form:"maxAttempts" json:"maxAttempts"`
+
+	// Timeout is the max duration in seconds to wait for a single webhook delivery response.
+	Timeout int64 `form:"timeout" json:"timeout"`
+}
+
+// Validate makes WebhooksConfig validatable by implementing [validation.Validatable] interface.
+func (c WebhooksConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.MaxAttempts, validation.When(c.Enabled, validation.Required, validation.Min(1)), validation.Min(0), validation.Max(50)),
+		validation.Field(&c.Timeout, validation.When(c.Enabled, validation.Required), validation.Min(0), validation.Max(300)),
+	)
+}
+
+// -------------------------------------------------------------------
+
+type JobsConfig struct {
+	// Workers is the number of concurrent background job workers
+	// started together with the app server.
+	//
+	// Set to 0 to disable the automatic jobs processing.
+	//
+	// Changes are applied on the next app start.
+	Workers int `form:"workers" json:"workers"`
+
+	// Timeout is the max duration in seconds of a single job run.
+	Timeout int64 `form:"timeout" json:"timeout"`
+}
+
+// Validate makes JobsConfig validatable by implementing [validation.Validatable] interface.
+func (c JobsConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Workers, validation.Min(0), validation.Max(100)),
+		validation.Field(&c.Timeout, validation.When(c.Workers > 0, validation.Required), validation.Min(0), validation.Max(86400)),
+	)
+}
+
+// -------------------------------------------------------------------
+
+type RealtimeConfig struct {
+	// Channels is a list with the custom realtime broadcast channels
+	// that clients could join, publish ephemeral messages to and see who else is present.
+	Channels []RealtimeChannel `form:"channels" json:"channels"`
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (c RealtimeConfig) MarshalJSON() ([]byte, error) {
+	type alias RealtimeConfig
+
+	// serialize as empty array
+	if c.Channels == nil {
+		c.Channels = []RealtimeChannel{}
+	}
+
+	return json.Marshal(alias(c))
+}
+
+// Validate makes RealtimeConfig validatable by implementing [validation.Validatable] interface.
+func (c RealtimeConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Channels, validation.By(checkUniqueRealtimeChannelName)),
+	)
+}
+
+// FindChannel returns the channel configuration matching the provided channel name.
+//
+// Exact name matches have priority over the wildcard patterns
+// and the longest matching wildcard pattern wins (e.g. "rooms:*" over "*").
+func (c *RealtimeConfig) FindChannel(name string) (RealtimeChannel, bool) {
+	var match RealtimeChannel
+	var found bool
+
+	for _, ch := range c.Channels {
+		if ch.Name == name {
+			return ch, true
+		}
+
+		prefix, isPattern := strings.CutSuffix(ch.Name, "*")
+		if isPattern &&
+			strings.HasPrefix(name, prefix) &&
+			(!found || len(ch.Name) > len(match.Name)) {
+			match = ch
+			found = true
+		}
+	}
+
+	return match, found
+}
+
+func checkUniqueRealtimeChannelName(value any) error {
+	channels, ok := value.([]RealtimeChannel)
+	if !ok {
+		return validators.ErrUnsupportedValueType
+	}
+
+	existing := make(map[string]struct{}, len(channels))
+
+	for i, ch := range channels {
+		if _, ok := existing[ch.Name]; ok {
+			return validation.Errors{
+				strconv.Itoa(i): validation.Errors{
+					"name": validation.NewError("validation_duplicated_realtime_channel", "Realtime channel with name {{.name}} already exists.").
+						SetParams(map[string]any{"name": ch.Name}),
+				},
+			}
+		}
+		existing[ch.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// RealtimeChannelNameRegex specifies the allowed realtime channel name characters.
+var RealtimeChannelNameRegex = regexp.MustCompile(`^[\w\-.:]+$`)
+
+var realtimeChannelPatternRegex = regexp.MustCompile(`^[\w\-.:]*\*?$`)
+
+type RealtimeChannel struct {
+	// Name is the channel name or a channel name pattern
+	// with a "*" wildcard suffix (e.g. "rooms:*").
+	Name string `form:"name" json:"name"`
+
+	// JoinRule is an optional filter expression that restricts
+	// who could join the channel and see its presence and messages.
+	//
+	// The rule could use the special "name" field (the joined channel name)
+	// and all @request.* and @collection.* fields (e.g. "@request.auth.id != ''").
+	//
+	// Leave it nil to allow only superusers.
+	JoinRule *string `form:"joinRule" json:"joinRule"`
+
+	// PublishRule is an optional filter expression that restricts
+	// who could publish ephemeral messages to the channel.
+	//
+	// The rule has the same fields as the JoinRule.
+	//
+	// Leave it nil to allow only superusers.
+	PublishRule *string `form:"publishRule" json:"publishRule"`
+}
+
+// Validate makes RealtimeChannel validatable by implementing [validation.Validatable] interface.
+func (c RealtimeChannel) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 255), validation.Match(realtimeChannelPatternRegex)),
+	)
+}
+
+// checkRealtimeChannelsRules checks the join and publish rules of the realtime channels
+// the same way as the collection rules (aka. with the channel record field resolver).
+func checkRealtimeChannelsRules(app App) validation.RuleFunc {
+	return func(value any) error {
+		config, ok := value.(RealtimeConfig)
+		if !ok {
+			return validators.ErrUnsupportedValueType
+		}
+
+		errs := validation.Errors{}
+
+		for i, ch := range config.Channels {
+			chErrs := validation.Errors{}
+
+			if err := checkRealtimeChannelRule(app, ch.JoinRule); err != nil {
+				chErrs["joinRule"] = err
+			}
+
+			if err := checkRealtimeChannelRule(app, ch.PublishRule); err != nil {
+				chErrs["publishRule"] = err
+			}
+
+			if len(chErrs) > 0 {
+				errs[strconv.Itoa(i)] = chErrs
+			}
+		}
+
+		if len(errs) > 0 {
+			return validation.Errors{"channels": errs}
+		}
+
+		return nil
+	}
+}
+
+func checkRealtimeChannelRule(app App, rule *string) error {
+	if rule == nil || *rule == "" {
+		return nil // nothing to check
+	}
+
+	r := NewRecordFieldResolver(app, newRealtimeChannelCollection(), nil, true)
+	if _, err := search.FilterData(*rule).BuildExpr(r); err != nil {
+		return validation.NewError("validation_invalid_rule", "Invalid rule. Raw error: "+err.Error())
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+type OIDCConfig struct {
+	// Enabled enables the built-in OAuth2/OpenID Connect authorization server
+	// (aka. the app could be used as identity provider for other apps).
+	Enabled bool `form:"enabled" json:"enabled"`
+
+	// AuthCollection is the name or id of the auth collection
+	// whose records are authenticated by the provider.
+	AuthCollection string `form:"authCollection" json:"authCollection"`
+
+	// LoginURL is the url of the app page where the users are redirected from the
+	// authorization endpoint (together with the original authorization request query params)
+	// to sign in and approve the authorization request.
+	LoginURL string `form:"loginURL" json:"loginURL"`
+
+	// TokenDuration is the duration in seconds of the issued access and ID tokens.
+	TokenDuration int64 `form:"tokenDuration" json:"tokenDuration"`
+}
+
+// Validate makes OIDCConfig validatable by implementing [validation.Validatable] interface.
+func (c OIDCConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.AuthCollection, validation.When(c.Enabled, validation.Required), validation.Length(0, 255)),
+		validation.Field(&c.LoginURL, validation.When(c.Enabled, validation.Required), is.URL),
+		validation.Field(&c.TokenDuration, validation.When(c.Enabled, validation.Required), validation.Min(0), validation.Max(86400)),
+	)
+}
+
+// TokenDurationTime returns the current config TokenDuration as [time.Duration].
+func (c OIDCConfig) TokenDurationTime() time.Duration {
+	return time.Duration(c.TokenDuration) * time.Second
+}
+
+// checkOIDCAuthCollection checks whether the OAuth2/OIDC provider auth collection
+// exists and signs its auth tokens with asymmetric key
+// (the ID and access tokens are signed with the same key and
+// could be verified with the public keys from /.well-known/jwks.json).
+func checkOIDCAuthCollection(app App) validation.RuleFunc {
+	return func(value any) error {
+		config, ok := value.(OIDCConfig)
+		if !ok {
+			return validators.ErrUnsupportedValueType
+		}
+
+		if !config.Enabled || config.AuthCollection == "" {
+			return nil // nothing to check
+		}
+
+		collection, err := app.FindCachedCollectionByNameOrId(config.AuthCollection)
+		if err != nil || !collection.IsAuth() {
+			return validation.Errors{"authCollection": validation.NewError(
+				"validation_invalid_auth_collection",
+				"Missing or invalid auth collection.",
+			)}
+		}
+
+		if !collection.AuthTokenSigning.IsAsymmetric() {
+			return validation.Errors{"authCollection": validation.NewError(
+				"validation_auth_collection_symmetric_signing",
+				"The auth collection must use RS256 or EdDSA auth token signing algorithm.",
+			)}
+		}
+
+		return nil
+	}
+}
+
+// -------------------------------------------------------------------
+
 type MetaConfig struct {
 	AppName       string `form:"appName" json:"appName"`
 	AppURL        string `form:"appURL" json:"appURL"`
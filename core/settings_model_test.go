@@ -10,6 +10,7 @@ import (
 	"github.com/hanzoai/backendPB/core"
 	"github.com/hanzoai/backendPB/tests"
 	"github.com/hanzoai/backendPB/tools/mailer"
+	"github.com/hanzoai/backendPB/tools/types"
 )
 
 func TestSettingsDelete(t *testing.T) {
@@ -99,6 +100,7 @@ func TestSettingsMarshalJSON(t *testing.T) {
 	settings.SMTP.Password = testSecret
 	settings.S3.Secret = testSecret
 	settings.Backups.S3.Secret = testSecret
+	settings.Backups.EncryptionKey = testSecret
 
 	raw, err := json.Marshal(settings)
 	if err != nil {
@@ -106,7 +108,7 @@ func TestSettingsMarshalJSON(t *testing.T) {
 	}
 	rawStr := string(raw)
 
-	expected := `{"smtp":{"enabled":false,"port":0,"host":"","username":"abc","authMethod":"","tls":false,"localName":""},"backups":{"cron":"","cronMaxKeep":0,"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false}},"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false},"meta":{"appName":"test123","appURL":"","senderName":"","senderAddress":"","hideControls":false},"rateLimits":{"rules":[],"enabled":false},"trustedProxy":{"headers":[],"useLeftmostIP":false},"batch":{"enabled":false,"maxRequests":0,"timeout":0,"maxBodySize":0},"logs":{"maxDays":0,"minLevel":0,"logIP":false,"logAuthId":false}}`
+	expected := `{"smtp":{"enabled":false,"port":0,"host":"","username":"abc","authMethod":"","tls":false,"localName":""},"backups":{"cron":"","cronMaxKeep":0,"stream":{"enabled":false,"interval":0,"maxKeep":0},"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false}},"s3":{"enabled":false,"bucket":"","region":"","endpoint":"","accessKey":"","forcePathStyle":false},"meta":{"appName":"test123","appURL":"","senderName":"","senderAddress":"","hideControls":false},"rateLimits":{"rules":[],"enabled":false},"trustedProxy":{"headers":[],"useLeftmostIP":false},"batch":{"enabled":false,"maxRequests":0,"timeout":0,"maxBodySize":0},"logs":{"maxDays":0,"minLevel":0,"logIP":false,"logAuthId":false},"webhooks":{"enabled":false,"maxAttempts":0,"timeout":0},"jobs":{"workers":0,"timeout":0},"realtime":{"channels":[]},"oidc":{"enabled":false,"authCollection":"","loginURL":"","tokenDuration":0}}`
 
 	if rawStr != expected {
 		t.Fatalf("Expected\n%v\ngot\n%v", expected, rawStr)
@@ -394,6 +396,13 @@ func TestBackupsConfigValidate(t *testing.T) {
 			},
 			[]string{"cron", "cronMaxKeep"},
 		},
+		{
+			"too short encryption key",
+			core.BackupsConfig{
+				EncryptionKey: "123456789012345",
+			},
+			[]string{"encryptionKey"},
+		},
 		{
 			"invalid enabled S3",
 			core.BackupsConfig{
@@ -414,8 +423,53 @@ func TestBackupsConfigValidate(t *testing.T) {
 					AccessKey: "test",
 					Secret:    "test",
 				},
-				Cron:        "*/10 * * * *",
-				CronMaxKeep: 1,
+				Cron:          "*/10 * * * *",
+				CronMaxKeep:   1,
+				EncryptionKey: "1234567890123456",
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestBackupsStreamConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.BackupsStreamConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.BackupsStreamConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.BackupsStreamConfig{Enabled: true},
+			[]string{"interval", "maxKeep"},
+		},
+		{
+			"invalid data (out of range values)",
+			core.BackupsStreamConfig{
+				Interval: 3601,
+				MaxKeep:  -1,
+			},
+			[]string{"interval", "maxKeep"},
+		},
+		{
+			"valid data",
+			core.BackupsStreamConfig{
+				Enabled:  true,
+				Interval: 10,
+				MaxKeep:  3,
 			},
 			[]string{},
 		},
@@ -485,6 +539,352 @@ func TestBatchConfigValidate(t *testing.T) {
 	}
 }
 
+func TestWebhooksConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.WebhooksConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.WebhooksConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.WebhooksConfig{Enabled: true},
+			[]string{"maxAttempts", "timeout"},
+		},
+		{
+			"invalid data (out of range values)",
+			core.WebhooksConfig{
+				MaxAttempts: 51,
+				Timeout:     -1,
+			},
+			[]string{"maxAttempts", "timeout"},
+		},
+		{
+			"valid data",
+			core.WebhooksConfig{
+				Enabled:     true,
+				MaxAttempts: 5,
+				Timeout:     10,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestJobsConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.JobsConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.JobsConfig{},
+			[]string{},
+		},
+		{
+			"zero value (with workers)",
+			core.JobsConfig{Workers: 1},
+			[]string{"timeout"},
+		},
+		{
+			"invalid data (out of range values)",
+			core.JobsConfig{
+				Workers: 101,
+				Timeout: -1,
+			},
+			[]string{"workers", "timeout"},
+		},
+		{
+			"valid data",
+			core.JobsConfig{
+				Workers: 2,
+				Timeout: 60,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestOIDCConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.OIDCConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.OIDCConfig{},
+			[]string{},
+		},
+		{
+			"zero value (enabled)",
+			core.OIDCConfig{Enabled: true},
+			[]string{"authCollection", "loginURL", "tokenDuration"},
+		},
+		{
+			"invalid data",
+			core.OIDCConfig{
+				AuthCollection: strings.Repeat("a", 256),
+				LoginURL:       "invalid",
+				TokenDuration:  86401,
+			},
+			[]string{"authCollection", "loginURL", "tokenDuration"},
+		},
+		{
+			"valid data",
+			core.OIDCConfig{
+				Enabled:        true,
+				AuthCollection: "users",
+				LoginURL:       "https://example.com/login",
+				TokenDuration:  3600,
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestOIDCConfigTokenDurationTime(t *testing.T) {
+	config := core.OIDCConfig{TokenDuration: 60}
+
+	if v := config.TokenDurationTime(); v != 60*time.Second {
+		t.Fatalf("Expected 60s, got %v", v)
+	}
+}
+
+func TestRealtimeConfigValidate(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		config         core.RealtimeConfig
+		expectedErrors []string
+	}{
+		{
+			"zero value",
+			core.RealtimeConfig{},
+			[]string{},
+		},
+		{
+			"invalid channels",
+			core.RealtimeConfig{
+				Channels: []core.RealtimeChannel{
+					{},
+					{Name: "a b"},
+					{Name: "a*b"},
+					{Name: "a", JoinRule: types.Pointer("id =")},
+				},
+			},
+			[]string{"channels"},
+		},
+		{
+			"duplicated channel names",
+			core.RealtimeConfig{
+				Channels: []core.RealtimeChannel{
+					{Name: "rooms:*"},
+					{Name: "rooms:*"},
+				},
+			},
+			[]string{"channels"},
+		},
+		{
+			"valid data",
+			core.RealtimeConfig{
+				Channels: []core.RealtimeChannel{
+					{Name: "*"},
+					{Name: "rooms:*", JoinRule: types.Pointer("@request.auth.id != ''")},
+					{Name: "lobby", JoinRule: types.Pointer(""), PublishRule: types.Pointer("")},
+				},
+			},
+			[]string{},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result := s.config.Validate()
+
+			tests.TestValidationErrors(t, result, s.expectedErrors)
+		})
+	}
+}
+
+func TestSettingsValidateRealtimeChannelRules(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name        string
+		channels    []core.RealtimeChannel
+		expectError bool
+	}{
+		{
+			"invalid rule syntax",
+			[]core.RealtimeChannel{{Name: "a", JoinRule: types.Pointer("name =")}},
+			true,
+		},
+		{
+			"unknown channel field",
+			[]core.RealtimeChannel{{Name: "a", PublishRule: types.Pointer("missing = 'a'")}},
+			true,
+		},
+		{
+			"unknown collection",
+			[]core.RealtimeChannel{{Name: "a", JoinRule: types.Pointer("@collection.missing.id != ''")}},
+			true,
+		},
+		{
+			"valid rules",
+			[]core.RealtimeChannel{{
+				Name:        "rooms:*",
+				JoinRule:    types.Pointer("name ~ 'rooms:' && @request.auth.id != '' && @collection.demo1.id ?= @request.auth.id"),
+				PublishRule: types.Pointer("@request.auth.collectionName = 'users'"),
+			}},
+			false,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			app, _ := tests.NewTestApp()
+			defer app.Cleanup()
+
+			app.Settings().Realtime.Channels = s.channels
+
+			err := app.Validate(app.Settings())
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				errBytes, _ := json.Marshal(err)
+				if !strings.Contains(string(errBytes), `"realtime":{"channels":{"0":`) {
+					t.Fatalf("Expected realtime channel rule error, got %s", errBytes)
+				}
+			}
+		})
+	}
+}
+
+func TestSettingsValidateOIDCAuthCollection(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []struct {
+		name           string
+		authCollection string
+		algorithm      string
+		expectError    bool
+	}{
+		{"missing collection", "missing", core.AuthTokenAlgorithmRS256, true},
+		{"non-auth collection", "demo1", core.AuthTokenAlgorithmRS256, true},
+		{"auth collection with HS256 signing", "users", core.AuthTokenAlgorithmHS256, true},
+		{"auth collection with RS256 signing", "users", core.AuthTokenAlgorithmRS256, false},
+		{"auth collection with EdDSA signing", "users", core.AuthTokenAlgorithmEdDSA, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			app, _ := tests.NewTestApp()
+			defer app.Cleanup()
+
+			users, err := app.FindCollectionByNameOrId("users")
+			if err != nil {
+				t.Fatal(err)
+			}
+			users.AuthTokenSigning.Algorithm = s.algorithm
+			if err = app.Save(users); err != nil {
+				t.Fatal(err)
+			}
+
+			app.Settings().OIDC.Enabled = true
+			app.Settings().OIDC.AuthCollection = s.authCollection
+			app.Settings().OIDC.LoginURL = "https://example.com/login"
+			app.Settings().OIDC.TokenDuration = 3600
+
+			err = app.Validate(app.Settings())
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				errBytes, _ := json.Marshal(err)
+				if !strings.Contains(string(errBytes), `"oidc":{"authCollection":`) {
+					t.Fatalf("Expected oidc authCollection error, got %s", errBytes)
+				}
+			}
+		})
+	}
+}
+
+func TestRealtimeConfigFindChannel(t *testing.T) {
+	config := core.RealtimeConfig{
+		Channels: []core.RealtimeChannel{
+			{Name: "*"},
+			{Name: "rooms:*"},
+			{Name: "rooms:a*"},
+			{Name: "rooms:abc"},
+		},
+	}
+
+	scenarios := []struct {
+		name     string
+		expected string
+	}{
+		{"lobby", "*"},
+		{"rooms:", "rooms:*"},
+		{"rooms:xyz", "rooms:*"},
+		{"rooms:ab", "rooms:a*"},
+		{"rooms:abc", "rooms:abc"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			ch, ok := config.FindChannel(s.name)
+			if !ok {
+				t.Fatal("Expected a matching channel")
+			}
+
+			if ch.Name != s.expected {
+				t.Fatalf("Expected channel %q, got %q", s.expected, ch.Name)
+			}
+		})
+	}
+
+	empty := core.RealtimeConfig{}
+	if _, ok := empty.FindChannel("lobby"); ok {
+		t.Fatal("Expected no matching channel")
+	}
+}
+
 func TestRateLimitsConfigValidate(t *testing.T) {
 	scenarios := []struct {
 		name           string
@@ -0,0 +1,375 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+const CollectionNameTOTPs = "_totps"
+
+const (
+	// TOTPRecoveryCodesCount is the number of the generated single use recovery codes.
+	TOTPRecoveryCodesCount = 10
+
+	// TOTPSkew is the number of time steps of allowed clock drift
+	// between the server and the authenticator app.
+	TOTPSkew = 1
+)
+
+const totpRecoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+
+var (
+	_ Model        = (*TOTP)(nil)
+	_ PreValidator = (*TOTP)(nil)
+	_ RecordProxy  = (*TOTP)(nil)
+)
+
+// TOTP defines a Record proxy for working with the
+// authenticator app (TOTP) enrollments collection.
+type TOTP struct {
+	*Record
+}
+
+// NewTOTP instantiates and returns a new blank *TOTP model.
+//
+// Example usage:
+//
+//	totp := core.NewTOTP(app)
+//	totp.SetRecordRef(user.Id)
+//	totp.SetCollectionRef(user.Collection().Id)
+//	totp.SetSecret(app, security.RandomTOTPSecret())
+//	app.Save(totp)
+func NewTOTP(app App) *TOTP {
+	m := &TOTP{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameTOTPs)
+	if err != nil {
+		// this is just to make tests easier since the totps collection is created on demand
+		// (note: the loaded record is further checked on TOTP.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *TOTP) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameTOTPs {
+		return errors.New("missing or invalid totp ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *TOTP) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *TOTP) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *TOTP) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *TOTP) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *TOTP) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *TOTP) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Verified returns the "verified" record field value
+// (aka. whether the enrollment was confirmed with a valid code).
+func (m *TOTP) Verified() bool {
+	return m.GetBool("verified")
+}
+
+// SetVerified updates the "verified" record field value.
+func (m *TOTP) SetVerified(verified bool) {
+	m.Set("verified", verified)
+}
+
+// LastUsedStep returns the "lastUsedStep" record field value
+// (the time step counter of the last accepted code).
+func (m *TOTP) LastUsedStep() int64 {
+	return int64(m.GetInt("lastUsedStep"))
+}
+
+// SetLastUsedStep updates the "lastUsedStep" record field value.
+func (m *TOTP) SetLastUsedStep(step int64) {
+	m.Set("lastUsedStep", step)
+}
+
+// Created returns the "created" record field value.
+func (m *TOTP) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *TOTP) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// SetSecret encrypts and stores the provided base32 TOTP secret
+// in the "secret" record field.
+//
+// The secret is encrypted with a key derived from the auth collection
+// TOTP secret and the app encryption env key (if set).
+func (m *TOTP) SetSecret(app App, secret string) error {
+	key, err := m.encryptionKey(app)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := security.Encrypt([]byte(secret), key)
+	if err != nil {
+		return err
+	}
+
+	m.Set("secret", encrypted)
+
+	return nil
+}
+
+// Secret decrypts and returns the base32 TOTP secret
+// stored in the "secret" record field.
+func (m *TOTP) Secret(app App) (string, error) {
+	key, err := m.encryptionKey(app)
+	if err != nil {
+		return "", err
+	}
+
+	decrypted, err := security.Decrypt(m.GetString("secret"), key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decrypted), nil
+}
+
+// Generator returns a new TOTP code generator initialized with
+// the decrypted record secret and the auth collection TOTP options.
+func (m *TOTP) Generator(app App) (security.TOTP, error) {
+	collection, err := app.FindCachedCollectionByNameOrId(m.CollectionRef())
+	if err != nil {
+		return security.TOTP{}, err
+	}
+
+	secret, err := m.Secret(app)
+	if err != nil {
+		return security.TOTP{}, err
+	}
+
+	return security.TOTP{
+		Secret: secret,
+		Digits: collection.TOTP.Digits,
+		Period: collection.TOTP.Period,
+	}, nil
+}
+
+// ValidateCode checks whether the provided code is a valid and not
+// already used authenticator app code and on success updates the
+// model "lastUsedStep" (the model still needs to be saved).
+//
+// Note that the check is only in memory. Use [BaseApp.ConsumeTOTPCode]
+// to also persist the code usage safely with concurrent requests.
+func (m *TOTP) ValidateCode(app App, code string) bool {
+	generator, err := m.Generator(app)
+	if err != nil {
+		return false
+	}
+
+	step, ok := generator.Validate(strings.TrimSpace(code), time.Now(), TOTPSkew)
+	if !ok || step <= m.LastUsedStep() {
+		return false
+	}
+
+	m.SetLastUsedStep(step)
+
+	return true
+}
+
+// GenerateRecoveryCodes generates and returns a new set of single use
+// recovery codes, replacing the existing ones.
+//
+// Only the codes hashes are stored in the "recoveryCodes" record field
+// (the model still needs to be saved).
+func (m *TOTP) GenerateRecoveryCodes() []string {
+	codes := make([]string, TOTPRecoveryCodesCount)
+	hashes := make([]string, TOTPRecoveryCodesCount)
+
+	for i := range codes {
+		code := security.RandomStringWithAlphabet(10, totpRecoveryCodeAlphabet)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = security.SHA256(code)
+	}
+
+	m.Set("recoveryCodes", hashes)
+
+	return codes
+}
+
+// RecoveryCodesLeft returns the number of the unused recovery codes.
+func (m *TOTP) RecoveryCodesLeft() int {
+	return len(m.recoveryCodeHashes())
+}
+
+// UseRecoveryCode checks whether the provided code matches one of the
+// unused recovery codes and on success removes it from the
+// "recoveryCodes" record field (the model still needs to be saved).
+//
+// Note that the check is only in memory. Use [BaseApp.ConsumeTOTPRecoveryCode]
+// to also persist the code usage safely with concurrent requests.
+func (m *TOTP) UseRecoveryCode(code string) bool {
+	hash := security.SHA256(normalizeTOTPRecoveryCode(code))
+
+	hashes := m.recoveryCodeHashes()
+
+	for i, h := range hashes {
+		if security.Equal(h, hash) {
+			m.Set("recoveryCodes", append(hashes[:i:i], hashes[i+1:]...))
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *TOTP) recoveryCodeHashes() []string {
+	hashes := []string{}
+
+	_ = m.UnmarshalJSONField("recoveryCodes", &hashes)
+
+	return hashes
+}
+
+func (m *TOTP) encryptionKey(app App) (string, error) {
+	collection, err := app.FindCachedCollectionByNameOrId(m.CollectionRef())
+	if err != nil {
+		return "", err
+	}
+
+	if collection.TOTP.Secret == "" {
+		return "", errors.New("missing auth collection TOTP secret")
+	}
+
+	sum := sha256.Sum256([]byte(collection.TOTP.Secret + os.Getenv(app.EncryptionEnv())))
+
+	// the raw 32 bytes digest is used as AES-256 key
+	return string(sum[:]), nil
+}
+
+func normalizeTOTPRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// -------------------------------------------------------------------
+
+// newTOTPsCollection returns the default authenticator app enrollments system collection definition.
+func newTOTPsCollection() *Collection {
+	col := NewBaseCollection(CollectionNameTOTPs)
+	col.System = true
+
+	ownerRule := "@request.auth.id != '' && recordRef = @request.auth.id && collectionRef = @request.auth.collectionId"
+	col.ListRule = types.Pointer(ownerRule)
+	col.ViewRule = types.Pointer(ownerRule)
+
+	col.Fields.Add(&TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "secret",
+		System:   true,
+		Required: true,
+		Hidden:   true,
+	})
+	col.Fields.Add(&JSONField{
+		Name:    "recoveryCodes",
+		System:  true,
+		Hidden:  true,
+		MaxSize: 5000,
+	})
+	col.Fields.Add(&BoolField{
+		Name:   "verified",
+		System: true,
+	})
+	col.Fields.Add(&NumberField{
+		Name:    "lastUsedStep",
+		System:  true,
+		Hidden:  true,
+		OnlyInt: true,
+		Min:     types.Pointer(0.0),
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_totps_collection_record", true, "collectionRef, recordRef", "")
+
+	return col
+}
+
+func (app *BaseApp) registerTOTPHooks() {
+	recordRefHooks[*TOTP](app, CollectionNameTOTPs, CollectionTypeAuth)
+
+	// create the totps system collection on demand
+	collectionSaveHandler := &hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			if !e.Collection.IsAuth() || !e.Collection.TOTP.Enabled {
+				return nil
+			}
+
+			if _, err := e.App.FindCachedCollectionByNameOrId(CollectionNameTOTPs); err == nil {
+				return nil // already exists
+			}
+
+			return e.App.Save(newTOTPsCollection())
+		},
+	}
+	app.OnCollectionCreateExecute().Bind(collectionSaveHandler)
+	app.OnCollectionUpdateExecute().Bind(collectionSaveHandler)
+}
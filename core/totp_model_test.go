@@ -0,0 +1,378 @@
+package core_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func enableTestTOTP(t *testing.T, app core.App, collectionName string) *core.Collection {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.TOTP.Enabled = true
+
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	return collection
+}
+
+func newTestTOTP(t *testing.T, app core.App, record *core.Record, secret string) *core.TOTP {
+	totp := core.NewTOTP(app)
+	totp.SetRecordRef(record.Id)
+	totp.SetCollectionRef(record.Collection().Id)
+
+	if err := totp.SetSecret(app, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	return totp
+}
+
+func TestTOTPsCollectionOnDemand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if _, err := app.FindCollectionByNameOrId(core.CollectionNameTOTPs); err == nil {
+		t.Fatal("Expected the totps collection to not exist")
+	}
+
+	users := enableTestTOTP(t, app, "users")
+
+	// existing auth collections should have the missing TOTP options initialized on save
+	if len(users.TOTP.Secret) != 50 || users.TOTP.Digits != security.DefaultTOTPDigits || users.TOTP.Period != security.DefaultTOTPPeriod {
+		t.Fatalf("Expected the default TOTP options to be initialized, got %#v", users.TOTP)
+	}
+
+	totps, err := app.FindCollectionByNameOrId(core.CollectionNameTOTPs)
+	if err != nil {
+		t.Fatalf("Expected the totps collection to be created, got %v", err)
+	}
+
+	if !totps.System {
+		t.Fatal("Expected the totps collection to be system")
+	}
+
+	for _, name := range []string{"secret", "recoveryCodes", "lastUsedStep"} {
+		if field := totps.Fields.GetByName(name); field == nil || !field.GetHidden() {
+			t.Fatalf("Expected hidden %s field, got %v", name, field)
+		}
+	}
+}
+
+func TestNewTOTP(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	// missing collection
+	if m := core.NewTOTP(app); m.Collection().Name == core.CollectionNameTOTPs {
+		t.Fatal("Expected invalid placeholder collection")
+	}
+
+	enableTestTOTP(t, app, "users")
+
+	m := core.NewTOTP(app)
+
+	if m.Collection().Name != core.CollectionNameTOTPs {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameTOTPs, m.Collection().Name)
+	}
+}
+
+func TestTOTPProxyRecord(t *testing.T) {
+	t.Parallel()
+
+	record := core.NewRecord(core.NewBaseCollection("test"))
+	record.Id = "test_id"
+
+	m := core.TOTP{}
+	m.SetProxyRecord(record)
+
+	if m.ProxyRecord() == nil || m.ProxyRecord().Id != record.Id {
+		t.Fatalf("Expected proxy record with id %q, got %v", record.Id, m.ProxyRecord())
+	}
+}
+
+func TestTOTPFields(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestTOTP(t, app, "users")
+
+	m := core.NewTOTP(app)
+
+	scenarios := []struct {
+		field  string
+		setter func(string)
+		getter func() string
+	}{
+		{"collectionRef", m.SetCollectionRef, m.CollectionRef},
+		{"recordRef", m.SetRecordRef, m.RecordRef},
+	}
+
+	for _, s := range scenarios {
+		for i, testValue := range []string{"test_1", "test2", ""} {
+			t.Run(fmt.Sprintf("%s_%d_%q", s.field, i, testValue), func(t *testing.T) {
+				s.setter(testValue)
+
+				if v := s.getter(); v != testValue {
+					t.Fatalf("Expected getter %q, got %q", testValue, v)
+				}
+
+				if v := m.GetString(s.field); v != testValue {
+					t.Fatalf("Expected field value %q, got %q", testValue, v)
+				}
+			})
+		}
+	}
+
+	m.SetVerified(true)
+	if !m.Verified() {
+		t.Fatal("Expected verified true")
+	}
+
+	m.SetLastUsedStep(123)
+	if v := m.LastUsedStep(); v != 123 {
+		t.Fatalf("Expected last used step 123, got %d", v)
+	}
+}
+
+func TestTOTPSecret(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users := enableTestTOTP(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("missing auth collection", func(t *testing.T) {
+		m := core.NewTOTP(app)
+		m.SetCollectionRef("missing")
+
+		if err := m.SetSecret(app, "test"); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		secret := security.RandomTOTPSecret()
+
+		m := newTestTOTP(t, app, user, secret)
+
+		if raw := m.GetString("secret"); raw == "" || raw == secret {
+			t.Fatalf("Expected encrypted secret, got %q", raw)
+		}
+
+		decrypted, err := m.Secret(app)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decrypted != secret {
+			t.Fatalf("Expected secret %q, got %q", secret, decrypted)
+		}
+
+		// changing the collection TOTP secret should invalidate the stored secret
+		users.TOTP.Secret = security.RandomString(50)
+		if err := app.Save(users); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := m.Secret(app); err == nil {
+			t.Fatal("Expected decrypt error after the collection secret change")
+		}
+	})
+}
+
+func TestTOTPValidateCode(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestTOTP(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := security.RandomTOTPSecret()
+	generator := security.TOTP{Secret: secret}
+
+	m := newTestTOTP(t, app, user, secret)
+
+	now := time.Now()
+
+	code, err := generator.Generate(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.ValidateCode(app, "") || m.ValidateCode(app, "invalid") {
+		t.Fatal("Expected invalid codes to fail")
+	}
+
+	if !m.ValidateCode(app, code) {
+		t.Fatal("Expected the current code to be valid")
+	}
+
+	if m.LastUsedStep() == 0 {
+		t.Fatal("Expected the last used step to be updated")
+	}
+
+	// reuse
+	if m.ValidateCode(app, code) {
+		t.Fatal("Expected the already used code to be rejected")
+	}
+
+	// older code (within the skew)
+	previous, err := generator.Generate(now.Add(-security.DefaultTOTPPeriod * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous != code && m.ValidateCode(app, previous) {
+		t.Fatal("Expected a code older than the last used one to be rejected")
+	}
+}
+
+func TestTOTPRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestTOTP(t, app, "users")
+
+	m := core.NewTOTP(app)
+
+	if v := m.RecoveryCodesLeft(); v != 0 {
+		t.Fatalf("Expected 0 recovery codes, got %d", v)
+	}
+
+	codes := m.GenerateRecoveryCodes()
+
+	if len(codes) != core.TOTPRecoveryCodesCount {
+		t.Fatalf("Expected %d recovery codes, got %d", core.TOTPRecoveryCodesCount, len(codes))
+	}
+
+	if v := m.RecoveryCodesLeft(); v != core.TOTPRecoveryCodesCount {
+		t.Fatalf("Expected %d stored recovery codes, got %d", core.TOTPRecoveryCodesCount, v)
+	}
+
+	if m.UseRecoveryCode("invalid") {
+		t.Fatal("Expected invalid recovery code to fail")
+	}
+
+	// case and whitespace insensitive
+	if !m.UseRecoveryCode(" " + strings.ToUpper(codes[1]) + " ") {
+		t.Fatalf("Expected recovery code %q to be valid", codes[1])
+	}
+
+	if m.UseRecoveryCode(codes[1]) {
+		t.Fatalf("Expected recovery code %q to be usable only once", codes[1])
+	}
+
+	if v := m.RecoveryCodesLeft(); v != core.TOTPRecoveryCodesCount-1 {
+		t.Fatalf("Expected %d recovery codes left, got %d", core.TOTPRecoveryCodesCount-1, v)
+	}
+}
+
+func TestTOTPPreValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestTOTP(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no proxy record", func(t *testing.T) {
+		m := &core.TOTP{}
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("non-totps collection", func(t *testing.T) {
+		m := &core.TOTP{}
+		m.SetProxyRecord(core.NewRecord(core.NewBaseCollection("invalid")))
+		m.SetRecordRef(user.Id)
+		m.SetCollectionRef(user.Collection().Id)
+		m.Set("secret", "test")
+
+		if err := app.Validate(m); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("totps collection", func(t *testing.T) {
+		m := newTestTOTP(t, app, user, security.RandomTOTPSecret())
+
+		if err := app.Validate(m); err != nil {
+			t.Fatalf("Expected nil validation error, got %v", err)
+		}
+	})
+}
+
+func TestTOTPRefsDelete(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestTOTP(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestTOTP(t, app, user, security.RandomTOTPSecret())
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// only one enrollment per auth record
+	duplicated := newTestTOTP(t, app, user, security.RandomTOTPSecret())
+	if err := app.Save(duplicated); err == nil {
+		t.Fatal("Expected duplicated enrollment error")
+	}
+
+	found, err := app.FindTOTPByRecord(user)
+	if err != nil || found.Id != m.Id {
+		t.Fatalf("Expected to find enrollment %q, got %v (%v)", m.Id, found, err)
+	}
+
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.FindTOTPByRecord(user); err == nil {
+		t.Fatal("Expected the enrollment to be deleted with the user")
+	}
+}
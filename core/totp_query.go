@@ -0,0 +1,126 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// ErrInvalidTOTPCode is returned when the authenticator app code
+// (or recovery code) is invalid or it was already used.
+var ErrInvalidTOTPCode = errors.New("invalid or already used authenticator app code")
+
+// FindTOTPByRecord returns the authenticator app (TOTP) enrollment
+// model linked to the provided auth record.
+func (app *BaseApp) FindTOTPByRecord(authRecord *Record) (*TOTP, error) {
+	result := &TOTP{}
+
+	err := app.RecordQuery(CollectionNameTOTPs).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ConsumeTOTPCode validates the provided authenticator app code and
+// persists its time step as "lastUsedStep" so that it couldn't be reused.
+//
+// The update is conditional (lastUsedStep < step) and [ErrInvalidTOTPCode]
+// is returned if the code is invalid or it was already used by another
+// (concurrent) request.
+func (app *BaseApp) ConsumeTOTPCode(totp *TOTP, code string) error {
+	originalStep := totp.LastUsedStep()
+
+	if !totp.ValidateCode(app, code) {
+		return ErrInvalidTOTPCode
+	}
+
+	step := totp.LastUsedStep()
+	now := types.NowDateTime()
+
+	res, err := app.NonconcurrentDB().Update(
+		CollectionNameTOTPs,
+		dbx.Params{"lastUsedStep": step, "updated": now.String()},
+		dbx.And(
+			dbx.HashExp{"id": totp.Id},
+			dbx.NewExp("[[lastUsedStep]] < {:step}", dbx.Params{"step": step}),
+		),
+	).Execute()
+	if err == nil {
+		err = checkTOTPUpdateResult(res)
+	}
+	if err != nil {
+		totp.SetLastUsedStep(originalStep)
+		return err
+	}
+
+	totp.SetRaw("updated", now)
+
+	return nil
+}
+
+// ConsumeTOTPRecoveryCode validates the provided single use recovery code
+// and removes it from the stored "recoveryCodes".
+//
+// The code is removed with a conditional update (only if it is still stored)
+// and [ErrInvalidTOTPCode] is returned if the code is invalid or
+// it was already used by another (concurrent) request.
+func (app *BaseApp) ConsumeTOTPRecoveryCode(totp *TOTP, code string) error {
+	originalCodes := totp.recoveryCodeHashes()
+
+	if !totp.UseRecoveryCode(code) {
+		return ErrInvalidTOTPCode
+	}
+
+	hash := security.SHA256(normalizeTOTPRecoveryCode(code))
+	now := types.NowDateTime()
+
+	// remove the code in the db query itself to avoid overwriting concurrent changes
+	res, err := app.NonconcurrentDB().NewQuery(
+		"UPDATE {{" + CollectionNameTOTPs + "}} SET " +
+			"[[recoveryCodes]] = (SELECT json_group_array([[value]]) FROM json_each([[recoveryCodes]]) WHERE [[value]] != {:hash}), " +
+			"[[updated]] = {:updated} " +
+			"WHERE [[id]] = {:id} AND EXISTS (SELECT 1 FROM json_each([[recoveryCodes]]) WHERE [[value]] = {:hash})",
+	).Bind(dbx.Params{
+		"id":      totp.Id,
+		"hash":    hash,
+		"updated": now.String(),
+	}).Execute()
+	if err == nil {
+		err = checkTOTPUpdateResult(res)
+	}
+	if err != nil {
+		totp.Set("recoveryCodes", originalCodes)
+		return err
+	}
+
+	totp.SetRaw("updated", now)
+
+	return nil
+}
+
+// checkTOTPUpdateResult returns [ErrInvalidTOTPCode] if the conditional
+// TOTP update didn't change exactly 1 row.
+func checkTOTPUpdateResult(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return ErrInvalidTOTPCode
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func TestConsumeTOTPCode(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestTOTP(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := security.RandomTOTPSecret()
+
+	m := newTestTOTP(t, app, user, secret)
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := security.TOTP{Secret: secret}.Generate(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate 2 concurrent requests loading the same enrollment
+	totp1, err := app.FindTOTPByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	totp2, err := app.FindTOTPByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.ConsumeTOTPCode(totp1, "invalid"); !errors.Is(err, core.ErrInvalidTOTPCode) {
+		t.Fatalf("Expected ErrInvalidTOTPCode for invalid code, got %v", err)
+	}
+
+	if err := app.ConsumeTOTPCode(totp1, code); err != nil {
+		t.Fatalf("Expected the code to be consumed, got %v", err)
+	}
+
+	if err := app.ConsumeTOTPCode(totp2, code); !errors.Is(err, core.ErrInvalidTOTPCode) {
+		t.Fatalf("Expected ErrInvalidTOTPCode for the concurrently used code, got %v", err)
+	}
+
+	if v := totp2.LastUsedStep(); v != 0 {
+		t.Fatalf("Expected the failed consume to revert the last used step, got %d", v)
+	}
+
+	stored, err := app.FindTOTPByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := stored.LastUsedStep(); v == 0 || v != totp1.LastUsedStep() {
+		t.Fatalf("Expected persisted last used step %d, got %d", totp1.LastUsedStep(), v)
+	}
+}
+
+func TestConsumeTOTPRecoveryCode(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestTOTP(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestTOTP(t, app, user, security.RandomTOTPSecret())
+	codes := m.GenerateRecoveryCodes()
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate 2 concurrent requests loading the same enrollment
+	totp1, err := app.FindTOTPByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	totp2, err := app.FindTOTPByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.ConsumeTOTPRecoveryCode(totp1, "invalid"); !errors.Is(err, core.ErrInvalidTOTPCode) {
+		t.Fatalf("Expected ErrInvalidTOTPCode for invalid code, got %v", err)
+	}
+
+	if err := app.ConsumeTOTPRecoveryCode(totp1, codes[0]); err != nil {
+		t.Fatalf("Expected the recovery code to be consumed, got %v", err)
+	}
+
+	if err := app.ConsumeTOTPRecoveryCode(totp2, codes[0]); !errors.Is(err, core.ErrInvalidTOTPCode) {
+		t.Fatalf("Expected ErrInvalidTOTPCode for the concurrently used recovery code, got %v", err)
+	}
+
+	if v := totp2.RecoveryCodesLeft(); v != core.TOTPRecoveryCodesCount {
+		t.Fatalf("Expected the failed consume to revert the recovery codes, got %d", v)
+	}
+
+	// a different code from the stale model shouldn't restore the already used one
+	if err := app.ConsumeTOTPRecoveryCode(totp2, codes[1]); err != nil {
+		t.Fatalf("Expected the second recovery code to be consumed, got %v", err)
+	}
+
+	stored, err := app.FindTOTPByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := stored.RecoveryCodesLeft(); v != core.TOTPRecoveryCodesCount-2 {
+		t.Fatalf("Expected %d recovery codes left, got %d", core.TOTPRecoveryCodesCount-2, v)
+	}
+
+	if stored.UseRecoveryCode(codes[0]) || stored.UseRecoveryCode(codes[1]) {
+		t.Fatal("Expected the used recovery codes to be removed")
+	}
+}
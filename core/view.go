@@ -7,7 +7,6 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/hanzoai/backendPB/tools/dbutils"
 	"github.com/hanzoai/backendPB/tools/inflector"
 	"github.com/hanzoai/backendPB/tools/security"
 	"github.com/hanzoai/backendPB/tools/tokenizer"
@@ -179,7 +178,7 @@ func (app *BaseApp) FindRecordByViewFile(viewCollectionModelOrIdentifier any, fi
 		query.AndWhere(dbx.HashExp{cleanFieldName: filename})
 	} else {
 		query.InnerJoin(
-			fmt.Sprintf(`%s as {{_je_file}}`, dbutils.JSONEach(cleanFieldName)),
+			fmt.Sprintf(`%s as {{_je_file}}`, app.DBDialect().JSONEach(cleanFieldName)),
 			dbx.HashExp{"_je_file.value": filename},
 		)
 	}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+const CollectionNameWebAuthnChallenges = "_webauthnChallenges"
+
+// WebAuthn challenge types.
+const (
+	WebAuthnChallengeTypeRegistration   = "registration"
+	WebAuthnChallengeTypeAuthentication = "authentication"
+)
+
+var (
+	_ Model        = (*WebAuthnChallenge)(nil)
+	_ PreValidator = (*WebAuthnChallenge)(nil)
+	_ RecordProxy  = (*WebAuthnChallenge)(nil)
+)
+
+// WebAuthnChallenge defines a Record proxy for working with the
+// issued and not yet verified WebAuthn challenges.
+//
+// The challenges are single use, aka. they are deleted on their first
+// verification attempt (see [BaseApp.ConsumeWebAuthnChallenge]).
+type WebAuthnChallenge struct {
+	*Record
+}
+
+// NewWebAuthnChallenge instantiates and returns a new blank *WebAuthnChallenge model.
+//
+// Example usage:
+//
+//	challenge := core.NewWebAuthnChallenge(app)
+//	challenge.SetCollectionRef(user.Collection().Id)
+//	challenge.SetType(core.WebAuthnChallengeTypeAuthentication)
+//	challenge.SetChallenge("...")
+//	app.Save(challenge)
+func NewWebAuthnChallenge(app App) *WebAuthnChallenge {
+	m := &WebAuthnChallenge{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameWebAuthnChallenges)
+	if err != nil {
+		// this is just to make tests easier since the challenges collection is created on demand
+		// (note: the loaded record is further checked on WebAuthnChallenge.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *WebAuthnChallenge) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameWebAuthnChallenges {
+		return errors.New("missing or invalid webauthn challenge ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *WebAuthnChallenge) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *WebAuthnChallenge) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *WebAuthnChallenge) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *WebAuthnChallenge) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value
+// (it is empty for the authentication challenges).
+func (m *WebAuthnChallenge) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *WebAuthnChallenge) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Type returns the "type" record field value
+// ("registration" or "authentication").
+func (m *WebAuthnChallenge) Type() string {
+	return m.GetString("type")
+}
+
+// SetType updates the "type" record field value.
+func (m *WebAuthnChallenge) SetType(challengeType string) {
+	m.Set("type", challengeType)
+}
+
+// Challenge returns the "challenge" record field value
+// (the base64url encoded challenge sent to the client).
+func (m *WebAuthnChallenge) Challenge() string {
+	return m.GetString("challenge")
+}
+
+// SetChallenge updates the "challenge" record field value.
+func (m *WebAuthnChallenge) SetChallenge(challenge string) {
+	m.Set("challenge", challenge)
+}
+
+// Created returns the "created" record field value.
+func (m *WebAuthnChallenge) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// HasExpired checks if the challenge is expired, aka. whether it has been
+// more than maxElapsed time since its creation.
+func (m *WebAuthnChallenge) HasExpired(maxElapsed time.Duration) bool {
+	return time.Since(m.Created().Time()) > maxElapsed
+}
+
+// -------------------------------------------------------------------
+
+// newWebAuthnChallengesCollection returns the default webauthn challenges system collection definition.
+func newWebAuthnChallengesCollection() *Collection {
+	col := NewBaseCollection(CollectionNameWebAuthnChallenges)
+	col.System = true
+
+	col.Fields.Add(&TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "recordRef",
+		System: true,
+	})
+	col.Fields.Add(&SelectField{
+		Name:      "type",
+		System:    true,
+		Required:  true,
+		MaxSelect: 1,
+		Values:    []string{WebAuthnChallengeTypeRegistration, WebAuthnChallengeTypeAuthentication},
+	})
+	col.Fields.Add(&TextField{
+		Name:     "challenge",
+		System:   true,
+		Required: true,
+		Hidden:   true,
+		Max:      255,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.AddIndex("idx_webauthnChallenges_challenge", true, "challenge", "")
+	col.AddIndex("idx_webauthnChallenges_collection_created", false, "collectionRef, created", "")
+
+	return col
+}
+
+func (app *BaseApp) registerWebAuthnChallengeHooks() {
+	// run on every hour to cleanup the expired (aka. never verified) challenges
+	app.Cron().Add("__pbWebAuthnChallengesCleanup__", "0 * * * *", func() {
+		if err := app.DeleteExpiredWebAuthnChallenges(); err != nil {
+			app.Logger().Warn("Failed to delete expired WebAuthn challenges", "error", err)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+// ConsumeWebAuthnChallenge finds and deletes the WebAuthn challenge
+// by its auth collection and base64url encoded challenge value.
+//
+// The challenge is removed with a conditional delete and [sql.ErrNoRows]
+// is returned if it is missing or it was already consumed by another
+// (concurrent) request, ensuring that each challenge is verified at most once.
+func (app *BaseApp) ConsumeWebAuthnChallenge(collectionId string, challenge string) (*WebAuthnChallenge, error) {
+	result := &WebAuthnChallenge{}
+
+	err := app.RecordQuery(CollectionNameWebAuthnChallenges).
+		AndWhere(dbx.HashExp{
+			"collectionRef": collectionId,
+			"challenge":     challenge,
+		}).
+		Limit(1).
+		One(result)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := app.NonconcurrentDB().Delete(CollectionNameWebAuthnChallenges, dbx.HashExp{"id": result.Id}).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected != 1 {
+		return nil, sql.ErrNoRows
+	}
+
+	return result, nil
+}
+
+// DeleteExpiredWebAuthnChallenges deletes the expired WebAuthn challenges
+// for all auth collections (including the challenges of the deleted collections).
+func (app *BaseApp) DeleteExpiredWebAuthnChallenges() error {
+	if _, err := app.FindCachedCollectionByNameOrId(CollectionNameWebAuthnChallenges); err != nil {
+		return nil // the collection is created on demand and may not exist yet
+	}
+
+	authCollections, err := app.FindAllCollections(CollectionTypeAuth)
+	if err != nil {
+		return err
+	}
+
+	collectionIds := make([]any, 0, len(authCollections))
+	exprs := make([]dbx.Expression, 0, len(authCollections)+1)
+
+	// note: perform even if WebAuthn is disabled to ensure that there are no dangling old records
+	for i, collection := range authCollections {
+		minValidDate, err := types.ParseDateTime(time.Now().Add(-1 * collection.WebAuthn.DurationTime()))
+		if err != nil {
+			return err
+		}
+
+		dateParam := "date" + strconv.Itoa(i)
+
+		collectionIds = append(collectionIds, collection.Id)
+		exprs = append(exprs, dbx.And(
+			dbx.HashExp{"collectionRef": collection.Id},
+			dbx.NewExp("[[created]] < {:"+dateParam+"}", dbx.Params{dateParam: minValidDate}),
+		))
+	}
+
+	exprs = append(exprs, dbx.NotIn("collectionRef", collectionIds...))
+
+	_, err = app.NonconcurrentDB().Delete(CollectionNameWebAuthnChallenges, dbx.Or(exprs...)).Execute()
+
+	return err
+}
@@ -0,0 +1,119 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+func newTestWebAuthnChallenge(t *testing.T, app core.App, collectionId string, challenge string) *core.WebAuthnChallenge {
+	m := core.NewWebAuthnChallenge(app)
+	m.SetCollectionRef(collectionId)
+	m.SetType(core.WebAuthnChallengeTypeAuthentication)
+	m.SetChallenge(challenge)
+
+	if err := app.Save(m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestConsumeWebAuthnChallenge(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	users := enableTestWebAuthn(t, app, "users")
+	clients := enableTestWebAuthn(t, app, "clients")
+
+	newTestWebAuthnChallenge(t, app, users.Id, "test1")
+	newTestWebAuthnChallenge(t, app, clients.Id, "test2")
+
+	scenarios := []struct {
+		name         string
+		collectionId string
+		challenge    string
+		expectError  bool
+	}{
+		{"missing challenge", users.Id, "missing", true},
+		{"challenge from different collection", users.Id, "test2", true},
+		{"valid challenge", users.Id, "test1", false},
+		{"already consumed challenge", users.Id, "test1", true},
+		{"valid challenge from the other collection", clients.Id, "test2", false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := app.ConsumeWebAuthnChallenge(s.collectionId, s.challenge)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if result.Challenge() != s.challenge || result.CollectionRef() != s.collectionId {
+				t.Fatalf("Expected challenge %q for collection %q, got %q for %q", s.challenge, s.collectionId, result.Challenge(), result.CollectionRef())
+			}
+		})
+	}
+}
+
+func TestDeleteExpiredWebAuthnChallenges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing webauthn challenges collection", func(t *testing.T) {
+		app, _ := tests.NewTestApp()
+		defer app.Cleanup()
+
+		if err := app.DeleteExpiredWebAuthnChallenges(); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	})
+
+	t.Run("existing webauthn challenges", func(t *testing.T) {
+		app, _ := tests.NewTestApp()
+		defer app.Cleanup()
+
+		users := enableTestWebAuthn(t, app, "users")
+		users.WebAuthn.Duration = 300
+		if err := app.Save(users); err != nil {
+			t.Fatal(err)
+		}
+
+		newTestWebAuthnChallenge(t, app, users.Id, "valid")
+
+		expired := newTestWebAuthnChallenge(t, app, users.Id, "expired")
+		expired.SetRaw("created", types.NowDateTime().Add(-10*time.Minute))
+		if err := app.Save(expired); err != nil {
+			t.Fatal(err)
+		}
+
+		// challenges of deleted (or non-auth) collections should be always deleted
+		newTestWebAuthnChallenge(t, app, "missing_collection", "orphan")
+
+		if err := app.DeleteExpiredWebAuthnChallenges(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := app.ConsumeWebAuthnChallenge(users.Id, "expired"); err == nil {
+			t.Fatal("Expected the expired challenge to be deleted")
+		}
+
+		if _, err := app.ConsumeWebAuthnChallenge("missing_collection", "orphan"); err == nil {
+			t.Fatal("Expected the orphan challenge to be deleted")
+		}
+
+		if _, err := app.ConsumeWebAuthnChallenge(users.Id, "valid"); err != nil {
+			t.Fatalf("Expected the valid challenge to remain, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+const CollectionNameWebAuthnCredentials = "_webauthnCredentials"
+
+var (
+	_ Model        = (*WebAuthnCredential)(nil)
+	_ PreValidator = (*WebAuthnCredential)(nil)
+	_ RecordProxy  = (*WebAuthnCredential)(nil)
+)
+
+// WebAuthnCredential defines a Record proxy for working with the
+// registered passkeys (WebAuthn credentials) collection.
+type WebAuthnCredential struct {
+	*Record
+}
+
+// NewWebAuthnCredential instantiates and returns a new blank *WebAuthnCredential model.
+//
+// Example usage:
+//
+//	credential := core.NewWebAuthnCredential(app)
+//	credential.SetRecordRef(user.Id)
+//	credential.SetCollectionRef(user.Collection().Id)
+//	credential.SetCredentialId("...")
+//	credential.SetPublicKey("...")
+//	app.Save(credential)
+func NewWebAuthnCredential(app App) *WebAuthnCredential {
+	m := &WebAuthnCredential{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameWebAuthnCredentials)
+	if err != nil {
+		// this is just to make tests easier since the credentials collection is created on demand
+		// (note: the loaded record is further checked on WebAuthnCredential.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *WebAuthnCredential) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameWebAuthnCredentials {
+		return errors.New("missing or invalid webauthn credential ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *WebAuthnCredential) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *WebAuthnCredential) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// CollectionRef returns the "collectionRef" field value.
+func (m *WebAuthnCredential) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *WebAuthnCredential) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *WebAuthnCredential) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *WebAuthnCredential) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Name returns the "name" record field value
+// (an optional user friendly passkey label).
+func (m *WebAuthnCredential) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *WebAuthnCredential) SetName(name string) {
+	m.Set("name", name)
+}
+
+// CredentialId returns the "credentialId" record field value
+// (the base64url encoded raw credential id).
+func (m *WebAuthnCredential) CredentialId() string {
+	return m.GetString("credentialId")
+}
+
+// SetCredentialId updates the "credentialId" record field value.
+func (m *WebAuthnCredential) SetCredentialId(credentialId string) {
+	m.Set("credentialId", credentialId)
+}
+
+// PublicKey returns the "publicKey" record field value
+// (the base64url encoded COSE_Key).
+func (m *WebAuthnCredential) PublicKey() string {
+	return m.GetString("publicKey")
+}
+
+// SetPublicKey updates the "publicKey" record field value.
+func (m *WebAuthnCredential) SetPublicKey(publicKey string) {
+	m.Set("publicKey", publicKey)
+}
+
+// Algorithm returns the "algorithm" record field value
+// (the COSE algorithm identifier of the public key).
+func (m *WebAuthnCredential) Algorithm() int {
+	return m.GetInt("algorithm")
+}
+
+// SetAlgorithm updates the "algorithm" record field value.
+func (m *WebAuthnCredential) SetAlgorithm(algorithm int) {
+	m.Set("algorithm", algorithm)
+}
+
+// SignCount returns the "signCount" record field value.
+func (m *WebAuthnCredential) SignCount() uint32 {
+	return uint32(m.GetInt("signCount"))
+}
+
+// SetSignCount updates the "signCount" record field value.
+func (m *WebAuthnCredential) SetSignCount(signCount uint32) {
+	m.Set("signCount", signCount)
+}
+
+// Created returns the "created" record field value.
+func (m *WebAuthnCredential) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *WebAuthnCredential) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// -------------------------------------------------------------------
+
+// newWebAuthnCredentialsCollection returns the default webauthn credentials system collection definition.
+func newWebAuthnCredentialsCollection() *Collection {
+	col := NewBaseCollection(CollectionNameWebAuthnCredentials)
+	col.System = true
+
+	ownerRule := "@request.auth.id != '' && recordRef = @request.auth.id && collectionRef = @request.auth.collectionId"
+	col.ListRule = types.Pointer(ownerRule)
+	col.ViewRule = types.Pointer(ownerRule)
+	col.DeleteRule = types.Pointer(ownerRule)
+
+	col.Fields.Add(&TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "name",
+		System: true,
+		Max:    255,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "credentialId",
+		System:   true,
+		Required: true,
+		Max:      1500,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "publicKey",
+		System:   true,
+		Required: true,
+		Hidden:   true,
+		Max:      2000,
+	})
+	col.Fields.Add(&NumberField{
+		Name:    "algorithm",
+		System:  true,
+		OnlyInt: true,
+	})
+	col.Fields.Add(&NumberField{
+		Name:    "signCount",
+		System:  true,
+		OnlyInt: true,
+		Min:     types.Pointer(0.0),
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_webauthnCredentials_collection_credential", true, "collectionRef, credentialId", "")
+	col.AddIndex("idx_webauthnCredentials_collection_record", false, "collectionRef, recordRef", "")
+
+	return col
+}
+
+func (app *BaseApp) registerWebAuthnCredentialHooks() {
+	recordRefHooks[*WebAuthnCredential](app, CollectionNameWebAuthnCredentials, CollectionTypeAuth)
+
+	// create the credentials and challenges system collections on demand
+	collectionSaveHandler := &hook.Handler[*CollectionEvent]{
+		Func: func(e *CollectionEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			if !e.Collection.IsAuth() || !e.Collection.WebAuthn.Enabled {
+				return nil
+			}
+
+			if _, err := e.App.FindCachedCollectionByNameOrId(CollectionNameWebAuthnCredentials); err != nil {
+				if err := e.App.Save(newWebAuthnCredentialsCollection()); err != nil {
+					return err
+				}
+			}
+
+			if _, err := e.App.FindCachedCollectionByNameOrId(CollectionNameWebAuthnChallenges); err != nil {
+				if err := e.App.Save(newWebAuthnChallengesCollection()); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+	app.OnCollectionCreateExecute().Bind(collectionSaveHandler)
+	app.OnCollectionUpdateExecute().Bind(collectionSaveHandler)
+}
@@ -0,0 +1,256 @@
+package core_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+)
+
+func enableTestWebAuthn(t *testing.T, app core.App, collectionName string) *core.Collection {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection.WebAuthn.Enabled = true
+	collection.WebAuthn.RPId = "localhost"
+	collection.WebAuthn.Origins = []string{"http://localhost:8090"}
+
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	return collection
+}
+
+func TestWebAuthnCredentialsCollectionOnDemand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if _, err := app.FindCollectionByNameOrId(core.CollectionNameWebAuthnCredentials); err == nil {
+		t.Fatal("Expected the webauthn credentials collection to not exist")
+	}
+
+	// deleting auth records without the collection shouldn't fail
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Delete(user); err != nil {
+		t.Fatalf("Expected the user to be deleted, got %v", err)
+	}
+
+	enableTestWebAuthn(t, app, "users")
+
+	credentials, err := app.FindCollectionByNameOrId(core.CollectionNameWebAuthnCredentials)
+	if err != nil {
+		t.Fatalf("Expected the webauthn credentials collection to be created, got %v", err)
+	}
+
+	if !credentials.System {
+		t.Fatal("Expected the webauthn credentials collection to be system")
+	}
+
+	if field := credentials.Fields.GetByName("publicKey"); field == nil || !field.GetHidden() {
+		t.Fatalf("Expected hidden publicKey field, got %v", field)
+	}
+}
+
+func TestNewWebAuthnCredential(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	// missing collection
+	if c := core.NewWebAuthnCredential(app); c.Collection().Name == core.CollectionNameWebAuthnCredentials {
+		t.Fatal("Expected invalid placeholder collection")
+	}
+
+	enableTestWebAuthn(t, app, "users")
+
+	c := core.NewWebAuthnCredential(app)
+
+	if c.Collection().Name != core.CollectionNameWebAuthnCredentials {
+		t.Fatalf("Expected record with %q collection, got %q", core.CollectionNameWebAuthnCredentials, c.Collection().Name)
+	}
+}
+
+func TestWebAuthnCredentialProxyRecord(t *testing.T) {
+	t.Parallel()
+
+	record := core.NewRecord(core.NewBaseCollection("test"))
+	record.Id = "test_id"
+
+	c := core.WebAuthnCredential{}
+	c.SetProxyRecord(record)
+
+	if c.ProxyRecord() == nil || c.ProxyRecord().Id != record.Id {
+		t.Fatalf("Expected proxy record with id %q, got %v", record.Id, c.ProxyRecord())
+	}
+}
+
+func TestWebAuthnCredentialStringFields(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestWebAuthn(t, app, "users")
+
+	c := core.NewWebAuthnCredential(app)
+
+	scenarios := []struct {
+		field  string
+		setter func(string)
+		getter func() string
+	}{
+		{"collectionRef", c.SetCollectionRef, c.CollectionRef},
+		{"recordRef", c.SetRecordRef, c.RecordRef},
+		{"name", c.SetName, c.Name},
+		{"credentialId", c.SetCredentialId, c.CredentialId},
+		{"publicKey", c.SetPublicKey, c.PublicKey},
+	}
+
+	for _, s := range scenarios {
+		for i, testValue := range []string{"test_1", "test2", ""} {
+			t.Run(fmt.Sprintf("%s_%d_%q", s.field, i, testValue), func(t *testing.T) {
+				s.setter(testValue)
+
+				if v := s.getter(); v != testValue {
+					t.Fatalf("Expected getter %q, got %q", testValue, v)
+				}
+
+				if v := c.GetString(s.field); v != testValue {
+					t.Fatalf("Expected field value %q, got %q", testValue, v)
+				}
+			})
+		}
+	}
+}
+
+func TestWebAuthnCredentialNumberFields(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestWebAuthn(t, app, "users")
+
+	c := core.NewWebAuthnCredential(app)
+
+	c.SetAlgorithm(-8)
+	if v := c.Algorithm(); v != -8 {
+		t.Fatalf("Expected algorithm -8, got %d", v)
+	}
+
+	c.SetSignCount(123)
+	if v := c.SignCount(); v != 123 {
+		t.Fatalf("Expected sign count 123, got %d", v)
+	}
+}
+
+func TestWebAuthnCredentialPreValidate(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestWebAuthn(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no proxy record", func(t *testing.T) {
+		c := &core.WebAuthnCredential{}
+
+		if err := app.Validate(c); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("non-credentials collection", func(t *testing.T) {
+		c := &core.WebAuthnCredential{}
+		c.SetProxyRecord(core.NewRecord(core.NewBaseCollection("invalid")))
+		c.SetRecordRef(user.Id)
+		c.SetCollectionRef(user.Collection().Id)
+		c.SetCredentialId("test")
+		c.SetPublicKey("test")
+
+		if err := app.Validate(c); err == nil {
+			t.Fatal("Expected collection validation error")
+		}
+	})
+
+	t.Run("credentials collection", func(t *testing.T) {
+		c := core.NewWebAuthnCredential(app)
+		c.SetRecordRef(user.Id)
+		c.SetCollectionRef(user.Collection().Id)
+		c.SetCredentialId("test")
+		c.SetPublicKey("test")
+
+		if err := app.Validate(c); err != nil {
+			t.Fatalf("Expected nil validation error, got %v", err)
+		}
+	})
+}
+
+func TestWebAuthnCredentialRefsDelete(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	enableTestWebAuthn(t, app, "users")
+
+	user, err := app.FindAuthRecordByEmail("users", "test@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		c := core.NewWebAuthnCredential(app)
+		c.SetRecordRef(user.Id)
+		c.SetCollectionRef(user.Collection().Id)
+		c.SetCredentialId(id)
+		c.SetPublicKey("test")
+		if err := app.Save(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	credentials, err := app.FindAllWebAuthnCredentialsByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credentials) != 2 {
+		t.Fatalf("Expected 2 credentials, got %d", len(credentials))
+	}
+
+	found, err := app.FindWebAuthnCredentialByCredentialId(user.Collection().Id, "b")
+	if err != nil || found.RecordRef() != user.Id {
+		t.Fatalf("Expected to find credential b, got %v (%v)", found, err)
+	}
+
+	if _, err := app.FindWebAuthnCredentialByCredentialId("missing", "b"); err == nil {
+		t.Fatal("Expected error for credential from different collection")
+	}
+
+	if err := app.Delete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, err = app.FindAllWebAuthnCredentialsByRecord(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credentials) != 0 {
+		t.Fatalf("Expected the credentials to be deleted with the user, got %d", len(credentials))
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import "github.com/hanzoai/dbx"
+
+// FindAllWebAuthnCredentialsByRecord returns all WebAuthnCredential models
+// linked to the provided auth record (in DESC order).
+func (app *BaseApp) FindAllWebAuthnCredentialsByRecord(authRecord *Record) ([]*WebAuthnCredential, error) {
+	result := []*WebAuthnCredential{}
+
+	err := app.RecordQuery(CollectionNameWebAuthnCredentials).
+		AndWhere(dbx.HashExp{
+			"collectionRef": authRecord.Collection().Id,
+			"recordRef":     authRecord.Id,
+		}).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindWebAuthnCredentialByCredentialId returns a single WebAuthnCredential model
+// by its auth collection and base64url encoded credential id.
+func (app *BaseApp) FindWebAuthnCredentialByCredentialId(collectionId string, credentialId string) (*WebAuthnCredential, error) {
+	result := &WebAuthnCredential{}
+
+	err := app.RecordQuery(CollectionNameWebAuthnCredentials).
+		AndWhere(dbx.HashExp{
+			"collectionRef": collectionId,
+			"credentialId":  credentialId,
+		}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
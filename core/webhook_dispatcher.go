@@ -0,0 +1,536 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/hook"
+	"github.com/hanzoai/backendPB/tools/inflector"
+	"github.com/hanzoai/backendPB/tools/routine"
+	"github.com/hanzoai/backendPB/tools/search"
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/types"
+	"github.com/hanzoai/dbx"
+)
+
+const (
+	// WebhookHeaderId is the request header with the unique webhook delivery id
+	// (it is the same for each delivery retry and could be used for deduplication).
+	WebhookHeaderId = "X-Webhook-Id"
+
+	// WebhookHeaderEvent is the request header with the webhook record action
+	// ("create", "update", "delete" or "restore").
+	WebhookHeaderEvent = "X-Webhook-Event"
+
+	// WebhookHeaderSignature is the request header with the webhook payload signature
+	// in the format "t=unixTimestamp,v1=hex(HMAC-SHA256(secret, unixTimestamp + "." + body))".
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	webhookDispatchBatchSize = 100
+
+	webhookMinRetryDelay = 30 * time.Second
+	webhookMaxRetryDelay = 12 * time.Hour
+
+	// webhookMaxErrorLength is the max number of characters from the error or response body to store.
+	webhookMaxErrorLength = 500
+)
+
+// SignWebhookPayload returns the [WebhookHeaderSignature] value
+// for the specified request body, timestamp and webhook secret.
+func SignWebhookPayload(body []byte, timestamp int64, secret string) string {
+	t := strconv.FormatInt(timestamp, 10)
+
+	return "t=" + t + ",v1=" + security.HS256(t+"."+string(body), secret)
+}
+
+// webhookRetryDelay returns the exponential backoff delay before the next delivery attempt.
+func webhookRetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	// cap the exponent to prevent overflow
+	if attempts > 20 {
+		return webhookMaxRetryDelay
+	}
+
+	delay := webhookMinRetryDelay * time.Duration(1<<(attempts-1))
+
+	return min(delay, webhookMaxRetryDelay)
+}
+
+// storeKeyWebhooksCache is the app store key of the [webhooksCache].
+const storeKeyWebhooksCache = "pbAppWebhooksCache"
+
+// webhooksCache caches the registered webhooks grouped by their target collection
+// so that the record changes don't have to query the webhooks collection every time.
+type webhooksCache struct {
+	mu      sync.RWMutex
+	version uint64
+
+	// items is nil if the cache is not loaded yet or it was invalidated
+	// (the map key is the webhook collectionRef, aka. empty string for all collections)
+	items map[string][]*Webhook
+}
+
+// webhooksCacheFromApp returns the webhooks cache associated with the app store.
+func webhooksCacheFromApp(app App) *webhooksCache {
+	cache, _ := app.Store().GetOrSet(storeKeyWebhooksCache, func() any {
+		return &webhooksCache{}
+	}).(*webhooksCache)
+
+	return cache
+}
+
+// invalidate clears the loaded webhooks so that they will be reloaded on the next use.
+func (c *webhooksCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = nil
+	c.version++
+}
+
+// findByCollection returns the cached webhooks that could target the
+// provided collection (the webhook actions and filter are not checked).
+//
+// The webhooks are loaded with app if the cache is not loaded yet.
+// Webhooks loaded as part of a transaction are not cached because
+// they could contain uncommitted changes.
+func (c *webhooksCache) findByCollection(app App, collection *Collection) ([]*Webhook, error) {
+	c.mu.RLock()
+	items, version := c.items, c.version
+	c.mu.RUnlock()
+
+	if items == nil {
+		webhooks := []*Webhook{}
+		if err := app.RecordQuery(CollectionNameWebhooks).All(&webhooks); err != nil {
+			return nil, err
+		}
+
+		items = make(map[string][]*Webhook, len(webhooks))
+		for _, webhook := range webhooks {
+			items[webhook.CollectionRef()] = append(items[webhook.CollectionRef()], webhook)
+		}
+
+		if !app.IsTransactional() {
+			c.mu.Lock()
+			// skip if the cache was invalidated while loading
+			if c.version == version {
+				c.items = items
+			}
+			c.mu.Unlock()
+		}
+	}
+
+	result := make([]*Webhook, 0, len(items[""])+len(items[collection.Id]))
+	result = append(result, items[""]...)
+	result = append(result, items[collection.Id]...)
+	if collection.Name != collection.Id {
+		result = append(result, items[collection.Name]...)
+	}
+
+	return result, nil
+}
+
+func (app *BaseApp) registerWebhookHooks() {
+	// create the webhook system collections on demand
+	settingsSaveHandler := &hook.Handler[*ModelEvent]{
+		Func: func(e *ModelEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			s, ok := e.Model.(*Settings)
+			if !ok || !s.Webhooks.Enabled {
+				return nil
+			}
+
+			return createWebhookCollections(e.App)
+		},
+	}
+	app.OnModelAfterCreateSuccess(paramsTable).Bind(settingsSaveHandler)
+	app.OnModelAfterUpdateSuccess(paramsTable).Bind(settingsSaveHandler)
+
+	// invalidate the webhooks cache on webhook change
+	webhookChangeHandler := &hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			webhooksCacheFromApp(e.App).invalidate()
+			return e.Next()
+		},
+	}
+	app.OnRecordAfterCreateSuccess(CollectionNameWebhooks).Bind(webhookChangeHandler)
+	app.OnRecordAfterUpdateSuccess(CollectionNameWebhooks).Bind(webhookChangeHandler)
+	app.OnRecordAfterDeleteSuccess(CollectionNameWebhooks).Bind(webhookChangeHandler)
+
+	// persist the outbox deliveries in the same transaction as the record change
+	// (the priority is lower than the system record handlers so that soft deletes are also included)
+	app.OnRecordCreateExecute().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			return enqueueWebhookDeliveries(e, WebhookActionCreate)
+		},
+		Priority: 98,
+	})
+
+	app.OnRecordUpdateExecute().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			if e.Record.IsRestored() {
+				return enqueueWebhookDeliveries(e, WebhookActionRestore)
+			}
+			return enqueueWebhookDeliveries(e, WebhookActionUpdate)
+		},
+		Priority: 98,
+	})
+
+	app.OnRecordDeleteExecute().Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			return enqueueWebhookDeliveries(e, WebhookActionDelete)
+		},
+		Priority: 98,
+	})
+
+	// send the new deliveries once persisted
+	app.OnRecordAfterCreateSuccess(CollectionNameWebhookDeliveries).Bind(&hook.Handler[*RecordEvent]{
+		Func: func(e *RecordEvent) error {
+			app := e.App
+			id := e.Record.Id
+
+			routine.FireAndForget(func() {
+				if err := app.DeliverWebhook(id); err != nil {
+					app.Logger().Debug("Webhook delivery attempt failed", "error", err, "deliveryId", id)
+				}
+			})
+
+			return e.Next()
+		},
+	})
+
+	// retry the due deliveries (including the ones left pending from a previous app run)
+	app.Cron().Add("__pbWebhooksDispatch__", "* * * * *", func() {
+		if !app.Settings().Webhooks.Enabled {
+			return
+		}
+
+		if err := app.DispatchWebhookDeliveries(); err != nil {
+			app.Logger().Warn("Failed to dispatch the pending webhook deliveries", "error", err)
+		}
+	})
+}
+
+// createWebhookCollections creates the webhook system collections (if missing).
+func createWebhookCollections(app App) error {
+	webhooks, err := app.FindCollectionByNameOrId(CollectionNameWebhooks)
+	if err != nil {
+		webhooks = newWebhooksCollection()
+		if err := app.Save(webhooks); err != nil {
+			return err
+		}
+	}
+
+	if _, err := app.FindCollectionByNameOrId(CollectionNameWebhookDeliveries); err == nil {
+		return nil // already exists
+	}
+
+	return app.Save(newWebhookDeliveriesCollection(webhooks.Id))
+}
+
+// enqueueWebhookDeliveries executes the record event in a transaction
+// and persists a new pending delivery for each webhook matching the event record.
+func enqueueWebhookDeliveries(e *RecordEvent, action string) error {
+	collection := e.Record.Collection()
+
+	if !e.App.Settings().Webhooks.Enabled ||
+		collection.Name == CollectionNameWebhooks ||
+		collection.Name == CollectionNameWebhookDeliveries {
+		return e.Next()
+	}
+
+	if _, err := e.App.FindCachedCollectionByNameOrId(CollectionNameWebhookDeliveries); err != nil {
+		return e.Next() // no webhooks
+	}
+
+	webhooks, err := webhooksCacheFromApp(e.App).findByCollection(e.App, collection)
+	if err != nil {
+		return fmt.Errorf("failed to load the webhooks: %w", err)
+	}
+
+	webhooks = slices.DeleteFunc(webhooks, func(webhook *Webhook) bool {
+		return !webhook.Matches(collection, action)
+	})
+	if len(webhooks) == 0 {
+		return e.Next() // no webhooks for the current collection and action
+	}
+
+	originalApp := e.App
+	txErr := e.App.RunInTransaction(func(txApp App) error {
+		e.App = txApp
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		var payload map[string]any
+
+		for _, webhook := range webhooks {
+			if filter := webhook.Filter(); filter != "" {
+				match, err := webhookFilterMatch(txApp, e.Record, filter)
+				if err != nil {
+					// invalid webhook filter shouldn't prevent the record change
+					txApp.Logger().Warn(
+						"Failed to evaluate the webhook filter",
+						"error", err,
+						"webhookId", webhook.Id,
+						"recordId", e.Record.Id,
+						"collectionId", collection.Id,
+					)
+				}
+				if !match {
+					continue
+				}
+			}
+
+			// lazy init to export the record only once
+			if payload == nil {
+				payload = map[string]any{
+					"action": action,
+					"collection": map[string]any{
+						"id":   collection.Id,
+						"name": collection.Name,
+					},
+					"record":    e.Record.Clone().IgnoreEmailVisibility(true),
+					"timestamp": types.NowDateTime(),
+				}
+			}
+
+			delivery := NewWebhookDelivery(txApp)
+			delivery.SetWebhookRef(webhook.Id)
+			delivery.SetCollectionRef(collection.Id)
+			delivery.SetRecordRef(e.Record.Id)
+			delivery.SetAction(action)
+			delivery.SetPayload(payload)
+			delivery.SetStatus(WebhookDeliveryStatusPending)
+			delivery.SetNextAttemptAt(types.NowDateTime())
+
+			if err := txApp.Save(delivery); err != nil {
+				return fmt.Errorf("failed to save webhook %q delivery: %w", webhook.Id, err)
+			}
+		}
+
+		return nil
+	})
+	e.App = originalApp
+
+	return txErr
+}
+
+// webhookFilterMatch checks whether the record data satisfies the specified filter expression.
+//
+// The check is performed against the in-memory record data (and not the db row)
+// so that it could be used also with already deleted records.
+func webhookFilterMatch(app App, record *Record, filter string) (bool, error) {
+	export, err := record.DBExport(app)
+	if err != nil {
+		return false, err
+	}
+
+	randomPart := "__hb_webhook__" + security.PseudorandomString(6)
+
+	params := make(dbx.Params, len(export))
+	selects := make([]string, 0, len(export))
+	var param string
+	for k, v := range export {
+		k = inflector.Columnify(k)
+		param = "__hb_webhook__" + k
+		params[param] = v
+		selects = append(selects, "{:"+param+"} AS [["+k+"]]")
+	}
+
+	// shallow clone the record collection so that the filter resolves against the data CTE
+	dummyCollection := *record.Collection()
+	dummyCollection.Id += randomPart
+	dummyCollection.Name += inflector.Columnify(randomPart)
+
+	withFrom := fmt.Sprintf("WITH {{%s}} as (SELECT %s)", dummyCollection.Name, strings.Join(selects, ","))
+
+	query := app.DB().Select("(1)").PreFragment(withFrom).From(dummyCollection.Name).AndBind(params)
+
+	resolver := NewRecordFieldResolver(app, &dummyCollection, nil, true)
+
+	expr, err := search.FilterData(filter).BuildExpr(resolver)
+	if err != nil {
+		return false, err
+	}
+	query.AndWhere(expr)
+
+	if err := resolver.UpdateQuery(query); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = query.Limit(1).Row(&exists)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// DispatchWebhookDeliveries sends all due pending webhook deliveries.
+//
+// Individual delivery failures are rescheduled with exponential backoff
+// and are not returned as error.
+func (app *BaseApp) DispatchWebhookDeliveries() error {
+	if _, err := app.FindCachedCollectionByNameOrId(CollectionNameWebhookDeliveries); err != nil {
+		return nil // no webhooks
+	}
+
+	for {
+		ids := []string{}
+
+		err := app.DB().Select("id").
+			From(CollectionNameWebhookDeliveries).
+			AndWhere(dbx.HashExp{"status": WebhookDeliveryStatusPending}).
+			AndWhere(dbx.NewExp("[[nextAttemptAt]] <= {:now}", dbx.Params{"now": types.NowDateTime().String()})).
+			OrderBy("nextAttemptAt ASC").
+			Limit(webhookDispatchBatchSize).
+			Column(&ids)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := app.DeliverWebhook(id); err != nil {
+				app.Logger().Debug("Webhook delivery attempt failed", "error", err, "deliveryId", id)
+			}
+		}
+
+		if len(ids) < webhookDispatchBatchSize {
+			return nil
+		}
+	}
+}
+
+// DeliverWebhook makes a single delivery attempt for the pending webhook delivery with the specified id.
+//
+// The delivery is skipped (without error) if it is not pending,
+// not due yet or it is currently processed by another dispatcher.
+//
+// On failure the delivery is rescheduled with exponential backoff or it is
+// marked as failed if the max allowed attempts are reached.
+func (app *BaseApp) DeliverWebhook(deliveryId string) error {
+	timeout := time.Duration(app.Settings().Webhooks.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	// claim the delivery by moving its next attempt date
+	// so that the same delivery is not sent concurrently by multiple dispatchers
+	now := types.NowDateTime()
+	lease := now.Add(timeout + time.Minute)
+	claim, err := app.NonconcurrentDB().Update(
+		CollectionNameWebhookDeliveries,
+		dbx.Params{"nextAttemptAt": lease.String()},
+		dbx.And(
+			dbx.HashExp{"id": deliveryId, "status": WebhookDeliveryStatusPending},
+			dbx.NewExp("[[nextAttemptAt]] <= {:now}", dbx.Params{"now": now.String()}),
+		),
+	).Execute()
+	if err != nil {
+		return err
+	}
+	if affected, _ := claim.RowsAffected(); affected == 0 {
+		return nil // already claimed, processed or not due
+	}
+
+	deliveryRecord, err := app.FindRecordById(CollectionNameWebhookDeliveries, deliveryId)
+	if err != nil {
+		return err
+	}
+	delivery := &WebhookDelivery{deliveryRecord}
+
+	webhook, err := app.FindWebhookById(delivery.WebhookRef())
+	if err != nil {
+		delivery.SetStatus(WebhookDeliveryStatusFailed)
+		delivery.SetLastError("missing webhook: " + err.Error())
+		return errors.Join(err, app.Save(delivery))
+	}
+
+	statusCode, sendErr := sendWebhook(webhook, delivery, timeout)
+
+	delivery.SetAttempts(delivery.Attempts() + 1)
+	delivery.SetLastStatusCode(statusCode)
+
+	if sendErr == nil {
+		delivery.SetStatus(WebhookDeliveryStatusDelivered)
+		delivery.SetLastError("")
+		return app.Save(delivery)
+	}
+
+	delivery.SetLastError(truncateWebhookError(sendErr.Error()))
+
+	// note: the settings validator requires at least 1 attempt when enabled
+	maxAttempts := max(app.Settings().Webhooks.MaxAttempts, 1)
+	if delivery.Attempts() >= maxAttempts {
+		delivery.SetStatus(WebhookDeliveryStatusFailed)
+	} else {
+		delivery.SetNextAttemptAt(types.NowDateTime().Add(webhookRetryDelay(delivery.Attempts())))
+	}
+
+	return errors.Join(sendErr, app.Save(delivery))
+}
+
+// sendWebhook sends the signed delivery payload to the webhook url
+// and returns the response status code.
+func sendWebhook(webhook *Webhook, delivery *WebhookDelivery, timeout time.Duration) (int, error) {
+	body := []byte(delivery.Payload())
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "HanzoBase-Webhooks")
+	req.Header.Set(WebhookHeaderId, delivery.Id)
+	req.Header.Set(WebhookHeaderEvent, delivery.Action())
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(body, time.Now().Unix(), webhook.Secret()))
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, webhookMaxErrorLength))
+		return res.StatusCode, fmt.Errorf("unexpected response status %d: %s", res.StatusCode, resBody)
+	}
+
+	// drain the body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	return res.StatusCode, nil
+}
+
+func truncateWebhookError(str string) string {
+	if len(str) > webhookMaxErrorLength {
+		return str[:webhookMaxErrorLength] + "..."
+	}
+
+	return str
+}
@@ -0,0 +1,359 @@
+package core_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tests"
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	t.Parallel()
+
+	result := core.SignWebhookPayload([]byte(`{"a":1}`), 123, "test")
+
+	expected := "t=123,v1=" + security.HS256("123."+`{"a":1}`, "test")
+	if result != expected {
+		t.Fatalf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestWebhooksCollectionsOnDemand(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	if _, err := app.FindCollectionByNameOrId(core.CollectionNameWebhooks); err == nil {
+		t.Fatal("Expected the webhooks collection to not exist")
+	}
+
+	app.Settings().Webhooks.Enabled = true
+	if err := app.Save(app.Settings()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{core.CollectionNameWebhooks, core.CollectionNameWebhookDeliveries} {
+		collection, err := app.FindCollectionByNameOrId(name)
+		if err != nil {
+			t.Fatalf("Expected collection %q to be created, got %v", name, err)
+		}
+		if !collection.System {
+			t.Fatalf("Expected collection %q to be system", name)
+		}
+	}
+}
+
+type webhookTestServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookTestServer(status int) *webhookTestServer {
+	s := &webhookTestServer{status: status}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		status := s.status
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+
+	return s
+}
+
+func (s *webhookTestServer) total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.requests)
+}
+
+func setupWebhookTest(t *testing.T, app core.App, url string, filter string) (*core.Collection, *core.Webhook) {
+	app.Settings().Webhooks.Enabled = true
+	app.Settings().Webhooks.MaxAttempts = 2
+	if err := app.Save(app.Settings()); err != nil {
+		t.Fatal(err)
+	}
+
+	collection := core.NewBaseCollection("webhook_test")
+	collection.Fields.Add(&core.TextField{Name: "title"})
+	if err := app.Save(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	webhook := core.NewWebhook(app)
+	webhook.SetName("test")
+	webhook.SetURL(url)
+	webhook.SetCollectionRef(collection.Name)
+	webhook.SetActions([]string{core.WebhookActionCreate, core.WebhookActionDelete})
+	webhook.SetFilter(filter)
+	if err := app.Save(webhook); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(webhook.Secret()) == 0 {
+		t.Fatal("Expected the webhook secret to be autogenerated")
+	}
+
+	return collection, webhook
+}
+
+// waitWebhookDeliveries waits until all webhook deliveries have the expected status.
+func waitWebhookDeliveries(t *testing.T, app core.App, webhook *core.Webhook, expectedTotal int, expectedStatus string) []*core.WebhookDelivery {
+	var deliveries []*core.WebhookDelivery
+
+	for i := 0; i < 100; i++ {
+		var err error
+		deliveries, err = app.FindAllWebhookDeliveriesByWebhook(webhook)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		done := len(deliveries) == expectedTotal
+		for _, d := range deliveries {
+			if d.Status() != expectedStatus || d.Attempts() == 0 {
+				done = false
+			}
+		}
+		if done {
+			return deliveries
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected %d %q deliveries, got %v", expectedTotal, expectedStatus, deliveries)
+
+	return nil
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	server := newWebhookTestServer(http.StatusOK)
+	defer server.Close()
+
+	collection, webhook := setupWebhookTest(t, app, server.URL, "title = 'match'")
+
+	matching := core.NewRecord(collection)
+	matching.Set("title", "match")
+	if err := app.Save(matching); err != nil {
+		t.Fatal(err)
+	}
+
+	nonMatching := core.NewRecord(collection)
+	nonMatching.Set("title", "other")
+	if err := app.Save(nonMatching); err != nil {
+		t.Fatal(err)
+	}
+
+	// update action is not watched
+	matching.Set("title", "match")
+	if err := app.Save(matching); err != nil {
+		t.Fatal(err)
+	}
+
+	// the filter should be evaluated against the deleted record data
+	if err := app.Delete(matching); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries := waitWebhookDeliveries(t, app, webhook, 2, core.WebhookDeliveryStatusDelivered)
+
+	if total := server.total(); total != 2 {
+		t.Fatalf("Expected 2 webhook requests, got %d", total)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	for i, r := range server.requests {
+		body := server.bodies[i]
+
+		parts := strings.Split(r.Header.Get(core.WebhookHeaderSignature), ",")
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") {
+			t.Fatalf("Invalid signature header %q", r.Header.Get(core.WebhookHeaderSignature))
+		}
+		timestamp, _ := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+		if expected := core.SignWebhookPayload(body, timestamp, webhook.Secret()); r.Header.Get(core.WebhookHeaderSignature) != expected {
+			t.Fatalf("Expected signature %q, got %q", expected, r.Header.Get(core.WebhookHeaderSignature))
+		}
+
+		payload := struct {
+			Action string         `json:"action"`
+			Record map[string]any `json:"record"`
+		}{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Record["id"] != matching.Id || payload.Record["title"] != "match" {
+			t.Fatalf("Invalid payload record %v", payload.Record)
+		}
+		if payload.Action != r.Header.Get(core.WebhookHeaderEvent) {
+			t.Fatalf("Expected event header %q, got %q", payload.Action, r.Header.Get(core.WebhookHeaderEvent))
+		}
+
+		var hasDelivery bool
+		for _, d := range deliveries {
+			if d.Id == r.Header.Get(core.WebhookHeaderId) {
+				hasDelivery = true
+			}
+		}
+		if !hasDelivery {
+			t.Fatalf("Missing delivery with id %q", r.Header.Get(core.WebhookHeaderId))
+		}
+	}
+}
+
+func TestWebhookDeliveryRetry(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	server := newWebhookTestServer(http.StatusInternalServerError)
+	defer server.Close()
+
+	collection, webhook := setupWebhookTest(t, app, server.URL, "")
+
+	record := core.NewRecord(collection)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	// the outbox delivery should be persisted together with the record
+	persisted, err := app.FindAllWebhookDeliveriesByWebhook(webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persisted) != 1 {
+		t.Fatalf("Expected 1 persisted delivery, got %d", len(persisted))
+	}
+
+	deliveries := waitWebhookDeliveries(t, app, webhook, 1, core.WebhookDeliveryStatusPending)
+
+	delivery := deliveries[0]
+	if delivery.Attempts() != 1 || delivery.LastStatusCode() != http.StatusInternalServerError || delivery.LastError() == "" {
+		t.Fatalf("Invalid failed delivery state %v", delivery)
+	}
+	if !delivery.NextAttemptAt().After(delivery.Created()) {
+		t.Fatalf("Expected the next attempt to be rescheduled, got %v", delivery.NextAttemptAt())
+	}
+
+	// not due yet
+	if err := app.DispatchWebhookDeliveries(); err != nil {
+		t.Fatal(err)
+	}
+	if total := server.total(); total != 1 {
+		t.Fatalf("Expected 1 webhook request, got %d", total)
+	}
+
+	// simulate elapsed backoff delay
+	delivery.SetNextAttemptAt(delivery.Created())
+	if err := app.Save(delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DispatchWebhookDeliveries(); err != nil {
+		t.Fatal(err)
+	}
+	if total := server.total(); total != 2 {
+		t.Fatalf("Expected 2 webhook requests, got %d", total)
+	}
+
+	// max attempts reached
+	waitWebhookDeliveries(t, app, webhook, 1, core.WebhookDeliveryStatusFailed)
+}
+
+func TestWebhooksCacheInvalidation(t *testing.T) {
+	t.Parallel()
+
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	server := newWebhookTestServer(http.StatusOK)
+	defer server.Close()
+
+	collection, webhook := setupWebhookTest(t, app, server.URL, "")
+
+	other := core.NewBaseCollection("webhook_other")
+	if err := app.Save(other); err != nil {
+		t.Fatal(err)
+	}
+
+	// load the cache
+	if err := app.Save(core.NewRecord(collection)); err != nil {
+		t.Fatal(err)
+	}
+	waitWebhookDeliveries(t, app, webhook, 1, core.WebhookDeliveryStatusDelivered)
+
+	// no webhooks for the collection
+	if err := app.Save(core.NewRecord(other)); err != nil {
+		t.Fatal(err)
+	}
+	waitWebhookDeliveries(t, app, webhook, 1, core.WebhookDeliveryStatusDelivered)
+
+	// retarget the webhook
+	webhook.SetCollectionRef(other.Id)
+	if err := app.Save(webhook); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Save(core.NewRecord(collection)); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Save(core.NewRecord(other)); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := waitWebhookDeliveries(t, app, webhook, 2, core.WebhookDeliveryStatusDelivered)
+
+	var totalOther int
+	for _, d := range deliveries {
+		if d.CollectionRef() == other.Id {
+			totalOther++
+		}
+	}
+	if totalOther != 1 {
+		t.Fatalf("Expected 1 delivery for the retargeted collection, got %d", totalOther)
+	}
+
+	// delete the webhook
+	if err := app.Delete(webhook); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Save(core.NewRecord(other)); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	err := app.RecordQuery(core.CollectionNameWebhookDeliveries).Select("count(*)").Row(&total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Fatalf("Expected no deliveries after the webhook delete, got %d", total)
+	}
+}
@@ -0,0 +1,462 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/hanzoai/backendPB/tools/types"
+)
+
+const (
+	CollectionNameWebhooks          = "_webhooks"
+	CollectionNameWebhookDeliveries = "_webhookDeliveries"
+)
+
+const (
+	WebhookActionCreate = "create"
+	WebhookActionUpdate = "update"
+	WebhookActionDelete = "delete"
+
+	// WebhookActionRestore is the action of a soft deleted record restore.
+	WebhookActionRestore = "restore"
+)
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+var (
+	_ Model        = (*Webhook)(nil)
+	_ PreValidator = (*Webhook)(nil)
+	_ RecordProxy  = (*Webhook)(nil)
+
+	_ Model        = (*WebhookDelivery)(nil)
+	_ PreValidator = (*WebhookDelivery)(nil)
+	_ RecordProxy  = (*WebhookDelivery)(nil)
+)
+
+// Webhook defines a Record proxy for working with the webhooks collection.
+type Webhook struct {
+	*Record
+}
+
+// NewWebhook instantiates and returns a new blank *Webhook model.
+//
+// Example usage:
+//
+//	webhook := core.NewWebhook(app)
+//	webhook.SetName("orders")
+//	webhook.SetURL("https://example.com/hooks/orders")
+//	webhook.SetCollectionRef("orders")
+//	webhook.SetActions([]string{core.WebhookActionCreate})
+//	app.Save(webhook)
+func NewWebhook(app App) *Webhook {
+	m := &Webhook{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameWebhooks)
+	if err != nil {
+		// this is just to make tests easier since the webhooks collection is created on demand
+		// (note: the loaded record is further checked on Webhook.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *Webhook) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameWebhooks {
+		return errors.New("missing or invalid webhook ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *Webhook) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *Webhook) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// Name returns the "name" record field value.
+func (m *Webhook) Name() string {
+	return m.GetString("name")
+}
+
+// SetName updates the "name" record field value.
+func (m *Webhook) SetName(name string) {
+	m.Set("name", name)
+}
+
+// URL returns the "url" record field value.
+func (m *Webhook) URL() string {
+	return m.GetString("url")
+}
+
+// SetURL updates the "url" record field value.
+func (m *Webhook) SetURL(url string) {
+	m.Set("url", url)
+}
+
+// Secret returns the "secret" record field value used for signing the webhook payloads.
+func (m *Webhook) Secret() string {
+	return m.GetString("secret")
+}
+
+// SetSecret updates the "secret" record field value.
+func (m *Webhook) SetSecret(secret string) {
+	m.Set("secret", secret)
+}
+
+// CollectionRef returns the "collectionRef" record field value
+// (the name or id of the watched collection; empty for all collections).
+func (m *Webhook) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *Webhook) SetCollectionRef(collectionNameOrId string) {
+	m.Set("collectionRef", collectionNameOrId)
+}
+
+// Actions returns the "actions" record field value
+// (the watched record actions; empty for all actions).
+func (m *Webhook) Actions() []string {
+	return m.GetStringSlice("actions")
+}
+
+// SetActions updates the "actions" record field value.
+func (m *Webhook) SetActions(actions []string) {
+	m.Set("actions", actions)
+}
+
+// Filter returns the "filter" record field value.
+func (m *Webhook) Filter() string {
+	return m.GetString("filter")
+}
+
+// SetFilter updates the "filter" record field value.
+func (m *Webhook) SetFilter(filter string) {
+	m.Set("filter", filter)
+}
+
+// Created returns the "created" record field value.
+func (m *Webhook) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *Webhook) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// Matches reports whether the webhook watches the specified collection and record action.
+//
+// Note that the webhook filter is not checked.
+func (m *Webhook) Matches(collection *Collection, action string) bool {
+	target := m.CollectionRef()
+	if target != "" && target != collection.Name && target != collection.Id {
+		return false
+	}
+
+	actions := m.Actions()
+
+	return len(actions) == 0 || slices.Contains(actions, action)
+}
+
+// -------------------------------------------------------------------
+
+// WebhookDelivery defines a Record proxy for working with the webhook deliveries (aka. outbox) collection.
+type WebhookDelivery struct {
+	*Record
+}
+
+// NewWebhookDelivery instantiates and returns a new blank *WebhookDelivery model.
+func NewWebhookDelivery(app App) *WebhookDelivery {
+	m := &WebhookDelivery{}
+
+	c, err := app.FindCachedCollectionByNameOrId(CollectionNameWebhookDeliveries)
+	if err != nil {
+		// this is just to make tests easier since the webhook deliveries collection is created on demand
+		// (note: the loaded record is further checked on WebhookDelivery.PreValidate())
+		c = NewBaseCollection("@__invalid__")
+	}
+
+	m.Record = NewRecord(c)
+
+	return m
+}
+
+// PreValidate implements the [PreValidator] interface and checks
+// whether the proxy is properly loaded.
+func (m *WebhookDelivery) PreValidate(ctx context.Context, app App) error {
+	if m.Record == nil || m.Record.Collection().Name != CollectionNameWebhookDeliveries {
+		return errors.New("missing or invalid webhook delivery ProxyRecord")
+	}
+
+	return nil
+}
+
+// ProxyRecord returns the proxied Record model.
+func (m *WebhookDelivery) ProxyRecord() *Record {
+	return m.Record
+}
+
+// SetProxyRecord loads the specified record model into the current proxy.
+func (m *WebhookDelivery) SetProxyRecord(record *Record) {
+	m.Record = record
+}
+
+// WebhookRef returns the "webhookRef" record field value.
+func (m *WebhookDelivery) WebhookRef() string {
+	return m.GetString("webhookRef")
+}
+
+// SetWebhookRef updates the "webhookRef" record field value.
+func (m *WebhookDelivery) SetWebhookRef(webhookId string) {
+	m.Set("webhookRef", webhookId)
+}
+
+// CollectionRef returns the "collectionRef" record field value.
+func (m *WebhookDelivery) CollectionRef() string {
+	return m.GetString("collectionRef")
+}
+
+// SetCollectionRef updates the "collectionRef" record field value.
+func (m *WebhookDelivery) SetCollectionRef(collectionId string) {
+	m.Set("collectionRef", collectionId)
+}
+
+// RecordRef returns the "recordRef" record field value.
+func (m *WebhookDelivery) RecordRef() string {
+	return m.GetString("recordRef")
+}
+
+// SetRecordRef updates the "recordRef" record field value.
+func (m *WebhookDelivery) SetRecordRef(recordId string) {
+	m.Set("recordRef", recordId)
+}
+
+// Action returns the "action" record field value.
+func (m *WebhookDelivery) Action() string {
+	return m.GetString("action")
+}
+
+// SetAction updates the "action" record field value.
+func (m *WebhookDelivery) SetAction(action string) {
+	m.Set("action", action)
+}
+
+// Payload returns the raw serialized "payload" record field value
+// (aka. the webhook request body).
+func (m *WebhookDelivery) Payload() types.JSONRaw {
+	raw, _ := m.GetRaw("payload").(types.JSONRaw)
+	return raw
+}
+
+// SetPayload updates the "payload" record field value.
+func (m *WebhookDelivery) SetPayload(payload any) {
+	m.Set("payload", payload)
+}
+
+// Status returns the "status" record field value.
+func (m *WebhookDelivery) Status() string {
+	return m.GetString("status")
+}
+
+// SetStatus updates the "status" record field value.
+func (m *WebhookDelivery) SetStatus(status string) {
+	m.Set("status", status)
+}
+
+// Attempts returns the "attempts" record field value.
+func (m *WebhookDelivery) Attempts() int {
+	return m.GetInt("attempts")
+}
+
+// SetAttempts updates the "attempts" record field value.
+func (m *WebhookDelivery) SetAttempts(attempts int) {
+	m.Set("attempts", attempts)
+}
+
+// NextAttemptAt returns the "nextAttemptAt" record field value.
+func (m *WebhookDelivery) NextAttemptAt() types.DateTime {
+	return m.GetDateTime("nextAttemptAt")
+}
+
+// SetNextAttemptAt updates the "nextAttemptAt" record field value.
+func (m *WebhookDelivery) SetNextAttemptAt(date types.DateTime) {
+	m.Set("nextAttemptAt", date)
+}
+
+// LastStatusCode returns the "lastStatusCode" record field value.
+func (m *WebhookDelivery) LastStatusCode() int {
+	return m.GetInt("lastStatusCode")
+}
+
+// SetLastStatusCode updates the "lastStatusCode" record field value.
+func (m *WebhookDelivery) SetLastStatusCode(code int) {
+	m.Set("lastStatusCode", code)
+}
+
+// LastError returns the "lastError" record field value.
+func (m *WebhookDelivery) LastError() string {
+	return m.GetString("lastError")
+}
+
+// SetLastError updates the "lastError" record field value.
+func (m *WebhookDelivery) SetLastError(err string) {
+	m.Set("lastError", err)
+}
+
+// Created returns the "created" record field value.
+func (m *WebhookDelivery) Created() types.DateTime {
+	return m.GetDateTime("created")
+}
+
+// Updated returns the "updated" record field value.
+func (m *WebhookDelivery) Updated() types.DateTime {
+	return m.GetDateTime("updated")
+}
+
+// -------------------------------------------------------------------
+
+// newWebhooksCollection returns the default webhooks system collection definition.
+func newWebhooksCollection() *Collection {
+	col := NewBaseCollection(CollectionNameWebhooks)
+	col.System = true
+
+	col.Fields.Add(&TextField{
+		Name:     "name",
+		System:   true,
+		Required: true,
+		Max:      255,
+	})
+	col.Fields.Add(&URLField{
+		Name:     "url",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:                "secret",
+		System:              true,
+		Required:            true,
+		Min:                 16,
+		Max:                 255,
+		AutogeneratePattern: "[a-zA-Z0-9]{40}",
+	})
+	col.Fields.Add(&TextField{
+		Name:   "collectionRef",
+		System: true,
+	})
+	col.Fields.Add(&SelectField{
+		Name:      "actions",
+		System:    true,
+		MaxSelect: 4,
+		Values:    []string{WebhookActionCreate, WebhookActionUpdate, WebhookActionDelete, WebhookActionRestore},
+	})
+	col.Fields.Add(&TextField{
+		Name:   "filter",
+		System: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+
+	return col
+}
+
+// newWebhookDeliveriesCollection returns the default webhook deliveries system collection definition.
+func newWebhookDeliveriesCollection(webhooksCollectionId string) *Collection {
+	col := NewBaseCollection(CollectionNameWebhookDeliveries)
+	col.System = true
+
+	col.Fields.Add(&RelationField{
+		Name:          "webhookRef",
+		System:        true,
+		Required:      true,
+		MaxSelect:     1,
+		CollectionId:  webhooksCollectionId,
+		CascadeDelete: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "collectionRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:     "recordRef",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&SelectField{
+		Name:      "action",
+		System:    true,
+		Required:  true,
+		MaxSelect: 1,
+		Values:    []string{WebhookActionCreate, WebhookActionUpdate, WebhookActionDelete, WebhookActionRestore},
+	})
+	col.Fields.Add(&JSONField{
+		Name:     "payload",
+		System:   true,
+		Required: true,
+	})
+	col.Fields.Add(&SelectField{
+		Name:      "status",
+		System:    true,
+		Required:  true,
+		MaxSelect: 1,
+		Values:    []string{WebhookDeliveryStatusPending, WebhookDeliveryStatusDelivered, WebhookDeliveryStatusFailed},
+	})
+	col.Fields.Add(&NumberField{
+		Name:    "attempts",
+		System:  true,
+		OnlyInt: true,
+	})
+	col.Fields.Add(&DateField{
+		Name:   "nextAttemptAt",
+		System: true,
+	})
+	col.Fields.Add(&NumberField{
+		Name:    "lastStatusCode",
+		System:  true,
+		OnlyInt: true,
+	})
+	col.Fields.Add(&TextField{
+		Name:   "lastError",
+		System: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "created",
+		System:   true,
+		OnCreate: true,
+	})
+	col.Fields.Add(&AutodateField{
+		Name:     "updated",
+		System:   true,
+		OnCreate: true,
+		OnUpdate: true,
+	})
+	col.AddIndex("idx_webhookDeliveries_status_nextAttemptAt", false, "status,nextAttemptAt", "")
+	col.AddIndex("idx_webhookDeliveries_webhookRef", false, "webhookRef", "")
+
+	return col
+}
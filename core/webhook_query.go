@@ -0,0 +1,38 @@
+package core
+
+import (
+	"github.com/hanzoai/dbx"
+)
+
+// FindWebhookById returns a single Webhook model by its id.
+func (app *BaseApp) FindWebhookById(id string) (*Webhook, error) {
+	result := &Webhook{}
+
+	err := app.RecordQuery(CollectionNameWebhooks).
+		AndWhere(dbx.HashExp{"id": id}).
+		Limit(1).
+		One(result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAllWebhookDeliveriesByWebhook returns all WebhookDelivery models
+// linked to the provided webhook (ordered from the newest to the oldest).
+func (app *BaseApp) FindAllWebhookDeliveriesByWebhook(webhook *Webhook) ([]*WebhookDelivery, error) {
+	result := []*WebhookDelivery{}
+
+	err := app.RecordQuery(CollectionNameWebhookDeliveries).
+		AndWhere(dbx.HashExp{"webhookRef": webhook.Id}).
+		OrderBy("created DESC").
+		All(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
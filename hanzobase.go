@@ -12,6 +12,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/hanzoai/backendPB/cmd"
 	"github.com/hanzoai/backendPB/core"
+	"github.com/hanzoai/backendPB/tools/dbutils"
 	"github.com/hanzoai/backendPB/tools/hook"
 	"github.com/hanzoai/backendPB/tools/list"
 	"github.com/hanzoai/backendPB/tools/routine"
@@ -59,6 +60,12 @@ type Config struct {
 	AuxMaxOpenConns  int                // default to core.DefaultAuxMaxOpenConns
 	AuxMaxIdleConns  int                // default to core.DefaultAuxMaxIdleConns
 	DBConnect        core.DBConnectFunc // default to core.dbConnect
+	DBDialect        dbutils.Dialect    // default to dbutils.SQLiteDialect
+
+	// optional read-only data db replicas
+	DataReplicas                []string
+	ReplicaDBConnect            core.DBConnectFunc // default to core.DefaultReplicaDBConnect
+	ReplicaReadYourWritesWindow time.Duration      // default to core.DefaultReplicaReadYourWritesWindow
 }
 
 // New creates a new HanzoBase instance with the default configuration.
@@ -134,6 +141,12 @@ func NewWithConfig(config Config) *HanzoBase {
 		AuxMaxOpenConns:  config.AuxMaxOpenConns,
 		AuxMaxIdleConns:  config.AuxMaxIdleConns,
 		DBConnect:        config.DBConnect,
+		DBDialect:        config.DBDialect,
+		Version:          Version,
+
+		DataReplicas:                config.DataReplicas,
+		ReplicaDBConnect:            config.ReplicaDBConnect,
+		ReplicaReadYourWritesWindow: config.ReplicaReadYourWritesWindow,
 	})
 
 	// hide the default help command (allow only `--help` flag)
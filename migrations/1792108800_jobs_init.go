@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/hanzoai/backendPB/core"
+)
+
+// creates the background jobs queue table
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, execErr := txApp.DB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_jobs}} (
+					[[id]]          TEXT PRIMARY KEY DEFAULT ('r'||lower(hex(randomblob(7)))) NOT NULL,
+					[[name]]        TEXT DEFAULT "" NOT NULL,
+					[[payload]]     JSON DEFAULT NULL,
+					[[status]]      TEXT DEFAULT "pending" NOT NULL,
+					[[priority]]    INTEGER DEFAULT 0 NOT NULL,
+					[[attempts]]    INTEGER DEFAULT 0 NOT NULL,
+					[[maxAttempts]] INTEGER DEFAULT 1 NOT NULL,
+					[[uniqueKey]]   TEXT DEFAULT "" NOT NULL,
+					[[lastError]]   TEXT DEFAULT "" NOT NULL,
+					[[runAt]]       TEXT DEFAULT (strftime('%Y-%m-%d %H:%M:%fZ')) NOT NULL,
+					[[created]]     TEXT DEFAULT (strftime('%Y-%m-%d %H:%M:%fZ')) NOT NULL,
+					[[updated]]     TEXT DEFAULT (strftime('%Y-%m-%d %H:%M:%fZ')) NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_jobs_status_runAt on {{_jobs}} ([[status]], [[runAt]]);
+				CREATE INDEX IF NOT EXISTS idx_jobs_name on {{_jobs}} ([[name]]);
+				CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_uniqueKey on {{_jobs}} ([[uniqueKey]])
+					WHERE [[uniqueKey]] != '' AND [[status]] IN ('pending', 'running');
+			`).Execute()
+
+			return execErr
+		},
+		Down: func(txApp core.App) error {
+			_, err := txApp.DB().DropTable("_jobs").Execute()
+			return err
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"github.com/hanzoai/backendPB/core"
+)
+
+// creates the cron job runs history table in the auxiliary db
+func init() {
+	core.SystemMigrations.Add(&core.Migration{
+		Up: func(txApp core.App) error {
+			_, execErr := txApp.AuxDB().NewQuery(`
+				CREATE TABLE IF NOT EXISTS {{_cronRuns}} (
+					[[id]]       TEXT PRIMARY KEY DEFAULT ('r'||lower(hex(randomblob(7)))) NOT NULL,
+					[[jobId]]    TEXT DEFAULT "" NOT NULL,
+					[[trigger]]  TEXT DEFAULT "" NOT NULL,
+					[[error]]    TEXT DEFAULT "" NOT NULL,
+					[[duration]] INTEGER DEFAULT 0 NOT NULL,
+					[[started]]  TEXT DEFAULT (strftime('%Y-%m-%d %H:%M:%fZ')) NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_cronRuns_jobId_started on {{_cronRuns}} ([[jobId]], [[started]]);
+			`).Execute()
+
+			return execErr
+		},
+		Down: func(txApp core.App) error {
+			_, err := txApp.AuxDB().DropTable("_cronRuns").Execute()
+			return err
+		},
+		ReapplyCondition: func(txApp core.App, runner *core.MigrationsRunner, fileName string) (bool, error) {
+			// reapply only if the _cronRuns table doesn't exist
+			exists := txApp.AuxHasTable("_cronRuns")
+			return !exists, nil
+		},
+	})
+}
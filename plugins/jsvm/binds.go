@@ -105,7 +105,7 @@ func cronBinds(app core.App, loader *goja.Runtime, executors *vmsPool) {
 	loader.Set("cronAdd", func(jobId, cronExpr, handler string) {
 		pr := goja.MustCompile("", "{("+handler+").apply(undefined)}", true)
 
-		err := app.Cron().Add(jobId, cronExpr, func() {
+		err := app.Cron().AddWithError(jobId, cronExpr, func() error {
 			err := executors.run(func(executor *goja.Runtime) error {
 				_, err := executor.RunProgram(pr)
 				return err
@@ -118,6 +118,8 @@ func cronBinds(app core.App, loader *goja.Runtime, executors *vmsPool) {
 					slog.String("error", err.Error()),
 				)
 			}
+
+			return err
 		})
 		if err != nil {
 			panic("[cronAdd] failed to register cron job " + jobId + ": " + err.Error())
@@ -147,6 +149,29 @@ func cronBinds(app core.App, loader *goja.Runtime, executors *vmsPool) {
 	}
 }
 
+func jobBinds(app core.App, loader *goja.Runtime, executors *vmsPool) {
+	loader.Set("jobHandler", func(name string, handler string) {
+		pr := goja.MustCompile("", "{("+handler+").apply(undefined, __args)}", true)
+
+		app.RegisterJobHandler(name, func(ctx context.Context, job *core.Job) error {
+			return executors.run(func(executor *goja.Runtime) error {
+				executor.Set("__args", []any{job})
+				res, err := executor.RunProgram(pr)
+				executor.Set("__args", goja.Undefined())
+
+				// check for returned Go error value
+				if res != nil {
+					if v, ok := res.Export().(error); ok {
+						return v
+					}
+				}
+
+				return normalizeException(err)
+			})
+		})
+	})
+}
+
 func routerBinds(app core.App, loader *goja.Runtime, executors *vmsPool) {
 	loader.Set("routerAdd", func(method string, path string, handler goja.Value, middlewares ...goja.Value) {
 		wrappedMiddlewares, err := wrapMiddlewares(executors, middlewares...)
@@ -503,6 +528,10 @@ func baseBinds(vm *goja.Runtime) {
 		instance := &core.FileField{}
 		return structConstructorUnmarshal(vm, call, instance)
 	})
+	vm.Set("GeoPointField", func(call goja.ConstructorCall) *goja.Object {
+		instance := &core.GeoPointField{}
+		return structConstructorUnmarshal(vm, call, instance)
+	})
 	// ---
 
 	vm.Set("MailerMessage", func(call goja.ConstructorCall) *goja.Object {
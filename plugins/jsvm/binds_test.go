@@ -44,7 +44,7 @@ func TestBaseBindsCount(t *testing.T) {
 	vm := goja.New()
 	baseBinds(vm)
 
-	testBindsCount(vm, "this", 32, t)
+	testBindsCount(vm, "this", 33, t)
 }
 
 func TestBaseBindsSleep(t *testing.T) {
@@ -399,6 +399,10 @@ func TestBaseBindsNamedFields(t *testing.T) {
 			"new FileField({name: 'test'})",
 			isType[*core.FileField],
 		},
+		{
+			"new GeoPointField({name: 'test'})",
+			isType[*core.GeoPointField],
+		},
 	}
 
 	for _, s := range scenarios {
@@ -1359,6 +1363,58 @@ func TestCronBindsCount(t *testing.T) {
 	})
 }
 
+func TestJobBindsCount(t *testing.T) {
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	vm := goja.New()
+	jobBinds(app, vm, nil)
+
+	testBindsCount(vm, "this", 1, t)
+}
+
+func TestJobBinds(t *testing.T) {
+	app, _ := tests.NewTestApp()
+	defer app.Cleanup()
+
+	result := &struct {
+		Payload string
+	}{}
+
+	vmFactory := func() *goja.Runtime {
+		vm := goja.New()
+		baseBinds(vm)
+		vm.Set("result", result)
+		return vm
+	}
+
+	pool := newPool(1, vmFactory)
+
+	vm := vmFactory()
+	jobBinds(app, vm, pool)
+
+	_, err := vm.RunString(`
+		jobHandler("test", (job) => {
+			result.payload = toString(job.payload)
+		})
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.Enqueue("test", map[string]any{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.ProcessJobs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Payload != `{"a":1}` {
+		t.Fatalf("Expected the job handler to be called with payload %q, got %q", `{"a":1}`, result.Payload)
+	}
+}
+
 func TestHooksBindsCount(t *testing.T) {
 	app, _ := tests.NewTestApp()
 	defer app.Cleanup()
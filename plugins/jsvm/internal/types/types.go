@@ -61,6 +61,40 @@ declare function cronAdd(
  */
 declare function cronRemove(jobId: string): void;
 
+// -------------------------------------------------------------------
+// jobBinds
+// -------------------------------------------------------------------
+
+/**
+ * JobHandler registers a new background job handler.
+ *
+ * If a job handler with the specified name already exist, it will be
+ * replaced with the new one.
+ *
+ * Throwing an error will reschedule the job for another attempt
+ * (until its maxAttempts is reached).
+ *
+ * Example:
+ *
+ * ` + "```" + `js
+ * jobHandler("sendWelcomeEmail", (job) => {
+ *     const payload = JSON.parse(toString(job.payload))
+ *     console.log("Sending welcome email to", payload.email)
+ * })
+ *
+ * // somewhere in a route or hook handler
+ * $app.enqueue("sendWelcomeEmail", { email: "test@example.com" })
+ * ` + "```" + `
+ *
+ * _Note that this method is available only in hb_hooks context._
+ *
+ * @group HanzoBase
+ */
+declare function jobHandler(
+  name:    string,
+  handler: (job: core.Job) => void,
+): void;
+
 // -------------------------------------------------------------------
 // routerBinds
 // -------------------------------------------------------------------
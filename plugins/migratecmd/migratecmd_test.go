@@ -38,6 +38,9 @@ migrate((app) => {
     "authToken": {
       "duration": 604800
     },
+    "authTokenSigning": {
+      "algorithm": "HS256"
+    },
     "confirmEmailChangeTemplate": {
       "body": "<p>Hello,</p>\n<p>Click on the button below to confirm your new email address.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-email-change/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Confirm new email</a>\n</p>\n<p><i>If you didn't ask to change your email address, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
       "subject": "Confirm your {APP_NAME} new email address"
@@ -47,6 +50,7 @@ migrate((app) => {
     "emailChangeToken": {
       "duration": 1800
     },
+    "fieldRules": null,
     "fields": [
       {
         "autogeneratePattern": "[a-z0-9]{15}",
@@ -122,6 +126,9 @@ migrate((app) => {
     "fileToken": {
       "duration": 180
     },
+    "fullTextSearch": {
+      "fields": []
+    },
     "id": "@TEST_RANDOM",
     "indexes": [
       "create index test on new_name (id)",
@@ -133,6 +140,7 @@ migrate((app) => {
     "mfa": {
       "duration": 1800,
       "enabled": false,
+      "requireTOTP": false,
       "rule": ""
     },
     "name": "new_name",
@@ -167,7 +175,25 @@ migrate((app) => {
       "body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
       "subject": "Reset your {APP_NAME} password"
     },
+    "revisions": {
+      "enabled": false
+    },
+    "sessions": {
+      "accessTokenDuration": 900,
+      "duration": 2592000,
+      "enabled": false
+    },
+    "softDelete": {
+      "enabled": false,
+      "maxDays": 0
+    },
     "system": true,
+    "totp": {
+      "digits": 6,
+      "enabled": false,
+      "issuer": "",
+      "period": 30
+    },
     "type": "auth",
     "updateRule": null,
     "verificationTemplate": {
@@ -177,7 +203,16 @@ migrate((app) => {
     "verificationToken": {
       "duration": 259200
     },
-    "viewRule": "id = \"1\""
+    "viewRule": "id = \"1\"",
+    "webauthn": {
+      "duration": 300,
+      "enabled": false,
+      "origins": null,
+      "requireSignCount": false,
+      "rpId": "",
+      "rpName": "",
+      "userVerification": "preferred"
+    }
   });
 
   return app.save(collection);
@@ -214,6 +249,9 @@ func init() {
 			"authToken": {
 				"duration": 604800
 			},
+			"authTokenSigning": {
+				"algorithm": "HS256"
+			},
 			"confirmEmailChangeTemplate": {
 				"body": "<p>Hello,</p>\n<p>Click on the button below to confirm your new email address.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-email-change/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Confirm new email</a>\n</p>\n<p><i>If you didn't ask to change your email address, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 				"subject": "Confirm your {APP_NAME} new email address"
@@ -223,6 +261,7 @@ func init() {
 			"emailChangeToken": {
 				"duration": 1800
 			},
+			"fieldRules": null,
 			"fields": [
 				{
 					"autogeneratePattern": "[a-z0-9]{15}",
@@ -298,6 +337,9 @@ func init() {
 			"fileToken": {
 				"duration": 180
 			},
+			"fullTextSearch": {
+				"fields": []
+			},
 			"id": "@TEST_RANDOM",
 			"indexes": [
 				"create index test on new_name (id)",
@@ -309,6 +351,7 @@ func init() {
 			"mfa": {
 				"duration": 1800,
 				"enabled": false,
+				"requireTOTP": false,
 				"rule": ""
 			},
 			"name": "new_name",
@@ -343,7 +386,25 @@ func init() {
 				"body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 				"subject": "Reset your {APP_NAME} password"
 			},
+			"revisions": {
+				"enabled": false
+			},
+			"sessions": {
+				"accessTokenDuration": 900,
+				"duration": 2592000,
+				"enabled": false
+			},
+			"softDelete": {
+				"enabled": false,
+				"maxDays": 0
+			},
 			"system": true,
+			"totp": {
+				"digits": 6,
+				"enabled": false,
+				"issuer": "",
+				"period": 30
+			},
 			"type": "auth",
 			"updateRule": null,
 			"verificationTemplate": {
@@ -353,7 +414,16 @@ func init() {
 			"verificationToken": {
 				"duration": 259200
 			},
-			"viewRule": "id = \"1\""
+			"viewRule": "id = \"1\"",
+			"webauthn": {
+				"duration": 300,
+				"enabled": false,
+				"origins": null,
+				"requireSignCount": false,
+				"rpId": "",
+				"rpName": "",
+				"userVerification": "preferred"
+			}
 		}` + "`" + `
 
 		collection := &core.Collection{}
@@ -479,6 +549,9 @@ migrate((app) => {
     "authToken": {
       "duration": 604800
     },
+    "authTokenSigning": {
+      "algorithm": "HS256"
+    },
     "confirmEmailChangeTemplate": {
       "body": "<p>Hello,</p>\n<p>Click on the button below to confirm your new email address.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-email-change/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Confirm new email</a>\n</p>\n<p><i>If you didn't ask to change your email address, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
       "subject": "Confirm your {APP_NAME} new email address"
@@ -488,6 +561,7 @@ migrate((app) => {
     "emailChangeToken": {
       "duration": 1800
     },
+    "fieldRules": null,
     "fields": [
       {
         "autogeneratePattern": "[a-z0-9]{15}",
@@ -563,6 +637,9 @@ migrate((app) => {
     "fileToken": {
       "duration": 180
     },
+    "fullTextSearch": {
+      "fields": []
+    },
     "id": "@TEST_RANDOM",
     "indexes": [
       "create index test on test123 (id)",
@@ -574,6 +651,7 @@ migrate((app) => {
     "mfa": {
       "duration": 1800,
       "enabled": false,
+      "requireTOTP": false,
       "rule": ""
     },
     "name": "test123",
@@ -608,7 +686,25 @@ migrate((app) => {
       "body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
       "subject": "Reset your {APP_NAME} password"
     },
+    "revisions": {
+      "enabled": false
+    },
+    "sessions": {
+      "accessTokenDuration": 900,
+      "duration": 2592000,
+      "enabled": false
+    },
+    "softDelete": {
+      "enabled": false,
+      "maxDays": 0
+    },
     "system": false,
+    "totp": {
+      "digits": 6,
+      "enabled": false,
+      "issuer": "",
+      "period": 30
+    },
     "type": "auth",
     "updateRule": null,
     "verificationTemplate": {
@@ -618,7 +714,16 @@ migrate((app) => {
     "verificationToken": {
       "duration": 259200
     },
-    "viewRule": "id = \"1\""
+    "viewRule": "id = \"1\"",
+    "webauthn": {
+      "duration": 300,
+      "enabled": false,
+      "origins": null,
+      "requireSignCount": false,
+      "rpId": "",
+      "rpName": "",
+      "userVerification": "preferred"
+    }
   });
 
   return app.save(collection);
@@ -658,6 +763,9 @@ func init() {
 			"authToken": {
 				"duration": 604800
 			},
+			"authTokenSigning": {
+				"algorithm": "HS256"
+			},
 			"confirmEmailChangeTemplate": {
 				"body": "<p>Hello,</p>\n<p>Click on the button below to confirm your new email address.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-email-change/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Confirm new email</a>\n</p>\n<p><i>If you didn't ask to change your email address, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 				"subject": "Confirm your {APP_NAME} new email address"
@@ -667,6 +775,7 @@ func init() {
 			"emailChangeToken": {
 				"duration": 1800
 			},
+			"fieldRules": null,
 			"fields": [
 				{
 					"autogeneratePattern": "[a-z0-9]{15}",
@@ -742,6 +851,9 @@ func init() {
 			"fileToken": {
 				"duration": 180
 			},
+			"fullTextSearch": {
+				"fields": []
+			},
 			"id": "@TEST_RANDOM",
 			"indexes": [
 				"create index test on test123 (id)",
@@ -753,6 +865,7 @@ func init() {
 			"mfa": {
 				"duration": 1800,
 				"enabled": false,
+				"requireTOTP": false,
 				"rule": ""
 			},
 			"name": "test123",
@@ -787,7 +900,25 @@ func init() {
 				"body": "<p>Hello,</p>\n<p>Click on the button below to reset your password.</p>\n<p>\n  <a class=\"btn\" href=\"{APP_URL}/_/#/auth/confirm-password-reset/{TOKEN}\" target=\"_blank\" rel=\"noopener\">Reset password</a>\n</p>\n<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>\n<p>\n  Thanks,<br/>\n  {APP_NAME} team\n</p>",
 				"subject": "Reset your {APP_NAME} password"
 			},
+			"revisions": {
+				"enabled": false
+			},
+			"sessions": {
+				"accessTokenDuration": 900,
+				"duration": 2592000,
+				"enabled": false
+			},
+			"softDelete": {
+				"enabled": false,
+				"maxDays": 0
+			},
 			"system": false,
+			"totp": {
+				"digits": 6,
+				"enabled": false,
+				"issuer": "",
+				"period": 30
+			},
 			"type": "auth",
 			"updateRule": null,
 			"verificationTemplate": {
@@ -797,7 +928,16 @@ func init() {
 			"verificationToken": {
 				"duration": 259200
 			},
-			"viewRule": "id = \"1\""
+			"viewRule": "id = \"1\"",
+			"webauthn": {
+				"duration": 300,
+				"enabled": false,
+				"origins": null,
+				"requireSignCount": false,
+				"rpId": "",
+				"rpName": "",
+				"userVerification": "preferred"
+			}
 		}` + "`" + `
 
 		collection := &core.Collection{}
@@ -708,6 +708,22 @@ func NewTestAppWithConfig(config core.BaseAppConfig) (*TestApp, error) {
 		Priority: -99999,
 	})
 
+	t.OnRecordAuthWithWebAuthnRequest().Bind(&hook.Handler[*core.RecordAuthWithWebAuthnRequestEvent]{
+		Func: func(e *core.RecordAuthWithWebAuthnRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithWebAuthnRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
+	t.OnRecordAuthWithTOTPRequest().Bind(&hook.Handler[*core.RecordAuthWithTOTPRequestEvent]{
+		Func: func(e *core.RecordAuthWithTOTPRequestEvent) error {
+			t.registerEventCall("OnRecordAuthWithTOTPRequest")
+			return e.Next()
+		},
+		Priority: -99999,
+	})
+
 	t.OnRecordsListRequest().Bind(&hook.Handler[*core.RecordsListRequestEvent]{
 		Func: func(e *core.RecordsListRequestEvent) error {
 			t.registerEventCall("OnRecordsListRequest")
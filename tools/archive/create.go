@@ -3,6 +3,8 @@ package archive
 import (
 	"archive/zip"
 	"compress/flate"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
 	"io/fs"
@@ -16,6 +18,10 @@ import (
 // You can specify skipPaths to skip/ignore certain directories and files (relative to src)
 // preventing adding them in the final archive.
 func Create(src string, dest string, skipPaths ...string) error {
+	return create(src, dest, nil, skipPaths...)
+}
+
+func create(src string, dest string, manifest *Manifest, skipPaths ...string) error {
 	if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
 		return err
 	}
@@ -32,7 +38,17 @@ func Create(src string, dest string, skipPaths ...string) error {
 		return flate.NewWriter(out, flate.BestSpeed)
 	})
 
-	err = zipAddFS(zw, os.DirFS(src), skipPaths...)
+	var checksums map[string]string
+	if manifest != nil {
+		checksums = map[string]string{}
+		skipPaths = append(skipPaths, ManifestName)
+	}
+
+	err = zipAddFS(zw, os.DirFS(src), checksums, skipPaths...)
+	if err == nil && manifest != nil {
+		manifest.Files = checksums
+		err = zipAddManifest(zw, manifest)
+	}
 	if err != nil {
 		// try to cleanup at least the created zip file
 		return errors.Join(err, zw.Close(), zf.Close(), os.Remove(dest))
@@ -42,7 +58,9 @@ func Create(src string, dest string, skipPaths ...string) error {
 }
 
 // note remove after similar method is added in the std lib (https://github.com/golang/go/issues/54898)
-func zipAddFS(w *zip.Writer, fsys fs.FS, skipPaths ...string) error {
+//
+// If checksums is not nil, it is populated with the sha256 checksums of the added files.
+func zipAddFS(w *zip.Writer, fsys fs.FS, checksums map[string]string, skipPaths ...string) error {
 	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -84,7 +102,17 @@ func zipAddFS(w *zip.Writer, fsys fs.FS, skipPaths ...string) error {
 		}
 		defer f.Close()
 
-		_, err = io.Copy(fw, f)
+		// note: only the regular files are extracted and checksumed
+		if checksums == nil || !d.Type().IsRegular() {
+			_, err = io.Copy(fw, f)
+			return err
+		}
+
+		hash := sha256.New()
+
+		_, err = io.Copy(io.MultiWriter(fw, hash), f)
+
+		checksums[name] = hex.EncodeToString(hash.Sum(nil))
 
 		return err
 	})
@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ManifestName is the name of the archive root manifest file.
+const ManifestName = ".manifest.json"
+
+// ErrMissingManifest is returned when verifying a dir without manifest file.
+var ErrMissingManifest = errors.New("missing archive manifest")
+
+// Manifest describes the content of an archive created with [CreateWithManifest].
+type Manifest struct {
+	// Meta holds arbitrary archive information (e.g. the version of the app that created it).
+	Meta map[string]string `json:"meta"`
+
+	// Files holds the sha256 checksums of all archived files indexed by their slash separated path.
+	Files map[string]string `json:"files"`
+}
+
+// CreateWithManifest is similar to [Create] but additionally stores
+// in the archive root a [ManifestName] file with the provided meta
+// and the checksums of all archived files.
+//
+// The manifest could be used after extraction to verify the archive integrity (see [VerifyManifest]).
+func CreateWithManifest(src string, dest string, meta map[string]string, skipPaths ...string) error {
+	return create(src, dest, &Manifest{Meta: meta}, skipPaths...)
+}
+
+func zipAddManifest(w *zip.Writer, manifest *Manifest) error {
+	raw, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	fw, err := w.Create(ManifestName)
+	if err != nil {
+		return err
+	}
+
+	_, err = fw.Write(raw)
+
+	return err
+}
+
+// VerifyManifest checks whether the files of the extracted archive dir
+// match exactly with the ones listed in its manifest and returns the parsed manifest.
+//
+// Returns [ErrMissingManifest] if the dir doesn't have a manifest file.
+func VerifyManifest(dir string) (*Manifest, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrMissingManifest
+		}
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(raw, manifest); err != nil {
+		return nil, fmt.Errorf("invalid archive manifest: %w", err)
+	}
+
+	var total int
+
+	err = fs.WalkDir(os.DirFS(dir), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || name == ManifestName {
+			return nil
+		}
+
+		expected, ok := manifest.Files[name]
+		if !ok {
+			return fmt.Errorf("unexpected archive file %q", name)
+		}
+
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+
+		if hex.EncodeToString(hash.Sum(nil)) != expected {
+			return fmt.Errorf("checksum mismatch for archive file %q", name)
+		}
+
+		total++
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if total != len(manifest.Files) {
+		return nil, fmt.Errorf("expected %d archive files, found %d", len(manifest.Files), total)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,85 @@
+package archive_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hanzoai/backendPB/tools/archive"
+)
+
+func TestCreateWithManifest(t *testing.T) {
+	testDir := createTestDir(t)
+	defer os.RemoveAll(testDir)
+
+	zipPath := filepath.Join(t.TempDir(), "hb_test.zip")
+
+	if err := archive.CreateWithManifest(testDir, zipPath, map[string]string{"version": "v1"}, "a/b/c"); err != nil {
+		t.Fatal(err)
+	}
+
+	extractedPath := filepath.Join(t.TempDir(), "extracted")
+
+	if err := archive.Extract(zipPath, extractedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := archive.VerifyManifest(extractedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.Meta["version"] != "v1" {
+		t.Fatalf("Expected version meta v1, got %v", manifest.Meta)
+	}
+
+	expectedFiles := []string{"test", "test2", "a/test", "a/b/sub1"}
+	if len(manifest.Files) != len(expectedFiles) {
+		t.Fatalf("Expected %d manifest files, got %v", len(expectedFiles), manifest.Files)
+	}
+	for _, name := range expectedFiles {
+		if _, ok := manifest.Files[name]; !ok {
+			t.Fatalf("Missing manifest file %q", name)
+		}
+	}
+
+	t.Run("tampered file", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(extractedPath, "test"), []byte("changed"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.WriteFile(filepath.Join(extractedPath, "test"), nil, 0644)
+
+		if _, err := archive.VerifyManifest(extractedPath); err == nil {
+			t.Fatal("Expected checksum error")
+		}
+	})
+
+	t.Run("extra file", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(extractedPath, "extra"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(filepath.Join(extractedPath, "extra"))
+
+		if _, err := archive.VerifyManifest(extractedPath); err == nil {
+			t.Fatal("Expected unexpected file error")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(extractedPath, "test2")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := archive.VerifyManifest(extractedPath); err == nil {
+			t.Fatal("Expected missing file error")
+		}
+	})
+
+	t.Run("missing manifest", func(t *testing.T) {
+		_, err := archive.VerifyManifest(testDir)
+		if !errors.Is(err, archive.ErrMissingManifest) {
+			t.Fatalf("Expected ErrMissingManifest, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// pageDiffMagic is the header signature of the page diff streams.
+var pageDiffMagic = []byte("HBPD1")
+
+// pageDiffEnd marks the end of the page diff entries.
+const pageDiffEnd = ^uint32(0)
+
+// maxPageSize is the max supported page size (the max SQLite page size).
+const maxPageSize = 65536
+
+// PageHash is the checksum of a single file page.
+type PageHash [sha256.Size]byte
+
+// PageWriter defines the destination of an applied page diff.
+//
+// It is usually an *os.File.
+type PageWriter interface {
+	io.WriterAt
+	Truncate(size int64) error
+}
+
+// WritePageDiff reads src in pageSize chunks and writes to dst
+// (gzip compressed) only the pages whose checksum differs from prevHashes.
+//
+// Pass nil prevHashes to write a full copy of src.
+//
+// Returns the checksums of all src pages (to be used as prevHashes for the next diff)
+// and the number of the written pages.
+func WritePageDiff(dst io.Writer, src io.Reader, pageSize int, prevHashes []PageHash) ([]PageHash, int, error) {
+	if pageSize <= 0 || pageSize > maxPageSize {
+		return nil, 0, fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	zw, err := gzip.NewWriterLevel(dst, gzip.BestSpeed)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	header := make([]byte, 0, len(pageDiffMagic)+4)
+	header = append(header, pageDiffMagic...)
+	header = binary.BigEndian.AppendUint32(header, uint32(pageSize))
+	if _, err := zw.Write(header); err != nil {
+		return nil, 0, err
+	}
+
+	var hashes []PageHash
+	var changed int
+	var totalSize uint64
+
+	page := make([]byte, pageSize)
+	entryHeader := make([]byte, 8)
+
+	for i := uint32(0); ; i++ {
+		n, readErr := io.ReadFull(src, page)
+		if n > 0 {
+			totalSize += uint64(n)
+
+			hash := PageHash(sha256.Sum256(page[:n]))
+			hashes = append(hashes, hash)
+
+			if int(i) >= len(prevHashes) || prevHashes[i] != hash {
+				binary.BigEndian.PutUint32(entryHeader[:4], i)
+				binary.BigEndian.PutUint32(entryHeader[4:], uint32(n))
+				if _, err := zw.Write(entryHeader); err != nil {
+					return nil, 0, err
+				}
+				if _, err := zw.Write(page[:n]); err != nil {
+					return nil, 0, err
+				}
+				changed++
+			}
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, 0, readErr
+		}
+	}
+
+	// end marker followed by the total src size
+	footer := binary.BigEndian.AppendUint32(nil, pageDiffEnd)
+	footer = binary.BigEndian.AppendUint64(footer, totalSize)
+	if _, err := zw.Write(footer); err != nil {
+		return nil, 0, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, 0, err
+	}
+
+	return hashes, changed, nil
+}
+
+// ApplyPageDiff applies the page diff stream created with [WritePageDiff] to dst.
+//
+// The diffs must be applied in the same order as they were created,
+// starting with a full copy (aka. a diff with nil prevHashes).
+func ApplyPageDiff(dst PageWriter, src io.Reader) error {
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	r := bufio.NewReader(zr)
+
+	header := make([]byte, len(pageDiffMagic)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("failed to read the page diff header: %w", err)
+	}
+
+	if !bytes.Equal(header[:len(pageDiffMagic)], pageDiffMagic) {
+		return errors.New("invalid page diff signature")
+	}
+
+	pageSize := int64(binary.BigEndian.Uint32(header[len(pageDiffMagic):]))
+	if pageSize <= 0 || pageSize > maxPageSize {
+		return fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	page := make([]byte, pageSize)
+	entryHeader := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, entryHeader[:4]); err != nil {
+			return fmt.Errorf("failed to read the page diff entry: %w", err)
+		}
+
+		index := binary.BigEndian.Uint32(entryHeader[:4])
+		if index == pageDiffEnd {
+			break
+		}
+
+		if _, err := io.ReadFull(r, entryHeader[4:]); err != nil {
+			return fmt.Errorf("failed to read the page diff entry: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(entryHeader[4:]))
+		if size <= 0 || size > pageSize {
+			return fmt.Errorf("invalid page %d size %d", index, size)
+		}
+
+		if _, err := io.ReadFull(r, page[:size]); err != nil {
+			return fmt.Errorf("failed to read page %d: %w", index, err)
+		}
+
+		if _, err := dst.WriteAt(page[:size], int64(index)*pageSize); err != nil {
+			return err
+		}
+	}
+
+	footer := make([]byte, 8)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return fmt.Errorf("failed to read the page diff footer: %w", err)
+	}
+
+	// remove the leftover pages (if the src has shrunk)
+	return dst.Truncate(int64(binary.BigEndian.Uint64(footer)))
+}
@@ -0,0 +1,85 @@
+package archive_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hanzoai/backendPB/tools/archive"
+)
+
+func TestWritePageDiffInvalidPageSize(t *testing.T) {
+	for _, size := range []int{-1, 0, 65537} {
+		if _, _, err := archive.WritePageDiff(&bytes.Buffer{}, bytes.NewReader([]byte("abc")), size, nil); err == nil {
+			t.Fatalf("Expected error for page size %d", size)
+		}
+	}
+}
+
+func TestApplyPageDiffInvalid(t *testing.T) {
+	dst, err := os.Create(filepath.Join(t.TempDir(), "dst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	if err := archive.ApplyPageDiff(dst, bytes.NewReader([]byte("invalid"))); err == nil {
+		t.Fatal("Expected invalid diff error")
+	}
+}
+
+func TestPageDiff(t *testing.T) {
+	const pageSize = 4
+
+	dst, err := os.Create(filepath.Join(t.TempDir(), "dst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	var hashes []archive.PageHash
+
+	scenarios := []struct {
+		name            string
+		src             string
+		expectedChanged int
+	}{
+		{"initial full copy", "aaaabbbbcccc", 3},
+		{"no changes", "aaaabbbbcccc", 0},
+		{"changed middle page", "aaaaxxxxcccc", 1},
+		{"appended partial page", "aaaaxxxxccccdd", 1},
+		{"shrunk", "aaaay", 1},
+		{"empty", "", 0},
+		{"regrow", "zzzzbbbb", 2},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			diff := &bytes.Buffer{}
+
+			newHashes, changed, err := archive.WritePageDiff(diff, bytes.NewReader([]byte(s.src)), pageSize, hashes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hashes = newHashes
+
+			if changed != s.expectedChanged {
+				t.Fatalf("Expected %d changed pages, got %d", s.expectedChanged, changed)
+			}
+
+			if err := archive.ApplyPageDiff(dst, diff); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := os.ReadFile(dst.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(result) != s.src {
+				t.Fatalf("Expected %q, got %q", s.src, result)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ type Cron struct {
 	ticker     *time.Ticker
 	startTimer *time.Timer
 	tickerDone chan bool
+	onRun      func(run Run)
 	jobs       []*Job
 	interval   time.Duration
 	mux        sync.RWMutex
@@ -64,6 +65,18 @@ func (c *Cron) SetTimezone(l *time.Location) {
 	c.timezone = l
 }
 
+// OnRun registers a listener function that is called after every
+// completed cron job run (scheduled or manual).
+//
+// The listener is called in the job goroutine so it is advised
+// to keep it lightweight. Pass nil to unregister the listener.
+func (c *Cron) OnRun(fn func(run Run)) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.onRun = fn
+}
+
 // MustAdd is similar to Add() but panic on failure.
 func (c *Cron) MustAdd(jobId string, cronExpr string, run func()) {
 	if err := c.Add(jobId, cronExpr, run); err != nil {
@@ -83,6 +96,20 @@ func (c *Cron) Add(jobId string, cronExpr string, fn func()) error {
 		return errors.New("failed to add new cron job: fn must be non-nil function")
 	}
 
+	return c.AddWithError(jobId, cronExpr, func() error {
+		fn()
+		return nil
+	})
+}
+
+// AddWithError is similar to Add() but allows the job function
+// to return an error that will be reported as part of the job run result
+// (see [Cron.OnRun]).
+func (c *Cron) AddWithError(jobId string, cronExpr string, fn func() error) error {
+	if fn == nil {
+		return errors.New("failed to add new cron job: fn must be non-nil function")
+	}
+
 	schedule, err := NewSchedule(cronExpr)
 	if err != nil {
 		return fmt.Errorf("failed to add new cron job: %w", err)
@@ -101,6 +128,7 @@ func (c *Cron) Add(jobId string, cronExpr string, fn func()) error {
 		id:       jobId,
 		fn:       fn,
 		schedule: schedule,
+		cron:     c,
 	})
 
 	return nil
@@ -222,7 +250,18 @@ func (c *Cron) runDue(t time.Time) {
 
 	for _, j := range c.jobs {
 		if j.schedule.IsDue(moment) {
-			go j.Run()
+			go j.run(TriggerSchedule)
 		}
 	}
 }
+
+// notifyRun calls the registered run listener (if any).
+func (c *Cron) notifyRun(run Run) {
+	c.mux.RLock()
+	fn := c.onRun
+	c.mux.RUnlock()
+
+	if fn != nil {
+		fn(run)
+	}
+}
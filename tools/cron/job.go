@@ -1,12 +1,33 @@
 package cron
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Run holds information about a single completed cron job run.
+type Run struct {
+	Started  time.Time
+	Error    error
+	JobId    string
+	Trigger  string
+	Duration time.Duration
+}
 
 // Job defines a single registered cron job.
 type Job struct {
-	fn       func()
+	fn       func() error
 	schedule *Schedule
+	cron     *Cron
 	id       string
+	running  int32
 }
 
 // Id returns the cron job id.
@@ -19,11 +40,53 @@ func (j *Job) Expression() string {
 	return j.schedule.rawExpr
 }
 
-// Run runs the cron job function.
+// IsRunning reports whether the cron job is currently executing.
+func (j *Job) IsRunning() bool {
+	return atomic.LoadInt32(&j.running) == 1
+}
+
+// Run manually runs the cron job function.
+//
+// The call is a no-op if the job is already running.
 func (j *Job) Run() {
-	if j.fn != nil {
-		j.fn()
+	j.run(TriggerManual)
+}
+
+// run executes the cron job function (if not already running) and
+// notifies the parent cron run listener (if any) with the run result.
+//
+// Panics are recovered and reported as run errors.
+func (j *Job) run(trigger string) bool {
+	if !atomic.CompareAndSwapInt32(&j.running, 0, 1) {
+		return false // still running from a previous call
 	}
+	defer atomic.StoreInt32(&j.running, 0)
+
+	result := Run{
+		JobId:   j.id,
+		Trigger: trigger,
+		Started: time.Now(),
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result.Error = fmt.Errorf("cron job panic: %v", r)
+			}
+		}()
+
+		if j.fn != nil {
+			result.Error = j.fn()
+		}
+	}()
+
+	result.Duration = time.Since(result.Started)
+
+	if j.cron != nil {
+		j.cron.notifyRun(result)
+	}
+
+	return true
 }
 
 // MarshalJSON implements [json.Marshaler] and export the current
@@ -2,6 +2,8 @@ package cron
 
 import (
 	"encoding/json"
+	"errors"
+	"sync/atomic"
 	"testing"
 )
 
@@ -40,7 +42,7 @@ func TestJobRun(t *testing.T) {
 	calls := ""
 
 	j1 := Job{}
-	j2 := Job{fn: func() { calls += "2" }}
+	j2 := Job{fn: func() error { calls += "2"; return nil }}
 
 	j1.Run()
 	j2.Run()
@@ -51,6 +53,89 @@ func TestJobRun(t *testing.T) {
 	}
 }
 
+func TestJobRunOverlap(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var calls int32
+
+	j := Job{fn: func() error {
+		atomic.AddInt32(&calls, 1)
+		close(started)
+		<-release
+		return nil
+	}}
+
+	done := make(chan bool)
+	go func() {
+		done <- j.run(TriggerSchedule)
+	}()
+
+	<-started
+
+	if !j.IsRunning() {
+		t.Fatal("Expected the job to be running")
+	}
+
+	if j.run(TriggerManual) {
+		t.Fatal("Expected the overlapping run to be skipped")
+	}
+
+	close(release)
+
+	if !<-done {
+		t.Fatal("Expected the first run to complete")
+	}
+
+	if j.IsRunning() {
+		t.Fatal("Expected the job to be no longer running")
+	}
+
+	if total := atomic.LoadInt32(&calls); total != 1 {
+		t.Fatalf("Expected 1 call, got %d", total)
+	}
+}
+
+func TestJobRunResult(t *testing.T) {
+	c := New()
+
+	runs := []Run{}
+	c.OnRun(func(run Run) {
+		runs = append(runs, run)
+	})
+
+	c.AddWithError("error", "* * * * *", func() error {
+		return errors.New("test")
+	})
+	c.Add("panic", "* * * * *", func() {
+		panic("test")
+	})
+	c.Add("ok", "* * * * *", func() {})
+
+	for _, j := range c.Jobs() {
+		j.Run()
+	}
+
+	if len(runs) != 3 {
+		t.Fatalf("Expected 3 runs, got %d", len(runs))
+	}
+
+	for _, run := range runs {
+		if run.Trigger != TriggerManual {
+			t.Errorf("[%s] Expected trigger %q, got %q", run.JobId, TriggerManual, run.Trigger)
+		}
+
+		if run.Started.IsZero() {
+			t.Errorf("[%s] Expected non-zero start time", run.JobId)
+		}
+
+		hasErr := run.Error != nil
+		if expectErr := run.JobId != "ok"; hasErr != expectErr {
+			t.Errorf("[%s] Expected hasErr %v, got %v (%v)", run.JobId, expectErr, hasErr, run.Error)
+		}
+	}
+}
+
 func TestJobMarshalJSON(t *testing.T) {
 	s, err := NewSchedule("1 2 3 4 5")
 	if err != nil {
@@ -0,0 +1,197 @@
+package dbutils
+
+import (
+	"fmt"
+)
+
+// DialectSQLite is the name of the builtin SQLite db dialect.
+const DialectSQLite = "sqlite"
+
+// Dialect defines the db engine specific SQL expressions and statements
+// used by the app (JSON helpers, column definitions, schema introspection, etc.).
+//
+// Note that only the SQLite dialect is currently shipped because the system
+// migrations, the logs stats, the geoDistance filter function and the default
+// connection pragmas are still SQLite specific, meaning that a custom dialect
+// is expected to target a SQLite compatible engine (e.g. libSQL).
+//
+// The returned expressions could contain the dbx quoting placeholders
+// (`[[column]]`, `{{table}}`) and the statements are expected
+// to be bound with the documented `{:param}` placeholders.
+type Dialect interface {
+	// Name returns the dialect name (e.g. "sqlite").
+	Name() string
+
+	// JSONEach returns a table-valued expression with the array items
+	// of the specified column (normalizing the non-json column values).
+	//
+	// The items are accessible via the "value" column of the table alias.
+	JSONEach(column string) string
+
+	// JSONEachExpr is similar to JSONEach but for a raw SQL expression
+	// holding a serialized JSON array (e.g. a bound placeholder).
+	JSONEachExpr(expr string) string
+
+	// JSONArrayLength returns an array length expression of the specified column
+	// (0 for empty string or NULL column values).
+	JSONArrayLength(column string) string
+
+	// JSONExtract returns an expression that extracts the value at
+	// the specified path (e.g. "a.b[0].c") of the column.
+	JSONExtract(column string, path string) string
+
+	// ToJSONArray returns an expression that converts the single value
+	// column into a JSON array ('[]' for empty or NULL values).
+	ToJSONArray(column string) string
+
+	// JSONArrayLast returns an expression with the last element
+	// of the JSON array column ('' for empty arrays).
+	JSONArrayLast(column string) string
+
+	// JSONColumnType returns the column type used for storing JSON data.
+	JSONColumnType() string
+
+	// PrimaryKeyColumnType returns the full column definition of the text primary key
+	// (with a random default value as last resort fallback for raw sql inserts).
+	PrimaryKeyColumnType() string
+
+	// TableInfoQuery returns a query with the columns of a single table or view
+	// (bound with {:tableName}).
+	//
+	// The query result rows must have the "cid", "name", "type", "notnull", "dflt_value" and "pk" columns.
+	TableInfoQuery() string
+
+	// TableIndexesQuery returns a query with the non-primary indexes of a single table
+	// (bound with {:tableName}).
+	//
+	// The query result rows must have the "name" and "sql" columns.
+	TableIndexesQuery() string
+
+	// IndexTableQuery returns a query with the table name of the index
+	// matching case-insensitively {:indexName}.
+	//
+	// The query result rows must have the "tbl_name" column.
+	IndexTableQuery() string
+
+	// HasTableQuery returns a query that checks whether a table or view
+	// matching case-insensitively {:tableName} exists.
+	HasTableQuery() string
+
+	// ViewsQuery returns a query with all views.
+	//
+	// The query result rows must have the "name" and "sql" (the full create view statement) columns.
+	ViewsQuery() string
+
+	// OptimizeQuery returns the statement that is executed after schema changes
+	// and periodically to update the query planner statistics (if any).
+	OptimizeQuery() string
+
+	// CheckpointQuery returns the statement that is executed periodically
+	// and before backups to flush the db write-ahead log (if any).
+	CheckpointQuery() string
+
+	// SupportsFullTextSearch reports whether the collections full-text search indexes
+	// are supported by the dialect.
+	SupportsFullTextSearch() bool
+}
+
+// -------------------------------------------------------------------
+
+// ensures that SQLiteDialect satisfies the Dialect interface.
+var _ Dialect = SQLiteDialect{}
+
+// SQLiteDialect defines the default SQLite db dialect.
+type SQLiteDialect struct{}
+
+// Name implements [Dialect.Name] interface method.
+func (SQLiteDialect) Name() string {
+	return DialectSQLite
+}
+
+// JSONEach implements [Dialect.JSONEach] interface method.
+func (SQLiteDialect) JSONEach(column string) string {
+	return JSONEach(column)
+}
+
+// JSONEachExpr implements [Dialect.JSONEachExpr] interface method.
+func (SQLiteDialect) JSONEachExpr(expr string) string {
+	return "json_each(" + expr + ")"
+}
+
+// JSONArrayLength implements [Dialect.JSONArrayLength] interface method.
+func (SQLiteDialect) JSONArrayLength(column string) string {
+	return JSONArrayLength(column)
+}
+
+// JSONExtract implements [Dialect.JSONExtract] interface method.
+func (SQLiteDialect) JSONExtract(column string, path string) string {
+	return JSONExtract(column, path)
+}
+
+// ToJSONArray implements [Dialect.ToJSONArray] interface method.
+func (SQLiteDialect) ToJSONArray(column string) string {
+	return fmt.Sprintf(
+		`(CASE WHEN COALESCE([[%s]], '') = '' THEN '[]' ELSE (CASE WHEN json_valid([[%s]]) AND json_type([[%s]]) == 'array' THEN [[%s]] ELSE json_array([[%s]]) END) END)`,
+		column, column, column, column, column,
+	)
+}
+
+// JSONArrayLast implements [Dialect.JSONArrayLast] interface method.
+func (SQLiteDialect) JSONArrayLast(column string) string {
+	return fmt.Sprintf(
+		`(CASE WHEN COALESCE([[%s]], '[]') = '[]' THEN '' ELSE (CASE WHEN json_valid([[%s]]) AND json_type([[%s]]) == 'array' THEN COALESCE(json_extract([[%s]], '$[#-1]'), '') ELSE [[%s]] END) END)`,
+		column, column, column, column, column,
+	)
+}
+
+// JSONColumnType implements [Dialect.JSONColumnType] interface method.
+func (SQLiteDialect) JSONColumnType() string {
+	return "JSON"
+}
+
+// PrimaryKeyColumnType implements [Dialect.PrimaryKeyColumnType] interface method.
+func (SQLiteDialect) PrimaryKeyColumnType() string {
+	return "TEXT PRIMARY KEY DEFAULT ('r'||lower(hex(randomblob(7)))) NOT NULL"
+}
+
+// TableInfoQuery implements [Dialect.TableInfoQuery] interface method.
+func (SQLiteDialect) TableInfoQuery() string {
+	return "SELECT * FROM PRAGMA_TABLE_INFO({:tableName})"
+}
+
+// TableIndexesQuery implements [Dialect.TableIndexesQuery] interface method.
+func (SQLiteDialect) TableIndexesQuery() string {
+	return "SELECT [[name]], [[sql]] FROM {{sqlite_master}} WHERE [[sql]] IS NOT NULL AND [[type]] = 'index' AND [[tbl_name]] = {:tableName}"
+}
+
+// IndexTableQuery implements [Dialect.IndexTableQuery] interface method.
+func (SQLiteDialect) IndexTableQuery() string {
+	return "SELECT [[tbl_name]] FROM {{sqlite_master}} WHERE [[type]] = 'index' AND LOWER([[name]]) = LOWER({:indexName})"
+}
+
+// HasTableQuery implements [Dialect.HasTableQuery] interface method.
+func (SQLiteDialect) HasTableQuery() string {
+	return "SELECT (1) FROM {{sqlite_schema}} WHERE [[type]] IN ('table', 'view') AND LOWER([[name]]) = LOWER({:tableName}) LIMIT 1"
+}
+
+// ViewsQuery implements [Dialect.ViewsQuery] interface method.
+func (SQLiteDialect) ViewsQuery() string {
+	return "SELECT [[name]], [[sql]] FROM {{sqlite_master}} WHERE [[sql]] IS NOT NULL AND [[type]] = 'view'"
+}
+
+// OptimizeQuery implements [Dialect.OptimizeQuery] interface method.
+//
+// (https://www.sqlite.org/pragma.html#pragma_optimize)
+func (SQLiteDialect) OptimizeQuery() string {
+	return "PRAGMA optimize"
+}
+
+// CheckpointQuery implements [Dialect.CheckpointQuery] interface method.
+func (SQLiteDialect) CheckpointQuery() string {
+	return "PRAGMA wal_checkpoint(TRUNCATE)"
+}
+
+// SupportsFullTextSearch implements [Dialect.SupportsFullTextSearch] interface method.
+func (SQLiteDialect) SupportsFullTextSearch() bool {
+	return true
+}
@@ -0,0 +1,34 @@
+package dbutils_test
+
+import (
+	"testing"
+
+	"github.com/hanzoai/backendPB/tools/dbutils"
+)
+
+func TestSQLiteDialect(t *testing.T) {
+	d := dbutils.SQLiteDialect{}
+
+	if d.Name() != dbutils.DialectSQLite {
+		t.Fatalf("Expected name %q, got %q", dbutils.DialectSQLite, d.Name())
+	}
+
+	// should match the package level helpers
+	if v := d.JSONEach("a.b"); v != dbutils.JSONEach("a.b") {
+		t.Fatalf("Unexpected JSONEach %q", v)
+	}
+	if v := d.JSONArrayLength("a.b"); v != dbutils.JSONArrayLength("a.b") {
+		t.Fatalf("Unexpected JSONArrayLength %q", v)
+	}
+	if v := d.JSONExtract("a.b", "c[0]"); v != dbutils.JSONExtract("a.b", "c[0]") {
+		t.Fatalf("Unexpected JSONExtract %q", v)
+	}
+
+	if v := d.JSONEachExpr("{:p}"); v != "json_each({:p})" {
+		t.Fatalf("Unexpected JSONEachExpr %q", v)
+	}
+
+	if !d.SupportsFullTextSearch() {
+		t.Fatal("Expected full-text search to be supported")
+	}
+}
@@ -0,0 +1,260 @@
+package search
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hanzoai/dbx"
+)
+
+// ErrInvalidCursor is returned when the provided search cursor
+// is malformed or doesn't match the current sort fields.
+var ErrInvalidCursor = errors.New("invalid or expired cursor")
+
+// keysetField defines a single resolved cursor (keyset) sort field.
+type keysetField struct {
+	identifier string
+	direction  string
+}
+
+// resolveKeysetFields resolves the provided sort fields into keyset fields.
+//
+// The countCol field is always appended as last tiebreaker (if not already sorted by it)
+// to guarantee stable ordering between the cursor pages.
+func resolveKeysetFields(fieldResolver FieldResolver, sort []SortField, countCol string) ([]keysetField, error) {
+	fields := make([]SortField, 0, len(sort)+1)
+	fields = append(fields, sort...)
+
+	hasTiebreaker := false
+	for _, f := range sort {
+		if f.Name == countCol {
+			hasTiebreaker = true
+			break
+		}
+	}
+	if !hasTiebreaker {
+		fields = append(fields, SortField{Name: countCol, Direction: SortAsc})
+	}
+
+	result := make([]keysetField, 0, len(fields))
+
+	for _, f := range fields {
+		if f.Name == randomSortKey || f.Name == rowidSortKey || f.Name == rankSortKey {
+			return nil, fmt.Errorf("sort field %q is not supported with cursor pagination", f.Name)
+		}
+
+		r, err := fieldResolver.Resolve(f.Name)
+		if err != nil || len(r.Params) > 0 || r.Identifier == "" || strings.ToLower(r.Identifier) == "null" {
+			return nil, fmt.Errorf("invalid sort field %q", f.Name)
+		}
+
+		direction := SortAsc
+		if strings.EqualFold(f.Direction, SortDesc) {
+			direction = SortDesc
+		}
+
+		result = append(result, keysetField{identifier: r.Identifier, direction: direction})
+	}
+
+	return result, nil
+}
+
+// encodeCursor encodes the provided keyset values into an opaque cursor string.
+func encodeCursor(values []any) (string, error) {
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor decodes the provided opaque cursor string into
+// its keyset values (one for each keyset field).
+func decodeCursor(cursor string, totalFields int) ([]any, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	values := []any{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber() // preserve the int64 precision
+	if err := decoder.Decode(&values); err != nil || len(values) != totalFields {
+		return nil, ErrInvalidCursor
+	}
+
+	for i, v := range values {
+		switch n := v.(type) {
+		case json.Number:
+			if iv, err := n.Int64(); err == nil {
+				values[i] = iv
+			} else if fv, err := n.Float64(); err == nil {
+				values[i] = fv
+			} else {
+				return nil, ErrInvalidCursor
+			}
+		case string, bool, nil:
+			// supported scalar
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return values, nil
+}
+
+// buildKeysetExpr builds a "row after the cursor" expression for the provided
+// keyset fields and their last seen values, e.g. for "-a,id":
+//
+//	(a < {:cursor0} OR a IS NULL) OR (a = {:cursor0} AND id > {:cursor1})
+//
+// NULL values follow the SQLite ordering (first for ASC and last for DESC).
+func buildKeysetExpr(fields []keysetField, values []any) dbx.Expression {
+	ors := make([]dbx.Expression, 0, len(fields))
+
+	for i, f := range fields {
+		ands := make([]dbx.Expression, 0, i+1)
+
+		// all previous fields must be equal
+		for j := 0; j < i; j++ {
+			ands = append(ands, keysetEqExpr(fields[j].identifier, j, values[j]))
+		}
+
+		after := keysetAfterExpr(f, i, values[i])
+		if after == nil {
+			continue // nothing can come after
+		}
+		ands = append(ands, after)
+
+		ors = append(ors, dbx.Enclose(dbx.And(ands...)))
+	}
+
+	if len(ors) == 0 {
+		return dbx.NewExp("1=0")
+	}
+
+	return dbx.Enclose(dbx.Or(ors...))
+}
+
+func keysetEqExpr(identifier string, index int, value any) dbx.Expression {
+	if value == nil {
+		return dbx.NewExp(identifier + " IS NULL")
+	}
+
+	placeholder := "cursor" + strconv.Itoa(index)
+
+	return dbx.NewExp(identifier+" = {:"+placeholder+"}", dbx.Params{placeholder: value})
+}
+
+func keysetAfterExpr(f keysetField, index int, value any) dbx.Expression {
+	if value == nil {
+		if f.direction == SortDesc {
+			return nil // NULLs are last
+		}
+		return dbx.NewExp(f.identifier + " IS NOT NULL")
+	}
+
+	placeholder := "cursor" + strconv.Itoa(index)
+	params := dbx.Params{placeholder: value}
+
+	if f.direction == SortDesc {
+		return dbx.NewExp("("+f.identifier+" < {:"+placeholder+"} OR "+f.identifier+" IS NULL)", params)
+	}
+
+	return dbx.NewExp(f.identifier+" > {:"+placeholder+"}", params)
+}
+
+// lastItemColumnValue returns the total number of the provided items
+// (pointer to a slice of models) and the db column value of the last item.
+//
+// The item could be a struct (or pointer to struct) with "db" tagged fields
+// or a map (eg. [dbx.NullStringMap]).
+func lastItemColumnValue(items any, column string) (int, any, bool) {
+	rv := reflect.ValueOf(items)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return 0, nil, false
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Slice {
+		return 0, nil, false
+	}
+
+	total := rv.Len()
+	if total == 0 {
+		return 0, nil, false
+	}
+
+	v, ok := columnValue(rv.Index(total-1), column)
+
+	return total, v, ok
+}
+
+func columnValue(rv reflect.Value, column string) (any, bool) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+
+		v := rv.MapIndex(reflect.ValueOf(column).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+
+		if valuer, ok := v.Interface().(driver.Valuer); ok {
+			dv, err := valuer.Value()
+			return dv, err == nil
+		}
+
+		return v.Interface(), true
+	case reflect.Struct:
+		rt := rv.Type()
+
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+
+			if field.Anonymous {
+				if v, ok := columnValue(rv.Field(i), column); ok {
+					return v, true
+				}
+				continue
+			}
+
+			if !field.IsExported() {
+				continue
+			}
+
+			name, _, _ := strings.Cut(field.Tag.Get("db"), ",")
+			if name == column && rv.Field(i).CanInterface() {
+				return rv.Field(i).Interface(), true
+			}
+		}
+	}
+
+	return nil, false
+}
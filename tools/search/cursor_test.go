@@ -0,0 +1,197 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/hanzoai/dbx"
+)
+
+func TestProviderCursorPagination(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	// extra rows with duplicated sort values
+	testDB.Insert("test", dbx.Params{"id": 3, "test1": 2, "test2": "test2.3"}).Execute()
+	testDB.Insert("test", dbx.Params{"id": 4, "test1": 2, "test2": "test2.4"}).Execute()
+	testDB.Insert("test", dbx.Params{"id": 5, "test1": 5, "test2": "test2.5"}).Execute()
+
+	scenarios := []struct {
+		name        string
+		sort        string
+		expectedIds []int
+	}{
+		{"default id tiebreaker", "", []int{1, 2, 3, 4, 5}},
+		{"asc with duplicated values", "test1", []int{1, 2, 3, 4, 5}},
+		{"desc with duplicated values", "-test1", []int{5, 2, 3, 4, 1}},
+		{"desc with desc tiebreaker", "-test1,-id", []int{5, 4, 3, 2, 1}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			ids := []int{}
+			cursor := ""
+
+			for i := 0; i < 10; i++ {
+				items := []cursorTestItem{}
+
+				query := "perPage=2&cursor=" + cursor
+				if s.sort != "" {
+					query += "&sort=" + s.sort
+				}
+
+				// the base ORDER BY should be ignored in cursor mode
+				result, err := NewProvider(&testFieldResolver{}).
+					Query(testDB.Select("*").From("test").OrderBy("test2 DESC")).
+					ParseAndExec(query, &items)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if result.TotalItems != 5 {
+					t.Fatalf("Expected the total of all pages to be returned, got %d", result.TotalItems)
+				}
+
+				for _, item := range items {
+					ids = append(ids, item.Id)
+				}
+
+				if result.NextCursor == "" {
+					break
+				}
+				cursor = result.NextCursor
+			}
+
+			if !slices.Equal(ids, s.expectedIds) {
+				t.Fatalf("Expected ids %v, got %v", s.expectedIds, ids)
+			}
+		})
+	}
+}
+
+func TestProviderCursorConcurrentInsert(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	provider := func(cursor string) (*Result, []cursorTestItem) {
+		items := []cursorTestItem{}
+
+		result, err := NewProvider(&testFieldResolver{}).
+			Query(testDB.Select("*").From("test")).
+			ParseAndExec("perPage=1&sort=-id&cursor="+cursor, &items)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return result, items
+	}
+
+	first, items := provider("")
+	if len(items) != 1 || items[0].Id != 2 || first.NextCursor == "" {
+		t.Fatalf("Unexpected first page %v (%q)", items, first.NextCursor)
+	}
+
+	// a new row at the beginning shouldn't shift the next page
+	testDB.Insert("test", dbx.Params{"id": 3, "test1": 3}).Execute()
+
+	_, items = provider(first.NextCursor)
+	if len(items) != 1 || items[0].Id != 1 {
+		t.Fatalf("Expected the second page to contain item 1, got %v", items)
+	}
+}
+
+func TestProviderCursorErrors(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	validCursor, _ := encodeCursor([]any{1, 1})
+
+	scenarios := []struct {
+		name  string
+		query string
+	}{
+		{"malformed cursor", "cursor=invalid"},
+		{"cursor with different number of sort fields", "sort=test1,test2&cursor=" + validCursor},
+		{"cursor with non-scalar value", "cursor=" + mustEncodeTestCursor(t, []any{map[string]any{"a": 1}, 1})},
+		{"unsupported random sort", "sort=@random&cursor="},
+		{"unsupported rowid sort", "sort=@rowid&cursor="},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			_, err := NewProvider(&testFieldResolver{}).
+				Query(testDB.Select("*").From("test")).
+				ParseAndExec(s.query, &[]cursorTestItem{})
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func mustEncodeTestCursor(t *testing.T, values []any) string {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+type cursorTestItem struct {
+	Id int `db:"id" json:"id"`
+}
+
+func TestLastItemColumnValue(t *testing.T) {
+	type pointerEmbeddedItem struct {
+		*cursorTestItem
+	}
+
+	scenarios := []struct {
+		name          string
+		items         any
+		expectedTotal int
+		expectedValue any
+		expectedOk    bool
+	}{
+		{"nil", nil, 0, nil, false},
+		{"non-slice", &cursorTestItem{Id: 1}, 0, nil, false},
+		{"empty slice", &[]cursorTestItem{}, 0, nil, false},
+		{"struct items", &[]cursorTestItem{{Id: 1}, {Id: 2}}, 2, 2, true},
+		{"struct pointer items", &[]*cursorTestItem{{Id: 1}, {Id: 2}, {Id: 3}}, 3, 3, true},
+		{"embedded pointer struct items", &[]pointerEmbeddedItem{{&cursorTestItem{Id: 5}}}, 1, 5, true},
+		{"nil last item", &[]*cursorTestItem{{Id: 1}, nil}, 2, nil, false},
+		{"map items", &[]map[string]any{{"id": "a"}, {"id": "b"}}, 2, "b", true},
+		{"map items with missing column", &[]map[string]any{{"title": "a"}}, 1, nil, false},
+		{"NullStringMap items", &[]dbx.NullStringMap{{"id": {String: "a", Valid: true}}}, 1, "a", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			total, value, ok := lastItemColumnValue(s.items, "id")
+
+			if total != s.expectedTotal {
+				t.Fatalf("Expected total %d, got %d", s.expectedTotal, total)
+			}
+
+			if ok != s.expectedOk {
+				t.Fatalf("Expected ok %v, got %v", s.expectedOk, ok)
+			}
+
+			if value != s.expectedValue {
+				t.Fatalf("Expected value %v, got %v", s.expectedValue, value)
+			}
+		})
+	}
+}
@@ -85,7 +85,12 @@ func (f FilterData) BuildExprWithLimit(
 		return buildParsedFilterExpr(data, fieldResolver, &maxExpressions)
 	}
 
-	data, err := fexpr.Parse(raw)
+	normalized, err := normalizeFilter(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fexpr.Parse(normalized)
 	if err != nil {
 		// depending on the users demand we may allow empty expressions
 		// (aka. expressions consisting only of whitespaces or comments)
@@ -153,6 +158,11 @@ func buildParsedFilterExpr(data []fexpr.ExprGroup, fieldResolver FieldResolver,
 }
 
 func resolveTokenizedExpr(expr fexpr.Expr, fieldResolver FieldResolver) (dbx.Expression, error) {
+	// full-text search expression (eg. "title @@ 'lorem'")
+	if expr.Left.Type == fexpr.TokenIdentifier && strings.HasPrefix(expr.Left.Literal, fullTextSearchIdentifierPrefix) {
+		return resolveFullTextSearchExpr(expr, fieldResolver)
+	}
+
 	lResult, lErr := resolveToken(expr.Left, fieldResolver)
 	if lErr != nil || lResult.Identifier == "" {
 		return nil, fmt.Errorf("invalid left operand %q - %v", expr.Left.Literal, lErr)
@@ -166,6 +176,26 @@ func resolveTokenizedExpr(expr fexpr.Expr, fieldResolver FieldResolver) (dbx.Exp
 	return buildResolversExpr(lResult, expr.Op, rResult)
 }
 
+func resolveFullTextSearchExpr(expr fexpr.Expr, fieldResolver FieldResolver) (dbx.Expression, error) {
+	field := strings.TrimPrefix(expr.Left.Literal, fullTextSearchIdentifierPrefix)
+
+	ftsResolver, ok := fieldResolver.(FullTextSearchResolver)
+	if !ok {
+		return nil, fmt.Errorf("full-text search is not supported for field %q", field)
+	}
+
+	if expr.Op != fexpr.SignEq {
+		return nil, fmt.Errorf("invalid full-text search operator %q", expr.Op)
+	}
+
+	query, err := resolveToken(expr.Right, fieldResolver)
+	if err != nil || query.Identifier == "" {
+		return nil, fmt.Errorf("invalid full-text search query %q - %v", expr.Right.Literal, err)
+	}
+
+	return ftsResolver.ResolveFullTextSearch(field, query)
+}
+
 func buildResolversExpr(
 	left *ResolverResult,
 	op fexpr.SignOp,
@@ -252,6 +282,12 @@ func buildResolversExpr(
 func resolveToken(token fexpr.Token, fieldResolver FieldResolver) (*ResolverResult, error) {
 	switch token.Type {
 	case fexpr.TokenIdentifier:
+		// check for functions
+		// ---
+		if strings.HasPrefix(token.Literal, functionIdentifierPrefix) {
+			return resolveFunctionToken(token.Literal, fieldResolver)
+		}
+
 		// check for macros
 		// ---
 		if macroFunc, ok := identifierMacros[token.Literal]; ok {
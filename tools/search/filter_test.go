@@ -121,6 +121,43 @@ func TestFilterDataBuildExpr(t *testing.T) {
 			false,
 			"([[test4_1]] > {:TEST} AND [[test4_2]] > {:TEST} AND [[test4_3]] > {:TEST} AND [[test4_4]] > {:TEST} AND [[test4_5]] > {:TEST} AND [[test4_6]] > {:TEST} AND [[test4_7]] > {:TEST} AND [[test4_9]] > {:TEST} AND [[test4_9]] > {:TEST} AND [[test4_10]] > {:TEST} AND [[test4_11]] > {:TEST} AND [[test4_12]] > {:TEST} AND [[test4_13]] > {:TEST} AND [[test4_14]] > {:TEST})",
 		},
+		{
+			"unknown function",
+			"missing(test1) > 1",
+			true,
+			"",
+		},
+		{
+			"geoDistance with invalid number of arguments",
+			"geoDistance(test1, test2, 1) > 1",
+			true,
+			"",
+		},
+		{
+			"geoDistance with invalid argument token",
+			"geoDistance(test1, test2, 'a', 2) > 1",
+			true,
+			"",
+		},
+		{
+			"geoDistance with unknown argument identifier",
+			"geoDistance(test1, test2, missing, 2) > 1",
+			true,
+			"",
+		},
+		{
+			"geoDistance with valid arguments",
+			"geoDistance(test1, test2, 1.5, -2.5) < 10 && geoDistance(test5.lon,test5.lat,test1,test2) >= 0",
+			false,
+			"((6371 * acos(min(1.0, max(-1.0, cos(radians([[test2]])) * cos(radians({:TEST})) * cos(radians({:TEST}) - radians([[test1]])) + sin(radians([[test2]])) * sin(radians({:TEST}))))) < {:TEST} AND " +
+				"(6371 * acos(min(1.0, max(-1.0, cos(radians(JSON_EXTRACT([[test5]], '$.lat'))) * cos(radians([[test2]])) * cos(radians([[test1]]) - radians(JSON_EXTRACT([[test5]], '$.lon'))) + sin(radians(JSON_EXTRACT([[test5]], '$.lat'))) * sin(radians([[test2]]))))) >= {:TEST})",
+		},
+		{
+			"full-text search with resolver that doesn't support it",
+			"test1 @@ 'example'",
+			true,
+			"",
+		},
 		{
 			"complex expression",
 			"((test1 > 1) || (test2 != 2)) && test3 ~ '%%example' && test4_sub = null",
@@ -269,6 +306,66 @@ func TestFilterDataBuildExprWithLimit(t *testing.T) {
 	}
 }
 
+func TestFilterDataGeoDistanceExec(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	db := dbx.NewFromDB(sqlDB, "sqlite")
+	defer db.Close()
+
+	_, err = db.CreateTable("places", map[string]string{
+		"name":  "text",
+		"point": "json",
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	places := map[string]string{
+		"sofia":   `{"lon":23.32,"lat":42.69}`,
+		"plovdiv": `{"lon":24.74,"lat":42.13}`, // ~130km from Sofia
+		"london":  `{"lon":-0.12,"lat":51.50}`,
+	}
+	for name, point := range places {
+		if _, err := db.Insert("places", dbx.Params{"name": name, "point": point}).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolver := search.NewSimpleFieldResolver(`^point\.\w+$`)
+
+	scenarios := []struct {
+		filter   search.FilterData
+		expected []string
+	}{
+		{"geoDistance(point.lon, point.lat, 23.32, 42.69) < 25", []string{"sofia"}},
+		{"geoDistance(point.lon, point.lat, 23.32, 42.69) < 200", []string{"plovdiv", "sofia"}},
+		{"geoDistance(point.lon, point.lat, 23.32, 42.69) > 1000", []string{"london"}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(string(s.filter), func(t *testing.T) {
+			expr, err := s.filter.BuildExpr(resolver)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := []string{}
+			err = db.Select("name").From("places").AndWhere(expr).OrderBy("name ASC").Column(&names)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(names, ",") != strings.Join(s.expected, ",") {
+				t.Fatalf("Expected %v, got %v", s.expected, names)
+			}
+		})
+	}
+}
+
 func TestLikeParamsWrapping(t *testing.T) {
 	// create a dummy db
 	sqlDB, err := sql.Open("sqlite", "file::memory:?cache=shared")
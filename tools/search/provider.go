@@ -1,6 +1,7 @@
 package search
 
 import (
+	"database/sql"
 	"errors"
 	"math"
 	"net/url"
@@ -48,21 +49,24 @@ const (
 	SortQueryParam      string = "sort"
 	FilterQueryParam    string = "filter"
 	SkipTotalQueryParam string = "skipTotal"
+	CursorQueryParam    string = "cursor"
 )
 
 // Result defines the returned search result structure.
 type Result struct {
-	Items      any `json:"items"`
-	Page       int `json:"page"`
-	PerPage    int `json:"perPage"`
-	TotalItems int `json:"totalItems"`
-	TotalPages int `json:"totalPages"`
+	Items      any    `json:"items"`
+	Page       int    `json:"page"`
+	PerPage    int    `json:"perPage"`
+	TotalItems int    `json:"totalItems"`
+	TotalPages int    `json:"totalPages"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // Provider represents a single configured search provider instance.
 type Provider struct {
 	fieldResolver      FieldResolver
 	query              *dbx.SelectQuery
+	cursor             *string
 	countCol           string
 	sort               []SortField
 	filter             []FilterData
@@ -148,6 +152,18 @@ func (s *Provider) PerPage(perPage int) *Provider {
 	return s
 }
 
+// Cursor enables the cursor (keyset) pagination mode and sets
+// the last returned `nextCursor` of the current search provider.
+//
+// An empty cursor string returns the first page.
+//
+// In cursor mode the `page` field is ignored, the base query ORDER BY is replaced
+// with the provider sort fields and the count column is always used as last sort tiebreaker.
+func (s *Provider) Cursor(cursor string) *Provider {
+	s.cursor = &cursor
+	return s
+}
+
 // Sort sets the `sort` field of the current search provider.
 func (s *Provider) Sort(sort []SortField) *Provider {
 	s.sort = sort
@@ -210,6 +226,10 @@ func (s *Provider) Parse(urlQuery string) error {
 		s.PerPage(v)
 	}
 
+	if params.Has(CursorQueryParam) {
+		s.Cursor(params.Get(CursorQueryParam))
+	}
+
 	if raw := params.Get(SortQueryParam); raw != "" {
 		for _, sortField := range ParseSortFromString(raw) {
 			s.AddSort(sortField)
@@ -233,12 +253,18 @@ func (s *Provider) Exec(items any) (*Result, error) {
 	// shallow clone the provider's query
 	modelsQuery := *s.query
 
+	// limit the "@rank" sort field only to the provider filters
+	var scopedResolver FieldResolver = s.fieldResolver
+	if _, ok := s.fieldResolver.(FullTextSearchResolver); ok {
+		scopedResolver = NewFullTextSearchScope(s.fieldResolver)
+	}
+
 	// build filters
 	for _, f := range s.filter {
 		if len(f) > MaxFilterLength {
 			return nil, ErrFilterLengthLimit
 		}
-		expr, err := f.BuildExprWithLimit(s.fieldResolver, s.maxFilterExprLimit)
+		expr, err := f.BuildExprWithLimit(scopedResolver, s.maxFilterExprLimit)
 		if err != nil {
 			return nil, err
 		}
@@ -251,11 +277,23 @@ func (s *Provider) Exec(items any) (*Result, error) {
 	if len(s.sort) > s.maxSortExprLimit {
 		return nil, ErrSortExprLimit
 	}
+
+	var keyset []keysetField
+	if s.cursor != nil {
+		var err error
+		keyset, err = resolveKeysetFields(scopedResolver, s.sort, s.countCol)
+		if err != nil {
+			return nil, err
+		}
+
+		modelsQuery.OrderBy( /* reset */ )
+	}
+
 	for _, sortField := range s.sort {
 		if len(sortField.Name) > MaxSortFieldLength {
 			return nil, ErrSortFieldLengthLimit
 		}
-		expr, err := sortField.BuildExpr(s.fieldResolver)
+		expr, err := sortField.BuildExpr(scopedResolver)
 		if err != nil {
 			return nil, err
 		}
@@ -272,13 +310,19 @@ func (s *Provider) Exec(items any) (*Result, error) {
 		}
 	}
 
+	// ensure stable ordering with the count column as last tiebreaker
+	if len(keyset) > len(s.sort) {
+		tiebreaker := keyset[len(keyset)-1]
+		modelsQuery.AndOrderBy(tiebreaker.identifier + " " + tiebreaker.direction)
+	}
+
 	// apply field resolver query modifications (if any)
 	if err := s.fieldResolver.UpdateQuery(&modelsQuery); err != nil {
 		return nil, err
 	}
 
 	// normalize page
-	if s.page <= 0 {
+	if s.page <= 0 || s.cursor != nil {
 		s.page = 1
 	}
 
@@ -295,6 +339,17 @@ func (s *Provider) Exec(items any) (*Result, error) {
 
 	// prepare a count query from the base one
 	countQuery := modelsQuery // shallow clone
+
+	// limit the models to the ones after the cursor
+	// (the count query is not affected and returns the total of all pages)
+	if s.cursor != nil && *s.cursor != "" {
+		values, err := decodeCursor(*s.cursor, len(keyset))
+		if err != nil {
+			return nil, err
+		}
+
+		modelsQuery.AndWhere(buildKeysetExpr(keyset, values))
+	}
 	countExec := func() error {
 		queryInfo := countQuery.Info()
 		countCol := s.countCol
@@ -347,9 +402,65 @@ func (s *Provider) Exec(items any) (*Result, error) {
 		Items:      items,
 	}
 
+	if s.cursor != nil {
+		nextCursor, err := s.nextCursor(modelsQuery, keyset, items)
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = nextCursor
+	}
+
 	return result, nil
 }
 
+// nextCursor returns the cursor of the last item from the fetched page
+// (or empty string if the page is not full and there are no more items).
+//
+// The keyset values are loaded by the last item primary key (aka. countCol)
+// so that the cursor always matches the returned item even if the rows were
+// changed after the page query.
+func (s *Provider) nextCursor(modelsQuery dbx.SelectQuery, keyset []keysetField, items any) (string, error) {
+	total, lastPK, ok := lastItemColumnValue(items, s.countCol)
+	if total < s.perPage {
+		return "", nil // no more items
+	}
+	if !ok {
+		return "", errors.New("failed to resolve the last item " + s.countCol + " value")
+	}
+
+	pkCol := s.countCol
+	if queryInfo := modelsQuery.Info(); len(queryInfo.From) > 0 {
+		pkCol = queryInfo.From[0] + "." + pkCol
+	}
+
+	selects := make([]string, len(keyset))
+	for i, f := range keyset {
+		selects[i] = f.identifier
+	}
+
+	values := make([]any, len(keyset))
+	dest := make([]any, len(keyset))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	// note: modelsQuery is shallow cloned and slice/map in-place modifications should be avoided
+	err := modelsQuery.
+		Select(selects...).
+		AndWhere(dbx.NewExp("[["+pkCol+"]] = {:cursorPK}", dbx.Params{"cursorPK": lastPK})).
+		Limit(1).
+		Offset(0).
+		Row(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil // the last item was deleted in the meantime
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return encodeCursor(values)
+}
+
 // ParseAndExec is a short convenient method to trigger both
 // `Parse()` and `Exec()` in a single call.
 func (s *Provider) ParseAndExec(urlQuery string, modelsSlice any) (*Result, error) {
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -46,6 +47,106 @@ type FieldResolver interface {
 	Resolve(field string) (*ResolverResult, error)
 }
 
+// FullTextSearchResolver defines an optional FieldResolver interface
+// for resolving the full-text search filter expressions (eg. "title @@ 'lorem'")
+// and the related "@rank" sort field.
+type FullTextSearchResolver interface {
+	// ResolveFullTextSearch returns the db expression that matches
+	// the specified field against the resolved full-text search query.
+	ResolveFullTextSearch(field string, query *ResolverResult) (dbx.Expression, error)
+
+	// ResolveFullTextSearchRank returns the rank identifier of the specified
+	// full-text search match (the smaller the value, the better the match).
+	ResolveFullTextSearchRank(field string, query *ResolverResult) (*ResolverResult, error)
+}
+
+// FullTextSearchScope wraps a FieldResolver and keeps track of the
+// full-text search expressions resolved through the scope itself so that
+// the "@rank" sort field could be resolved only against them
+// (and not against other expressions resolved by the same FieldResolver, eg. API rules).
+//
+// Use [ResolverAs] to access the other optional interfaces
+// implemented by the wrapped FieldResolver.
+type FullTextSearchScope struct {
+	FieldResolver
+
+	lastField string
+	lastQuery *ResolverResult
+}
+
+// NewFullTextSearchScope creates a new FullTextSearchScope for the provided FieldResolver.
+func NewFullTextSearchScope(fieldResolver FieldResolver) *FullTextSearchScope {
+	return &FullTextSearchScope{FieldResolver: fieldResolver}
+}
+
+// Unwrap returns the wrapped FieldResolver.
+func (s *FullTextSearchScope) Unwrap() FieldResolver {
+	return s.FieldResolver
+}
+
+// ResolveFullTextSearch implements the [FullTextSearchResolver] interface
+// by forwarding the call to the wrapped FieldResolver.
+func (s *FullTextSearchScope) ResolveFullTextSearch(field string, query *ResolverResult) (dbx.Expression, error) {
+	ftsResolver, ok := s.FieldResolver.(FullTextSearchResolver)
+	if !ok {
+		return nil, fmt.Errorf("full-text search is not supported for field %q", field)
+	}
+
+	expr, err := ftsResolver.ResolveFullTextSearch(field, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lastField = field
+	s.lastQuery = query
+
+	return expr, nil
+}
+
+// ResolveFullTextSearchRank implements the [FullTextSearchResolver] interface
+// by resolving the rank of the last full-text search expression from the current scope.
+func (s *FullTextSearchScope) ResolveFullTextSearchRank(field string, query *ResolverResult) (*ResolverResult, error) {
+	ftsResolver, ok := s.FieldResolver.(FullTextSearchResolver)
+	if !ok {
+		return nil, errors.New("full-text search is not supported")
+	}
+
+	if field == "" {
+		if s.lastQuery == nil {
+			return nil, errors.New("the @rank sort field requires a full-text search filter expression")
+		}
+
+		field = s.lastField
+		query = s.lastQuery
+	}
+
+	return ftsResolver.ResolveFullTextSearchRank(field, query)
+}
+
+// ResolverAs finds the first FieldResolver in the resolver's Unwrap chain
+// that implements T (usually an optional FieldResolver interface).
+//
+// It is similar to [errors.As] and allows accessing the optional interfaces
+// of a FieldResolver wrapped by another one (eg. [FullTextSearchScope]).
+func ResolverAs[T any](fieldResolver FieldResolver) (T, bool) {
+	for fieldResolver != nil {
+		if v, ok := fieldResolver.(T); ok {
+			return v, true
+		}
+
+		u, ok := fieldResolver.(interface{ Unwrap() FieldResolver })
+		if !ok {
+			break
+		}
+
+		fieldResolver = u.Unwrap()
+	}
+
+	var zero T
+
+	return zero, false
+}
+
 // NewSimpleFieldResolver creates a new `SimpleFieldResolver` with the
 // provided `allowedFields`.
 //
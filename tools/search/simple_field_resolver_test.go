@@ -85,3 +85,53 @@ func TestSimpleFieldResolverResolve(t *testing.T) {
 		})
 	}
 }
+
+type testFullTextSearchResolver struct {
+	*search.SimpleFieldResolver
+}
+
+func (r *testFullTextSearchResolver) ResolveFullTextSearch(field string, query *search.ResolverResult) (dbx.Expression, error) {
+	return dbx.NewExp("1=1"), nil
+}
+
+func (r *testFullTextSearchResolver) ResolveFullTextSearchRank(field string, query *search.ResolverResult) (*search.ResolverResult, error) {
+	return &search.ResolverResult{Identifier: "rank"}, nil
+}
+
+func (r *testFullTextSearchResolver) AllowedFields() []string {
+	return []string{"test"}
+}
+
+func TestResolverAs(t *testing.T) {
+	type allowedFieldsResolver interface {
+		AllowedFields() []string
+	}
+
+	base := &testFullTextSearchResolver{search.NewSimpleFieldResolver("test")}
+
+	scenarios := []struct {
+		name     string
+		resolver search.FieldResolver
+		expected bool
+	}{
+		{"nil resolver", nil, false},
+		{"non-matching resolver", search.NewSimpleFieldResolver("test"), false},
+		{"matching resolver", base, true},
+		{"wrapped matching resolver", search.NewFullTextSearchScope(base), true},
+		{"double wrapped matching resolver", search.NewFullTextSearchScope(search.NewFullTextSearchScope(base)), true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, ok := search.ResolverAs[allowedFieldsResolver](s.resolver)
+
+			if ok != s.expected {
+				t.Fatalf("Expected %v, got %v", s.expected, ok)
+			}
+
+			if ok && result.AllowedFields()[0] != "test" {
+				t.Fatalf("Expected the wrapped resolver, got %v", result)
+			}
+		})
+	}
+}
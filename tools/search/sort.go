@@ -8,6 +8,7 @@ import (
 const (
 	randomSortKey string = "@random"
 	rowidSortKey  string = "@rowid"
+	rankSortKey   string = "@rank"
 )
 
 // sort field directions
@@ -34,7 +35,20 @@ func (s *SortField) BuildExpr(fieldResolver FieldResolver) (string, error) {
 		return fmt.Sprintf("[[_rowid_]] %s", s.Direction), nil
 	}
 
-	result, err := fieldResolver.Resolve(s.Name)
+	var result *ResolverResult
+	var err error
+
+	// special case for the full-text search rank
+	if s.Name == rankSortKey {
+		ftsResolver, ok := fieldResolver.(FullTextSearchResolver)
+		if !ok {
+			return "", fmt.Errorf("invalid sort field %q", s.Name)
+		}
+		// resolve against the last full-text search match of the resolver scope (if any)
+		result, err = ftsResolver.ResolveFullTextSearchRank("", nil)
+	} else {
+		result, err = fieldResolver.Resolve(s.Name)
+	}
 
 	// invalidate empty fields and non-column identifiers
 	if err != nil || len(result.Params) > 0 || result.Identifier == "" || strings.ToLower(result.Identifier) == "null" {
@@ -0,0 +1,353 @@
+package search
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ganigeorgiev/fexpr"
+)
+
+// TokenFunctionFunc defines a filter function handler.
+//
+// argTokenResolverFunc could be used to resolve the function arguments
+// the same way as the regular filter expression operands.
+type TokenFunctionFunc func(
+	argTokenResolverFunc func(fexpr.Token) (*ResolverResult, error),
+	args ...fexpr.Token,
+) (*ResolverResult, error)
+
+// TokenFunctions contains the list of the supported filter functions
+// that could be used as expression operands (eg. "geoDistance(lonA, latA, lonB, latB) < 25").
+//
+// Each function argument is expected to be a single identifier, number or text token.
+var TokenFunctions = map[string]TokenFunctionFunc{
+	// geoDistance(lonA, latA, lonB, latB) calculates the Haversine
+	// distance between 2 points in kilometres (https://www.movable-type.co.uk/scripts/latlong.html).
+	//
+	// The returned distance is approximated and it is not suitable for
+	// high precision calculations but it should be enough for the
+	// most common "nearby" searches.
+	"geoDistance": func(argTokenResolverFunc func(fexpr.Token) (*ResolverResult, error), args ...fexpr.Token) (*ResolverResult, error) {
+		if len(args) != 4 {
+			return nil, fmt.Errorf("[geoDistance] expected 4 arguments, got %d", len(args))
+		}
+
+		resolvedArgs := make([]*ResolverResult, 4)
+		for i, arg := range args {
+			if arg.Type != fexpr.TokenIdentifier && arg.Type != fexpr.TokenNumber {
+				return nil, fmt.Errorf("[geoDistance] argument %d must be an identifier or number", i)
+			}
+
+			resolved, err := argTokenResolverFunc(arg)
+			if err != nil {
+				return nil, fmt.Errorf("[geoDistance] failed to resolve argument %d: %w", i, err)
+			}
+
+			resolvedArgs[i] = resolved
+		}
+
+		lonA := resolvedArgs[0].Identifier
+		latA := resolvedArgs[1].Identifier
+		lonB := resolvedArgs[2].Identifier
+		latB := resolvedArgs[3].Identifier
+
+		return &ResolverResult{
+			NoCoalesce: true,
+			// note: the acos argument is clamped to [-1, 1] to prevent
+			// returning NULL due to floating point rounding errors (eg. for identical points)
+			Identifier: `(6371 * acos(min(1.0, max(-1.0, ` +
+				`cos(radians(` + latA + `)) * cos(radians(` + latB + `)) * ` +
+				`cos(radians(` + lonB + `) - radians(` + lonA + `)) + ` +
+				`sin(radians(` + latA + `)) * sin(radians(` + latB + `))` +
+				`))))`,
+			Params: mergeParams(
+				resolvedArgs[0].Params,
+				resolvedArgs[1].Params,
+				resolvedArgs[2].Params,
+				resolvedArgs[3].Params,
+			),
+		}, nil
+	},
+}
+
+// -------------------------------------------------------------------
+
+// functionIdentifierPrefix is the prefix of the placeholder identifier
+// that normalizeFilter uses to replace the function calls.
+//
+// The function name and its raw hex encoded arguments are appended to the prefix
+// (eg. "@fn:geoDistance:6c6f6e2c6c6174") so that the result could be parsed as
+// a regular fexpr identifier without loosing any information.
+const functionIdentifierPrefix = "@fn:"
+
+// fullTextSearchIdentifierPrefix is the prefix that normalizeFilter
+// uses to mark the left operand of a full-text search expression
+// (eg. "title @@ 'lorem'" is normalized to "#fts:title = 'lorem'").
+const fullTextSearchIdentifierPrefix = "#fts:"
+
+// fullTextSearchOperator is the full-text search filter operator.
+const fullTextSearchOperator = "@@"
+
+// normalizeFilter rewrites the filter syntax extensions that are not
+// natively supported by the fexpr parser into fexpr compatible expressions:
+//
+//   - all function calls are replaced with special identifiers
+//     (eg. "geoDistance(a, b, c, d) < 10" -> "@fn:geoDistance:... < 10")
+//   - the full-text search operator is replaced with a marked equal expression
+//     (eg. "title @@ 'lorem'" -> "#fts:title = 'lorem'")
+//
+// Text literals and comments are left untouched.
+func normalizeFilter(raw string) (string, error) {
+	if !strings.Contains(raw, "(") && !strings.Contains(raw, fullTextSearchOperator) {
+		return raw, nil // nothing to normalize
+	}
+
+	rs := []rune(raw)
+	total := len(rs)
+
+	parts := make([]string, 0, 10)
+
+	// the index of the last operand part (aka. identifier, text or number)
+	// that could be used as full-text search operator left operand
+	lastOperandIndex := -1
+
+	for i := 0; i < total; i++ {
+		ch := rs[i]
+
+		// whitespace
+		if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' {
+			parts = append(parts, string(ch))
+			continue
+		}
+
+		// full-text search operator
+		if ch == '@' && i+1 < total && rs[i+1] == '@' {
+			if lastOperandIndex < 0 || !isIdentifierStartRune([]rune(parts[lastOperandIndex])[0]) || strings.HasPrefix(parts[lastOperandIndex], "@") {
+				return "", errors.New("the left operand of the full-text search operator must be a field identifier")
+			}
+
+			parts[lastOperandIndex] = fullTextSearchIdentifierPrefix + parts[lastOperandIndex]
+			parts = append(parts, "=")
+			lastOperandIndex = -1
+			i++
+			continue
+		}
+
+		// quoted text
+		if ch == '\'' || ch == '"' {
+			end := skipQuoted(rs, i)
+			parts = append(parts, string(rs[i:end]))
+			lastOperandIndex = len(parts) - 1
+			i = end - 1
+			continue
+		}
+
+		// comment
+		if ch == '/' && i+1 < total && rs[i+1] == '/' {
+			end := i
+			for end < total && rs[end] != '\n' {
+				end++
+			}
+			parts = append(parts, string(rs[i:end]))
+			i = end - 1
+			continue
+		}
+
+		// identifier (and eventually a function call)
+		if isIdentifierStartRune(ch) {
+			end := i
+			for end < total && (isIdentifierStartRune(rs[end]) || isDigitRune(rs[end]) || rs[end] == '.' || rs[end] == ':') {
+				// stop at the full-text search operator (eg. "title@@'lorem'")
+				if rs[end] == '@' && end+1 < total && rs[end+1] == '@' {
+					break
+				}
+				end++
+			}
+
+			name := string(rs[i:end])
+
+			if end >= total || rs[end] != '(' {
+				parts = append(parts, name)
+				lastOperandIndex = len(parts) - 1
+				i = end - 1
+				continue
+			}
+
+			closeIndex, err := findClosingParenthesis(rs, end)
+			if err != nil {
+				return "", err
+			}
+
+			args, err := normalizeFilter(string(rs[end+1 : closeIndex]))
+			if err != nil {
+				return "", err
+			}
+
+			var fn strings.Builder
+			fn.WriteString(functionIdentifierPrefix)
+			fn.WriteString(name)
+			if args = strings.TrimSpace(args); args != "" {
+				fn.WriteString(":")
+				fn.WriteString(hex.EncodeToString([]byte(args)))
+			}
+
+			parts = append(parts, fn.String())
+			lastOperandIndex = len(parts) - 1
+			i = closeIndex
+			continue
+		}
+
+		// number (skip to prevent treating the exponent or other suffixes as identifiers)
+		if isDigitRune(ch) {
+			end := i
+			for end < total && (isDigitRune(rs[end]) || rs[end] == '.') {
+				end++
+			}
+			parts = append(parts, string(rs[i:end]))
+			lastOperandIndex = len(parts) - 1
+			i = end - 1
+			continue
+		}
+
+		parts = append(parts, string(ch))
+		lastOperandIndex = -1
+	}
+
+	return strings.Join(parts, ""), nil
+}
+
+// resolveFunctionToken resolves a function identifier previously
+// generated by normalizeFilter.
+func resolveFunctionToken(literal string, fieldResolver FieldResolver) (*ResolverResult, error) {
+	name, encodedArgs, _ := strings.Cut(strings.TrimPrefix(literal, functionIdentifierPrefix), ":")
+
+	fn, ok := TokenFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	rawArgs, err := hex.DecodeString(encodedArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q function arguments: %w", name, err)
+	}
+
+	args, err := splitFunctionArgs(string(rawArgs))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q function arguments: %w", name, err)
+	}
+
+	return fn(func(t fexpr.Token) (*ResolverResult, error) {
+		return resolveToken(t, fieldResolver)
+	}, args...)
+}
+
+// splitFunctionArgs splits the raw comma separated function arguments
+// and scans each of them as a single fexpr token.
+func splitFunctionArgs(raw string) ([]fexpr.Token, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	rs := []rune(raw)
+	total := len(rs)
+
+	rawArgs := []string{}
+	start := 0
+	for i := 0; i < total; i++ {
+		switch rs[i] {
+		case '\'', '"':
+			i = skipQuoted(rs, i) - 1
+		case ',':
+			rawArgs = append(rawArgs, string(rs[start:i]))
+			start = i + 1
+		}
+	}
+	rawArgs = append(rawArgs, string(rs[start:]))
+
+	result := make([]fexpr.Token, 0, len(rawArgs))
+
+	for _, arg := range rawArgs {
+		var token fexpr.Token
+
+		scanner := fexpr.NewScanner(strings.NewReader(arg))
+		for {
+			t, err := scanner.Scan()
+			if err != nil {
+				return nil, err
+			}
+
+			if t.Type == fexpr.TokenEOF {
+				break
+			}
+
+			if t.Type == fexpr.TokenWS || t.Type == fexpr.TokenComment {
+				continue
+			}
+
+			if token.Type != "" {
+				return nil, fmt.Errorf("expected a single token per argument, got %q", strings.TrimSpace(arg))
+			}
+
+			token = t
+		}
+
+		if token.Type == "" {
+			return nil, errors.New("empty function argument")
+		}
+
+		result = append(result, token)
+	}
+
+	return result, nil
+}
+
+// skipQuoted returns the index right after the closing quote of
+// the quoted text starting at rs[start].
+func skipQuoted(rs []rune, start int) int {
+	quote := rs[start]
+
+	for i := start + 1; i < len(rs); i++ {
+		if rs[i] == '\\' {
+			i++ // skip the escaped char
+			continue
+		}
+
+		if rs[i] == quote {
+			return i + 1
+		}
+	}
+
+	return len(rs)
+}
+
+// findClosingParenthesis returns the index of the parenthesis that
+// closes the one located at rs[start].
+func findClosingParenthesis(rs []rune, start int) (int, error) {
+	depth := 0
+
+	for i := start; i < len(rs); i++ {
+		switch rs[i] {
+		case '\'', '"':
+			i = skipQuoted(rs, i) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, errors.New("invalid or incomplete function call - missing closing parenthesis")
+}
+
+func isIdentifierStartRune(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_' || ch == '@' || ch == '#'
+}
+
+func isDigitRune(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
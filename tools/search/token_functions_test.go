@@ -0,0 +1,247 @@
+package search
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ganigeorgiev/fexpr"
+)
+
+func TestNormalizeFilter(t *testing.T) {
+	encode := func(str string) string {
+		return hex.EncodeToString([]byte(str))
+	}
+
+	scenarios := []struct {
+		name        string
+		raw         string
+		expected    string
+		expectError bool
+	}{
+		{
+			"empty",
+			"",
+			"",
+			false,
+		},
+		{
+			"no functions",
+			"(a = 1 && b > 2) || c != 'd'",
+			"(a = 1 && b > 2) || c != 'd'",
+			false,
+		},
+		{
+			"function with no arguments",
+			"test() = 1",
+			"@fn:test = 1",
+			false,
+		},
+		{
+			"function with arguments",
+			"geoDistance(a.b, c, 1.5, -2) < 10",
+			"@fn:geoDistance:" + encode("a.b, c, 1.5, -2") + " < 10",
+			false,
+		},
+		{
+			"function inside a group",
+			"(a = 1 || test(b, 'c)')>2)",
+			"(a = 1 || @fn:test:" + encode("b, 'c)'") + ">2)",
+			false,
+		},
+		{
+			"nested function call",
+			"a(b(c), d) = 1",
+			"@fn:a:" + encode("@fn:b:"+encode("c")+", d") + " = 1",
+			false,
+		},
+		{
+			"function-like text and comment",
+			"a = 'test(1)' && b = \"test(2)\" // test(3)\n&& c = 1",
+			"a = 'test(1)' && b = \"test(2)\" // test(3)\n&& c = 1",
+			false,
+		},
+		{
+			"identifier followed by whitespace and group",
+			"test (a = 1)",
+			"test (a = 1)",
+			false,
+		},
+		{
+			"full-text search operator",
+			"title @@ 'lorem' && (b.c@@\"ipsum\" || d @@ {:e})",
+			"#fts:title = 'lorem' && (#fts:b.c=\"ipsum\" || #fts:d = {:e})",
+			false,
+		},
+		{
+			"full-text search operator inside text and comment",
+			"a = 'b @@ c' // d @@ e",
+			"a = 'b @@ c' // d @@ e",
+			false,
+		},
+		{
+			"full-text search operator with text left operand",
+			"'a' @@ b",
+			"",
+			true,
+		},
+		{
+			"full-text search operator with macro left operand",
+			"@now @@ b",
+			"",
+			true,
+		},
+		{
+			"full-text search operator with function left operand",
+			"a(b) @@ c",
+			"",
+			true,
+		},
+		{
+			"full-text search operator without left operand",
+			"(@@ b)",
+			"",
+			true,
+		},
+		{
+			"missing closing parenthesis",
+			"test(a, b = 1",
+			"",
+			true,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := normalizeFilter(s.raw)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if result != s.expected {
+				t.Fatalf("Expected\n%q\ngot\n%q", s.expected, result)
+			}
+		})
+	}
+}
+
+func TestSplitFunctionArgs(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		raw         string
+		expected    []fexpr.Token
+		expectError bool
+	}{
+		{
+			"empty",
+			"  ",
+			nil,
+			false,
+		},
+		{
+			"single argument",
+			"a.b",
+			[]fexpr.Token{{Type: fexpr.TokenIdentifier, Literal: "a.b"}},
+			false,
+		},
+		{
+			"multiple arguments",
+			` a , -1.5,"b, c" , 'd' `,
+			[]fexpr.Token{
+				{Type: fexpr.TokenIdentifier, Literal: "a"},
+				{Type: fexpr.TokenNumber, Literal: "-1.5"},
+				{Type: fexpr.TokenText, Literal: "b, c"},
+				{Type: fexpr.TokenText, Literal: "d"},
+			},
+			false,
+		},
+		{
+			"empty argument",
+			"a,,b",
+			nil,
+			true,
+		},
+		{
+			"multiple tokens in a single argument",
+			"a b, c",
+			nil,
+			true,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, err := splitFunctionArgs(s.raw)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if len(result) != len(s.expected) {
+				t.Fatalf("Expected %d tokens, got %d: %v", len(s.expected), len(result), result)
+			}
+
+			for i, token := range result {
+				if token.Type != s.expected[i].Type || token.Literal != s.expected[i].Literal {
+					t.Fatalf("Expected token %d to be %v, got %v", i, s.expected[i], token)
+				}
+			}
+		})
+	}
+}
+
+func TestGeoDistanceTokenFunction(t *testing.T) {
+	fn, ok := TokenFunctions["geoDistance"]
+	if !ok {
+		t.Fatal("Missing geoDistance token function")
+	}
+
+	resolver := NewSimpleFieldResolver("a", "b")
+
+	argResolver := func(token fexpr.Token) (*ResolverResult, error) {
+		return resolveToken(token, resolver)
+	}
+
+	t.Run("invalid number of arguments", func(t *testing.T) {
+		_, err := fn(argResolver, fexpr.Token{Type: fexpr.TokenIdentifier, Literal: "a"})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("text argument", func(t *testing.T) {
+		_, err := fn(
+			argResolver,
+			fexpr.Token{Type: fexpr.TokenIdentifier, Literal: "a"},
+			fexpr.Token{Type: fexpr.TokenIdentifier, Literal: "b"},
+			fexpr.Token{Type: fexpr.TokenText, Literal: "1"},
+			fexpr.Token{Type: fexpr.TokenNumber, Literal: "2"},
+		)
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("valid arguments", func(t *testing.T) {
+		result, err := fn(
+			argResolver,
+			fexpr.Token{Type: fexpr.TokenIdentifier, Literal: "a"},
+			fexpr.Token{Type: fexpr.TokenIdentifier, Literal: "b"},
+			fexpr.Token{Type: fexpr.TokenNumber, Literal: "1"},
+			fexpr.Token{Type: fexpr.TokenNumber, Literal: "2"},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.NoCoalesce {
+			t.Fatal("Expected NoCoalesce to be true")
+		}
+
+		if len(result.Params) != 2 {
+			t.Fatalf("Expected 2 params, got %v", result.Params)
+		}
+	})
+}
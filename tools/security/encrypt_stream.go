@@ -0,0 +1,175 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encryptedStreamMagic is the header signature of the encrypted streams.
+var encryptedStreamMagic = []byte("HBENC1")
+
+const (
+	encryptedStreamSaltSize   = 16
+	encryptedStreamPrefixSize = 7
+	encryptedStreamChunkSize  = 64 * 1024
+	encryptedStreamIterations = 210000
+
+	encryptedStreamHeaderSize = 6 + encryptedStreamSaltSize + encryptedStreamPrefixSize
+)
+
+// ErrInvalidEncryptedStream is returned when the encrypted stream is
+// malformed, truncated, tampered or the secret is wrong.
+var ErrInvalidEncryptedStream = errors.New("invalid or tampered encrypted stream (or wrong secret)")
+
+// IsEncryptedStream reports whether the provided data starts
+// with the [EncryptStream] header signature.
+func IsEncryptedStream(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, encryptedStreamMagic)
+}
+
+// EncryptStream encrypts the src data with the specified secret and writes the result to dst.
+//
+// The AES-256 key is derived from the secret (PBKDF2-SHA256 with random salt)
+// and the data is sealed in 64KB AES-256-GCM chunks, allowing encryption
+// of large files without loading them in memory.
+// The chunks order and the end of the stream are authenticated
+// so reordered or truncated streams are detected on decryption.
+func EncryptStream(dst io.Writer, src io.Reader, secret string) error {
+	header := make([]byte, encryptedStreamHeaderSize)
+	copy(header, encryptedStreamMagic)
+
+	// populates the salt and the nonce prefix with a cryptographically secure random sequence
+	if _, err := io.ReadFull(crand.Reader, header[len(encryptedStreamMagic):]); err != nil {
+		return err
+	}
+
+	gcm, err := newStreamGCM(header, secret)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, encryptedStreamChunkSize)
+	chunk := make([]byte, encryptedStreamChunkSize)
+	sealed := make([]byte, 0, encryptedStreamChunkSize+gcm.Overhead())
+
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(r, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		isLast := err != nil
+		if !isLast {
+			// check if there is more data
+			if _, peekErr := r.Peek(1); peekErr == io.EOF {
+				isLast = true
+			}
+		}
+
+		sealed = gcm.Seal(sealed[:0], streamNonce(header, counter, isLast), chunk[:n], header)
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+
+		if isLast {
+			return nil
+		}
+
+		if counter == ^uint32(0) {
+			return errors.New("the data is too large to be encrypted")
+		}
+	}
+}
+
+// DecryptStream decrypts the src data created with [EncryptStream]
+// and writes the result to dst.
+//
+// Note that on error dst could still have partially written data
+// and it should be discarded.
+func DecryptStream(dst io.Writer, src io.Reader, secret string) error {
+	header := make([]byte, encryptedStreamHeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil || !IsEncryptedStream(header) {
+		return ErrInvalidEncryptedStream
+	}
+
+	gcm, err := newStreamGCM(header, secret)
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, encryptedStreamChunkSize+gcm.Overhead())
+	sealed := make([]byte, encryptedStreamChunkSize+gcm.Overhead())
+	chunk := make([]byte, 0, encryptedStreamChunkSize)
+
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(r, sealed)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		isLast := err != nil
+		if !isLast {
+			if _, peekErr := r.Peek(1); peekErr == io.EOF {
+				isLast = true
+			}
+		}
+
+		chunk, err = gcm.Open(chunk[:0], streamNonce(header, counter, isLast), sealed[:n], header)
+		if err != nil {
+			return ErrInvalidEncryptedStream
+		}
+
+		if _, err := dst.Write(chunk); err != nil {
+			return err
+		}
+
+		if isLast {
+			return nil
+		}
+	}
+}
+
+func newStreamGCM(header []byte, secret string) (cipher.AEAD, error) {
+	if secret == "" {
+		return nil, errors.New("missing encryption secret")
+	}
+
+	salt := header[len(encryptedStreamMagic) : len(encryptedStreamMagic)+encryptedStreamSaltSize]
+
+	key := pbkdf2.Key([]byte(secret), salt, encryptedStreamIterations, 32, sha256.New)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// streamNonce constructs the chunk nonce in the format:
+// 7 bytes random prefix + 4 bytes chunk counter + 1 byte last chunk flag.
+func streamNonce(header []byte, counter uint32, isLast bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, header[encryptedStreamHeaderSize-encryptedStreamPrefixSize:]...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if isLast {
+		nonce = append(nonce, 1)
+	} else {
+		nonce = append(nonce, 0)
+	}
+
+	return nonce
+}
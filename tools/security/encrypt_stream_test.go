@@ -0,0 +1,95 @@
+package security_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+func TestEncryptDecryptStream(t *testing.T) {
+	scenarios := []struct {
+		data        string
+		secret      string
+		expectError bool
+	}{
+		{"123", "", true},
+		{"", "test", false},
+		{"123", "test", false},
+		{strings.Repeat("a", 64*1024), "test", false},      // exactly 1 chunk
+		{strings.Repeat("b", 3*64*1024+10), "test", false}, // multiple chunks
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%d", i, len(s.data)), func(t *testing.T) {
+			encrypted := &bytes.Buffer{}
+
+			err := security.EncryptStream(encrypted, strings.NewReader(s.data), s.secret)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if !security.IsEncryptedStream(encrypted.Bytes()) {
+				t.Fatal("Expected IsEncryptedStream to be true")
+			}
+
+			if len(s.data) > 0 && bytes.Contains(encrypted.Bytes(), []byte(s.data)) {
+				t.Fatal("Expected the data to be encrypted")
+			}
+
+			decrypted := &bytes.Buffer{}
+			if err := security.DecryptStream(decrypted, bytes.NewReader(encrypted.Bytes()), s.secret); err != nil {
+				t.Fatal(err)
+			}
+
+			if decrypted.String() != s.data {
+				t.Fatalf("Expected the decrypted data to match with the original (%d vs %d)", decrypted.Len(), len(s.data))
+			}
+		})
+	}
+}
+
+func TestDecryptStreamInvalid(t *testing.T) {
+	data := strings.Repeat("a", 2*64*1024+100)
+
+	encrypted := &bytes.Buffer{}
+	if err := security.EncryptStream(encrypted, strings.NewReader(data), "test"); err != nil {
+		t.Fatal(err)
+	}
+	raw := encrypted.Bytes()
+
+	tampered := bytes.Clone(raw)
+	tampered[len(tampered)/2] ^= 1
+
+	// drop the last chunk
+	truncated := raw[:len(raw)-100-16]
+
+	scenarios := []struct {
+		name   string
+		data   []byte
+		secret string
+	}{
+		{"not encrypted", []byte(data), "test"},
+		{"wrong secret", raw, "test2"},
+		{"tampered", tampered, "test"},
+		{"truncated", truncated, "test"},
+		{"truncated header", raw[:10], "test"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			err := security.DecryptStream(&bytes.Buffer{}, bytes.NewReader(s.data), s.secret)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
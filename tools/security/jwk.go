@@ -0,0 +1,168 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+// DefaultRSAKeyBits is the default size of the generated RSA keys.
+const DefaultRSAKeyBits = 2048
+
+// JWK defines a single public JSON Web Key as described in [RFC 7517].
+//
+// [RFC 7517]: https://datatracker.ietf.org/doc/html/rfc7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+
+	// RSA public key params
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP (Ed25519) public key params
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet defines a JSON Web Key Set as described in [RFC 7517].
+//
+// [RFC 7517]: https://datatracker.ietf.org/doc/html/rfc7517#section-5
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK creates and returns a new public signing JWK from the provided public key.
+//
+// Currently only RSA and Ed25519 keys are supported.
+func NewJWK(publicKey crypto.PublicKey) (JWK, error) {
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk := JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+		jwk.Kid = jwk.Thumbprint()
+
+		return jwk, nil
+	case ed25519.PublicKey:
+		jwk := JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: "EdDSA",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}
+		jwk.Kid = jwk.Thumbprint()
+
+		return jwk, nil
+	default:
+		return JWK{}, errors.New("unsupported public key type")
+	}
+}
+
+// Thumbprint returns the base64url encoded SHA-256 [RFC 7638] thumbprint of the key
+// (usually used as key id).
+//
+// [RFC 7638]: https://datatracker.ietf.org/doc/html/rfc7638
+func (k JWK) Thumbprint() string {
+	// the required members in lexicographic order
+	var raw []byte
+	if k.Kty == "OKP" {
+		// https://datatracker.ietf.org/doc/html/rfc8037#appendix-A.3
+		raw, _ = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X})
+	} else {
+		raw, _ = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N})
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// GeneratePrivateKeyPEM generates a new RSA private key with
+// [DefaultRSAKeyBits] size and returns it as PKCS #8 PEM encoded string.
+func GeneratePrivateKeyPEM() (string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, DefaultRSAKeyBits)
+	if err != nil {
+		return "", err
+	}
+
+	return encodePrivateKeyPEM(key)
+}
+
+// GenerateEd25519PrivateKeyPEM generates a new Ed25519 private key
+// and returns it as PKCS #8 PEM encoded string.
+func GenerateEd25519PrivateKeyPEM() (string, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	return encodePrivateKeyPEM(key)
+}
+
+func encodePrivateKeyPEM(key any) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParsePrivateKeyPEM parses a PKCS #8 or PKCS #1 PEM encoded private key.
+//
+// Currently only RSA and Ed25519 keys are supported.
+func ParsePrivateKeyPEM(privateKeyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid PEM encoded private key")
+	}
+
+	var key any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < DefaultRSAKeyBits {
+			return nil, errors.New("the RSA private key must be at least 2048 bits")
+		}
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+}
@@ -1,6 +1,9 @@
 package security
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"errors"
 	"time"
 
@@ -25,6 +28,20 @@ func ParseUnverifiedJWT(token string) (jwt.MapClaims, error) {
 	return claims, err
 }
 
+// ParseUnverifiedJWTHeader parses JWT and returns its header
+// (e.g. to lookup the "kid" of the key used for the signing)
+// but DOES NOT verify the signature or the claims.
+func ParseUnverifiedJWTHeader(token string) (map[string]any, error) {
+	parser := &jwt.Parser{}
+
+	parsed, _, err := parser.ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.Header, nil
+}
+
 // ParseJWT verifies and parses JWT and returns its claims.
 func ParseJWT(token string, verificationKey string) (jwt.MapClaims, error) {
 	parser := jwt.NewParser(jwt.WithValidMethods([]string{"HS256"}))
@@ -55,3 +72,66 @@ func NewJWT(payload jwt.MapClaims, signingKey string, duration time.Duration) (s
 
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(signingKey))
 }
+
+// NewSignedJWT generates and returns new JWT signed with the provided asymmetric private key.
+//
+// The kid argument is set as "kid" token header and it is usually used
+// by the verifier to select the public key from a JSON Web Key Set.
+//
+// Currently only RSA (signed with RS256) and Ed25519 (signed with EdDSA) keys are supported.
+func NewSignedJWT(payload jwt.MapClaims, signingKey crypto.Signer, kid string, duration time.Duration) (string, error) {
+	method, err := signingMethod(signingKey.Public())
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"exp": time.Now().Add(duration).Unix(),
+	}
+
+	for k, v := range payload {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	return token.SignedString(signingKey)
+}
+
+// ParseSignedJWT verifies and parses JWT signed with the
+// private key of the provided asymmetric public key.
+func ParseSignedJWT(token string, verificationKey crypto.PublicKey) (jwt.MapClaims, error) {
+	method, err := signingMethod(verificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{method.Alg()}))
+
+	parsedToken, err := parser.Parse(token, func(t *jwt.Token) (any, error) {
+		return verificationKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+		return claims, nil
+	}
+
+	return nil, errors.New("unable to parse token")
+}
+
+func signingMethod(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errors.New("unsupported signing key type")
+	}
+}
@@ -0,0 +1,171 @@
+package security
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default TOTP parameters supported by the majority of the authenticator apps.
+const (
+	DefaultTOTPDigits = 6
+	DefaultTOTPPeriod = 30
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RandomTOTPSecret generates a new random 160 bits TOTP secret
+// (as recommended by [RFC 4226]) encoded as unpadded base32 string.
+//
+// [RFC 4226]: https://datatracker.ietf.org/doc/html/rfc4226#section-4
+func RandomTOTPSecret() string {
+	b := make([]byte, 20)
+
+	if _, err := crand.Read(b); err != nil {
+		panic(err) // crypto/rand should never fail
+	}
+
+	return totpEncoding.EncodeToString(b)
+}
+
+// TOTP defines a time-based one-time password generator
+// as described in [RFC 6238] (HMAC-SHA1 variant).
+//
+// [RFC 6238]: https://datatracker.ietf.org/doc/html/rfc6238
+type TOTP struct {
+	// Secret is the base32 encoded shared secret (the padding is optional).
+	Secret string
+
+	// Digits is the length of the generated codes (6 if not set).
+	Digits int
+
+	// Period is the time step duration in seconds (30 if not set).
+	Period int64
+}
+
+// Generate returns the TOTP code for the specified time.
+func (t TOTP) Generate(at time.Time) (string, error) {
+	key, err := t.key()
+	if err != nil {
+		return "", err
+	}
+
+	return t.hotp(key, t.step(at)), nil
+}
+
+// Validate checks whether code is a valid TOTP for the specified time,
+// allowing up to skew time steps clock drift in both directions.
+//
+// On success it returns the matched time step counter, which could be
+// used to reject an already used code.
+func (t TOTP) Validate(code string, at time.Time, skew int) (int64, bool) {
+	if len(code) != t.digits() {
+		return 0, false
+	}
+
+	key, err := t.key()
+	if err != nil {
+		return 0, false
+	}
+
+	current := t.step(at)
+
+	for i := -int64(skew); i <= int64(skew); i++ {
+		step := current + i
+		if step < 0 {
+			continue
+		}
+
+		if Equal(t.hotp(key, step), code) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// URI returns the otpauth:// key URI used for provisioning the authenticator apps
+// (usually encoded as QR code).
+//
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format.
+func (t TOTP) URI(issuer string, account string) string {
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+
+	query := url.Values{}
+	query.Set("secret", strings.TrimRight(strings.ToUpper(t.Secret), "="))
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(t.digits()))
+	query.Set("period", strconv.FormatInt(t.period(), 10))
+
+	return "otpauth://totp/" + url.PathEscape(label) + "?" + query.Encode()
+}
+
+func (t TOTP) digits() int {
+	if t.Digits <= 0 {
+		return DefaultTOTPDigits
+	}
+	return t.Digits
+}
+
+func (t TOTP) period() int64 {
+	if t.Period <= 0 {
+		return DefaultTOTPPeriod
+	}
+	return t.Period
+}
+
+func (t TOTP) step(at time.Time) int64 {
+	return at.Unix() / t.period()
+}
+
+func (t TOTP) key() ([]byte, error) {
+	secret := strings.TrimRight(strings.ToUpper(strings.ReplaceAll(t.Secret, " ", "")), "=")
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base32 TOTP secret: %w", err)
+	}
+
+	if len(key) == 0 {
+		return nil, errors.New("empty TOTP secret")
+	}
+
+	return key, nil
+}
+
+// hotp generates a HOTP code as described in RFC 4226.
+func (t TOTP) hotp(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	h := hmac.New(sha1.New, key)
+	h.Write(msg)
+	sum := h.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	digits := t.digits()
+
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
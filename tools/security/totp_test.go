@@ -0,0 +1,153 @@
+package security_test
+
+import (
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+// RFC 6238 Appendix B test secret ("12345678901234567890") encoded as base32.
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestRandomTOTPSecret(t *testing.T) {
+	generated := map[string]struct{}{}
+
+	for i := 0; i < 100; i++ {
+		secret := security.RandomTOTPSecret()
+
+		if strings.Contains(secret, "=") {
+			t.Fatalf("Expected unpadded secret, got %q", secret)
+		}
+
+		key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+		if err != nil {
+			t.Fatalf("Expected valid base32 secret, got %v", err)
+		}
+
+		if len(key) != 20 {
+			t.Fatalf("Expected 20 bytes key, got %d", len(key))
+		}
+
+		if _, ok := generated[secret]; ok {
+			t.Fatalf("Repeating secret %q", secret)
+		}
+		generated[secret] = struct{}{}
+	}
+}
+
+func TestTOTPGenerate(t *testing.T) {
+	scenarios := []struct {
+		totp     security.TOTP
+		unix     int64
+		expected string
+	}{
+		// RFC 6238 Appendix B (SHA1)
+		{security.TOTP{Secret: rfcTOTPSecret, Digits: 8}, 59, "94287082"},
+		{security.TOTP{Secret: rfcTOTPSecret, Digits: 8}, 1111111109, "07081804"},
+		{security.TOTP{Secret: rfcTOTPSecret, Digits: 8}, 1111111111, "14050471"},
+		{security.TOTP{Secret: rfcTOTPSecret, Digits: 8}, 1234567890, "89005924"},
+		{security.TOTP{Secret: rfcTOTPSecret, Digits: 8}, 2000000000, "69279037"},
+		{security.TOTP{Secret: rfcTOTPSecret, Digits: 8}, 20000000000, "65353130"},
+		// defaults
+		{security.TOTP{Secret: rfcTOTPSecret}, 59, "287082"},
+		{security.TOTP{Secret: strings.ToLower(rfcTOTPSecret) + "===="}, 1111111109, "081804"},
+		// custom period
+		{security.TOTP{Secret: rfcTOTPSecret, Digits: 8, Period: 60}, 119, "94287082"},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%d", i, s.unix), func(t *testing.T) {
+			code, err := s.totp.Generate(time.Unix(s.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code != s.expected {
+				t.Fatalf("Expected code %q, got %q", s.expected, code)
+			}
+		})
+	}
+}
+
+func TestTOTPGenerateInvalidSecret(t *testing.T) {
+	for _, secret := range []string{"", "!invalid!"} {
+		t.Run(secret, func(t *testing.T) {
+			_, err := security.TOTP{Secret: secret}.Generate(time.Now())
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestTOTPValidate(t *testing.T) {
+	totp := security.TOTP{Secret: rfcTOTPSecret, Digits: 8}
+	at := time.Unix(1111111111, 0) // step 37037037
+
+	scenarios := []struct {
+		code         string
+		skew         int
+		expectedOk   bool
+		expectedStep int64
+	}{
+		{"", 1, false, 0},
+		{"1405047", 1, false, 0},
+		{"140504710", 1, false, 0},
+		{"00000000", 1, false, 0},
+		{"14050471", 0, true, 37037037},
+		{"14050471", 1, true, 37037037},
+		// previous step
+		{"07081804", 0, false, 0},
+		{"07081804", 1, true, 37037036},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%s_%d", i, s.code, s.skew), func(t *testing.T) {
+			step, ok := totp.Validate(s.code, at, s.skew)
+
+			if ok != s.expectedOk {
+				t.Fatalf("Expected ok %v, got %v", s.expectedOk, ok)
+			}
+
+			if step != s.expectedStep {
+				t.Fatalf("Expected step %d, got %d", s.expectedStep, step)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	scenarios := []struct {
+		totp     security.TOTP
+		issuer   string
+		account  string
+		expected string
+	}{
+		{
+			security.TOTP{Secret: rfcTOTPSecret},
+			"",
+			"test@example.com",
+			"otpauth://totp/test@example.com?algorithm=SHA1&digits=6&period=30&secret=" + rfcTOTPSecret,
+		},
+		{
+			security.TOTP{Secret: strings.ToLower(rfcTOTPSecret) + "==", Digits: 8, Period: 60},
+			"Acme Inc",
+			"test@example.com",
+			"otpauth://totp/Acme%20Inc:test@example.com?algorithm=SHA1&digits=8&issuer=Acme+Inc&period=60&secret=" + rfcTOTPSecret,
+		},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("%d_%s", i, s.issuer), func(t *testing.T) {
+			uri := s.totp.URI(s.issuer, s.account)
+
+			if uri != s.expected {
+				t.Fatalf("Expected\n%s\ngot\n%s", s.expected, uri)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package subscriptions
+
+import "encoding/json"
+
+// Event defines a single broker event that is fanned out
+// between multiple app instances (aka. nodes).
+type Event struct {
+	Name string          `json:"name"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Backend is an interface for a pluggable pub/sub transport that
+// could be used to fan out the broker events between multiple app nodes.
+type Backend interface {
+	// Publish sends the event to all other nodes.
+	//
+	// Implementations should not block on slow or unavailable nodes.
+	Publish(event Event) error
+
+	// Subscribe registers a handler that is called for each event
+	// received from the other nodes and returns a function to unregister it.
+	Subscribe(handler func(event Event)) (unsubscribe func())
+
+	// Close stops the backend and releases its resources.
+	Close() error
+}
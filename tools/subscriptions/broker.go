@@ -2,6 +2,7 @@ package subscriptions
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/hanzoai/backendPB/tools/list"
 	"github.com/hanzoai/backendPB/tools/store"
@@ -9,7 +10,9 @@ import (
 
 // Broker defines a struct for managing subscriptions clients.
 type Broker struct {
-	store *store.Store[string, Client]
+	store   *store.Store[string, Client]
+	backend Backend
+	mux     sync.RWMutex
 }
 
 // NewBroker initializes and returns a new Broker instance.
@@ -63,3 +66,24 @@ func (b *Broker) Unregister(clientId string) {
 	client.Discard()
 	b.store.Remove(clientId)
 }
+
+// Backend returns the broker pub/sub backend used to fan out
+// the broadcast events between multiple app nodes.
+//
+// Returns nil if the broker is in-process only (default).
+func (b *Broker) Backend() Backend {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	return b.backend
+}
+
+// SetBackend replaces the broker pub/sub backend.
+//
+// Set it to nil to disable the multi-node fan-out.
+func (b *Broker) SetBackend(backend Backend) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.backend = backend
+}
@@ -132,3 +132,32 @@ func TestUnregister(t *testing.T) {
 		t.Fatalf("Expected client with id %s, got error %v", clientB.Id(), err)
 	}
 }
+
+func TestBrokerBackend(t *testing.T) {
+	b := subscriptions.NewBroker()
+
+	if b.Backend() != nil {
+		t.Fatal("Expected nil default backend")
+	}
+
+	backend, err := subscriptions.NewPeersBackend(subscriptions.PeersConfig{
+		ListenAddr: "127.0.0.1:0",
+		Secret:     "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	b.SetBackend(backend)
+
+	if b.Backend() != backend {
+		t.Fatalf("Expected backend %v, got %v", backend, b.Backend())
+	}
+
+	b.SetBackend(nil)
+
+	if b.Backend() != nil {
+		t.Fatal("Expected nil backend")
+	}
+}
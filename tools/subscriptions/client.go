@@ -17,6 +17,9 @@ const optionsParam = "options"
 type Message struct {
 	Name string `json:"name"`
 	Data []byte `json:"data"`
+
+	// Id is an optional message event id (e.g. used for replaying missed messages on reconnect).
+	Id string `json:"id,omitempty"`
 }
 
 // SubscriptionOptions defines the request options (query params, headers, etc.)
@@ -0,0 +1,420 @@
+package subscriptions
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/security"
+)
+
+// ensures that PeersBackend satisfies the Backend interface
+var _ Backend = (*PeersBackend)(nil)
+
+const (
+	defaultPeersQueueSize    = 1000
+	defaultPeersDialTimeout  = 5 * time.Second
+	defaultPeersMaxClockSkew = 30 * time.Second
+
+	// maxPeersEventSize specifies the max allowed size (in bytes) of a single received event line.
+	maxPeersEventSize = 16 << 20
+)
+
+// ErrPeersQueueFull is returned by [PeersBackend.Publish] when the
+// event couldn't be queued for one or more of the peers.
+var ErrPeersQueueFull = errors.New("peers queue is full, the event was dropped")
+
+// PeersConfig defines the [PeersBackend] configuration options.
+type PeersConfig struct {
+	// ListenAddr is the TCP address to listen for events from the other nodes (e.g. "10.0.0.1:8091").
+	ListenAddr string
+
+	// Secret is the shared secret used to sign and verify the events.
+	Secret string
+
+	// Peers is a list with the TCP addresses of the other nodes (e.g. ["10.0.0.2:8091", "10.0.0.3:8091"]).
+	Peers []string
+
+	// QueueSize is the max number of pending events per peer (default to 1000).
+	QueueSize int
+
+	// MaxClockSkew is the max allowed difference between the event
+	// timestamp and the receiver clock (default to 30s).
+	//
+	// Events outside of the window are rejected as stale.
+	MaxClockSkew time.Duration
+
+	// TLSConfig is an optional TLS configuration for the peers connections.
+	//
+	// When set, the listener accepts only TLS connections and the events are sent
+	// to the peers over TLS (the config should include the node certificate and
+	// the CA used to verify the other nodes, e.g. with mutual TLS).
+	//
+	// It is strongly recommended to be set when the nodes don't communicate
+	// over a trusted private network because otherwise the events
+	// (including the broadcasted record data) are sent in plain text.
+	TLSConfig *tls.Config
+}
+
+// PeersBackend is a simple TCP gossip [Backend] implementation
+// that sends the published events directly to all configured peers.
+//
+// Each event is sent as a single "{hmac} {nodeId} {timestamp} {seq} {json}\n" line
+// signed with the shared secret, where nodeId is a random id of the sender
+// generated on start and seq is a monotonic per sender event counter.
+// Events with invalid signature, outside of the MaxClockSkew window or with
+// already received (or lower) sequence number are rejected to prevent replays.
+//
+// The delivery is best-effort, meaning that events for unavailable
+// or slow peers are dropped (the events are not relayed or persisted).
+//
+// Note that without [PeersConfig.TLSConfig] the events are not encrypted
+// and the backend is expected to be used only within a private network.
+type PeersBackend struct {
+	listener net.Listener
+	closed   chan struct{}
+	handlers map[int]func(event Event)
+	inbound  map[net.Conn]struct{}
+	senders  map[string]*peerSender
+	config   PeersConfig
+	nodeId   string
+	peers    []*peer
+	seq      atomic.Uint64
+	wg       sync.WaitGroup
+	mux      sync.RWMutex
+	lastId   int
+	isClosed bool
+}
+
+// peerSender stores the replay protection state of a single sender node.
+type peerSender struct {
+	lastSeen time.Time
+	lastSeq  uint64
+}
+
+// NewPeersBackend creates a new PeersBackend instance and starts
+// listening for events from the other nodes.
+func NewPeersBackend(config PeersConfig) (*PeersBackend, error) {
+	if config.Secret == "" {
+		return nil, errors.New("missing peers secret")
+	}
+
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultPeersQueueSize
+	}
+
+	if config.MaxClockSkew <= 0 {
+		config.MaxClockSkew = defaultPeersMaxClockSkew
+	}
+
+	listener, err := net.Listen("tcp", config.ListenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.TLSConfig != nil {
+		listener = tls.NewListener(listener, config.TLSConfig)
+	}
+
+	p := &PeersBackend{
+		config:   config,
+		listener: listener,
+		nodeId:   security.RandomString(16),
+		closed:   make(chan struct{}),
+		handlers: map[int]func(event Event){},
+		inbound:  map[net.Conn]struct{}{},
+		senders:  map[string]*peerSender{},
+	}
+
+	for _, addr := range config.Peers {
+		peer := &peer{addr: addr, tlsConfig: config.TLSConfig, queue: make(chan []byte, config.QueueSize)}
+		p.peers = append(p.peers, peer)
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			peer.run(p.closed)
+		}()
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.accept()
+	}()
+
+	return p, nil
+}
+
+// Addr returns the backend listener network address.
+func (p *PeersBackend) Addr() net.Addr {
+	return p.listener.Addr()
+}
+
+// Publish implements the [Backend.Publish] interface method.
+func (p *PeersBackend) Publish(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	header := p.nodeId + " " +
+		strconv.FormatInt(time.Now().Unix(), 10) + " " +
+		strconv.FormatUint(p.seq.Add(1), 10) + " "
+
+	signed := make([]byte, 0, len(header)+len(payload))
+	signed = append(signed, header...)
+	signed = append(signed, payload...)
+
+	line := make([]byte, 0, len(signed)+66)
+	line = append(line, security.HS256(string(signed), p.config.Secret)...)
+	line = append(line, ' ')
+	line = append(line, signed...)
+	line = append(line, '\n')
+
+	var queueErr error
+
+	for _, peer := range p.peers {
+		select {
+		case peer.queue <- line:
+		default:
+			queueErr = ErrPeersQueueFull
+		}
+	}
+
+	return queueErr
+}
+
+// Subscribe implements the [Backend.Subscribe] interface method.
+func (p *PeersBackend) Subscribe(handler func(event Event)) func() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.lastId++
+	id := p.lastId
+	p.handlers[id] = handler
+
+	return func() {
+		p.mux.Lock()
+		defer p.mux.Unlock()
+
+		delete(p.handlers, id)
+	}
+}
+
+// Close implements the [Backend.Close] interface method.
+//
+// It is safe to call Close() multiple times.
+func (p *PeersBackend) Close() error {
+	p.mux.Lock()
+	if p.isClosed {
+		p.mux.Unlock()
+		return nil
+	}
+	p.isClosed = true
+	close(p.closed)
+	for conn := range p.inbound {
+		conn.Close()
+	}
+	p.mux.Unlock()
+
+	err := p.listener.Close()
+
+	p.wg.Wait()
+
+	return err
+}
+
+func (p *PeersBackend) accept() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			select {
+			case <-p.closed:
+				return
+			default:
+				time.Sleep(100 * time.Millisecond) // temporary accept error
+				continue
+			}
+		}
+
+		p.mux.Lock()
+		if p.isClosed {
+			p.mux.Unlock()
+			conn.Close()
+			return
+		}
+		p.inbound[conn] = struct{}{}
+		p.mux.Unlock()
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.read(conn)
+		}()
+	}
+}
+
+// read processes sequentially the event lines of a single inbound peer connection.
+func (p *PeersBackend) read(conn net.Conn) {
+	defer func() {
+		p.mux.Lock()
+		delete(p.inbound, conn)
+		p.mux.Unlock()
+
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxPeersEventSize)
+
+	for scanner.Scan() {
+		signature, signed, ok := bytes.Cut(scanner.Bytes(), []byte{' '})
+		if !ok || !security.Equal(string(signature), security.HS256(string(signed), p.config.Secret)) {
+			return // invalid or unauthorized peer
+		}
+
+		parts := bytes.SplitN(signed, []byte{' '}, 4)
+		if len(parts) != 4 {
+			return // invalid peer
+		}
+
+		timestamp, err := strconv.ParseInt(string(parts[1]), 10, 64)
+		if err != nil {
+			return // invalid peer
+		}
+
+		seq, err := strconv.ParseUint(string(parts[2]), 10, 64)
+		if err != nil {
+			return // invalid peer
+		}
+
+		if !p.acceptSeq(string(parts[0]), time.Unix(timestamp, 0), seq) {
+			continue // stale or duplicated event
+		}
+
+		payload := parts[3]
+
+		var event Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			continue
+		}
+
+		p.mux.RLock()
+		handlers := make([]func(event Event), 0, len(p.handlers))
+		for _, h := range p.handlers {
+			handlers = append(handlers, h)
+		}
+		p.mux.RUnlock()
+
+		for _, h := range handlers {
+			h(event)
+		}
+	}
+}
+
+// acceptSeq reports whether an event with the specified sender node id,
+// timestamp and sequence number is not stale or already received and
+// updates the sender replay protection state.
+func (p *PeersBackend) acceptSeq(nodeId string, timestamp time.Time, seq uint64) bool {
+	if nodeId == p.nodeId {
+		return false // the node doesn't send events to itself
+	}
+
+	now := time.Now()
+
+	if timestamp.Before(now.Add(-p.config.MaxClockSkew)) || timestamp.After(now.Add(p.config.MaxClockSkew)) {
+		return false
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	sender, ok := p.senders[nodeId]
+	if !ok {
+		// forget the inactive senders
+		// (their replayed events would be rejected anyway as stale)
+		for id, s := range p.senders {
+			if now.Sub(s.lastSeen) > 2*p.config.MaxClockSkew {
+				delete(p.senders, id)
+			}
+		}
+
+		sender = &peerSender{}
+		p.senders[nodeId] = sender
+	}
+
+	if seq <= sender.lastSeq {
+		return false
+	}
+
+	sender.lastSeq = seq
+	sender.lastSeen = now
+
+	return true
+}
+
+// peer defines a single outbound peer connection.
+type peer struct {
+	conn      net.Conn
+	tlsConfig *tls.Config
+	queue     chan []byte
+	addr      string
+}
+
+// run writes the queued events to the peer until closed is signaled.
+func (p *peer) run(closed chan struct{}) {
+	defer func() {
+		if p.conn != nil {
+			p.conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case line := <-p.queue:
+			// retry once with a new connection in case of a stale one
+			if err := p.write(line); err != nil {
+				p.write(line)
+			}
+		}
+	}
+}
+
+func (p *peer) write(line []byte) error {
+	if p.conn == nil {
+		var conn net.Conn
+		var err error
+
+		dialer := &net.Dialer{Timeout: defaultPeersDialTimeout}
+		if p.tlsConfig != nil {
+			conn, err = tls.DialWithDialer(dialer, "tcp", p.addr, p.tlsConfig)
+		} else {
+			conn, err = dialer.Dial("tcp", p.addr)
+		}
+		if err != nil {
+			return err
+		}
+
+		p.conn = conn
+	}
+
+	p.conn.SetWriteDeadline(time.Now().Add(defaultPeersDialTimeout))
+
+	if _, err := p.conn.Write(line); err != nil {
+		p.conn.Close()
+		p.conn = nil
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package subscriptions_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hanzoai/backendPB/tools/security"
+	"github.com/hanzoai/backendPB/tools/subscriptions"
+)
+
+func TestNewPeersBackendMissingSecret(t *testing.T) {
+	_, err := subscriptions.NewPeersBackend(subscriptions.PeersConfig{ListenAddr: "127.0.0.1:0"})
+	if err == nil {
+		t.Fatal("Expected error for missing secret")
+	}
+}
+
+func TestPeersBackendPublish(t *testing.T) {
+	nodeB, err := subscriptions.NewPeersBackend(subscriptions.PeersConfig{
+		ListenAddr: "127.0.0.1:0",
+		Secret:     "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nodeB.Close()
+
+	nodeA, err := subscriptions.NewPeersBackend(subscriptions.PeersConfig{
+		ListenAddr: "127.0.0.1:0",
+		Secret:     "test",
+		Peers:      []string{nodeB.Addr().String()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nodeA.Close()
+
+	// peer with invalid secret
+	nodeC, err := subscriptions.NewPeersBackend(subscriptions.PeersConfig{
+		ListenAddr: "127.0.0.1:0",
+		Secret:     "invalid",
+		Peers:      []string{nodeB.Addr().String()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nodeC.Close()
+
+	received := make(chan subscriptions.Event, 10)
+	unsubscribe := nodeB.Subscribe(func(event subscriptions.Event) {
+		received <- event
+	})
+
+	if err := nodeC.Publish(subscriptions.Event{Name: "c", Data: []byte(`{"c":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nodeA.Publish(subscriptions.Event{Name: "a1", Data: []byte(`{"a":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nodeA.Publish(subscriptions.Event{Name: "a2", Data: []byte(`{"a":2}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"a1", "a2"} {
+		select {
+		case event := <-received:
+			if event.Name != expected {
+				t.Fatalf("Expected event %q, got %q", expected, event.Name)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Timeout waiting for event %q", expected)
+		}
+	}
+
+	unsubscribe()
+
+	if err := nodeA.Publish(subscriptions.Event{Name: "a3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-received:
+		t.Fatalf("Expected no more events, got %q", event.Name)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestPeersBackendClose(t *testing.T) {
+	node, err := subscriptions.NewPeersBackend(subscriptions.PeersConfig{
+		ListenAddr: "127.0.0.1:0",
+		Secret:     "test",
+		Peers:      []string{"127.0.0.1:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// multiple calls should be safe
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPeersBackendReplay(t *testing.T) {
+	nodeB, err := subscriptions.NewPeersBackend(subscriptions.PeersConfig{
+		ListenAddr:   "127.0.0.1:0",
+		Secret:       "test",
+		MaxClockSkew: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nodeB.Close()
+
+	received := make(chan subscriptions.Event, 10)
+	nodeB.Subscribe(func(event subscriptions.Event) {
+		received <- event
+	})
+
+	conn, err := net.Dial("tcp", nodeB.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	frame := func(nodeId string, timestamp time.Time, seq int, name string) string {
+		signed := fmt.Sprintf(`%s %d %d {"name":%q}`, nodeId, timestamp.Unix(), seq, name)
+		return security.HS256(signed, "test") + " " + signed + "\n"
+	}
+
+	now := time.Now()
+
+	frames := []string{
+		frame("n1", now.Add(-1*time.Minute), 1, "stale"),
+		frame("n1", now.Add(1*time.Minute), 2, "future"),
+		frame("n1", now, 3, "a1"),
+		frame("n1", now, 3, "duplicate"),
+		frame("n1", now, 2, "older"),
+		frame("n2", now, 1, "b1"), // different sender
+		frame("n1", now, 4, "a2"),
+	}
+
+	for _, f := range frames {
+		if _, err := conn.Write([]byte(f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, expected := range []string{"a1", "b1", "a2"} {
+		select {
+		case event := <-received:
+			if event.Name != expected {
+				t.Fatalf("Expected event %q, got %q", expected, event.Name)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Timeout waiting for event %q", expected)
+		}
+	}
+
+	select {
+	case event := <-received:
+		t.Fatalf("Expected no more events, got %q", event.Name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
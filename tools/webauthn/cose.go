@@ -0,0 +1,126 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Supported COSE algorithm identifiers (https://www.iana.org/assignments/cose/cose.xhtml#algorithms).
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms lists the supported COSE algorithms in order of preference.
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters.
+const (
+	coseKeyKty = 1
+	coseKeyAlg = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// PublicKey defines a parsed COSE credential public key.
+type PublicKey struct {
+	Key       crypto.PublicKey
+	Algorithm int64
+}
+
+// ParsePublicKey parses a COSE_Key encoded credential public key.
+func ParsePublicKey(raw []byte) (*PublicKey, error) {
+	decoded, rest, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("invalid public key: unexpected trailing data")
+	}
+
+	params, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, errors.New("invalid public key: expected COSE_Key map")
+	}
+
+	kty, _ := params[int64(coseKeyKty)].(int64)
+	alg, _ := params[int64(coseKeyAlg)].(int64)
+	crv, _ := params[int64(-1)].(int64)
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		x, _ := params[int64(-2)].([]byte)
+		y, _ := params[int64(-3)].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid ES256 public key")
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid ES256 public key point")
+		}
+
+		return &PublicKey{Key: key, Algorithm: alg}, nil
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		x, _ := params[int64(-2)].([]byte)
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid EdDSA public key")
+		}
+
+		return &PublicKey{Key: ed25519.PublicKey(x), Algorithm: alg}, nil
+	case kty == coseKtyRSA && alg == AlgRS256:
+		n, _ := params[int64(-1)].([]byte)
+		e, _ := params[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RS256 public key")
+		}
+
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+
+		return &PublicKey{Key: key, Algorithm: alg}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported public key type %d with algorithm %d", kty, alg)
+}
+
+// Verify verifies the signature of the provided data.
+func (k *PublicKey) Verify(data []byte, signature []byte) error {
+	var ok bool
+
+	switch key := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(data)
+		ok = ecdsa.VerifyASN1(key, hash[:], signature)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		hash := sha256.Sum256(data)
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature) == nil
+	}
+
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
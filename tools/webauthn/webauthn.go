@@ -0,0 +1,320 @@
+// Package webauthn implements a minimal WebAuthn (passkeys) relying party
+// registration and assertion verification.
+//
+// Only the "none" attestation conveyance is supported, meaning that the
+// attestation statements are not verified and the registered authenticators
+// are trusted on first use (which is the default for most passkey deployments).
+//
+// Supported credential algorithms: ES256 (-7), EdDSA (-8) and RS256 (-257).
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Client data types.
+const (
+	ClientDataTypeCreate = "webauthn.create"
+	ClientDataTypeGet    = "webauthn.get"
+)
+
+// Authenticator data flags.
+const (
+	FlagUserPresent            byte = 0x01
+	FlagUserVerified           byte = 0x04
+	FlagBackupEligible         byte = 0x08
+	FlagBackupState            byte = 0x10
+	FlagAttestedCredentialData byte = 0x40
+	FlagExtensionData          byte = 0x80
+)
+
+// Common verification errors.
+var (
+	ErrInvalidClientData        = errors.New("invalid client data")
+	ErrInvalidAuthenticatorData = errors.New("invalid authenticator data")
+	ErrInvalidSignature         = errors.New("invalid assertion signature")
+	ErrSignCountRollback        = errors.New("the authenticator sign count is not greater than the stored one (possible cloned authenticator)")
+	ErrSignCountUnsupported     = errors.New("the authenticator doesn't support sign counters")
+)
+
+// ClientData defines the collected client data JSON fields (https://www.w3.org/TR/webauthn-3/#dictionary-client-data).
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ParseClientData parses the raw client data JSON.
+func ParseClientData(raw []byte) (*ClientData, error) {
+	data := &ClientData{}
+
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidClientData, err)
+	}
+
+	return data, nil
+}
+
+// AuthenticatorData defines the parsed authenticator data (https://www.w3.org/TR/webauthn-3/#sctn-authenticator-data).
+type AuthenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// attested credential data (available only on registration)
+	AAGUID       []byte
+	CredentialId []byte
+	PublicKey    []byte // COSE_Key encoded
+}
+
+// HasFlag reports whether the authenticator data has the specified flag set.
+func (d *AuthenticatorData) HasFlag(flag byte) bool {
+	return d.Flags&flag == flag
+}
+
+// ParseAuthenticatorData parses the raw authenticator data bytes.
+func ParseAuthenticatorData(raw []byte) (*AuthenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	result := &AuthenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	if !result.HasFlag(FlagAttestedCredentialData) {
+		return result, nil
+	}
+
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	result.AAGUID = rest[:16]
+
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	result.CredentialId = rest[:idLength]
+	rest = rest[idLength:]
+
+	_, afterKey, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAuthenticatorData, err)
+	}
+
+	result.PublicKey = rest[:len(rest)-len(afterKey)]
+
+	return result, nil
+}
+
+// Credential defines a verified registered public key credential.
+type Credential struct {
+	Id                []byte
+	PublicKey         []byte // COSE_Key encoded
+	AAGUID            []byte
+	Algorithm         int64
+	SignCount         uint32
+	AttestationFormat string
+	BackupEligible    bool
+}
+
+// RelyingParty defines the WebAuthn relying party verification options.
+type RelyingParty struct {
+	// Id is the relying party identifier (usually the app domain, e.g. "example.com").
+	Id string
+
+	// Origins is a list of the allowed client origins (e.g. "https://example.com").
+	Origins []string
+
+	// RequireUserVerification requires the authenticator
+	// to verify the user (e.g. with PIN or biometrics).
+	RequireUserVerification bool
+
+	// RequireSignCount rejects the assertions of authenticators that
+	// don't implement sign counters (aka. always report 0), making the
+	// cloned authenticators detection mandatory.
+	//
+	// Note that most synced passkeys (e.g. iCloud Keychain, Google Password Manager)
+	// don't implement sign counters and will be rejected if enabled.
+	RequireSignCount bool
+}
+
+// VerifyRegistration verifies the navigator.credentials.create() response
+// data and returns the registered credential.
+//
+// expectedChallenge is the base64url encoded (without padding) challenge sent to the client.
+func (rp *RelyingParty) VerifyRegistration(clientDataJSON []byte, attestationObject []byte, expectedChallenge string) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, ClientDataTypeCreate, expectedChallenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation object: %w", err)
+	}
+
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, errors.New("invalid attestation object")
+	}
+
+	format, _ := attestation["fmt"].(string)
+	rawAuthData, _ := attestation["authData"].([]byte)
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+
+	if !authData.HasFlag(FlagAttestedCredentialData) {
+		return nil, fmt.Errorf("%w: missing attested credential data", ErrInvalidAuthenticatorData)
+	}
+
+	key, err := ParsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		Id:                authData.CredentialId,
+		PublicKey:         authData.PublicKey,
+		AAGUID:            authData.AAGUID,
+		Algorithm:         key.Algorithm,
+		SignCount:         authData.SignCount,
+		AttestationFormat: format,
+		BackupEligible:    authData.HasFlag(FlagBackupEligible),
+	}, nil
+}
+
+// VerifyAssertion verifies the navigator.credentials.get() response data
+// against the stored credential public key and sign count.
+//
+// expectedChallenge is the base64url encoded (without padding) challenge sent to the client.
+//
+// Returns the new authenticator sign count that should be stored.
+func (rp *RelyingParty) VerifyAssertion(
+	publicKey []byte,
+	storedSignCount uint32,
+	clientDataJSON []byte,
+	authenticatorData []byte,
+	signature []byte,
+	expectedChallenge string,
+) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, ClientDataTypeGet, expectedChallenge); err != nil {
+		return 0, err
+	}
+
+	authData, err := ParseAuthenticatorData(authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return 0, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+
+	signed := make([]byte, 0, len(authenticatorData)+len(clientDataHash))
+	signed = append(signed, authenticatorData...)
+	signed = append(signed, clientDataHash[:]...)
+
+	if err := key.Verify(signed, signature); err != nil {
+		return 0, err
+	}
+
+	// https://www.w3.org/TR/webauthn-3/#sctn-sign-counter
+	switch {
+	case authData.SignCount == 0 && storedSignCount == 0:
+		// the authenticator doesn't support counters (they always return 0)
+		// and the cloned authenticators can't be detected
+		if rp.RequireSignCount {
+			return 0, ErrSignCountUnsupported
+		}
+	case authData.SignCount <= storedSignCount:
+		// a counter that doesn't increase (including a counter reset to 0)
+		// is a signal that the credential private key could have been cloned
+		return 0, ErrSignCountRollback
+	}
+
+	return authData.SignCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, expectedType string, expectedChallenge string) error {
+	data, err := ParseClientData(raw)
+	if err != nil {
+		return err
+	}
+
+	if data.Type != expectedType {
+		return fmt.Errorf("%w: expected type %q, got %q", ErrInvalidClientData, expectedType, data.Type)
+	}
+
+	if expectedChallenge == "" || subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(expectedChallenge)) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", ErrInvalidClientData)
+	}
+
+	if !slices.Contains(rp.Origins, data.Origin) {
+		return fmt.Errorf("%w: origin %q is not allowed", ErrInvalidClientData, data.Origin)
+	}
+
+	if data.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin requests are not allowed", ErrInvalidClientData)
+	}
+
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *AuthenticatorData) error {
+	rpIdHash := sha256.Sum256([]byte(rp.Id))
+	if !bytes.Equal(authData.RPIDHash, rpIdHash[:]) {
+		return fmt.Errorf("%w: rpId hash mismatch", ErrInvalidAuthenticatorData)
+	}
+
+	if !authData.HasFlag(FlagUserPresent) {
+		return fmt.Errorf("%w: user is not present", ErrInvalidAuthenticatorData)
+	}
+
+	if rp.RequireUserVerification && !authData.HasFlag(FlagUserVerified) {
+		return fmt.Errorf("%w: user is not verified", ErrInvalidAuthenticatorData)
+	}
+
+	return nil
+}
+
+// EncodeBase64URL encodes the provided bytes in the base64url (without padding)
+// format used for the WebAuthn binary values (challenges, credential ids, etc.).
+func EncodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeBase64URL decodes a base64url encoded string (with or without padding).
+func DecodeBase64URL(str string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(str, "="))
+}
@@ -0,0 +1,487 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+// -------------------------------------------------------------------
+// test authenticator helpers
+// -------------------------------------------------------------------
+
+func encodeTestCBORHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	}
+}
+
+// encodeTestCBOR encodes int, []byte, string and ordered key-value pairs (as map).
+func encodeTestCBOR(v any) []byte {
+	switch val := v.(type) {
+	case int:
+		if val < 0 {
+			return encodeTestCBORHead(1, uint64(-1-val))
+		}
+		return encodeTestCBORHead(0, uint64(val))
+	case []byte:
+		return append(encodeTestCBORHead(2, uint64(len(val))), val...)
+	case string:
+		return append(encodeTestCBORHead(3, uint64(len(val))), val...)
+	case []any: // key-value pairs
+		result := encodeTestCBORHead(5, uint64(len(val)/2))
+		for _, item := range val {
+			result = append(result, encodeTestCBOR(item)...)
+		}
+		return result
+	}
+
+	panic(fmt.Sprintf("unsupported test cbor value %T", v))
+}
+
+type testAuthenticator struct {
+	credentialId []byte
+	signer       crypto.Signer
+	coseKey      []byte
+	signCount    uint32
+}
+
+func newTestAuthenticator(t *testing.T, alg int64) *testAuthenticator {
+	a := &testAuthenticator{credentialId: []byte("test_credential_id")}
+
+	switch alg {
+	case AlgES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.signer = key
+		a.coseKey = encodeTestCBOR([]any{
+			1, 2,
+			3, -7,
+			-1, 1,
+			-2, key.X.FillBytes(make([]byte, 32)),
+			-3, key.Y.FillBytes(make([]byte, 32)),
+		})
+	case AlgEdDSA:
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.signer = key
+		a.coseKey = encodeTestCBOR([]any{
+			1, 1,
+			3, -8,
+			-1, 6,
+			-2, []byte(pub),
+		})
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.signer = key
+		a.coseKey = encodeTestCBOR([]any{
+			1, 3,
+			3, -257,
+			-1, key.N.Bytes(),
+			-2, big.NewInt(int64(key.E)).Bytes(),
+		})
+	default:
+		t.Fatalf("unsupported test algorithm %d", alg)
+	}
+
+	return a
+}
+
+func (a *testAuthenticator) authData(rpId string, flags byte, withCredential bool) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+
+	result := append([]byte(nil), rpIdHash[:]...)
+	result = append(result, flags)
+	result = binary.BigEndian.AppendUint32(result, a.signCount)
+
+	if withCredential {
+		result = append(result, make([]byte, 16)...) // zero AAGUID
+		result = binary.BigEndian.AppendUint16(result, uint16(len(a.credentialId)))
+		result = append(result, a.credentialId...)
+		result = append(result, a.coseKey...)
+	}
+
+	return result
+}
+
+func (a *testAuthenticator) sign(t *testing.T, data []byte) []byte {
+	var digest []byte
+	var opts crypto.SignerOpts = crypto.Hash(0)
+
+	if _, ok := a.signer.(ed25519.PrivateKey); ok {
+		digest = data
+	} else {
+		hash := sha256.Sum256(data)
+		digest = hash[:]
+		opts = crypto.SHA256
+	}
+
+	signature, err := a.signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signature
+}
+
+func testClientData(t *testing.T, typ, challenge, origin string) []byte {
+	raw, err := json.Marshal(ClientData{Type: typ, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// -------------------------------------------------------------------
+
+func TestDecodeCBOR(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		data        []byte
+		expected    string
+		expectError bool
+	}{
+		{"empty", nil, "", true},
+		{"small uint", []byte{0x17}, "23", false},
+		{"uint16", []byte{0x19, 0x01, 0x00}, "256", false},
+		{"negative int", []byte{0x38, 0x63}, "-100", false},
+		{"truncated uint32", []byte{0x1a, 0x01}, "", true},
+		{"text", []byte{0x63, 'a', 'b', 'c'}, "abc", false},
+		{"truncated text", []byte{0x63, 'a'}, "", true},
+		{"bytes", []byte{0x42, 0x01, 0x02}, "[1 2]", false},
+		{"array", []byte{0x82, 0x01, 0xf5}, "[1 true]", false},
+		{"map", []byte{0xa1, 0x61, 'a', 0x20}, "map[a:-1]", false},
+		{"map with unsupported key", []byte{0xa1, 0x80, 0x01}, "", true},
+		{"tagged", []byte{0xc1, 0x01}, "1", false},
+		{"null", []byte{0xf6}, "<nil>", false},
+		{"indefinite length", []byte{0x9f, 0x01, 0xff}, "", true},
+		{"array length overflow", []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, "", true},
+		{"nesting too deep", slices.Repeat([]byte{0x81}, 20), "", true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			result, _, err := decodeCBOR(s.data)
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if str := fmt.Sprintf("%v", result); str != s.expected {
+				t.Fatalf("Expected %q, got %q", s.expected, str)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORRest(t *testing.T) {
+	result, rest, err := decodeCBOR([]byte{0x01, 0x02, 0x03})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != int64(1) {
+		t.Fatalf("Expected 1, got %v", result)
+	}
+
+	if len(rest) != 2 {
+		t.Fatalf("Expected 2 remaining bytes, got %v", rest)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	for _, alg := range SupportedAlgorithms {
+		t.Run(fmt.Sprintf("alg_%d", alg), func(t *testing.T) {
+			a := newTestAuthenticator(t, alg)
+
+			key, err := ParsePublicKey(a.coseKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if key.Algorithm != alg {
+				t.Fatalf("Expected algorithm %d, got %d", alg, key.Algorithm)
+			}
+
+			data := []byte("test")
+			if err := key.Verify(data, a.sign(t, data)); err != nil {
+				t.Fatalf("Expected valid signature, got %v", err)
+			}
+
+			if err := key.Verify([]byte("other"), a.sign(t, data)); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		// EC2 with ES384
+		_, err := ParsePublicKey(encodeTestCBOR([]any{1, 2, 3, -35}))
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("invalid point", func(t *testing.T) {
+		_, err := ParsePublicKey(encodeTestCBOR([]any{
+			1, 2,
+			3, -7,
+			-1, 1,
+			-2, make([]byte, 32),
+			-3, make([]byte, 32),
+		}))
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+}
+
+func TestParseAuthenticatorData(t *testing.T) {
+	a := newTestAuthenticator(t, AlgEdDSA)
+	a.signCount = 5
+
+	if _, err := ParseAuthenticatorData([]byte{1, 2, 3}); err == nil {
+		t.Fatal("Expected error for too short authenticator data")
+	}
+
+	raw := a.authData("example.com", FlagUserPresent|FlagAttestedCredentialData, true)
+
+	data, err := ParseAuthenticatorData(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.SignCount != 5 {
+		t.Fatalf("Expected sign count 5, got %d", data.SignCount)
+	}
+
+	if string(data.CredentialId) != string(a.credentialId) {
+		t.Fatalf("Expected credential id %q, got %q", a.credentialId, data.CredentialId)
+	}
+
+	if string(data.PublicKey) != string(a.coseKey) {
+		t.Fatalf("Expected public key %v, got %v", a.coseKey, data.PublicKey)
+	}
+
+	// truncated credential data
+	if _, err := ParseAuthenticatorData(raw[:60]); err == nil {
+		t.Fatal("Expected error for truncated credential data")
+	}
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	rp := &RelyingParty{
+		Id:                      "example.com",
+		Origins:                 []string{"https://example.com"},
+		RequireUserVerification: true,
+	}
+
+	a := newTestAuthenticator(t, AlgES256)
+
+	attestation := func(flags byte, rpId string) []byte {
+		return encodeTestCBOR([]any{
+			"fmt", "none",
+			"attStmt", []any{},
+			"authData", a.authData(rpId, flags, true),
+		})
+	}
+
+	validFlags := FlagUserPresent | FlagUserVerified | FlagAttestedCredentialData
+
+	scenarios := []struct {
+		name        string
+		clientData  []byte
+		attestation []byte
+		expectError bool
+	}{
+		{
+			"invalid client data json",
+			[]byte("{"),
+			attestation(validFlags, rp.Id),
+			true,
+		},
+		{
+			"invalid client data type",
+			testClientData(t, ClientDataTypeGet, "abc", "https://example.com"),
+			attestation(validFlags, rp.Id),
+			true,
+		},
+		{
+			"challenge mismatch",
+			testClientData(t, ClientDataTypeCreate, "abcd", "https://example.com"),
+			attestation(validFlags, rp.Id),
+			true,
+		},
+		{
+			"origin mismatch",
+			testClientData(t, ClientDataTypeCreate, "abc", "https://evil.com"),
+			attestation(validFlags, rp.Id),
+			true,
+		},
+		{
+			"rpId mismatch",
+			testClientData(t, ClientDataTypeCreate, "abc", "https://example.com"),
+			attestation(validFlags, "evil.com"),
+			true,
+		},
+		{
+			"missing user verification",
+			testClientData(t, ClientDataTypeCreate, "abc", "https://example.com"),
+			attestation(FlagUserPresent|FlagAttestedCredentialData, rp.Id),
+			true,
+		},
+		{
+			"invalid attestation object",
+			testClientData(t, ClientDataTypeCreate, "abc", "https://example.com"),
+			[]byte{0x01},
+			true,
+		},
+		{
+			"valid",
+			testClientData(t, ClientDataTypeCreate, "abc", "https://example.com"),
+			attestation(validFlags, rp.Id),
+			false,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			credential, err := rp.VerifyRegistration(s.clientData, s.attestation, "abc")
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if hasErr {
+				return
+			}
+
+			if string(credential.Id) != string(a.credentialId) {
+				t.Fatalf("Expected credential id %q, got %q", a.credentialId, credential.Id)
+			}
+
+			if string(credential.PublicKey) != string(a.coseKey) {
+				t.Fatalf("Expected public key %v, got %v", a.coseKey, credential.PublicKey)
+			}
+
+			if credential.Algorithm != AlgES256 {
+				t.Fatalf("Expected algorithm %d, got %d", AlgES256, credential.Algorithm)
+			}
+
+			if credential.AttestationFormat != "none" {
+				t.Fatalf("Expected attestation format none, got %q", credential.AttestationFormat)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	rp := &RelyingParty{
+		Id:      "example.com",
+		Origins: []string{"https://example.com"},
+	}
+
+	a := newTestAuthenticator(t, AlgEdDSA)
+
+	scenarios := []struct {
+		name             string
+		storedSignCount  uint32
+		signCount        uint32
+		requireSignCount bool
+		flags            byte
+		origin           string
+		tamper           bool
+		expectError      bool
+	}{
+		{"invalid origin", 0, 1, false, FlagUserPresent, "https://evil.com", false, true},
+		{"missing user presence", 0, 1, false, FlagUserVerified, "https://example.com", false, true},
+		{"invalid signature", 0, 1, false, FlagUserPresent, "https://example.com", true, true},
+		{"sign count rollback", 5, 5, false, FlagUserPresent, "https://example.com", false, true},
+		{"sign count reset to 0", 5, 0, false, FlagUserPresent, "https://example.com", false, true},
+		{"valid without counter", 0, 0, false, FlagUserPresent, "https://example.com", false, false},
+		{"without counter and required sign count", 0, 0, true, FlagUserPresent, "https://example.com", false, true},
+		{"valid with counter", 5, 6, false, FlagUserPresent, "https://example.com", false, false},
+		{"valid with counter and required sign count", 0, 1, true, FlagUserPresent, "https://example.com", false, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			a.signCount = s.signCount
+			rp.RequireSignCount = s.requireSignCount
+
+			clientData := testClientData(t, ClientDataTypeGet, "abc", s.origin)
+			authData := a.authData(rp.Id, s.flags, false)
+
+			clientDataHash := sha256.Sum256(clientData)
+			signature := a.sign(t, append(append([]byte(nil), authData...), clientDataHash[:]...))
+			if s.tamper {
+				signature[0] ^= 0xff
+			}
+
+			signCount, err := rp.VerifyAssertion(a.coseKey, s.storedSignCount, clientData, authData, signature, "abc")
+
+			hasErr := err != nil
+			if hasErr != s.expectError {
+				t.Fatalf("Expected hasErr %v, got %v (%v)", s.expectError, hasErr, err)
+			}
+
+			if !hasErr && signCount != s.signCount {
+				t.Fatalf("Expected sign count %d, got %d", s.signCount, signCount)
+			}
+		})
+	}
+}
+
+func TestBase64URL(t *testing.T) {
+	encoded := EncodeBase64URL([]byte("test?>"))
+
+	if encoded != "dGVzdD8-" {
+		t.Fatalf("Expected base64url encoded string, got %q", encoded)
+	}
+
+	for _, str := range []string{"dGVzdD8-", "dGVzdD8-=="} {
+		decoded, err := DecodeBase64URL(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(decoded) != "test?>" {
+			t.Fatalf("Expected %q, got %q", "test?>", decoded)
+		}
+	}
+
+	if _, err := DecodeBase64URL("dGVzdD8+"); err == nil {
+		t.Fatal("Expected error for standard base64 encoding")
+	}
+}